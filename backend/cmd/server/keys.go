@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+
+	"ai-interview/internal/biz"
+	"ai-interview/internal/conf"
+	"ai-interview/internal/data"
+	"ai-interview/internal/middleware"
+	"ai-interview/internal/service"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// initEncryptor 从配置的密钥来源加载密钥环，未配置任何密钥时返回 nil
+func initEncryptor(c *conf.Auth) (*middleware.Encryptor, error) {
+	source, err := middleware.NewKeySource(c)
+	if err != nil || source == nil {
+		return nil, err
+	}
+	set, err := source.Load(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("load encryption keys from %s: %w", source.Name(), err)
+	}
+	return middleware.NewEncryptor(set)
+}
+
+// runKeys 执行 keys 子命令: generate | reencrypt [-legacy-key=hex] [-plaintext] [-dry-run]
+func runKeys(dc *conf.Data, ac *conf.Auth, args []string, logger log.Logger) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: keys generate | reencrypt [-legacy-key=hex] [-plaintext] [-dry-run]")
+	}
+
+	switch args[0] {
+	case "generate":
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		fmt.Println(hex.EncodeToString(key))
+		return nil
+	case "reencrypt":
+		return runReencrypt(dc, ac, args[1:], logger)
+	default:
+		return fmt.Errorf("unknown keys command %q", args[0])
+	}
+}
+
+func runReencrypt(dc *conf.Data, ac *conf.Auth, args []string, logger log.Logger) error {
+	fs := flag.NewFlagSet("keys reencrypt", flag.ContinueOnError)
+	var opts service.ReencryptOptions
+	fs.StringVar(&opts.LegacyKey, "legacy-key", "", "hex key of ciphertexts written before key IDs were introduced")
+	fs.BoolVar(&opts.EncryptPlaintext, "plaintext", false, "encrypt values stored in plaintext while no encryption key was configured")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "report what would be rewritten without writing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	encryptor, err := initEncryptor(ac)
+	if err != nil {
+		return err
+	}
+	if encryptor == nil {
+		return errors.New("no encryption key configured")
+	}
+	if opts.LegacyKey != "" {
+		if _, err := middleware.NewEnvKeySource(opts.LegacyKey).Load(context.Background()); err != nil {
+			return fmt.Errorf("legacy key: %w", err)
+		}
+	}
+
+	d, cleanup, err := data.NewData(dc, logger)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	authSvc := service.NewAuthService(biz.NewUserUsecase(data.NewUserRepo(d, logger), nil, nil, logger), nil, encryptor)
+	result, err := authSvc.ReencryptAPIKeys(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range result.Failures {
+		fmt.Printf("user %d %s: %v\n", f.UserID, f.Field, f.Err)
+	}
+	verb := "rewritten"
+	if opts.DryRun {
+		verb = "would be rewritten"
+	}
+	fmt.Printf("primary key %q: %d rows scanned, %d %s, %d skipped (modified concurrently), %d field(s) failed\n",
+		encryptor.PrimaryKeyID(), result.Scanned, result.Rewritten, verb, result.Conflicts, len(result.Failures))
+	if len(result.Failures) > 0 {
+		return fmt.Errorf("%d field(s) could not be re-encrypted", len(result.Failures))
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"ai-interview/internal/conf"
@@ -10,6 +12,8 @@ import (
 	"ai-interview/internal/provider/llm"
 	"ai-interview/internal/provider/stt"
 	"ai-interview/internal/provider/tts"
+	"ai-interview/internal/sandbox"
+	"ai-interview/internal/server"
 
 	"github.com/go-kratos/kratos/v2"
 	"github.com/go-kratos/kratos/v2/config"
@@ -23,22 +27,26 @@ var (
 	Name    = "ai-interview"
 	Version string
 
-	flagconf string
-	id, _    = os.Hostname()
+	flagconf        string
+	flagAutoMigrate bool
+	flagDemo        bool
+	id, _           = os.Hostname()
 )
 
 func init() {
 	flag.StringVar(&flagconf, "conf", "configs/", "config path, eg: -conf config.yaml")
+	flag.BoolVar(&flagAutoMigrate, "auto-migrate", false, "apply pending database migrations on start")
+	flag.BoolVar(&flagDemo, "demo", false, "demo mode: use mock LLM/TTS/STT providers, no API keys required")
 }
 
-func newApp(logger log.Logger, hs *http.Server) *kratos.App {
+func newApp(logger log.Logger, hs *http.Server, janitor *server.AudioJanitor) *kratos.App {
 	return kratos.New(
 		kratos.ID(id),
 		kratos.Name(Name),
 		kratos.Version(Version),
 		kratos.Metadata(map[string]string{}),
 		kratos.Logger(logger),
-		kratos.Server(hs),
+		kratos.Server(hs, janitor),
 	)
 }
 
@@ -75,6 +83,9 @@ func main() {
 	}
 
 	// 从环境变量覆盖敏感配置
+	if driver := os.Getenv("DB_DRIVER"); driver != "" {
+		bc.Data.Database.Driver = driver
+	}
 	if dsn := os.Getenv("DB_DSN"); dsn != "" {
 		bc.Data.Database.Source = dsn
 	}
@@ -90,23 +101,91 @@ func main() {
 	if encKey := os.Getenv("ENCRYPTION_KEY"); encKey != "" {
 		bc.Auth.EncryptionKey = encKey
 	}
+	if masterKey := os.Getenv("LOCAL_KMS_MASTER_KEY"); masterKey != "" {
+		if bc.Auth.KeySource == nil {
+			bc.Auth.KeySource = &conf.Auth_KeySource{}
+		}
+		bc.Auth.KeySource.MasterKey = masterKey
+	}
+	if bc.Data.Audio != nil && bc.Data.Audio.S3 != nil {
+		if ak := os.Getenv("S3_ACCESS_KEY_ID"); ak != "" {
+			bc.Data.Audio.S3.AccessKeyId = ak
+		}
+		if sk := os.Getenv("S3_SECRET_ACCESS_KEY"); sk != "" {
+			bc.Data.Audio.S3.SecretAccessKey = sk
+		}
+	}
+	// 备用链的 API Key: <PROVIDER>_API_KEY，如 DEEPSEEK_API_KEY
+	if bc.Llm != nil {
+		for _, fb := range bc.Llm.Fallbacks {
+			if key := os.Getenv(strings.ToUpper(fb.Provider) + "_API_KEY"); key != "" {
+				fb.ApiKey = key
+			}
+		}
+		// 自定义服务的 API Key: <NAME>_API_KEY，- 转 _，如 MY_VLLM_API_KEY
+		for _, cp := range bc.Llm.CustomProviders {
+			if key := os.Getenv(strings.ToUpper(strings.ReplaceAll(cp.Name, "-", "_")) + "_API_KEY"); key != "" {
+				cp.ApiKey = key
+			}
+		}
+	}
+	if bc.Tts != nil {
+		for _, fb := range bc.Tts.Fallbacks {
+			if key := os.Getenv(strings.ToUpper(fb.Provider) + "_API_KEY"); key != "" {
+				fb.ApiKey = key
+			}
+		}
+	}
+	if flagAutoMigrate {
+		bc.Data.Database.AutoMigrate = true
+	}
+	if flagDemo {
+		if bc.Demo == nil {
+			bc.Demo = &conf.Demo{}
+		}
+		bc.Demo.Enabled = true
+	}
 
-	// 初始化 Encryptor（API Key 加密）
-	var encryptor *middleware.Encryptor
-	if encKey := bc.Auth.EncryptionKey; encKey != "" {
-		var encErr error
-		encryptor, encErr = middleware.NewEncryptor(encKey)
-		if encErr != nil {
-			log.NewHelper(logger).Warnf("failed to create encryptor: %v, API keys will be stored in plaintext", encErr)
+	// 子命令: server [-conf ...] migrate up|down|status
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			if err := runMigrate(bc.Data, args[1:], logger); err != nil {
+				log.NewHelper(logger).Errorf("migrate: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "keys":
+			if err := runKeys(bc.Data, bc.Auth, args[1:], logger); err != nil {
+				log.NewHelper(logger).Errorf("keys: %v", err)
+				os.Exit(1)
+			}
+			return
+		default:
+			log.NewHelper(logger).Errorf("unknown command %q", args[0])
+			os.Exit(2)
 		}
-	} else {
+	}
+
+	// 初始化 Encryptor（API Key 加密）
+	encryptor, err := initEncryptor(bc.Auth)
+	if err != nil {
+		panic(err)
+	}
+	if encryptor == nil {
 		log.NewHelper(logger).Warn("ENCRYPTION_KEY not set, API keys will be stored in plaintext")
 	}
 
 	// 初始化 Provider 注册表
-	ttsRegistry := initTTSRegistry()
-	llmRegistry := initLLMRegistry()
-	sttRegistry := initSTTRegistry()
+	ttsRegistry := initTTSRegistry(bc.Demo)
+	llmRegistry, err := initLLMRegistry(bc.Demo, bc.Llm)
+	if err != nil {
+		panic(err)
+	}
+	sttRegistry := initSTTRegistry(bc.Demo)
+	if bc.Demo != nil && bc.Demo.Enabled {
+		log.NewHelper(logger).Warn("demo mode enabled, all interviews use mock LLM/TTS/STT providers")
+	}
 
 	// 初始化 JWT Helper
 	tokenExpire := bc.Auth.TokenExpire.AsDuration()
@@ -116,7 +195,13 @@ func main() {
 	}
 	jwtHelper := middleware.NewJWTHelper(bc.Auth.JwtSecret, tokenExpire)
 
-	app, cleanup, err := wireApp(bc.Server, bc.Data, logger, ttsRegistry, llmRegistry, sttRegistry, jwtHelper, encryptor)
+	// 初始化代码沙箱 (编程面试)
+	sb, err := initSandbox(bc.Coding, logger)
+	if err != nil {
+		panic(err)
+	}
+
+	app, cleanup, err := wireApp(bc.Server, bc.Data, bc.Demo, bc.Coding, bc.Llm, bc.Tts, bc.Interview, logger, ttsRegistry, llmRegistry, sttRegistry, jwtHelper, encryptor, sb)
 	if err != nil {
 		panic(err)
 	}
@@ -127,26 +212,88 @@ func main() {
 	}
 }
 
-func initTTSRegistry() *tts.Registry {
+// initSandbox 编程模式启用时创建代码沙箱，否则返回 nil
+func initSandbox(c *conf.Coding, logger log.Logger) (*sandbox.Sandbox, error) {
+	if c == nil || !c.Enabled {
+		return nil, nil
+	}
+	return sandbox.New(sandbox.Config{
+		WorkDir:         c.WorkDir,
+		Timeout:         c.Timeout.AsDuration(),
+		CompileTimeout:  c.CompileTimeout.AsDuration(),
+		MemoryMB:        int(c.MemoryMb),
+		MaxOutputBytes:  int(c.MaxOutputKb) << 10,
+		MaxConcurrent:   int(c.MaxConcurrent),
+		MaxProcesses:    int(c.MaxProcesses),
+		UID:             int(c.Uid),
+		GID:             int(c.Gid),
+		AllowUnisolated: c.AllowUnisolated,
+	}, logger)
+}
+
+func initTTSRegistry(demo *conf.Demo) *tts.Registry {
 	registry := tts.NewRegistry()
 	registry.Register(tts.NewOpenAIProvider())
+	registry.Register(tts.NewAzureProvider("", ""))
 	registry.Register(tts.NewFishAudioProvider())
 	registry.Register(tts.NewElevenLabsProvider())
 	registry.Register(tts.NewEdgeTTSProvider())
+	registry.Register(tts.NewAzureSpeechProvider(""))
+	registry.Register(tts.NewMockProvider(demo != nil && demo.Tone))
 	return registry
 }
 
-func initLLMRegistry() *llm.Registry {
+func initLLMRegistry(demo *conf.Demo, c *conf.LLM) (*llm.Registry, error) {
 	registry := llm.NewRegistry()
 	registry.Register(llm.NewOpenAIProvider())
+	registry.Register(llm.NewAzureProvider("", ""))
 	registry.Register(llm.NewAnthropicProvider())
 	registry.Register(llm.NewDeepSeekProvider())
 	registry.Register(llm.NewGeminiProvider())
-	return registry
+
+	var script llm.MockScript
+	if demo != nil {
+		script = llm.MockScript{
+			Questions:  demo.Questions,
+			Evaluation: demo.Evaluation,
+			TokenDelay: demo.TokenDelay.AsDuration(),
+		}
+	}
+	registry.Register(llm.NewMockProvider(script))
+
+	// 系统级自定义 OpenAI 兼容服务
+	if c != nil {
+		for _, cp := range c.CustomProviders {
+			if cp.Name == "" || cp.BaseUrl == "" {
+				return nil, fmt.Errorf("llm custom provider: name and base_url are required")
+			}
+			if _, err := registry.Get(cp.Name); err == nil {
+				return nil, fmt.Errorf("llm custom provider %q: name already in use", cp.Name)
+			}
+			registry.Register(llm.NewCustomProvider(llm.CustomConfig{
+				Name:         cp.Name,
+				BaseURL:      cp.BaseUrl,
+				Auth:         cp.Auth,
+				AuthHeader:   cp.AuthHeader,
+				APIKey:       cp.ApiKey,
+				DefaultModel: cp.DefaultModel,
+				Headers:      cp.Headers,
+			}))
+		}
+	}
+	return registry, nil
 }
 
-func initSTTRegistry() *stt.Registry {
+func initSTTRegistry(demo *conf.Demo) *stt.Registry {
 	registry := stt.NewRegistry()
 	registry.Register(stt.NewWhisperProvider())
+	registry.Register(stt.NewAzureProvider("", ""))
+	registry.Register(stt.NewAzureSpeechProvider(""))
+
+	var transcripts []string
+	if demo != nil {
+		transcripts = demo.Transcripts
+	}
+	registry.Register(stt.NewMockProvider(transcripts))
 	return registry
 }
@@ -11,6 +11,7 @@ import (
 	"ai-interview/internal/provider/llm"
 	"ai-interview/internal/provider/stt"
 	"ai-interview/internal/provider/tts"
+	"ai-interview/internal/sandbox"
 	"ai-interview/internal/server"
 	"ai-interview/internal/service"
 
@@ -22,12 +23,18 @@ import (
 func wireApp(
 	*conf.Server,
 	*conf.Data,
+	*conf.Demo,
+	*conf.Coding,
+	*conf.LLM,
+	*conf.TTS,
+	*conf.Interview,
 	log.Logger,
 	*tts.Registry,
 	*llm.Registry,
 	*stt.Registry,
 	*middleware.JWTHelper,
 	*middleware.Encryptor,
+	*sandbox.Sandbox,
 ) (*kratos.App, func(), error) {
 	panic(wire.Build(
 		server.ProviderSet,
@@ -0,0 +1,140 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+)
+
+// 图片附件：候选人在消息中附带白板、截图，面试官回复与评估时作为图片内容交给模型
+
+const (
+	defaultAttachmentBytes       = 5 << 20
+	defaultAttachmentsPerMessage = 4
+)
+
+// attachmentTypes 接受的图片类型
+var attachmentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// Attachment 候选人消息附带的图片
+type Attachment struct {
+	ID          int64
+	InterviewID int64
+	MessageID   int64
+	ContentType string
+	Data        []byte // 列表查询不加载
+	SizeBytes   int64
+	CreatedAt   time.Time
+}
+
+// attachmentPolicy 图片附件配置
+type attachmentPolicy struct {
+	enabled       bool
+	maxBytes      int64
+	maxPerMessage int
+}
+
+func newAttachmentPolicy(c *conf.Interview) attachmentPolicy {
+	p := attachmentPolicy{maxBytes: defaultAttachmentBytes, maxPerMessage: defaultAttachmentsPerMessage}
+	if c == nil || c.Attachments == nil {
+		return p
+	}
+	p.enabled = c.Attachments.Enabled
+	if c.Attachments.MaxBytes > 0 {
+		p.maxBytes = c.Attachments.MaxBytes
+	}
+	if c.Attachments.MaxPerMessage > 0 {
+		p.maxPerMessage = int(c.Attachments.MaxPerMessage)
+	}
+	return p
+}
+
+// check 校验候选人上传的图片，按内容识别类型，不信任客户端声明的类型
+func (p attachmentPolicy) check(images []*Attachment) error {
+	if len(images) == 0 {
+		return nil
+	}
+	if !p.enabled {
+		return ErrAttachmentDisabled
+	}
+	if len(images) > p.maxPerMessage {
+		return fmt.Errorf("%w: at most %d images per message", ErrInvalidAttachment, p.maxPerMessage)
+	}
+	for _, img := range images {
+		if len(img.Data) == 0 || int64(len(img.Data)) > p.maxBytes {
+			return fmt.Errorf("%w: image must be 1 to %d bytes", ErrInvalidAttachment, p.maxBytes)
+		}
+		contentType := http.DetectContentType(img.Data)
+		if !slices.Contains(attachmentTypes, contentType) {
+			return fmt.Errorf("%w: unsupported type %s", ErrInvalidAttachment, contentType)
+		}
+		img.ContentType, img.SizeBytes = contentType, int64(len(img.Data))
+	}
+	return nil
+}
+
+// saveAttachments 保存用户消息附带的图片，并关联到 msg
+func (uc *InterviewUsecase) saveAttachments(ctx context.Context, msg *InterviewMessage, images []*Attachment) error {
+	for _, img := range images {
+		img.InterviewID, img.MessageID = msg.InterviewID, msg.ID
+		saved, err := uc.repo.CreateAttachment(ctx, img)
+		if err != nil {
+			return fmt.Errorf("save attachment: %w", err)
+		}
+		msg.Attachments = append(msg.Attachments, saved)
+	}
+	return nil
+}
+
+// loadAttachments 读取面试的图片 (含内容)，关联到 messages 中对应的消息；未开启图片附件时不读取
+func (uc *InterviewUsecase) loadAttachments(ctx context.Context, interviewID int64, messages []*InterviewMessage) error {
+	if !uc.attachments.enabled {
+		return nil
+	}
+	list, err := uc.repo.ListAttachments(ctx, interviewID)
+	if err != nil {
+		return fmt.Errorf("list attachments: %w", err)
+	}
+	byID := make(map[int64]*InterviewMessage, len(messages))
+	for _, m := range messages {
+		byID[m.ID] = m
+	}
+	for _, a := range list {
+		m := byID[a.MessageID]
+		if m == nil {
+			continue
+		}
+		full, err := uc.repo.GetAttachment(ctx, a.ID)
+		if err != nil {
+			return fmt.Errorf("get attachment %d: %w", a.ID, err)
+		}
+		m.Attachments = append(m.Attachments, full)
+	}
+	return nil
+}
+
+// ListAttachments 列出面试的全部图片 (不含内容)
+func (uc *InterviewUsecase) ListAttachments(ctx context.Context, interviewID int64) ([]*Attachment, error) {
+	return uc.repo.ListAttachments(ctx, interviewID)
+}
+
+// GetAttachment 获取图片 (含内容)
+func (uc *InterviewUsecase) GetAttachment(ctx context.Context, id int64) (*Attachment, error) {
+	return uc.repo.GetAttachment(ctx, id)
+}
+
+// imageParts 把已加载内容的图片转换为 LLM 图片内容
+func imageParts(attachments []*Attachment) []llm.ContentPart {
+	var parts []llm.ContentPart
+	for _, a := range attachments {
+		if len(a.Data) > 0 {
+			parts = append(parts, llm.ImagePart(llm.Image{MIMEType: a.ContentType, Data: a.Data}))
+		}
+	}
+	return parts
+}
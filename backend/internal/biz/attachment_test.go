@@ -0,0 +1,94 @@
+package biz
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-interview/internal/conf"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestAttachmentPolicy_Check(t *testing.T) {
+	disabled := newAttachmentPolicy(nil)
+	if err := disabled.check(nil); err != nil {
+		t.Errorf("messages without images should pass: %v", err)
+	}
+	if err := disabled.check([]*Attachment{{Data: testPNG}}); !errors.Is(err, ErrAttachmentDisabled) {
+		t.Errorf("expected ErrAttachmentDisabled, got %v", err)
+	}
+
+	p := newAttachmentPolicy(&conf.Interview{Attachments: &conf.Interview_Attachments{Enabled: true, MaxBytes: 64, MaxPerMessage: 2}})
+	for name, images := range map[string][]*Attachment{
+		"too many":  {{Data: testPNG}, {Data: testPNG}, {Data: testPNG}},
+		"too large": {{Data: append(append([]byte(nil), testPNG...), make([]byte, 64)...)}},
+		"empty":     {{}},
+		"not image": {{Data: []byte("<html><body>hi</body></html>")}},
+	} {
+		if err := p.check(images); !errors.Is(err, ErrInvalidAttachment) {
+			t.Errorf("%s: expected ErrInvalidAttachment, got %v", name, err)
+		}
+	}
+
+	img := &Attachment{ContentType: "text/plain", Data: testPNG}
+	if err := p.check([]*Attachment{img}); err != nil {
+		t.Fatalf("check error: %v", err)
+	}
+	if img.ContentType != "image/png" || img.SizeBytes != int64(len(testPNG)) {
+		t.Errorf("type should be detected from the content: %+v", img)
+	}
+}
+
+func TestBuildLLMMessages_Images(t *testing.T) {
+	personas, _ := NewPersonas(nil)
+	uc := &InterviewUsecase{prompts: newTestPrompts(t, nil), personas: personas, log: log.NewHelper(log.DefaultLogger)}
+	msgs := []*InterviewMessage{
+		{Role: "assistant", Content: "Design a URL shortener."},
+		{Role: "user", Content: "Here is my diagram.", Attachments: []*Attachment{{ContentType: "image/png", Data: testPNG}}},
+	}
+
+	built, err := uc.buildLLMMessages(&Interview{Position: "Go", Language: "en-US"}, nil, nil, msgs, 1, 0)
+	if err != nil {
+		t.Fatalf("buildLLMMessages error: %v", err)
+	}
+	if built[1].Content != "Design a URL shortener." || len(built[1].Parts) != 0 {
+		t.Errorf("messages without images should stay plain: %+v", built[1])
+	}
+	parts := built[2].Parts
+	if len(parts) != 2 || parts[0].Text != "Here is my diagram." || parts[1].Image == nil || parts[1].Image.MIMEType != "image/png" {
+		t.Errorf("unexpected parts: %+v", parts)
+	}
+}
+
+func TestPrompts_EvaluationImages(t *testing.T) {
+	p := newTestPrompts(t, nil)
+	image := func() []*Attachment { return []*Attachment{{ContentType: "image/png", Data: testPNG}} }
+	msgs := []*InterviewMessage{
+		{Role: "assistant", Content: "Draw the architecture."},
+		{Role: "user", Content: "Here.", Attachments: image()},
+		{Role: "assistant", Content: "And the data model?"},
+		{Role: "user", Content: "Tables.", Attachments: append(image(), image()...)},
+	}
+
+	got, err := p.Evaluation(&Interview{Position: "Go", Language: "en-US"}, nil, msgs, nil, 0)
+	if err != nil {
+		t.Fatalf("Evaluation error: %v", err)
+	}
+	user := got[1]
+	if user.Content != "" || len(user.Parts) != 4 {
+		t.Fatalf("images should follow the request text, got %d parts", len(user.Parts))
+	}
+	for _, want := range []string{"Here. [image 1]", "Tables. [image 2] [image 3]", "=== Images ==="} {
+		if !strings.Contains(user.Parts[0].Text, want) {
+			t.Errorf("evaluation prompt missing %q:\n%s", want, user.Parts[0].Text)
+		}
+	}
+
+	plain, _ := p.Evaluation(&Interview{Position: "Go", Language: "en-US"}, nil, msgs[:1], nil, 0)
+	if len(plain[1].Parts) != 0 || strings.Contains(plain[1].Content, "=== Images ===") {
+		t.Errorf("evaluation without images should be plain text")
+	}
+}
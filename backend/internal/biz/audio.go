@@ -0,0 +1,202 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/tts"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+var (
+	ErrAudioDisabled     = errors.New("audio recording is not enabled")
+	ErrRecordingNotFound = errors.New("recording not found")
+	ErrAudioNotFound     = errors.New("audio object not found")
+)
+
+// cleanupBatchSize 每批清理的过期录音数
+const cleanupBatchSize = 100
+
+// AudioStore 录音对象存储 (本地文件系统 / S3 兼容存储)
+type AudioStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Open 返回对象内容及其大小，对象不存在时返回 ErrAudioNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, int64, error)
+	// Delete 删除对象，对象不存在不视为错误
+	Delete(ctx context.Context, key string) error
+}
+
+// AudioRecording 一段录音：面试官的一句合成语音或候选人的一段发言
+type AudioRecording struct {
+	ID          int64
+	InterviewID int64
+	MessageID   int64
+	Role        string // user, assistant
+	Seq         int    // 同一条消息内的片段序号，从 0 开始
+	StorageKey  string
+	Format      string
+	DurationMs  int64
+	SizeBytes   int64
+	CreatedAt   time.Time
+}
+
+// AudioRepo 录音元数据仓储接口
+type AudioRepo interface {
+	CreateRecording(ctx context.Context, rec *AudioRecording) (*AudioRecording, error)
+	GetRecording(ctx context.Context, id int64) (*AudioRecording, error)
+	ListRecordings(ctx context.Context, interviewID int64) ([]*AudioRecording, error)
+	ListRecordingsBefore(ctx context.Context, before time.Time, limit int) ([]*AudioRecording, error)
+	DeleteRecording(ctx context.Context, id int64) error
+}
+
+// AudioClip 待保存的音频片段，Duration 为 0 时从音频数据解析
+type AudioClip struct {
+	Format   string
+	Data     []byte
+	Duration time.Duration
+}
+
+// AudioContentType 音频格式对应的 MIME 类型
+func AudioContentType(format string) string {
+	switch format {
+	case "mp3":
+		return "audio/mpeg"
+	case "wav":
+		return "audio/wav"
+	case "pcm":
+		return "audio/L16;rate=24000;channels=1"
+	case "opus", "ogg":
+		return "audio/ogg"
+	case "webm":
+		return "audio/webm"
+	case "m4a", "mp4":
+		return "audio/mp4"
+	}
+	return "application/octet-stream"
+}
+
+// AudioUsecase 面试录音业务逻辑
+type AudioUsecase struct {
+	repo      AudioRepo
+	store     AudioStore
+	retention time.Duration
+	maxUpload int64
+	log       *log.Helper
+}
+
+// NewAudioUsecase 创建录音 UseCase，store 为 nil 时录音功能关闭
+func NewAudioUsecase(repo AudioRepo, store AudioStore, c *conf.Data, logger log.Logger) *AudioUsecase {
+	uc := &AudioUsecase{repo: repo, store: store, maxUpload: 10 << 20, log: log.NewHelper(logger)}
+	if c != nil && c.Audio != nil {
+		uc.retention = c.Audio.Retention.AsDuration()
+		if c.Audio.MaxUploadBytes > 0 {
+			uc.maxUpload = c.Audio.MaxUploadBytes
+		}
+	}
+	return uc
+}
+
+// Enabled 是否保存录音
+func (uc *AudioUsecase) Enabled() bool {
+	return uc != nil && uc.store != nil
+}
+
+// MaxUploadBytes 单段候选人录音的大小上限
+func (uc *AudioUsecase) MaxUploadBytes() int64 {
+	return uc.maxUpload
+}
+
+// Retention 录音保留时长，0 表示永久保留
+func (uc *AudioUsecase) Retention() time.Duration {
+	return uc.retention
+}
+
+// SaveRecordings 保存一条消息对应的全部音频片段，按顺序编号
+func (uc *AudioUsecase) SaveRecordings(ctx context.Context, interviewID, messageID int64, role string, clips []*AudioClip) ([]*AudioRecording, error) {
+	if !uc.Enabled() {
+		return nil, ErrAudioDisabled
+	}
+
+	recs := make([]*AudioRecording, 0, len(clips))
+	for seq, clip := range clips {
+		if len(clip.Data) == 0 {
+			continue
+		}
+		duration := clip.Duration
+		if d, ok := tts.AudioDuration(clip.Format, clip.Data); ok {
+			duration = d
+		}
+
+		key := fmt.Sprintf("interviews/%d/%d-%s-%d.%s", interviewID, messageID, role, seq, clip.Format)
+		if err := uc.store.Put(ctx, key, clip.Data, AudioContentType(clip.Format)); err != nil {
+			return recs, fmt.Errorf("store audio: %w", err)
+		}
+		rec, err := uc.repo.CreateRecording(ctx, &AudioRecording{
+			InterviewID: interviewID,
+			MessageID:   messageID,
+			Role:        role,
+			Seq:         seq,
+			StorageKey:  key,
+			Format:      clip.Format,
+			DurationMs:  duration.Milliseconds(),
+			SizeBytes:   int64(len(clip.Data)),
+		})
+		if err != nil {
+			_ = uc.store.Delete(ctx, key)
+			return recs, fmt.Errorf("save recording: %w", err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// ListRecordings 按消息顺序列出面试的全部录音
+func (uc *AudioUsecase) ListRecordings(ctx context.Context, interviewID int64) ([]*AudioRecording, error) {
+	return uc.repo.ListRecordings(ctx, interviewID)
+}
+
+// GetRecording 获取录音元数据
+func (uc *AudioUsecase) GetRecording(ctx context.Context, id int64) (*AudioRecording, error) {
+	return uc.repo.GetRecording(ctx, id)
+}
+
+// OpenRecording 打开录音内容
+func (uc *AudioUsecase) OpenRecording(ctx context.Context, rec *AudioRecording) (io.ReadCloser, int64, error) {
+	if !uc.Enabled() {
+		return nil, 0, ErrAudioDisabled
+	}
+	return uc.store.Open(ctx, rec.StorageKey)
+}
+
+// CleanupExpired 删除超过保留时长的录音 (对象与元数据)，返回删除数量
+func (uc *AudioUsecase) CleanupExpired(ctx context.Context) (int, error) {
+	if !uc.Enabled() || uc.retention <= 0 {
+		return 0, nil
+	}
+
+	before := time.Now().Add(-uc.retention)
+	deleted := 0
+	for {
+		recs, err := uc.repo.ListRecordingsBefore(ctx, before, cleanupBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("list expired recordings: %w", err)
+		}
+		for _, rec := range recs {
+			if err := uc.store.Delete(ctx, rec.StorageKey); err != nil {
+				return deleted, fmt.Errorf("delete audio %s: %w", rec.StorageKey, err)
+			}
+			if err := uc.repo.DeleteRecording(ctx, rec.ID); err != nil {
+				return deleted, fmt.Errorf("delete recording %d: %w", rec.ID, err)
+			}
+			deleted++
+		}
+		if len(recs) < cleanupBatchSize {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+
+	"ai-interview/internal/provider/tts"
+)
+
+// Azure OpenAI：用户配置一组资源端点与 Key，对话、语音合成与语音识别选择 azure 时共用，各自使用独立的部署。
+// 未配置时 azure 使用注册表中的 provider，凭据来自系统备用链 (base_url 为端点，model 为部署名)
+//
+// Microsoft Speech (azurespeech)：官方语音合成与识别服务，用户配置区域与 Key；未配置时同样使用系统备用链的凭据
+
+const (
+	azureProvider  = "azure"
+	speechProvider = "azurespeech"
+)
+
+// azureConfigured 用户是否配置了 Azure OpenAI 资源
+func azureConfigured(settings *UserSettings) bool {
+	return settings != nil && settings.AzureEndpoint != ""
+}
+
+// speechConfigured 用户是否配置了 Microsoft Speech 资源
+func speechConfigured(settings *UserSettings) bool {
+	return settings != nil && settings.SpeechRegion != ""
+}
+
+// ttsTarget 按名称获取 TTS 调用目标，own 表示目标自带凭据 (用户配置的 Azure OpenAI / Microsoft Speech 资源)，不使用用户设置中的 Key
+func (uc *InterviewUsecase) ttsTarget(name string, settings *UserSettings) (target tts.Target, own bool, err error) {
+	switch {
+	case name == azureProvider && azureConfigured(settings):
+		p := tts.NewAzureProvider(settings.AzureAPIVersion, settings.AzureTTSDeployment)
+		return tts.Target{Provider: p, APIKey: settings.AzureAPIKey, BaseURL: settings.AzureEndpoint}, true, nil
+	case name == speechProvider && speechConfigured(settings):
+		p := tts.NewAzureSpeechProvider(settings.SpeechRegion)
+		return tts.Target{Provider: p, APIKey: settings.SpeechAPIKey}, true, nil
+	}
+	p, err := uc.ttsRegistry.Get(name)
+	return tts.Target{Provider: p}, false, err
+}
+
+// ListTTSVoices 列出 TTS provider 的可用音色，使用用户配置的资源，未配置时使用系统备用链中同名条目的凭据
+func (uc *InterviewUsecase) ListTTSVoices(ctx context.Context, name string, settings *UserSettings) ([]*tts.Voice, error) {
+	target, own, err := uc.ttsTarget(name, settings)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrVoiceListUnsupported, err)
+	}
+	lister, ok := target.Provider.(tts.VoiceLister)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrVoiceListUnsupported, name)
+	}
+	if !own {
+		for _, fb := range uc.ttsFallbackConf {
+			if fb.Provider == name {
+				target.APIKey, target.BaseURL = fb.ApiKey, fb.BaseUrl
+				break
+			}
+		}
+	}
+	return lister.Voices(ctx, target.APIKey, target.BaseURL)
+}
@@ -3,4 +3,4 @@ package biz
 import "github.com/google/wire"
 
 // ProviderSet is biz providers.
-var ProviderSet = wire.NewSet(NewInterviewUsecase, NewUserUsecase)
+var ProviderSet = wire.NewSet(NewInterviewUsecase, NewPrompts, NewPersonas, NewUserUsecase, NewCodingUsecase, NewAudioUsecase)
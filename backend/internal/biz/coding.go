@@ -0,0 +1,314 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/sandbox"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// 面试模式
+const (
+	InterviewModeChat   = "chat"
+	InterviewModeCoding = "coding"
+)
+
+// 提交状态
+const (
+	SubmissionAccepted     = "accepted"
+	SubmissionWrongAnswer  = "wrong_answer"
+	SubmissionCompileError = "compile_error"
+)
+
+// 单个测试用例状态
+const (
+	TestPassed       = "passed"
+	TestWrongAnswer  = "wrong_answer"
+	TestRuntimeError = "runtime_error"
+	TestTimeout      = "timeout"
+)
+
+// maxCodeBytes 单次提交的代码大小上限
+const maxCodeBytes = 64 << 10
+
+var (
+	ErrCodingDisabled  = errors.New("coding mode is not enabled")
+	ErrProblemNotFound = errors.New("problem not found")
+	ErrNotCodingMode   = errors.New("interview is not in coding mode")
+)
+
+// Problem 编程题
+type Problem struct {
+	ID          string
+	Title       string
+	Difficulty  string
+	Description string
+	Templates   map[string]string // 语言 -> 初始代码
+	Tests       []TestCase
+}
+
+// TestCase 测试用例：stdin 输入与期望 stdout
+type TestCase struct {
+	Input  string
+	Output string
+	Hidden bool // 隐藏用例不向候选人展示输入输出
+}
+
+// SampleTests 返回可以展示给候选人的样例
+func (p *Problem) SampleTests() []TestCase {
+	var samples []TestCase
+	for _, tc := range p.Tests {
+		if !tc.Hidden {
+			samples = append(samples, tc)
+		}
+	}
+	return samples
+}
+
+// TestResult 单个测试用例的运行结果，隐藏用例不记录输入输出
+type TestResult struct {
+	Index      int
+	Hidden     bool
+	Status     string // passed, wrong_answer, runtime_error, timeout
+	DurationMs int64
+	Input      string
+	Expected   string
+	Actual     string
+	Stderr     string
+}
+
+// CodeSubmission 代码提交记录
+type CodeSubmission struct {
+	ID            int64
+	InterviewID   int64
+	ProblemID     string
+	Language      string
+	Code          string
+	Status        string // accepted, wrong_answer, compile_error
+	Passed        int
+	Total         int
+	CompileOutput string
+	Results       []TestResult
+	CreatedAt     time.Time
+}
+
+// CodingRepo 代码提交仓储接口
+type CodingRepo interface {
+	CreateSubmission(ctx context.Context, sub *CodeSubmission) (*CodeSubmission, error)
+	ListSubmissions(ctx context.Context, interviewID int64) ([]*CodeSubmission, error)
+}
+
+// CodingUsecase 编程面试业务逻辑
+type CodingUsecase struct {
+	repo          CodingRepo
+	interviewRepo InterviewRepo
+	sandbox       *sandbox.Sandbox
+	problems      []*Problem
+	log           *log.Helper
+}
+
+// NewCodingUsecase 创建编程面试 UseCase，sb 为 nil 时编程模式不可用
+func NewCodingUsecase(repo CodingRepo, interviewRepo InterviewRepo, sb *sandbox.Sandbox, c *conf.Coding, logger log.Logger) *CodingUsecase {
+	uc := &CodingUsecase{repo: repo, interviewRepo: interviewRepo, sandbox: sb, log: log.NewHelper(logger)}
+	if c == nil {
+		return uc
+	}
+	for _, p := range c.Problems {
+		problem := &Problem{
+			ID:          p.Id,
+			Title:       p.Title,
+			Difficulty:  p.Difficulty,
+			Description: strings.TrimSpace(p.Description),
+			Templates:   p.Templates,
+		}
+		for _, tc := range p.Tests {
+			problem.Tests = append(problem.Tests, TestCase{Input: tc.Input, Output: tc.Output, Hidden: tc.Hidden})
+		}
+		uc.problems = append(uc.problems, problem)
+	}
+	return uc
+}
+
+// Enabled 沙箱可用且题库非空
+func (uc *CodingUsecase) Enabled() bool {
+	return uc != nil && uc.sandbox != nil && len(uc.problems) > 0
+}
+
+// ListProblems 返回题库
+func (uc *CodingUsecase) ListProblems() []*Problem {
+	return uc.problems
+}
+
+// GetProblem 按 ID 获取题目
+func (uc *CodingUsecase) GetProblem(id string) (*Problem, error) {
+	for _, p := range uc.problems {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrProblemNotFound, id)
+}
+
+// assignProblem 为编程面试选题：指定 ID 时校验存在，否则从题库随机抽取
+func (uc *CodingUsecase) assignProblem(id string) (*Problem, error) {
+	if !uc.Enabled() {
+		return nil, ErrCodingDisabled
+	}
+	if id != "" {
+		return uc.GetProblem(id)
+	}
+	return uc.problems[rand.IntN(len(uc.problems))], nil
+}
+
+// Submit 编译运行候选人代码并逐个执行测试用例，结果落库
+func (uc *CodingUsecase) Submit(ctx context.Context, interviewID int64, language, code string) (*CodeSubmission, error) {
+	lang, err := uc.checkCode(language, code)
+	if err != nil {
+		return nil, err
+	}
+
+	interview, err := uc.interviewRepo.GetByID(ctx, interviewID)
+	if err != nil {
+		return nil, fmt.Errorf("get interview: %w", err)
+	}
+	if interview.Mode != InterviewModeCoding {
+		return nil, ErrNotCodingMode
+	}
+	problem, err := uc.GetProblem(interview.ProblemID)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &CodeSubmission{
+		InterviewID: interviewID,
+		ProblemID:   problem.ID,
+		Language:    string(lang),
+		Code:        code,
+		Total:       len(problem.Tests),
+	}
+	if err := uc.runTests(ctx, lang, problem, sub); err != nil {
+		return nil, err
+	}
+	return uc.repo.CreateSubmission(ctx, sub)
+}
+
+// Run 在沙箱中运行一段代码，不落库；供面试官通过 run_code 工具验证候选人的代码或自己构造的用例
+func (uc *CodingUsecase) Run(ctx context.Context, language, code, stdin string) (*sandbox.Result, error) {
+	lang, err := uc.checkCode(language, code)
+	if err != nil {
+		return nil, err
+	}
+	program, err := uc.sandbox.Prepare(ctx, lang, code)
+	if err != nil {
+		return nil, err
+	}
+	defer program.Close()
+	return program.Run(ctx, stdin)
+}
+
+// checkCode 校验沙箱可用、语言支持与代码大小
+func (uc *CodingUsecase) checkCode(language, code string) (sandbox.Language, error) {
+	if !uc.Enabled() {
+		return "", ErrCodingDisabled
+	}
+	lang, err := sandbox.ParseLanguage(language)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(code) == "" {
+		return "", errors.New("code is empty")
+	}
+	if len(code) > maxCodeBytes {
+		return "", fmt.Errorf("code exceeds %d bytes", maxCodeBytes)
+	}
+	return lang, nil
+}
+
+func (uc *CodingUsecase) runTests(ctx context.Context, lang sandbox.Language, problem *Problem, sub *CodeSubmission) error {
+	program, err := uc.sandbox.Prepare(ctx, lang, sub.Code)
+	var compileErr *sandbox.CompileError
+	if errors.As(err, &compileErr) {
+		sub.Status = SubmissionCompileError
+		sub.CompileOutput = compileErr.Output
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("prepare program: %w", err)
+	}
+	defer program.Close()
+
+	for i, tc := range problem.Tests {
+		res, err := program.Run(ctx, tc.Input)
+		if err != nil {
+			return fmt.Errorf("run test %d: %w", i+1, err)
+		}
+
+		result := TestResult{Index: i + 1, Hidden: tc.Hidden, DurationMs: res.Duration.Milliseconds()}
+		switch {
+		case res.TimedOut:
+			result.Status = TestTimeout
+		case res.ExitCode != 0:
+			result.Status = TestRuntimeError
+		case normalizeOutput(res.Stdout) == normalizeOutput(tc.Output):
+			result.Status = TestPassed
+			sub.Passed++
+		default:
+			result.Status = TestWrongAnswer
+		}
+		if !tc.Hidden {
+			result.Input, result.Expected, result.Actual = tc.Input, tc.Output, res.Stdout
+			result.Stderr = res.Stderr
+		}
+		sub.Results = append(sub.Results, result)
+	}
+
+	sub.Status = SubmissionWrongAnswer
+	if sub.Passed == sub.Total {
+		sub.Status = SubmissionAccepted
+	}
+	return nil
+}
+
+// ListSubmissions 列出面试的全部提交
+func (uc *CodingUsecase) ListSubmissions(ctx context.Context, interviewID int64) ([]*CodeSubmission, error) {
+	return uc.repo.ListSubmissions(ctx, interviewID)
+}
+
+// normalizeOutput 忽略行尾空白和末尾空行
+func normalizeOutput(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// newCodingReport 汇总提交结果：最好成绩与最后一次提交
+func newCodingReport(p *Problem, subs []*CodeSubmission) *codingReport {
+	r := &codingReport{Problem: p, Submissions: subs}
+	if len(subs) == 0 {
+		return r
+	}
+	r.Best, r.Last = subs[0], subs[len(subs)-1]
+	for _, s := range subs {
+		if s.Passed > r.Best.Passed {
+			r.Best = s
+		}
+	}
+	return r
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
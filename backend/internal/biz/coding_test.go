@@ -0,0 +1,128 @@
+package biz
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeOutput(t *testing.T) {
+	cases := []struct{ a, b string }{
+		{"3\n", "3"},
+		{"1 2  \r\n3\t\n\n", "1 2\n3"},
+		{"a\n\nb\n", "a\n\nb"},
+	}
+	for _, c := range cases {
+		if normalizeOutput(c.a) != normalizeOutput(c.b) {
+			t.Errorf("normalizeOutput(%q) != normalizeOutput(%q)", c.a, c.b)
+		}
+	}
+	if normalizeOutput(" 3") == normalizeOutput("3") {
+		t.Error("leading whitespace should be significant")
+	}
+}
+
+func testSubmission() *CodeSubmission {
+	return &CodeSubmission{
+		Language: "python",
+		Code:     "print(input())",
+		Status:   SubmissionWrongAnswer,
+		Passed:   1,
+		Total:    3,
+		Results: []TestResult{
+			{Index: 1, Status: TestPassed, Input: "a", Expected: "a", Actual: "a"},
+			{Index: 2, Status: TestWrongAnswer, Input: "b", Expected: "visible-expected", Actual: "visible-actual"},
+			{Index: 3, Hidden: true, Status: TestTimeout},
+		},
+	}
+}
+
+func TestFormatSubmission_HidesHiddenTests(t *testing.T) {
+	prompts, err := NewPrompts(nil)
+	if err != nil {
+		t.Fatalf("NewPrompts error: %v", err)
+	}
+	uc := &InterviewUsecase{prompts: prompts}
+
+	msg, err := uc.FormatSubmission(&Interview{Language: "zh-CN"}, testSubmission())
+	if err != nil {
+		t.Fatalf("FormatSubmission error: %v", err)
+	}
+	want := "[代码提交] 语言：python，结果：wrong_answer，通过 1/3 个测试用例\n" +
+		"- 测试 2：wrong_answer，期望输出 \"visible-expected\"，实际输出 \"visible-actual\"\n" +
+		"- 测试 3 (隐藏)：timeout\n" +
+		"```python\nprint(input())\n```"
+	if msg != want {
+		t.Errorf("message =\n%s\nwant\n%s", msg, want)
+	}
+}
+
+func TestFormatSubmission_English(t *testing.T) {
+	prompts, err := NewPrompts(nil)
+	if err != nil {
+		t.Fatalf("NewPrompts error: %v", err)
+	}
+	uc := &InterviewUsecase{prompts: prompts}
+
+	msg, err := uc.FormatSubmission(&Interview{Language: "en-US"}, testSubmission())
+	if err != nil {
+		t.Fatalf("FormatSubmission error: %v", err)
+	}
+	for _, want := range []string{"[Code submission] Language: python", "1/3 tests passed", `expected output "visible-expected"`, "Test 3 (hidden): timeout"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+	if strings.ContainsFunc(msg, func(r rune) bool { return r >= 0x4e00 && r <= 0x9fff }) {
+		t.Errorf("en-US message should not contain Chinese:\n%s", msg)
+	}
+
+	sub := &CodeSubmission{Language: "go", Code: "x", Status: SubmissionCompileError, CompileOutput: "undefined: x"}
+	msg, err = uc.FormatSubmission(&Interview{Language: "en-US"}, sub)
+	if err != nil || !strings.Contains(msg, "Compile error:\nundefined: x\n```go") {
+		t.Errorf("compile error message = %q, %v", msg, err)
+	}
+}
+
+func TestCodingEvaluationSection(t *testing.T) {
+	prompts, err := NewPrompts(nil)
+	if err != nil {
+		t.Fatalf("NewPrompts error: %v", err)
+	}
+	p := &Problem{Title: "两数之和", Difficulty: "easy"}
+	if s, _ := prompts.render("zh-CN", "coding_evaluation", newCodingReport(p, nil)); !strings.Contains(s, "未提交代码") {
+		t.Errorf("expected no-submission note, got %q", s)
+	}
+
+	subs := []*CodeSubmission{
+		{Language: "go", Code: "v1", Status: SubmissionWrongAnswer, Passed: 3, Total: 4},
+		{Language: "go", Code: "v2", Status: SubmissionCompileError, Passed: 0, Total: 4},
+	}
+	s, err := prompts.render("zh-CN", "coding_evaluation", newCodingReport(p, subs))
+	if err != nil {
+		t.Fatalf("render error: %v", err)
+	}
+	for _, want := range []string{"共提交 2 次", "最好成绩通过 3/4", "compile_error", "v2"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("section missing %q:\n%s", want, s)
+		}
+	}
+}
+
+func TestProblem_SampleTests(t *testing.T) {
+	p := &Problem{Tests: []TestCase{{Input: "1"}, {Input: "2", Hidden: true}, {Input: "3"}}}
+	samples := p.SampleTests()
+	if len(samples) != 2 || samples[1].Input != "3" {
+		t.Errorf("unexpected samples: %+v", samples)
+	}
+	prompts, err := NewPrompts(nil)
+	if err != nil {
+		t.Fatalf("NewPrompts error: %v", err)
+	}
+	prompt, _, err := prompts.System(&Interview{Language: "zh-CN"}, nil, nil, p, nil, 0, 0)
+	if err != nil {
+		t.Fatalf("System error: %v", err)
+	}
+	if !strings.Contains(prompt, "样例 2") || strings.Contains(prompt, "样例 3") {
+		t.Errorf("prompt should not include hidden tests:\n%s", prompt)
+	}
+}
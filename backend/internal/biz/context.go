@@ -0,0 +1,258 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+)
+
+// InterviewSummary 面试早期对话的滚动摘要：超出上下文预算的对话被压缩进摘要，之后的对话原样发送
+type InterviewSummary struct {
+	InterviewID      int64
+	Content          string   // 已压缩对话的摘要
+	Questions        []string // 已问过的问题原文，提示面试官不要重复提问
+	ThroughMessageID int64    // 已压缩到的最后一条消息 ID
+	UpdatedAt        time.Time
+}
+
+const (
+	maxOutputTokens       = 4096 // 每次调用为模型输出预留的 token
+	defaultSummarizeRatio = 0.75
+	defaultKeepRecent     = 6
+	maxSummaryQuestions   = 40 // 摘要中保留的已问问题上限，超出时保留最近的
+)
+
+// contextPolicy 对话上下文预算
+type contextPolicy struct {
+	maxHistory int              // 每轮输入 token 上限，0 表示只受上下文窗口限制
+	ratio      float64          // 超过预算的该比例时压缩早期对话
+	keepRecent int              // 压缩时原样保留的最近消息条数
+	windows    map[string]int32 // 按模型名前缀覆盖上下文窗口
+}
+
+func newContextPolicy(c *conf.LLM_Context) contextPolicy {
+	p := contextPolicy{ratio: defaultSummarizeRatio, keepRecent: defaultKeepRecent}
+	if c == nil {
+		return p
+	}
+	p.maxHistory = int(c.MaxHistoryTokens)
+	if c.SummarizeRatio > 0 && c.SummarizeRatio <= 1 {
+		p.ratio = float64(c.SummarizeRatio)
+	}
+	if c.KeepRecent > 0 {
+		p.keepRecent = int(c.KeepRecent)
+	}
+	p.windows = c.Windows
+	return p
+}
+
+// budget 返回一次调用的输入 token 上限：上下文窗口扣除输出预留，且不超过 maxHistory
+func (p contextPolicy) budget(provider, model string) int {
+	b := llm.ContextWindow(provider, model, p.windows) - maxOutputTokens
+	if p.maxHistory > 0 && p.maxHistory < b {
+		b = p.maxHistory
+	}
+	return max(b, 1)
+}
+
+// buildContext 组装本轮发送给 LLM 的消息：系统提示 (含滚动摘要与已问问题) + 摘要之后的原始对话。
+// 超过预算的 ratio 时把较早的对话压缩进摘要，只保留最近 keepRecent 条原文；
+// 压缩失败或压缩后仍超出预算时丢弃最早的对话。speaker 为小组面试中本轮发言的面试官，difficulty 为下一个问题的难度。
+func (uc *InterviewUsecase) buildContext(ctx context.Context, interview *Interview, chain llm.Provider, messages []*InterviewMessage, speaker *Panelist, difficulty int) ([]llm.Message, error) {
+	summary, err := uc.repo.GetSummary(ctx, interview.ID)
+	if err != nil {
+		if !errors.Is(err, ErrSummaryNotFound) {
+			uc.log.Warnf("get summary of interview %d: %v", interview.ID, err)
+		}
+		summary = &InterviewSummary{InterviewID: interview.ID}
+	}
+	recent := messagesAfter(messages, summary.ThroughMessageID)
+	budget := uc.context.budget(chain.Name(), interview.LLMModel)
+	asked := countRole(messages, "assistant")
+
+	built, err := uc.buildLLMMessages(interview, speaker, summary, recent, asked, difficulty)
+	if err != nil {
+		return nil, err
+	}
+	if llm.EstimateMessages(built) <= int(float64(budget)*uc.context.ratio) {
+		return built, nil
+	}
+	if older, rest := splitRecent(recent, uc.context.keepRecent); len(older) > 0 {
+		updated, err := uc.summarize(ctx, chain, interview, summary, older)
+		if err != nil {
+			uc.log.Warnf("summarize interview %d: %v", interview.ID, err)
+		} else {
+			summary, recent = updated, rest
+			if built, err = uc.buildLLMMessages(interview, speaker, summary, recent, asked, difficulty); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 单条回答过长等情况下仍可能超出预算：从最早的对话开始丢弃，至少保留最后一条
+	dropped := 0
+	for len(recent) > 1 && llm.EstimateMessages(built) > budget {
+		recent = recent[1:]
+		dropped++
+		if built, err = uc.buildLLMMessages(interview, speaker, summary, recent, asked, difficulty); err != nil {
+			return nil, err
+		}
+	}
+	if dropped > 0 {
+		uc.log.Warnf("interview %d exceeds context budget, dropped %d oldest message(s)", interview.ID, dropped)
+	}
+	return built, nil
+}
+
+// summarize 将 older 中的对话并入滚动摘要并保存。已问过的问题从面试官消息中原样提取，不依赖模型复述。
+func (uc *InterviewUsecase) summarize(ctx context.Context, chain llm.Provider, interview *Interview, prev *InterviewSummary, older []*InterviewMessage) (*InterviewSummary, error) {
+	prompt, err := uc.prompts.Summary(interview, prev.Content, older)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := uc.chatStream(ctx, chain, prompt, 0.3)
+	if err != nil {
+		return nil, err
+	}
+	content, err := collectStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	if content = strings.TrimSpace(content); content == "" {
+		return nil, errors.New("empty summary")
+	}
+
+	next := &InterviewSummary{
+		InterviewID:      interview.ID,
+		Content:          content,
+		Questions:        appendQuestions(prev.Questions, older),
+		ThroughMessageID: older[len(older)-1].ID,
+	}
+	if err := uc.repo.SaveSummary(ctx, next); err != nil {
+		return nil, fmt.Errorf("save summary: %w", err)
+	}
+	return next, nil
+}
+
+// messagesAfter 返回 ID 大于 id 的非系统消息
+func messagesAfter(messages []*InterviewMessage, id int64) []*InterviewMessage {
+	var out []*InterviewMessage
+	for _, m := range messages {
+		if m.ID > id && m.Role != "system" {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// splitRecent 将对话分为待压缩的较早部分和原样保留的最近部分 (至少 keep 条)；
+// 保留部分从候选人的消息开始，避免以面试官消息开头。
+func splitRecent(messages []*InterviewMessage, keep int) (older, recent []*InterviewMessage) {
+	i := len(messages) - keep
+	for i > 0 && messages[i].Role != "user" {
+		i--
+	}
+	if i <= 0 {
+		return nil, messages
+	}
+	return messages[:i], messages[i:]
+}
+
+// appendQuestions 把 messages 中面试官提出的问题追加到 questions (去重，超出上限时保留最近的)
+func appendQuestions(questions []string, messages []*InterviewMessage) []string {
+	out := append([]string(nil), questions...)
+	seen := make(map[string]bool, len(out))
+	for _, q := range out {
+		seen[q] = true
+	}
+	for _, m := range messages {
+		if m.Role != "assistant" {
+			continue
+		}
+		// 面试官通过 next_question 记录了问题时不再从原文提取
+		questions := extractQuestions(m.Content)
+		if m.Question != "" {
+			questions = []string{m.Question}
+		}
+		for _, q := range questions {
+			if !seen[q] {
+				seen[q] = true
+				out = append(out, q)
+			}
+		}
+	}
+	if len(out) > maxSummaryQuestions {
+		out = out[len(out)-maxSummaryQuestions:]
+	}
+	return out
+}
+
+// extractQuestions 提取文本中以问号结尾的句子
+func extractQuestions(text string) []string {
+	var out []string
+	start := 0
+	for i, r := range text {
+		end := i + utf8.RuneLen(r)
+		switch r {
+		case '.':
+			// 英文句号后须为空白或文本结尾，避免切开小数和缩写
+			if end < len(text) && text[end] != ' ' && text[end] != '\n' {
+				continue
+			}
+		case '。', '！', '!', '？', '?', '\n':
+		default:
+			continue
+		}
+		sentence := strings.TrimSpace(text[start:end])
+		start = end
+		if (r == '？' || r == '?') && utf8.RuneCountInString(sentence) > 1 {
+			out = append(out, sentence)
+		}
+	}
+	return out
+}
+
+// logUsage 转发 stream，结束时记录本轮的 token 用量与缓存命中情况
+func (uc *InterviewUsecase) logUsage(interviewID int64, stream <-chan llm.StreamEvent) <-chan llm.StreamEvent {
+	out := make(chan llm.StreamEvent, 32)
+	go func() {
+		defer close(out)
+		for event := range stream {
+			if u := event.Usage; u != nil {
+				uc.log.Debugf("interview %d llm usage: input %d (cache read %d, cache write %d), output %d",
+					interviewID, u.InputTokens, u.CachedTokens, u.CacheWriteTokens, u.OutputTokens)
+			}
+			out <- event
+		}
+	}()
+	return out
+}
+
+// collectStream 读取完整的流式回复
+func collectStream(stream <-chan llm.StreamEvent) (string, error) {
+	var sb strings.Builder
+	for event := range stream {
+		if event.Err != nil {
+			return sb.String(), event.Err
+		}
+		sb.WriteString(event.Content)
+	}
+	return sb.String(), nil
+}
+
+// countRole 统计 role 的消息条数
+func countRole(messages []*InterviewMessage, role string) int {
+	n := 0
+	for _, m := range messages {
+		if m.Role == role {
+			n++
+		}
+	}
+	return n
+}
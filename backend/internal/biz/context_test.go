@@ -0,0 +1,175 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+func TestExtractQuestions(t *testing.T) {
+	got := extractQuestions("回答得不错。请介绍一下 Go 的 GC？另外，版本 1.5 之后有什么变化? Thanks. What about escape analysis?")
+	want := []string{"请介绍一下 Go 的 GC？", "另外，版本 1.5 之后有什么变化?", "What about escape analysis?"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSplitRecent(t *testing.T) {
+	msgs := []*InterviewMessage{
+		{ID: 1, Role: "assistant"}, {ID: 2, Role: "user"}, {ID: 3, Role: "assistant"},
+		{ID: 4, Role: "user"}, {ID: 5, Role: "assistant"}, {ID: 6, Role: "user"},
+	}
+	if older, recent := splitRecent(msgs, 3); len(older) != 3 || recent[0].ID != 4 {
+		t.Errorf("older %d, recent starts at %d", len(older), recent[0].ID)
+	}
+	// 保留部分从候选人消息开始：向前扩展到 ID 4
+	older, recent := splitRecent(msgs, 2)
+	if len(older) != 3 || recent[0].ID != 4 {
+		t.Errorf("older %d, recent starts at %d", len(older), recent[0].ID)
+	}
+	if older, recent = splitRecent(msgs, 10); older != nil || len(recent) != 6 {
+		t.Errorf("nothing to compress expected, got %d / %d", len(older), len(recent))
+	}
+}
+
+func TestAppendQuestions(t *testing.T) {
+	prev := []string{"问题一？"}
+	msgs := []*InterviewMessage{
+		{Role: "assistant", Content: "问题一？"},
+		{Role: "user", Content: "这是为什么？"},
+		{Role: "assistant", Content: "好的。问题二？"},
+	}
+	if got := appendQuestions(prev, msgs); !reflect.DeepEqual(got, []string{"问题一？", "问题二？"}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+// summaryRepo 只实现摘要读写的 InterviewRepo
+type summaryRepo struct {
+	InterviewRepo
+	summary *InterviewSummary
+	saves   int
+}
+
+func (r *summaryRepo) GetSummary(context.Context, int64) (*InterviewSummary, error) {
+	if r.summary == nil {
+		return nil, ErrSummaryNotFound
+	}
+	return r.summary, nil
+}
+
+func (r *summaryRepo) SaveSummary(_ context.Context, s *InterviewSummary) error {
+	r.summary = s
+	r.saves++
+	return nil
+}
+
+// summaryLLM 总是返回固定摘要，并记录收到的请求
+type summaryLLM struct{ requests [][]llm.Message }
+
+func (p *summaryLLM) Name() string { return "fake" }
+
+func (p *summaryLLM) ChatStream(_ context.Context, req *llm.ChatRequest) (<-chan llm.StreamEvent, error) {
+	p.requests = append(p.requests, req.Messages)
+	ch := make(chan llm.StreamEvent, 2)
+	ch <- llm.StreamEvent{Content: "候选人熟悉 Go 并发。"}
+	ch <- llm.StreamEvent{Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func TestBuildContext_RollingSummary(t *testing.T) {
+	repo := &summaryRepo{}
+	prompts, err := NewPrompts(nil)
+	if err != nil {
+		t.Fatalf("NewPrompts error: %v", err)
+	}
+	uc := &InterviewUsecase{
+		repo:    repo,
+		prompts: prompts,
+		context: newContextPolicy(&conf.LLM_Context{MaxHistoryTokens: 600, KeepRecent: 4}),
+		log:     log.NewHelper(log.DefaultLogger),
+	}
+	interview := &Interview{ID: 1, Position: "后端工程师", Language: "zh-CN"}
+	provider := &summaryLLM{}
+
+	var msgs []*InterviewMessage
+	for i := 1; i <= 20; i += 2 {
+		msgs = append(msgs,
+			&InterviewMessage{ID: int64(i), Role: "assistant", Content: fmt.Sprintf("第 %d 个问题：请谈谈你的项目经验？", i)},
+			&InterviewMessage{ID: int64(i + 1), Role: "user", Content: strings.Repeat("我负责后端服务的设计与实现。", 3)},
+		)
+	}
+
+	built, err := uc.buildContext(context.Background(), interview, provider, msgs, nil, 0)
+	if err != nil {
+		t.Fatalf("buildContext error: %v", err)
+	}
+	if repo.saves != 1 || len(provider.requests) != 1 {
+		t.Fatalf("expected one summarization, saves %d, llm calls %d", repo.saves, len(provider.requests))
+	}
+	if repo.summary.ThroughMessageID != 15 || len(repo.summary.Questions) != 8 {
+		t.Errorf("summary covers through %d with %d questions", repo.summary.ThroughMessageID, len(repo.summary.Questions))
+	}
+	system := built[0].Content
+	if !strings.Contains(system, "候选人熟悉 Go 并发。") || !strings.Contains(system, "第 15 个问题：请谈谈你的项目经验？") {
+		t.Errorf("system prompt missing summary or asked questions:\n%s", system)
+	}
+	if len(built) != 6 || built[1].Content != msgs[15].Content {
+		t.Errorf("expected system + 5 recent messages, got %d", len(built))
+	}
+	if llm.EstimateMessages(built) > 600 {
+		t.Errorf("context exceeds budget: %d tokens", llm.EstimateMessages(built))
+	}
+
+	// 未超出阈值时直接使用已有摘要，不再调用 LLM
+	if _, err := uc.buildContext(context.Background(), interview, provider, msgs, nil, 0); err != nil {
+		t.Fatalf("buildContext error: %v", err)
+	}
+	if len(provider.requests) != 1 {
+		t.Errorf("summary should be reused, got %d llm calls", len(provider.requests))
+	}
+}
+
+func TestBuildLLMMessages_StablePrefix(t *testing.T) {
+	personas, _ := NewPersonas(nil)
+	uc := &InterviewUsecase{prompts: newTestPrompts(t, &conf.Interview{MaxQuestions: 2}), personas: personas, log: log.NewHelper(log.DefaultLogger)}
+	interview := &Interview{Position: "Go", Language: "en-US", Resume: "5 years of Go."}
+	msgs := []*InterviewMessage{
+		{Role: "assistant", Content: "Tell me about goroutines."},
+		{Role: "user", Content: "Lightweight threads."},
+	}
+
+	first, err := uc.buildLLMMessages(interview, nil, nil, msgs, 1, 3)
+	if err != nil {
+		t.Fatalf("buildLLMMessages error: %v", err)
+	}
+	msgs = append(msgs, &InterviewMessage{Role: "assistant", Content: "How are they scheduled?"}, &InterviewMessage{Role: "user", Content: "M:N."})
+	second, err := uc.buildLLMMessages(interview, nil, nil, msgs, 2, 4)
+	if err != nil {
+		t.Fatalf("buildLLMMessages error: %v", err)
+	}
+
+	// 难度与结束要求每轮变化，放在对话之后，前一轮的系统提示与历史是本轮请求的前缀
+	if len(first) != 4 || !reflect.DeepEqual(first[:3], second[:3]) {
+		t.Errorf("system prompt and history should be a stable prefix:\n%+v\n%+v", first, second)
+	}
+	if last := first[len(first)-1]; last.Role != "system" || !strings.Contains(last.Content, "level 3") {
+		t.Errorf("turn instructions should follow the conversation: %+v", last)
+	}
+	if last := second[len(second)-1]; last.Role != "system" || !strings.Contains(last.Content, "Do not ask any new questions") {
+		t.Errorf("closing instructions should follow the conversation: %+v", last)
+	}
+
+	plain, _ := uc.buildLLMMessages(interview, nil, nil, msgs, 1, 0)
+	if last := plain[len(plain)-1]; last.Role != "user" {
+		t.Errorf("no turn instructions expected, got %+v", last)
+	}
+}
@@ -0,0 +1,214 @@
+package biz
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+)
+
+// 自定义 LLM provider：用户 (或管理员在配置中) 注册的 OpenAI 兼容服务，与内置 provider 一样按名称使用
+
+// provider 列表中的来源
+const (
+	ProviderScopeBuiltin = "builtin" // 内置 provider
+	ProviderScopeSystem  = "system"  // 管理员在配置中注册，对所有用户可用
+	ProviderScopeUser    = "user"    // 用户自己注册
+)
+
+const maxCustomHeaders = 16
+
+var customProviderName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// reservedHeaders 由 provider 自己设置的请求头，自定义 headers 不能覆盖 (凭据另加 AuthHeader)
+var reservedHeaders = []string{"Authorization", "Api-Key", "Host", "Content-Type"}
+
+// CustomProvider 用户注册的 OpenAI 兼容服务
+type CustomProvider struct {
+	ID           int64
+	UserID       int64
+	Name         string
+	BaseURL      string
+	Auth         string // bearer / header / none
+	AuthHeader   string // Auth 为 header 时携带 Key 的请求头
+	APIKey       string // 已加密
+	DefaultModel string
+	Headers      map[string]string // 明文存储并返回给用户，不用于携带凭据
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// ProviderInfo provider 列表项，Custom 仅用户注册的 provider 有值
+type ProviderInfo struct {
+	Name         string
+	Scope        string
+	DefaultModel string
+	Custom       *CustomProvider
+}
+
+// provider 创建调用该服务的 LLM provider，API Key 由调用方随请求传入
+func (cp *CustomProvider) provider() llm.Provider {
+	return llm.NewCustomProvider(llm.CustomConfig{
+		Name:         cp.Name,
+		BaseURL:      cp.BaseURL,
+		Auth:         cp.Auth,
+		AuthHeader:   cp.AuthHeader,
+		DefaultModel: cp.DefaultModel,
+		Headers:      cp.Headers,
+	})
+}
+
+// findCustomProvider 按名称查找用户注册的 provider
+func findCustomProvider(list []*CustomProvider, name string) *CustomProvider {
+	for _, cp := range list {
+		if cp.Name == name {
+			return cp
+		}
+	}
+	return nil
+}
+
+// checkCustomProvider 校验并补全用户注册的 provider，名称不能与内置或系统级 provider 重复
+func (uc *UserUsecase) checkCustomProvider(cp *CustomProvider) error {
+	if !customProviderName.MatchString(cp.Name) {
+		return fmt.Errorf("%w: name must be 1-50 lowercase letters, digits, '-' or '_'", ErrInvalidCustomProvider)
+	}
+	if uc.llmRegistry != nil {
+		if _, err := uc.llmRegistry.Get(cp.Name); err == nil {
+			return fmt.Errorf("%w: name %q is already in use", ErrInvalidCustomProvider, cp.Name)
+		}
+	}
+	u, err := url.Parse(cp.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: base_url must be an http(s) URL", ErrInvalidCustomProvider)
+	}
+	switch cp.Auth {
+	case "":
+		cp.Auth = llm.AuthBearer
+	case llm.AuthBearer, llm.AuthNone:
+	case llm.AuthHeader:
+		if cp.AuthHeader == "" {
+			return fmt.Errorf("%w: auth_header is required for header auth", ErrInvalidCustomProvider)
+		}
+	default:
+		return fmt.Errorf("%w: unknown auth %q", ErrInvalidCustomProvider, cp.Auth)
+	}
+	if cp.Auth != llm.AuthHeader {
+		cp.AuthHeader = ""
+	}
+	if len(cp.Headers) > maxCustomHeaders {
+		return fmt.Errorf("%w: at most %d headers", ErrInvalidCustomProvider, maxCustomHeaders)
+	}
+	for k := range cp.Headers {
+		if k == "" {
+			return fmt.Errorf("%w: empty header name", ErrInvalidCustomProvider)
+		}
+		if slices.ContainsFunc(reservedHeaders, func(h string) bool { return strings.EqualFold(h, k) }) ||
+			strings.EqualFold(k, cp.AuthHeader) {
+			return fmt.Errorf("%w: header %q is set by the provider; put credentials in api_key", ErrInvalidCustomProvider, k)
+		}
+	}
+	return nil
+}
+
+// ListLLMProviders 列出用户可用的 LLM provider：内置、系统级与用户注册的
+func (uc *UserUsecase) ListLLMProviders(ctx context.Context, userID int64) ([]*ProviderInfo, error) {
+	var list []*ProviderInfo
+	if uc.llmRegistry != nil {
+		names := uc.llmRegistry.List()
+		slices.Sort(names)
+		for _, name := range names {
+			info := &ProviderInfo{Name: name, Scope: ProviderScopeBuiltin}
+			if cp := uc.systemProviders[name]; cp != nil {
+				info.Scope, info.DefaultModel = ProviderScopeSystem, cp.DefaultModel
+			}
+			list = append(list, info)
+		}
+	}
+	custom, err := uc.repo.ListCustomProviders(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list custom providers: %w", err)
+	}
+	for _, cp := range custom {
+		list = append(list, &ProviderInfo{Name: cp.Name, Scope: ProviderScopeUser, DefaultModel: cp.DefaultModel, Custom: cp})
+	}
+	return list, nil
+}
+
+// ListCustomProviders 列出用户注册的 provider
+func (uc *UserUsecase) ListCustomProviders(ctx context.Context, userID int64) ([]*CustomProvider, error) {
+	return uc.repo.ListCustomProviders(ctx, userID)
+}
+
+// SaveCustomProvider 注册或更新用户的 provider；APIKey 为空时保留已保存的 Key
+func (uc *UserUsecase) SaveCustomProvider(ctx context.Context, cp *CustomProvider) error {
+	if err := uc.checkCustomProvider(cp); err != nil {
+		return err
+	}
+	if cp.APIKey == "" {
+		list, err := uc.repo.ListCustomProviders(ctx, cp.UserID)
+		if err != nil {
+			return fmt.Errorf("list custom providers: %w", err)
+		}
+		if existing := findCustomProvider(list, cp.Name); existing != nil {
+			cp.APIKey = existing.APIKey
+		}
+	}
+	return uc.repo.SaveCustomProvider(ctx, cp)
+}
+
+// DeleteCustomProvider 删除用户注册的 provider
+func (uc *UserUsecase) DeleteCustomProvider(ctx context.Context, userID int64, name string) error {
+	ok, err := uc.repo.DeleteCustomProvider(ctx, userID, name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrCustomProviderNotFound
+	}
+	return nil
+}
+
+// ListAllCustomProviders 分页列出所有用户注册的 provider (供密钥轮换使用)
+func (uc *UserUsecase) ListAllCustomProviders(ctx context.Context, afterID int64, limit int) ([]*CustomProvider, error) {
+	return uc.repo.ListAllCustomProviders(ctx, afterID, limit)
+}
+
+// ReplaceCustomProviderKey 以比较后写入的方式替换 provider 的 API Key 密文
+func (uc *UserUsecase) ReplaceCustomProviderKey(ctx context.Context, id int64, old, updated string) (bool, error) {
+	return uc.repo.ReplaceCustomProviderKey(ctx, id, old, updated)
+}
+
+// systemProviderSet 配置中的系统级 provider，按名称索引
+func systemProviderSet(c *conf.LLM) map[string]*conf.LLM_CustomProvider {
+	set := map[string]*conf.LLM_CustomProvider{}
+	if c != nil {
+		for _, cp := range c.CustomProviders {
+			set[cp.Name] = cp
+		}
+	}
+	return set
+}
+
+// llmTarget 按名称获取 LLM 调用目标，own 表示目标自带凭据 (用户注册的 provider 或用户配置的 Azure 资源)，
+// 不使用用户设置中的 Key 与 Base URL。用户注册的 provider 优先 (其名称不会与注册表重复)
+func (uc *InterviewUsecase) llmTarget(name string, settings *UserSettings) (target llm.Target, own bool, err error) {
+	if settings != nil {
+		if cp := findCustomProvider(settings.CustomProviders, name); cp != nil {
+			// BaseURL 同时区分不同用户的同名 provider 的熔断状态
+			return llm.Target{Provider: cp.provider(), APIKey: cp.APIKey, BaseURL: cp.BaseURL}, true, nil
+		}
+		if name == azureProvider && azureConfigured(settings) {
+			p := llm.NewAzureProvider(settings.AzureAPIVersion, settings.AzureChatDeployment)
+			return llm.Target{Provider: p, APIKey: settings.AzureAPIKey, BaseURL: settings.AzureEndpoint}, true, nil
+		}
+	}
+	p, err := uc.llmRegistry.Get(name)
+	return llm.Target{Provider: p}, false, err
+}
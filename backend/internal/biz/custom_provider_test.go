@@ -0,0 +1,71 @@
+package biz
+
+import (
+	"errors"
+	"testing"
+
+	"ai-interview/internal/provider/llm"
+)
+
+func TestCheckCustomProvider(t *testing.T) {
+	registry := llm.NewRegistry()
+	registry.Register(llm.NewOpenAIProvider())
+	uc := &UserUsecase{llmRegistry: registry}
+
+	cp := &CustomProvider{Name: "my-vllm", BaseURL: "http://localhost:8000/v1", AuthHeader: "api-key", Headers: map[string]string{"X-Tenant": "acme"}}
+	if err := uc.checkCustomProvider(cp); err != nil {
+		t.Fatalf("checkCustomProvider error: %v", err)
+	}
+	if cp.Auth != llm.AuthBearer || cp.AuthHeader != "" {
+		t.Errorf("bearer auth should be the default without auth header: %+v", cp)
+	}
+
+	invalid := map[string]*CustomProvider{
+		"builtin name":   {Name: "openai", BaseURL: "https://example.com/v1"},
+		"bad name":       {Name: "My Gateway", BaseURL: "https://example.com/v1"},
+		"no scheme":      {Name: "gw", BaseURL: "example.com/v1"},
+		"ftp":            {Name: "gw", BaseURL: "ftp://example.com"},
+		"unknown auth":   {Name: "gw", BaseURL: "https://example.com/v1", Auth: "basic"},
+		"missing header": {Name: "gw", BaseURL: "https://example.com/v1", Auth: llm.AuthHeader},
+		"empty header":   {Name: "gw", BaseURL: "https://example.com/v1", Headers: map[string]string{"": "x"}},
+		"authorization":  {Name: "gw", BaseURL: "https://example.com/v1", Headers: map[string]string{"authorization": "Bearer x"}},
+		"api-key":        {Name: "gw", BaseURL: "https://example.com/v1", Headers: map[string]string{"API-KEY": "x"}},
+		"host":           {Name: "gw", BaseURL: "https://example.com/v1", Headers: map[string]string{"Host": "evil.example"}},
+		"content-type":   {Name: "gw", BaseURL: "https://example.com/v1", Headers: map[string]string{"Content-Type": "text/plain"}},
+		"auth header": {Name: "gw", BaseURL: "https://example.com/v1", Auth: llm.AuthHeader, AuthHeader: "X-Gateway-Key",
+			Headers: map[string]string{"x-gateway-key": "x"}},
+	}
+	for name, cp := range invalid {
+		if err := uc.checkCustomProvider(cp); !errors.Is(err, ErrInvalidCustomProvider) {
+			t.Errorf("%s: expected ErrInvalidCustomProvider, got %v", name, err)
+		}
+	}
+}
+
+func TestLLMTarget(t *testing.T) {
+	registry := llm.NewRegistry()
+	registry.Register(llm.NewOpenAIProvider())
+	registry.Register(llm.NewAzureProvider("", ""))
+	uc := &InterviewUsecase{llmRegistry: registry}
+	settings := &UserSettings{CustomProviders: []*CustomProvider{{Name: "my-vllm", BaseURL: "http://localhost:8000/v1", APIKey: "vllm-key"}}}
+
+	target, own, err := uc.llmTarget("my-vllm", settings)
+	if err != nil || !own || target.Provider.Name() != "my-vllm" || target.APIKey != "vllm-key" || target.BaseURL != "http://localhost:8000/v1" {
+		t.Errorf("user provider not resolved: %+v %v", target, err)
+	}
+	if target, own, err := uc.llmTarget("openai", settings); err != nil || own || target.Provider.Name() != "openai" {
+		t.Errorf("builtin provider not resolved: %v", err)
+	}
+	if _, _, err := uc.llmTarget("my-vllm", nil); err == nil {
+		t.Error("user provider should not be visible without settings")
+	}
+
+	if _, own, _ := uc.llmTarget("azure", settings); own {
+		t.Error("azure without a configured resource should use the registry")
+	}
+	settings.AzureEndpoint, settings.AzureAPIKey = "https://acme.openai.azure.com", "azure-key"
+	target, own, err = uc.llmTarget("azure", settings)
+	if err != nil || !own || target.APIKey != "azure-key" || target.BaseURL != settings.AzureEndpoint {
+		t.Errorf("azure resource not used: %+v %v", target, err)
+	}
+}
@@ -0,0 +1,68 @@
+package biz
+
+import (
+	"strings"
+	"unicode"
+
+	"ai-interview/internal/provider/tts"
+)
+
+// 面试官语句的朗读方式：按句推断语气与语言，由 TTS provider 映射为语气指令、SSML 风格或音色参数
+
+// 追问与鼓励的提示词 (小写匹配)
+var (
+	probeCues = []string{
+		"why", "elaborate", "tell me more", "specifically", "what if", "what would happen", "walk me through", "for example",
+		"为什么", "具体", "详细", "展开", "举个例子", "如果", "怎么保证",
+	}
+	praiseCues = []string{
+		"great", "good", "nice", "excellent", "well done", "exactly", "that's right", "impressive",
+		"不错", "很好", "很棒", "非常好", "没错", "回答得", "思路清晰",
+	}
+)
+
+// sentenceDelivery 推断一句话的语气：问句为中性提问 (含追问提示词时为追问)，含肯定词的陈述为鼓励
+func sentenceDelivery(text string) string {
+	lower := strings.ToLower(text)
+	trimmed := strings.TrimSpace(text)
+	if strings.HasSuffix(trimmed, "?") || strings.HasSuffix(trimmed, "？") {
+		if containsAny(lower, probeCues) {
+			return tts.DeliveryProbing
+		}
+		return tts.DeliveryNeutral
+	}
+	if containsAny(lower, praiseCues) {
+		return tts.DeliveryEncouraging
+	}
+	return tts.DeliveryNeutral
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// sentenceLanguage 推断一句话的朗读语言：中文面试中不含汉字的英文句子用英文朗读，英文面试中含汉字的句子用中文朗读
+func sentenceLanguage(text, language string) string {
+	var han, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			latin++
+		}
+	}
+	chinese := strings.HasPrefix(language, "zh")
+	switch {
+	case chinese && han == 0 && latin > 0:
+		return "en-US"
+	case !chinese && strings.HasPrefix(language, "en") && han > 0:
+		return "zh-CN"
+	}
+	return language
+}
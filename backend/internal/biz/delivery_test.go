@@ -0,0 +1,61 @@
+package biz
+
+import (
+	"testing"
+
+	"ai-interview/internal/provider/tts"
+)
+
+func TestSentenceDelivery(t *testing.T) {
+	tests := map[string]string{
+		"Tell me about your last project.":           tts.DeliveryNeutral,
+		"How does a goroutine differ from a thread?": tts.DeliveryNeutral,
+		"Why did you choose Kafka over RabbitMQ?":    tts.DeliveryProbing,
+		"Great, that's a solid answer.":              tts.DeliveryEncouraging,
+		"你能具体说说缓存是怎么失效的吗？":                           tts.DeliveryProbing,
+		"很好，思路很清晰。":                                  tts.DeliveryEncouraging,
+		"请介绍一下你自己。":                                  tts.DeliveryNeutral,
+	}
+	for text, want := range tests {
+		if got := sentenceDelivery(text); got != want {
+			t.Errorf("sentenceDelivery(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestSentenceLanguage(t *testing.T) {
+	tests := []struct {
+		text, language, want string
+	}{
+		{"请介绍一下 goroutine 的调度。", "zh-CN", "zh-CN"},
+		{"What is a goroutine?", "zh-CN", "en-US"},
+		{"What is a goroutine?", "en-US", "en-US"},
+		{"你好。", "en-US", "zh-CN"},
+		{"42.", "zh-CN", "zh-CN"},
+	}
+	for _, tt := range tests {
+		if got := sentenceLanguage(tt.text, tt.language); got != tt.want {
+			t.Errorf("sentenceLanguage(%q, %q) = %q, want %q", tt.text, tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestSpeechRequest(t *testing.T) {
+	speech := &Speech{Speed: 1, Language: "zh-CN"}
+	sentences := []string{"请看代码：\n```go\nfunc f() {", "return 1\n}\n```", "See https://zh.wikipedia.org/wiki/哈希表 for details."}
+	var got []*tts.Request
+	for _, s := range sentences {
+		got = append(got, speech.Request(s))
+	}
+
+	if got[0] == nil {
+		t.Fatal("text before the code block should be spoken")
+	}
+	if got[1] != nil {
+		t.Errorf("sentence inside the code block should not be synthesized: %+v", got[1])
+	}
+	// 语言按去掉链接后的文本推断
+	if got[2] == nil || got[2].Language != "en-US" {
+		t.Errorf("language should be inferred after normalization: %+v", got[2])
+	}
+}
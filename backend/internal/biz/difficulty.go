@@ -0,0 +1,165 @@
+package biz
+
+import (
+	"context"
+	"math"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+)
+
+// 自适应难度：每个回答由模型评分，按 问题难度 + 得分 估计候选人的能力等级，据此决定下一个问题的难度
+
+const (
+	minDifficulty = 1
+	maxDifficulty = 5
+
+	defaultInitialDifficulty = 3
+	passingScore             = 60  // 恰好胜任当前难度的得分
+	scorePerLevel            = 20  // 得分每高出 / 低于 passingScore 该值，视为能力高 / 低一级
+	skillSmoothing           = 0.5 // 能力估计的指数平滑系数，越大越看重最近的回答
+)
+
+// problemDifficulty 编程题难度对应的初始等级
+var problemDifficulty = map[string]int{"easy": 2, "medium": 3, "hard": 4}
+
+// difficultyPolicy 自适应难度配置
+type difficultyPolicy struct {
+	enabled bool
+	initial int
+}
+
+func newDifficultyPolicy(c *conf.Interview) difficultyPolicy {
+	p := difficultyPolicy{initial: defaultInitialDifficulty}
+	if c == nil || c.Difficulty == nil {
+		return p
+	}
+	p.enabled = c.Difficulty.Enabled
+	if c.Difficulty.Initial >= minDifficulty && c.Difficulty.Initial <= maxDifficulty {
+		p.initial = int(c.Difficulty.Initial)
+	}
+	return p
+}
+
+// initialLevel 第一个问题的难度：编程面试按题目难度，其余使用配置
+func (uc *InterviewUsecase) initialLevel(interview *Interview) int {
+	if interview.Mode == InterviewModeCoding {
+		if problem, err := uc.coding.GetProblem(interview.ProblemID); err == nil {
+			if level, ok := problemDifficulty[problem.Difficulty]; ok {
+				return level
+			}
+		}
+	}
+	return uc.difficulty.initial
+}
+
+// nextDifficulty 返回下一个问题的难度，未启用自适应难度时为 0
+func (uc *InterviewUsecase) nextDifficulty(interview *Interview, messages []*InterviewMessage) int {
+	if !uc.difficulty.enabled {
+		return 0
+	}
+	initial := uc.initialLevel(interview)
+	last := lastDifficulty(messages)
+	if last == 0 {
+		return initial
+	}
+	return stepDifficulty(estimateSkill(float64(initial), messages), last)
+}
+
+// estimateLevel 返回评估报告中的能力估计 (1-5，保留一位小数)，没有已评分的回答时为 0
+func (uc *InterviewUsecase) estimateLevel(interview *Interview, messages []*InterviewMessage) float64 {
+	if !hasGrades(messages) {
+		return 0
+	}
+	return math.Round(estimateSkill(float64(uc.initialLevel(interview)), messages)*10) / 10
+}
+
+// estimateSkill 按时间顺序回放已评分的回答：在难度 d 的问题上得分 s，视为能力 d + (s-60)/20 的一次观测，
+// 对观测做指数平滑。未评分的回答不影响估计。
+func estimateSkill(initial float64, messages []*InterviewMessage) float64 {
+	skill := initial
+	level := 0
+	for _, m := range messages {
+		switch {
+		case m.Role == "assistant":
+			level = m.Difficulty
+		case m.Role == "user" && m.Score != nil && level > 0:
+			observed := float64(level) + float64(*m.Score-passingScore)/scorePerLevel
+			skill += skillSmoothing * (observed - skill)
+		}
+	}
+	return clampLevel(skill)
+}
+
+// stepDifficulty 取最接近能力估计的难度，每个问题最多调整一级
+func stepDifficulty(skill float64, last int) int {
+	target := int(math.Round(skill))
+	return min(max(target, last-1, minDifficulty), last+1, maxDifficulty)
+}
+
+func clampLevel(v float64) float64 {
+	return min(max(v, minDifficulty), maxDifficulty)
+}
+
+// lastDifficulty 返回最近一个面试官问题的难度
+func lastDifficulty(messages []*InterviewMessage) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" {
+			return messages[i].Difficulty
+		}
+	}
+	return 0
+}
+
+func hasGrades(messages []*InterviewMessage) bool {
+	for _, m := range messages {
+		if m.Score != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// gradeAnswer 让模型 (面试的 LLM 设置) 为候选人对上一个问题的回答评分；没有带难度的问题或评分失败时返回 nil
+func (uc *InterviewUsecase) gradeAnswer(ctx context.Context, interview *Interview, history []*InterviewMessage, answer string, settings *UserSettings) *int32 {
+	var question *InterviewMessage
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == "assistant" {
+			question = history[i]
+			break
+		}
+	}
+	if question == nil || question.Difficulty == 0 {
+		return nil
+	}
+	score, err := uc.grade(ctx, interview, question, answer, settings)
+	if err != nil {
+		uc.log.Warnf("grade answer of interview %d: %v", interview.ID, err)
+		return nil
+	}
+	return &score
+}
+
+func (uc *InterviewUsecase) grade(ctx context.Context, interview *Interview, question *InterviewMessage, answer string, settings *UserSettings) (int32, error) {
+	chain, err := uc.resolveLLM(interview, settings)
+	if err != nil {
+		return 0, err
+	}
+	prompt, err := uc.prompts.Grading(interview, question, answer)
+	if err != nil {
+		return 0, err
+	}
+	result, err := llm.ChatJSON[gradeResult](ctx, chain, &llm.ChatRequest{
+		Messages:  prompt,
+		MaxTokens: maxOutputTokens,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.Score, nil
+}
+
+// gradeResult 是评分请求的结构化输出
+type gradeResult struct {
+	Score int32 `json:"score" jsonschema:"minimum=0,maximum=100"`
+}
@@ -0,0 +1,151 @@
+package biz
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+	"ai-interview/internal/provider/resilience"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+func score(s int32) *int32 { return &s }
+
+func TestEstimateSkill(t *testing.T) {
+	msgs := []*InterviewMessage{
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Difficulty: 3},
+		{Role: "user", Score: score(100)}, // 观测 5 -> 4
+		{Role: "assistant", Difficulty: 4},
+		{Role: "user", Score: score(20)}, // 观测 2 -> 3
+		{Role: "assistant", Difficulty: 3},
+		{Role: "user"}, // 未评分
+	}
+	if got := estimateSkill(3, msgs[:3]); got != 4 {
+		t.Errorf("after a perfect answer: got %v, want 4", got)
+	}
+	if got := estimateSkill(3, msgs); got != 3 {
+		t.Errorf("after a failed harder question: got %v, want 3", got)
+	}
+	// 估计限制在 1-5
+	if got := estimateSkill(5, []*InterviewMessage{{Role: "assistant", Difficulty: 5}, {Role: "user", Score: score(100)}}); got != 5 {
+		t.Errorf("skill should be capped at 5, got %v", got)
+	}
+}
+
+func TestStepDifficulty(t *testing.T) {
+	tests := []struct {
+		skill float64
+		last  int
+		want  int
+	}{
+		{4.6, 3, 4}, // 每次最多上调一级
+		{1.2, 3, 2},
+		{3.4, 3, 3},
+		{5, 5, 5},
+		{1, 1, 1},
+	}
+	for _, tt := range tests {
+		if got := stepDifficulty(tt.skill, tt.last); got != tt.want {
+			t.Errorf("stepDifficulty(%v, %d) = %d, want %d", tt.skill, tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestNextDifficulty(t *testing.T) {
+	interview := &Interview{Mode: InterviewModeChat}
+	disabled := &InterviewUsecase{difficulty: newDifficultyPolicy(nil)}
+	if got := disabled.nextDifficulty(interview, nil); got != 0 {
+		t.Errorf("disabled: got %d", got)
+	}
+
+	uc := &InterviewUsecase{difficulty: newDifficultyPolicy(&conf.Interview{Difficulty: &conf.Interview_Difficulty{Enabled: true, Initial: 2}})}
+	msgs := []*InterviewMessage{{Role: "user", Content: "hi"}}
+	if got := uc.nextDifficulty(interview, msgs); got != 2 {
+		t.Errorf("first question: got %d, want 2", got)
+	}
+	msgs = append(msgs, &InterviewMessage{Role: "assistant", Difficulty: 2}, &InterviewMessage{Role: "user", Score: score(95)})
+	if got := uc.nextDifficulty(interview, msgs); got != 3 {
+		t.Errorf("after a strong answer: got %d, want 3", got)
+	}
+	if got := uc.estimateLevel(interview, msgs); got != 2.9 {
+		t.Errorf("estimateLevel = %v, want 2.9", got)
+	}
+	if got := uc.estimateLevel(interview, msgs[:2]); got != 0 {
+		t.Errorf("estimateLevel without grades = %v, want 0", got)
+	}
+}
+
+func TestGradeAnswer(t *testing.T) {
+	registry := llm.NewRegistry()
+	registry.Register(&moderatorLLM{reply: "```json\n{\"score\": 75}\n```"})
+	uc := &InterviewUsecase{
+		llmRegistry: registry,
+		llmGuard:    resilience.NewGuard(resilience.Config{}),
+		prompts:     newTestPrompts(t, nil),
+		log:         log.NewHelper(log.DefaultLogger),
+	}
+	interview := &Interview{LLMProvider: "moderator", Position: "Go"}
+	history := []*InterviewMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Difficulty: 3, Content: "Explain channels."}}
+
+	if got := uc.gradeAnswer(context.Background(), interview, history, "They pass values.", nil); got == nil || *got != 75 {
+		t.Errorf("expected score 75, got %v", got)
+	}
+	// 不符合 Schema 的输出不评分
+	registry.Register(&moderatorLLM{reply: `{"score": 120}`})
+	if got := uc.gradeAnswer(context.Background(), interview, history, "They pass values.", nil); got != nil {
+		t.Errorf("out of range score should be rejected, got %d", *got)
+	}
+	// 问题未分级 (未启用自适应难度时提出) 不评分
+	history[1].Difficulty = 0
+	if got := uc.gradeAnswer(context.Background(), interview, history, "They pass values.", nil); got != nil {
+		t.Errorf("ungraded question should not be scored, got %d", *got)
+	}
+}
+
+func TestPrompts_Difficulty(t *testing.T) {
+	p := newTestPrompts(t, &conf.Interview{MaxQuestions: 10})
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{"zh-CN", "下一个问题的难度为 4 级：进阶"},
+		{"en-US", "next question should be at level 4: advanced"},
+	}
+	for _, tt := range tests {
+		interview := &Interview{Position: "Go", Language: tt.language}
+		system, turn, err := p.System(interview, nil, nil, nil, nil, 2, 4)
+		if err != nil {
+			t.Fatalf("%s: System error: %v", tt.language, err)
+		}
+		if !strings.Contains(turn, tt.want) || strings.Contains(system, tt.want) {
+			t.Errorf("%s: difficulty belongs to the turn instructions, got %q", tt.language, turn)
+		}
+		// 收尾时不再要求出题难度
+		if _, turn, _ := p.System(interview, nil, nil, nil, nil, 10, 4); strings.Contains(turn, tt.want) {
+			t.Errorf("%s: closing prompt should not ask for a new question", tt.language)
+		}
+	}
+
+	interview := &Interview{Position: "Go", Language: "en-US"}
+	msgs := []*InterviewMessage{{Role: "assistant", Difficulty: 4, Content: "Explain the scheduler."}, {Role: "user", Content: "M:N."}}
+	eval, err := p.Evaluation(interview, nil, msgs, nil, 3.5)
+	if err != nil {
+		t.Fatalf("Evaluation error: %v", err)
+	}
+	for _, want := range []string{"Interviewer [difficulty 4]: Explain the scheduler.", "estimated level is 3.5"} {
+		if !strings.Contains(eval[1].Content, want) {
+			t.Errorf("evaluation request missing %q:\n%s", want, eval[1].Content)
+		}
+	}
+	grading, err := p.Grading(interview, msgs[0], "M:N.")
+	if err != nil {
+		t.Fatalf("Grading error: %v", err)
+	}
+	if !strings.Contains(grading[1].Content, "level 4 (1-5)") || !strings.Contains(grading[1].Content, "M:N.") {
+		t.Errorf("unexpected grading request:\n%s", grading[1].Content)
+	}
+}
@@ -13,7 +13,21 @@ var (
 	ErrInvalidPassword    = errors.New("invalid password")
 	ErrInterviewNotFound  = errors.New("interview not found")
 	ErrInterviewEnded     = errors.New("interview already ended")
+	ErrEvaluationNotFound = errors.New("evaluation not found")
+	ErrSummaryNotFound    = errors.New("summary not found")
 	ErrUnauthorized       = errors.New("unauthorized")
+	ErrInvalidFallbacks   = errors.New("invalid provider fallbacks")
+	ErrInvalidTTSSpeed    = errors.New("invalid tts speed")
+	ErrPersonaNotFound    = errors.New("persona not found")
+	ErrInvalidPanel       = errors.New("invalid interview panel")
+	ErrAttachmentDisabled = errors.New("image attachments are not enabled")
+	ErrInvalidAttachment  = errors.New("invalid image attachment")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+
+	ErrInvalidCustomProvider  = errors.New("invalid custom llm provider")
+	ErrCustomProviderNotFound = errors.New("custom llm provider not found")
+
+	ErrVoiceListUnsupported = errors.New("tts provider does not support listing voices")
 )
 
 func hashPassword(password string) (string, error) {
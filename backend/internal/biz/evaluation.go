@@ -0,0 +1,54 @@
+package biz
+
+import "ai-interview/internal/provider/llm"
+
+// evaluationJSON 是 LLM 输出的评估结构
+type evaluationJSON struct {
+	OverallScore int32  `json:"overall_score"`
+	Summary      string `json:"summary"`
+	Categories   []struct {
+		Category string `json:"category"`
+		Score    int32  `json:"score"`
+		Comment  string `json:"comment"`
+	} `json:"categories"`
+	Strengths   string `json:"strengths"`
+	Weaknesses  string `json:"weaknesses"`
+	Suggestions string `json:"suggestions"`
+}
+
+// evaluationFormat 要求评估请求按 evaluationJSON 输出
+var evaluationFormat = llm.JSONSchemaFormat[evaluationJSON]()
+
+// parseEvaluation 按 Schema 校验并解析评估 JSON (兼容 ```json 代码块和前后多余文字)
+func parseEvaluation(content string) (*Evaluation, error) {
+	raw, err := llm.DecodeJSON[evaluationJSON](content)
+	if err != nil {
+		return nil, err
+	}
+
+	eval := &Evaluation{
+		OverallScore: clampScore(raw.OverallScore),
+		Summary:      raw.Summary,
+		Strengths:    raw.Strengths,
+		Weaknesses:   raw.Weaknesses,
+		Suggestions:  raw.Suggestions,
+	}
+	for _, c := range raw.Categories {
+		eval.Categories = append(eval.Categories, CategoryScore{
+			Category: c.Category,
+			Score:    clampScore(c.Score),
+			Comment:  c.Comment,
+		})
+	}
+	return eval, nil
+}
+
+func clampScore(s int32) int32 {
+	if s < 0 {
+		return 0
+	}
+	if s > 100 {
+		return 100
+	}
+	return s
+}
@@ -0,0 +1,29 @@
+package biz
+
+import "testing"
+
+func TestParseEvaluation(t *testing.T) {
+	content := "好的，评估如下：\n```json\n" +
+		`{"overall_score": 85, "summary": "不错", "categories": [{"category": "技术能力", "score": 120, "comment": "扎实"}],` +
+		` "strengths": "基础好", "weaknesses": "经验少", "suggestions": "多练习"}` +
+		"\n```"
+
+	eval, err := parseEvaluation(content)
+	if err != nil {
+		t.Fatalf("parseEvaluation error: %v", err)
+	}
+	if eval.OverallScore != 85 || eval.Summary != "不错" || eval.Suggestions != "多练习" {
+		t.Errorf("unexpected evaluation: %+v", eval)
+	}
+	if len(eval.Categories) != 1 || eval.Categories[0].Score != 100 {
+		t.Errorf("category score should be clamped to 100, got %+v", eval.Categories)
+	}
+}
+
+func TestParseEvaluation_Invalid(t *testing.T) {
+	for _, content := range []string{"", "总体表现良好", "{not json}", `{"overall_score": "高", "summary": "不错"}`} {
+		if _, err := parseEvaluation(content); err == nil {
+			t.Errorf("parseEvaluation(%q) should fail", content)
+		}
+	}
+}
@@ -3,10 +3,11 @@ package biz
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
+	"ai-interview/internal/conf"
 	"ai-interview/internal/provider/llm"
+	"ai-interview/internal/provider/resilience"
 	"ai-interview/internal/provider/stt"
 	"ai-interview/internal/provider/tts"
 
@@ -26,6 +27,10 @@ type Interview struct {
 	TTSProvider string
 	TTSVoice    string
 	Resume      string
+	Mode        string      // chat, coding
+	ProblemID   string      // 编程面试的题目 ID
+	Persona     string      // 面试官人设 ID
+	Panel       []*Panelist // 小组面试的面试官，为空表示单面试官
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
@@ -35,7 +40,13 @@ type InterviewMessage struct {
 	ID          int64
 	InterviewID int64
 	Role        string // system, user, assistant
+	Speaker     string // 小组面试中发言的面试官 ID
 	Content     string
+	Difficulty  int           // 自适应难度下面试官问题的难度 (1-5)，0 表示未分级
+	Score       *int32        // 自适应难度下候选人回答的评分 (0-100)，nil 表示未评分
+	Reasoning   string        // 推理模型生成该回复时的思考过程，仅在开启审计时保存
+	Question    string        // 面试官通过 next_question 工具记录的新问题
+	Attachments []*Attachment // 候选人附带的图片，按需加载
 	CreatedAt   time.Time
 }
 
@@ -49,6 +60,8 @@ type Evaluation struct {
 	Strengths    string
 	Weaknesses   string
 	Suggestions  string
+	Level        float64 // 自适应难度估计的能力等级 (1-5)，0 表示未启用
+	Reasoning    string  // 推理模型生成评估时的思考过程，仅在开启审计时保存
 	CreatedAt    time.Time
 }
 
@@ -66,9 +79,17 @@ type InterviewRepo interface {
 	ListByUserID(ctx context.Context, userID int64, page, pageSize int) ([]*Interview, int, error)
 	UpdateStatus(ctx context.Context, id int64, status string) error
 	CreateMessage(ctx context.Context, msg *InterviewMessage) (*InterviewMessage, error)
+	UpdateMessageScore(ctx context.Context, id int64, score int32) error
 	ListMessages(ctx context.Context, interviewID int64) ([]*InterviewMessage, error)
 	CreateEvaluation(ctx context.Context, eval *Evaluation) (*Evaluation, error)
 	GetEvaluation(ctx context.Context, interviewID int64) (*Evaluation, error)
+	SaveSummary(ctx context.Context, summary *InterviewSummary) error
+	GetSummary(ctx context.Context, interviewID int64) (*InterviewSummary, error)
+	CreateAttachment(ctx context.Context, a *Attachment) (*Attachment, error)
+	// GetAttachment 返回含内容的图片，不存在时返回 ErrAttachmentNotFound
+	GetAttachment(ctx context.Context, id int64) (*Attachment, error)
+	// ListAttachments 按上传顺序返回面试的图片，不含内容
+	ListAttachments(ctx context.Context, interviewID int64) ([]*Attachment, error)
 }
 
 // InterviewUsecase 面试业务逻辑
@@ -78,6 +99,22 @@ type InterviewUsecase struct {
 	llmRegistry *llm.Registry
 	ttsRegistry *tts.Registry
 	sttRegistry *stt.Registry
+	coding      *CodingUsecase
+	prompts     *Prompts
+	personas    *Personas
+	// 系统备用链，用户未设置备用链时使用
+	llmFallbackConf []*conf.LLM_Fallback
+	ttsFallbackConf []*conf.TTS_Fallback
+	// 各 provider 共享的重试与熔断状态
+	llmGuard    *resilience.Guard
+	ttsGuard    *resilience.Guard
+	context     contextPolicy
+	panel       panelPolicy
+	difficulty  difficultyPolicy
+	reasoning   reasoningPolicy
+	tools       toolPolicy
+	attachments attachmentPolicy
+	demo        bool
 	log         *log.Helper
 }
 
@@ -88,22 +125,176 @@ func NewInterviewUsecase(
 	llmRegistry *llm.Registry,
 	ttsRegistry *tts.Registry,
 	sttRegistry *stt.Registry,
+	coding *CodingUsecase,
+	prompts *Prompts,
+	personas *Personas,
+	llmConf *conf.LLM,
+	ttsConf *conf.TTS,
+	interviewConf *conf.Interview,
+	demo *conf.Demo,
 	logger log.Logger,
 ) *InterviewUsecase {
-	return &InterviewUsecase{
+	uc := &InterviewUsecase{
 		repo:        repo,
 		userRepo:    userRepo,
 		llmRegistry: llmRegistry,
 		ttsRegistry: ttsRegistry,
 		sttRegistry: sttRegistry,
+		coding:      coding,
+		prompts:     prompts,
+		personas:    personas,
+		demo:        demo != nil && demo.Enabled,
 		log:         log.NewHelper(logger),
 	}
+	var llmResilience, ttsResilience *conf.Resilience
+	var llmContext *conf.LLM_Context
+	var llmReasoning *conf.LLM_Reasoning
+	if llmConf != nil {
+		uc.llmFallbackConf, llmResilience, llmContext, llmReasoning = llmConf.Fallbacks, llmConf.Resilience, llmConf.Context, llmConf.Reasoning
+	}
+	if ttsConf != nil {
+		uc.ttsFallbackConf, ttsResilience = ttsConf.Fallbacks, ttsConf.Resilience
+	}
+	uc.llmGuard = resilience.NewGuard(resilienceConfig(llmResilience))
+	uc.ttsGuard = resilience.NewGuard(resilienceConfig(ttsResilience))
+	uc.context = newContextPolicy(llmContext)
+	uc.panel = newPanelPolicy(interviewConf)
+	uc.difficulty = newDifficultyPolicy(interviewConf)
+	uc.reasoning = newReasoningPolicy(llmReasoning)
+	uc.tools = newToolPolicy(interviewConf)
+	uc.attachments = newAttachmentPolicy(interviewConf)
+	return uc
+}
+
+// resilienceConfig 将配置转换为重试与熔断参数，未配置的字段使用默认值
+func resilienceConfig(c *conf.Resilience) resilience.Config {
+	if c == nil {
+		return resilience.Config{}
+	}
+	return resilience.Config{
+		MaxAttempts:      int(c.MaxAttempts),
+		InitialBackoff:   c.InitialBackoff.AsDuration(),
+		MaxBackoff:       c.MaxBackoff.AsDuration(),
+		FailureThreshold: int(c.FailureThreshold),
+		Cooldown:         c.Cooldown.AsDuration(),
+	}
+}
+
+// demoProvider 是演示模式下统一使用的 mock provider 名称
+const demoProvider = "mock"
+
+// resolveLLM 返回本次调用的 LLM 备用链：首选 provider 按 面试配置 → 用户设置 → 默认 openai 的顺序选择，
+// 其后为用户设置的备用链 (未设置时使用系统备用链)；演示模式下固定使用 mock provider。
+func (uc *InterviewUsecase) resolveLLM(interview *Interview, settings *UserSettings) (*llm.Failover, error) {
+	providerName := interview.LLMProvider
+	if providerName == "" && settings != nil {
+		providerName = settings.LLMProvider
+	}
+	if providerName == "" {
+		providerName = "openai"
+	}
+	if uc.demo {
+		providerName = demoProvider
+	}
+
+	primary, own, err := uc.llmTarget(providerName, settings)
+	if err != nil {
+		return nil, fmt.Errorf("get llm provider: %w", err)
+	}
+	primary.Model = interview.LLMModel
+
+	targets := []llm.Target{primary}
+	if !uc.demo {
+		if !own && settings != nil {
+			targets[0].APIKey = settings.LLMAPIKey
+			targets[0].BaseURL = settings.LLMBaseURL
+		}
+		targets = append(targets, uc.llmFallbacks(providerName, settings)...)
+	}
+	return llm.NewFailover(uc.llmGuard, targets, func(from llm.Target, err error) {
+		uc.log.Warnf("llm provider %s unavailable for interview %d: %v", from.Provider.Name(), interview.ID, err)
+	}), nil
+}
+
+// llmFallbacks 返回首选 provider 之后的备用候选。用户备用链中的 provider 使用系统备用链中
+// 同名条目的模型与凭据 (未配置时以空凭据调用，适用于无需 Key 的 provider)；自带凭据的目标使用其自身的凭据。
+func (uc *InterviewUsecase) llmFallbacks(primary string, settings *UserSettings) []llm.Target {
+	system := map[string]*conf.LLM_Fallback{}
+	var names []string
+	for _, fb := range uc.llmFallbackConf {
+		if _, ok := system[fb.Provider]; !ok {
+			system[fb.Provider] = fb
+			names = append(names, fb.Provider)
+		}
+	}
+	if settings != nil && len(settings.LLMFallbacks) > 0 {
+		names = settings.LLMFallbacks
+	}
+
+	var targets []llm.Target
+	for _, name := range names {
+		if name == primary {
+			continue
+		}
+		target, own, err := uc.llmTarget(name, settings)
+		if err != nil {
+			uc.log.Warnf("skip llm fallback: %v", err)
+			continue
+		}
+		if fb := system[name]; fb != nil && !own {
+			target.Model, target.APIKey, target.BaseURL = fb.Model, fb.ApiKey, fb.BaseUrl
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// chatStream 以给定的消息和温度调用 LLM 流式接口
+func (uc *InterviewUsecase) chatStream(ctx context.Context, p llm.Provider, messages []llm.Message, temperature float64) (<-chan llm.StreamEvent, error) {
+	return p.ChatStream(ctx, &llm.ChatRequest{
+		Messages:    messages,
+		MaxTokens:   maxOutputTokens,
+		Temperature: temperature,
+	})
 }
 
 // CreateInterview 创建面试会话
 func (uc *InterviewUsecase) CreateInterview(ctx context.Context, userID int64, interview *Interview) (*Interview, error) {
 	interview.UserID = userID
 	interview.Status = "pending"
+	if interview.Language == "" {
+		interview.Language = uc.prompts.DefaultLanguage()
+	}
+	if interview.Persona == "" {
+		interview.Persona = uc.personas.DefaultID()
+	} else if _, err := uc.personas.Get(interview.Persona); err != nil {
+		return nil, err
+	}
+	var custom []*CustomProvider
+	if len(interview.Panel) > 0 {
+		list, err := uc.userRepo.ListCustomProviders(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("list custom providers: %w", err)
+		}
+		custom = list
+	}
+	if err := uc.validatePanel(interview.Panel, custom); err != nil {
+		return nil, err
+	}
+
+	switch interview.Mode {
+	case "", InterviewModeChat:
+		interview.Mode = InterviewModeChat
+		interview.ProblemID = ""
+	case InterviewModeCoding:
+		problem, err := uc.coding.assignProblem(interview.ProblemID)
+		if err != nil {
+			return nil, err
+		}
+		interview.ProblemID = problem.ID
+	default:
+		return nil, fmt.Errorf("unknown interview mode %q", interview.Mode)
+	}
 
 	created, err := uc.repo.Create(ctx, interview)
 	if err != nil {
@@ -139,104 +330,60 @@ func (uc *InterviewUsecase) ListInterviews(ctx context.Context, userID int64, pa
 	return uc.repo.ListByUserID(ctx, userID, page, pageSize)
 }
 
-// SendMessage 处理用户消息并生成 AI 回复
-func (uc *InterviewUsecase) SendMessage(ctx context.Context, interviewID int64, userContent string, settings *UserSettings) (*InterviewMessage, string, error) {
-	interview, err := uc.repo.GetByID(ctx, interviewID)
-	if err != nil {
-		return nil, "", ErrInterviewNotFound
-	}
-	if interview.Status == "completed" {
-		return nil, "", ErrInterviewEnded
-	}
+// Reply 面试官的流式回复
+type Reply struct {
+	Speaker    *Panelist              // 小组面试中本轮发言的面试官，单面试官时为 nil
+	Difficulty int                    // 自适应难度下本轮问题的难度，未启用时为 0
+	Stream     <-chan llm.StreamEvent // 只含回答，思考过程与工具调用不会出现在其中
+	thoughts   *thoughts
+	question   string // next_question 记录的问题
+	end        bool   // 面试官调用了 end_interview
+}
 
-	// 更新状态为进行中
-	if interview.Status == "pending" {
-		_ = uc.repo.UpdateStatus(ctx, interviewID, "in_progress")
-	}
+// EndRequested 面试官通过 end_interview 要求在本轮回复后结束面试，Stream 读完后有效
+func (r *Reply) EndRequested() bool {
+	return r.end
+}
 
-	// 保存用户消息
-	userMsg := &InterviewMessage{
-		InterviewID: interviewID,
-		Role:        "user",
-		Content:     userContent,
-	}
-	userMsg, err = uc.repo.CreateMessage(ctx, userMsg)
-	if err != nil {
-		return nil, "", fmt.Errorf("save user message: %w", err)
+// Reasoning 返回已读取部分的思考过程
+func (r *Reply) Reasoning() string {
+	if r.thoughts == nil {
+		return ""
 	}
+	return r.thoughts.String()
+}
 
-	// 获取对话历史
-	messages, err := uc.repo.ListMessages(ctx, interviewID)
+// SendMessage 处理用户消息 (可附带图片) 并生成 AI 回复，返回保存的用户消息与面试官消息；
+// 面试官通过 end_interview 结束面试时同时生成评估并返回，否则评估为 nil
+func (uc *InterviewUsecase) SendMessage(ctx context.Context, interviewID int64, userContent string, images []*Attachment, settings *UserSettings) (*InterviewMessage, *InterviewMessage, *Evaluation, error) {
+	userMsg, reply, err := uc.StreamMessage(ctx, interviewID, userContent, images, settings)
 	if err != nil {
-		return nil, "", fmt.Errorf("get history: %w", err)
-	}
-
-	// 构建 LLM 请求
-	llmMessages := uc.buildLLMMessages(interview, messages)
-
-	// 获取 LLM Provider
-	providerName := interview.LLMProvider
-	if providerName == "" && settings != nil {
-		providerName = settings.LLMProvider
-	}
-	if providerName == "" {
-		providerName = "openai"
+		return nil, nil, nil, err
 	}
 
-	provider, err := uc.llmRegistry.Get(providerName)
+	// 收集完整回复
+	assistantContent, err := collectStream(reply.Stream)
 	if err != nil {
-		return nil, "", fmt.Errorf("get llm provider: %w", err)
+		return nil, nil, nil, fmt.Errorf("llm stream: %w", err)
 	}
 
-	apiKey := ""
-	baseURL := ""
-	if settings != nil {
-		apiKey = settings.LLMAPIKey
-		baseURL = settings.LLMBaseURL
-	}
-
-	// 流式调用 LLM
-	stream, err := provider.ChatStream(ctx, &llm.ChatRequest{
-		Messages:    llmMessages,
-		Model:       interview.LLMModel,
-		MaxTokens:   4096,
-		Temperature: 0.7,
-		APIKey:      apiKey,
-		BaseURL:     baseURL,
-	})
+	assistantMsg, err := uc.SaveAssistantMessage(ctx, interviewID, reply, assistantContent)
 	if err != nil {
-		return nil, "", fmt.Errorf("llm chat: %w", err)
+		return nil, nil, nil, err
 	}
-
-	// 收集完整回复
-	var sb strings.Builder
-	for event := range stream {
-		if event.Err != nil {
-			return nil, "", fmt.Errorf("llm stream: %w", event.Err)
-		}
-		if event.Content != "" {
-			sb.WriteString(event.Content)
-		}
+	if !reply.EndRequested() {
+		return userMsg, assistantMsg, nil, nil
 	}
-
-	assistantContent := sb.String()
-
-	// 保存助手消息
-	assistantMsg := &InterviewMessage{
-		InterviewID: interviewID,
-		Role:        "assistant",
-		Content:     assistantContent,
-	}
-	_, err = uc.repo.CreateMessage(ctx, assistantMsg)
+	eval, err := uc.EndInterview(ctx, interviewID, settings)
 	if err != nil {
-		return nil, assistantContent, fmt.Errorf("save assistant message: %w", err)
+		return nil, nil, nil, err
 	}
-
-	return userMsg, assistantContent, nil
+	return userMsg, assistantMsg, eval, nil
 }
 
-// StreamMessage 流式处理消息，返回 LLM 文本流 channel
-func (uc *InterviewUsecase) StreamMessage(ctx context.Context, interviewID int64, userContent string, settings *UserSettings) (*InterviewMessage, <-chan llm.StreamEvent, error) {
+// StreamMessage 流式处理消息：保存用户消息 (启用自适应难度时先为其评分)，选出本轮发言的面试官与问题难度，
+// 返回其 LLM 文本流；开启工具调用时在流中执行面试官的工具调用
+func (uc *InterviewUsecase) StreamMessage(ctx context.Context, interviewID int64, userContent string, images []*Attachment, settings *UserSettings) (*InterviewMessage, *Reply, error) {
 	interview, err := uc.repo.GetByID(ctx, interviewID)
 	if err != nil {
 		return nil, nil, ErrInterviewNotFound
@@ -244,62 +391,93 @@ func (uc *InterviewUsecase) StreamMessage(ctx context.Context, interviewID int64
 	if interview.Status == "completed" {
 		return nil, nil, ErrInterviewEnded
 	}
+	if err := uc.attachments.check(images); err != nil {
+		return nil, nil, err
+	}
 
 	if interview.Status == "pending" {
 		_ = uc.repo.UpdateStatus(ctx, interviewID, "in_progress")
 	}
 
+	history, err := uc.repo.ListMessages(ctx, interviewID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get history: %w", err)
+	}
+	if err := uc.loadAttachments(ctx, interviewID, history); err != nil {
+		return nil, nil, err
+	}
+
 	// 保存用户消息
 	userMsg := &InterviewMessage{
 		InterviewID: interviewID,
 		Role:        "user",
 		Content:     userContent,
 	}
+	// 开启工具调用时由面试官在回复中通过 record_score 评分
+	if uc.difficulty.enabled && !uc.tools.enabled {
+		userMsg.Score = uc.gradeAnswer(ctx, interview, history, userContent, settings)
+	}
 	userMsg, err = uc.repo.CreateMessage(ctx, userMsg)
 	if err != nil {
 		return nil, nil, fmt.Errorf("save user message: %w", err)
 	}
+	if err := uc.saveAttachments(ctx, userMsg, images); err != nil {
+		return nil, nil, err
+	}
+	messages := append(history, userMsg)
 
-	messages, err := uc.repo.ListMessages(ctx, interviewID)
+	difficulty := uc.nextDifficulty(interview, messages)
+	speaker := uc.nextSpeaker(ctx, interview, messages, settings)
+	interview, chain, err := uc.speakerLLM(interview, speaker, settings)
 	if err != nil {
-		return nil, nil, fmt.Errorf("get history: %w", err)
+		return nil, nil, err
 	}
 
-	llmMessages := uc.buildLLMMessages(interview, messages)
-
-	providerName := interview.LLMProvider
-	if providerName == "" && settings != nil {
-		providerName = settings.LLMProvider
-	}
-	if providerName == "" {
-		providerName = "openai"
+	// 构建 LLM 请求 (必要时先压缩早期对话)
+	llmMessages, err := uc.buildContext(ctx, interview, chain, messages, speaker, difficulty)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	provider, err := uc.llmRegistry.Get(providerName)
+	reply := &Reply{Speaker: speaker, Difficulty: difficulty, thoughts: &thoughts{}}
+	var stream <-chan llm.StreamEvent
+	if uc.tools.enabled {
+		stream, err = uc.toolStream(ctx, chain, interview, userMsg, llmMessages, reply)
+	} else {
+		stream, err = uc.thinkStream(ctx, chain, llmMessages, 0.7, nil)
+	}
 	if err != nil {
-		return nil, nil, fmt.Errorf("get llm provider: %w", err)
+		return nil, nil, fmt.Errorf("llm chat: %w", err)
 	}
+	reply.Stream = uc.logUsage(interviewID, answerStream(stream, reply.thoughts))
+	return userMsg, reply, nil
+}
 
-	apiKey := ""
-	baseURL := ""
-	if settings != nil {
-		apiKey = settings.LLMAPIKey
-		baseURL = settings.LLMBaseURL
+// SaveAssistantMessage 保存流式回复完成后的面试官消息，reply 为 StreamMessage 返回的回复 (发言人、问题难度与思考过程)
+func (uc *InterviewUsecase) SaveAssistantMessage(ctx context.Context, interviewID int64, reply *Reply, content string) (*InterviewMessage, error) {
+	msg := &InterviewMessage{
+		InterviewID: interviewID,
+		Role:        "assistant",
+		Content:     content,
+		Difficulty:  reply.Difficulty,
+		Question:    reply.question,
 	}
-
-	stream, err := provider.ChatStream(ctx, &llm.ChatRequest{
-		Messages:    llmMessages,
-		Model:       interview.LLMModel,
-		MaxTokens:   4096,
-		Temperature: 0.7,
-		APIKey:      apiKey,
-		BaseURL:     baseURL,
-	})
+	if reply.Speaker != nil {
+		msg.Speaker = reply.Speaker.ID
+	}
+	if uc.reasoning.audit {
+		msg.Reasoning = reply.Reasoning()
+	}
+	msg, err := uc.repo.CreateMessage(ctx, msg)
 	if err != nil {
-		return nil, nil, fmt.Errorf("llm chat: %w", err)
+		return nil, fmt.Errorf("save assistant message: %w", err)
 	}
+	return msg, nil
+}
 
-	return userMsg, stream, nil
+// FormatSubmission 把提交结果格式化为发给面试官 (LLM) 的候选人消息，语言与面试一致
+func (uc *InterviewUsecase) FormatSubmission(interview *Interview, sub *CodeSubmission) (string, error) {
+	return uc.prompts.Submission(interview, sub)
 }
 
 // EndInterview 结束面试并生成评估
@@ -320,57 +498,56 @@ func (uc *InterviewUsecase) EndInterview(ctx context.Context, id int64, settings
 	if err != nil {
 		return nil, fmt.Errorf("get messages: %w", err)
 	}
+	if err := uc.loadAttachments(ctx, id, messages); err != nil {
+		return nil, err
+	}
 
 	// 构建评估请求
-	evalPrompt := uc.buildEvaluationPrompt(interview, messages)
-
-	providerName := interview.LLMProvider
-	if providerName == "" && settings != nil {
-		providerName = settings.LLMProvider
-	}
-	if providerName == "" {
-		providerName = "openai"
+	var coding *codingReport
+	if interview.Mode == InterviewModeCoding {
+		if coding, err = uc.codingSection(ctx, interview); err != nil {
+			return nil, err
+		}
 	}
-
-	provider, err := uc.llmRegistry.Get(providerName)
+	chain, err := uc.resolveLLM(interview, settings)
 	if err != nil {
-		return nil, fmt.Errorf("get llm provider: %w", err)
+		return nil, err
 	}
 
-	apiKey := ""
-	baseURL := ""
-	if settings != nil {
-		apiKey = settings.LLMAPIKey
-		baseURL = settings.LLMBaseURL
+	level := uc.estimateLevel(interview, messages)
+	evalPrompt, err := uc.prompts.Evaluation(interview, nil, messages, coding, level)
+	if err != nil {
+		return nil, err
+	}
+	// 完整记录超出上下文预算时，较早的对话以滚动摘要代替
+	if llm.EstimateMessages(evalPrompt) > uc.context.budget(chain.Name(), interview.LLMModel) {
+		if summary, err := uc.repo.GetSummary(ctx, id); err == nil {
+			if evalPrompt, err = uc.prompts.Evaluation(interview, summary, messagesAfter(messages, summary.ThroughMessageID), coding, level); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	stream, err := provider.ChatStream(ctx, &llm.ChatRequest{
-		Messages:    evalPrompt,
-		Model:       interview.LLMModel,
-		MaxTokens:   4096,
-		Temperature: 0.3,
-		APIKey:      apiKey,
-		BaseURL:     baseURL,
-	})
+	stream, err := uc.thinkStream(ctx, chain, evalPrompt, 0.3, evaluationFormat)
 	if err != nil {
 		return nil, fmt.Errorf("llm eval: %w", err)
 	}
 
-	var sb strings.Builder
-	for event := range stream {
-		if event.Err != nil {
-			return nil, fmt.Errorf("llm eval stream: %w", event.Err)
-		}
-		if event.Content != "" {
-			sb.WriteString(event.Content)
-		}
+	content, reasoning, err := collectReasoning(stream)
+	if err != nil {
+		return nil, fmt.Errorf("llm eval stream: %w", err)
 	}
 
-	// TODO: 解析 LLM 返回的 JSON 评估结果
-	eval := &Evaluation{
-		InterviewID:  id,
-		OverallScore: 70,
-		Summary:      sb.String(),
+	eval, err := parseEvaluation(content)
+	if err != nil {
+		// 模型未按要求输出 JSON 时保留原文，分数留空
+		uc.log.Warnf("parse evaluation of interview %d: %v", id, err)
+		eval = &Evaluation{Summary: content}
+	}
+	eval.InterviewID = id
+	eval.Level = level
+	if uc.reasoning.audit {
+		eval.Reasoning = reasoning
 	}
 
 	eval, err = uc.repo.CreateEvaluation(ctx, eval)
@@ -391,65 +568,243 @@ func (uc *InterviewUsecase) GetTTSProvider(providerName string) (tts.Provider, e
 	return uc.ttsRegistry.Get(providerName)
 }
 
-// GetSTTProvider 获取 STT Provider
-func (uc *InterviewUsecase) GetSTTProvider(providerName string) (stt.Provider, error) {
-	return uc.sttRegistry.Get(providerName)
+// Speech 面试官一轮回复的语音：TTS 备用链、语速与面试语言；按回复的句子顺序调用 Request
+type Speech struct {
+	Provider tts.Provider
+	Speed    float64
+	Language string
+
+	normalizer tts.Normalizer // 跨句记录代码块状态
 }
 
-func (uc *InterviewUsecase) buildLLMMessages(interview *Interview, messages []*InterviewMessage) []llm.Message {
-	result := make([]llm.Message, 0, len(messages)+1)
+// Request 返回一句话的合成请求：去除 Markdown 与代码块，按句推断语言与语气，再按语言展开数字、单位与缩写；
+// 没有可朗读的内容时返回 nil
+func (s *Speech) Request(text string) *tts.Request {
+	text = s.normalizer.Speakable(text, s.Language)
+	if text == "" {
+		return nil
+	}
+	language := sentenceLanguage(text, s.Language)
+	return &tts.Request{
+		Text:     tts.Verbalize(text, language),
+		Speed:    s.Speed,
+		Language: language,
+		Delivery: sentenceDelivery(text),
+	}
+}
 
-	// System prompt
-	systemPrompt := fmt.Sprintf(
-		"你是一位专业的面试官，正在面试%s岗位的候选人。\n"+
-			"请根据岗位要求逐一提出面试问题，每次只问一个问题。\n"+
-			"等候选人回答后，进行简短评价并提出下一个问题。\n"+
-			"面试语言：%s",
-		interview.Position, interview.Language,
-	)
-	if interview.Resume != "" {
-		systemPrompt += "\n\n候选人简历：\n" + interview.Resume
+// ResolveSpeech 返回面试官语音，未启用语音时返回 nil。speaker 为小组面试中本轮发言的面试官；
+// 首选 provider 的音色依次取面试官指定的音色、面试指定的音色、人设在该 provider 上的默认音色、用户设置的音色；
+// 语速为人设语速与用户设置语速的乘积。
+func (uc *InterviewUsecase) ResolveSpeech(ctx context.Context, interviewID int64, speaker *Panelist, settings *UserSettings) *Speech {
+	interview, err := uc.repo.GetByID(ctx, interviewID)
+	if err != nil {
+		uc.log.Warnf("resolve speech of interview %d: %v", interviewID, err)
+		return nil
 	}
+	persona := uc.speakerPersona(interview, speaker)
+	provider := uc.resolveTTS(settings, func(name string) string {
+		if speaker != nil && speaker.TTSVoice != "" {
+			return speaker.TTSVoice
+		}
+		if interview.TTSVoice != "" {
+			return interview.TTSVoice
+		}
+		if persona != nil {
+			return persona.Voice(name)
+		}
+		return ""
+	})
+	if provider == nil {
+		return nil
+	}
+	speech := &Speech{Provider: provider, Speed: 1.0, Language: interview.Language}
+	if persona != nil {
+		speech.Speed = persona.Speed
+	}
+	if settings != nil && settings.TTSSpeed > 0 {
+		speech.Speed = min(max(speech.Speed*settings.TTSSpeed, minTTSSpeed), maxTTSSpeed)
+	}
+	return speech
+}
 
-	result = append(result, llm.Message{Role: "system", Content: systemPrompt})
+// persona 返回面试的人设，未选择或已从配置中移除时为 nil (中立面试官)
+func (uc *InterviewUsecase) persona(interview *Interview) *Persona {
+	if interview.Persona == "" {
+		return nil
+	}
+	p, err := uc.personas.Get(interview.Persona)
+	if err != nil {
+		uc.log.Warnf("interview %d: %v", interview.ID, err)
+		return nil
+	}
+	return p
+}
 
-	for _, msg := range messages {
-		if msg.Role == "system" {
+// ListPersonas 返回面试官人设目录
+func (uc *InterviewUsecase) ListPersonas() []*Persona {
+	return uc.personas.List()
+}
+
+// DefaultPersona 返回创建面试未指定人设时使用的人设 ID
+func (uc *InterviewUsecase) DefaultPersona() string {
+	return uc.personas.DefaultID()
+}
+
+// resolveTTS 返回用户启用的 TTS 备用链，未启用语音时返回 nil：首选为用户设置的 provider，
+// 其后为用户设置的备用链 (未设置时使用系统备用链)；演示模式下固定使用 mock provider。
+// voice 返回首选 provider 的音色，为空时使用用户设置的音色。
+func (uc *InterviewUsecase) resolveTTS(settings *UserSettings, voice func(provider string) string) tts.Provider {
+	name := ""
+	if settings != nil && settings.TTSEnabled {
+		name = settings.TTSProvider
+	}
+	if uc.demo {
+		name = demoProvider
+	}
+	if name == "" {
+		return nil
+	}
+	primary, own, err := uc.ttsTarget(name, settings)
+	if err != nil {
+		uc.log.Warnf("resolve tts provider: %v", err)
+		return nil
+	}
+
+	primary.Voice = voice(name)
+	if settings != nil {
+		if primary.Voice == "" {
+			primary.Voice = settings.TTSVoice
+		}
+		if !uc.demo && !own {
+			primary.APIKey = settings.TTSAPIKey
+		}
+	}
+	targets := []tts.Target{primary}
+	if !uc.demo {
+		targets = append(targets, uc.ttsFallbacks(name, settings)...)
+	}
+	return tts.NewFailover(uc.ttsGuard, targets, func(from tts.Target, err error) {
+		uc.log.Warnf("tts provider %s unavailable: %v", from.Provider.Name(), err)
+	})
+}
+
+// ttsFallbacks 返回首选 provider 之后的备用候选，规则同 llmFallbacks
+func (uc *InterviewUsecase) ttsFallbacks(primary string, settings *UserSettings) []tts.Target {
+	system := map[string]*conf.TTS_Fallback{}
+	var names []string
+	for _, fb := range uc.ttsFallbackConf {
+		if _, ok := system[fb.Provider]; !ok {
+			system[fb.Provider] = fb
+			names = append(names, fb.Provider)
+		}
+	}
+	if settings != nil && len(settings.TTSFallbacks) > 0 {
+		names = settings.TTSFallbacks
+	}
+
+	var targets []tts.Target
+	for _, name := range names {
+		if name == primary {
+			continue
+		}
+		target, own, err := uc.ttsTarget(name, settings)
+		if err != nil {
+			uc.log.Warnf("skip tts fallback: %v", err)
 			continue
 		}
-		result = append(result, llm.Message{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
+		if fb := system[name]; fb != nil && !own {
+			target.Voice, target.APIKey, target.BaseURL = fb.Voice, fb.ApiKey, fb.BaseUrl
+		}
+		targets = append(targets, target)
 	}
+	return targets
+}
 
-	return result
+// GetSTTProvider 获取 STT Provider
+func (uc *InterviewUsecase) GetSTTProvider(providerName string) (stt.Provider, error) {
+	return uc.sttRegistry.Get(providerName)
 }
 
-func (uc *InterviewUsecase) buildEvaluationPrompt(interview *Interview, messages []*InterviewMessage) []llm.Message {
-	var sb strings.Builder
-	sb.WriteString("请根据以下面试记录，给出综合评估。\n\n")
-	sb.WriteString(fmt.Sprintf("面试岗位：%s\n\n", interview.Position))
-	sb.WriteString("=== 面试记录 ===\n\n")
+// ResolveSTTProvider 返回服务端语音识别 Provider 及调用凭据，用户使用浏览器识别 (browser) 或未设置时 Provider 为 nil；
+// 演示模式下固定使用 mock provider。
+func (uc *InterviewUsecase) ResolveSTTProvider(settings *UserSettings) (p stt.Provider, apiKey, baseURL string) {
+	name := ""
+	if settings != nil && settings.STTProvider != "browser" {
+		name = settings.STTProvider
+	}
+	if uc.demo {
+		name = demoProvider
+	}
+	if name == "" {
+		return nil, "", ""
+	}
+	if name == azureProvider && azureConfigured(settings) {
+		return stt.NewAzureProvider(settings.AzureAPIVersion, settings.AzureSTTDeployment), settings.AzureAPIKey, settings.AzureEndpoint
+	}
+	if name == speechProvider && speechConfigured(settings) {
+		return stt.NewAzureSpeechProvider(settings.SpeechRegion), settings.SpeechAPIKey, ""
+	}
+	p, err := uc.sttRegistry.Get(name)
+	if err != nil {
+		uc.log.Warnf("resolve stt provider: %v", err)
+		return nil, "", ""
+	}
+	if uc.demo || settings == nil {
+		return p, "", ""
+	}
+	return p, settings.STTAPIKey, ""
+}
+
+// buildLLMMessages 构建面试官的系统提示与对话历史；summary 为已压缩的早期对话，messages 为其后的原始对话，
+// asked 为面试官已发言的轮数，difficulty 为下一个问题的难度 (0 表示不分级)。
+// 小组面试中 speaker 为本轮发言的面试官，其他面试官的发言以 "[名字] " 开头。
+func (uc *InterviewUsecase) buildLLMMessages(interview *Interview, speaker *Panelist, summary *InterviewSummary, messages []*InterviewMessage, asked, difficulty int) ([]llm.Message, error) {
+	var problem *Problem
+	if interview.Mode == InterviewModeCoding {
+		problem, _ = uc.coding.GetProblem(interview.ProblemID)
+	}
+	systemPrompt, turn, err := uc.prompts.System(interview, speaker, uc.speakerPersona(interview, speaker), problem, summary, asked, difficulty)
+	if err != nil {
+		return nil, err
+	}
 
+	// 系统提示与对话历史在各轮之间保持相同的前缀，供应商可以缓存；本轮要求放在最后
+	result := make([]llm.Message, 0, len(messages)+2)
+	result = append(result, llm.Message{Role: "system", Content: systemPrompt})
 	for _, msg := range messages {
 		if msg.Role == "system" {
 			continue
 		}
-		role := "面试官"
-		if msg.Role == "user" {
-			role = "候选人"
+		content := msg.Content
+		if speaker != nil && msg.Role == "assistant" && msg.Speaker != speaker.ID {
+			if other := panelistByID(interview.Panel, msg.Speaker); other != nil {
+				content = "[" + other.Name + "] " + content
+			}
 		}
-		sb.WriteString(fmt.Sprintf("%s: %s\n\n", role, msg.Content))
+		m := llm.Message{Role: msg.Role, Content: content}
+		// 候选人附带的图片跟在回答文字之后
+		if images := imageParts(msg.Attachments); len(images) > 0 {
+			m.Content, m.Parts = "", append([]llm.ContentPart{llm.TextPart(content)}, images...)
+		}
+		result = append(result, m)
+	}
+	if turn != "" {
+		result = append(result, llm.Message{Role: "system", Content: turn})
 	}
 
-	sb.WriteString("=== 评估要求 ===\n")
-	sb.WriteString("请从以下维度进行评分 (0-100) 并给出评语：\n")
-	sb.WriteString("1. 技术能力\n2. 沟通表达\n3. 逻辑思维\n4. 问题解决\n5. 学习潜力\n\n")
-	sb.WriteString("同时给出：\n- 总体评分 (0-100)\n- 总结\n- 优势\n- 不足\n- 改进建议\n")
+	return result, nil
+}
 
-	return []llm.Message{
-		{Role: "system", Content: "你是一位资深面试评估专家。请根据面试记录给出客观、详细的评估报告。"},
-		{Role: "user", Content: sb.String()},
+// codingSection 汇总编程面试的题目与提交结果，供评估使用
+func (uc *InterviewUsecase) codingSection(ctx context.Context, interview *Interview) (*codingReport, error) {
+	problem, err := uc.coding.GetProblem(interview.ProblemID)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := uc.coding.ListSubmissions(ctx, interview.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list submissions: %w", err)
 	}
+	return newCodingReport(problem, subs), nil
 }
@@ -0,0 +1,276 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+)
+
+// 小组面试的主持策略
+const (
+	ModeratorRotation = "rotation" // 按顺序轮换，候选人点名时交给被点名者
+	ModeratorLLM      = "llm"      // 由模型决定下一位发言人，失败时退回 rotation
+)
+
+const (
+	defaultPanelTurns    = 2
+	defaultMaxPanelists  = 4
+	maxPanelistIDLen     = 64 // interview_messages.speaker 列宽
+	moderatorHistorySize = 6  // llm 主持人参考的最近消息条数
+)
+
+// Panelist 小组面试中的一位面试官
+type Panelist struct {
+	ID          string // 发言人标识，对应消息的 Speaker
+	Name        string
+	Role        string // 角色，如 技术面试官、招聘经理
+	Focus       string // 负责考察的方向
+	Persona     string // 人设 ID，为空时沿用面试的人设
+	LLMProvider string // 为空时沿用面试的 LLM 设置
+	LLMModel    string
+	TTSVoice    string // 为空时沿用面试 / 人设 / 用户设置的音色
+}
+
+// panelPolicy 小组面试配置
+type panelPolicy struct {
+	moderator    string
+	turns        int // rotation：同一位面试官连续发言的轮数
+	maxPanelists int
+}
+
+func newPanelPolicy(c *conf.Interview) panelPolicy {
+	p := panelPolicy{moderator: ModeratorRotation, turns: defaultPanelTurns, maxPanelists: defaultMaxPanelists}
+	if c == nil || c.Panel == nil {
+		return p
+	}
+	if c.Panel.Moderator == ModeratorLLM {
+		p.moderator = ModeratorLLM
+	}
+	if c.Panel.Turns > 0 {
+		p.turns = int(c.Panel.Turns)
+	}
+	if c.Panel.MaxPanelists > 0 {
+		p.maxPanelists = int(c.Panel.MaxPanelists)
+	}
+	return p
+}
+
+// validatePanel 校验并补全创建面试时声明的面试官：ID 为空时按顺序生成 (p1, p2 ...)，名字为空时使用 ID；
+// 面试官的 LLM provider 可以是用户注册的 provider (custom)
+func (uc *InterviewUsecase) validatePanel(panel []*Panelist, custom []*CustomProvider) error {
+	if len(panel) == 0 {
+		return nil
+	}
+	if len(panel) < 2 || len(panel) > uc.panel.maxPanelists {
+		return fmt.Errorf("%w: need 2 to %d panelists, got %d", ErrInvalidPanel, uc.panel.maxPanelists, len(panel))
+	}
+	seen := map[string]bool{}
+	for i, p := range panel {
+		if p == nil {
+			return fmt.Errorf("%w: panelist %d is empty", ErrInvalidPanel, i+1)
+		}
+		p.ID = strings.TrimSpace(p.ID)
+		p.Name = strings.TrimSpace(p.Name)
+		if p.ID == "" {
+			p.ID = fmt.Sprintf("p%d", i+1)
+		}
+		if len(p.ID) > maxPanelistIDLen {
+			return fmt.Errorf("%w: panelist id %q is too long", ErrInvalidPanel, p.ID)
+		}
+		if seen[p.ID] {
+			return fmt.Errorf("%w: duplicate panelist id %q", ErrInvalidPanel, p.ID)
+		}
+		seen[p.ID] = true
+		if p.Name == "" {
+			p.Name = p.ID
+		}
+		if p.Persona != "" {
+			if _, err := uc.personas.Get(p.Persona); err != nil {
+				return fmt.Errorf("%w: panelist %q: %w", ErrInvalidPanel, p.ID, err)
+			}
+		}
+		if p.LLMProvider != "" {
+			if _, _, err := uc.llmTarget(p.LLMProvider, &UserSettings{CustomProviders: custom}); err != nil {
+				return fmt.Errorf("%w: panelist %q: %w", ErrInvalidPanel, p.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// nextSpeaker 决定下一位发言的面试官，非小组面试返回 nil。messages 以候选人的最新消息结尾。
+func (uc *InterviewUsecase) nextSpeaker(ctx context.Context, interview *Interview, messages []*InterviewMessage, settings *UserSettings) *Panelist {
+	if len(interview.Panel) == 0 {
+		return nil
+	}
+	if uc.panel.moderator == ModeratorLLM {
+		p, err := uc.moderate(ctx, interview, messages, settings)
+		if err == nil {
+			return p
+		}
+		uc.log.Warnf("moderate interview %d: %v", interview.ID, err)
+	}
+	return rotate(interview.Panel, messages, uc.panel.turns)
+}
+
+// moderate 让模型 (面试的 LLM 设置) 根据最近的对话选择下一位发言人
+func (uc *InterviewUsecase) moderate(ctx context.Context, interview *Interview, messages []*InterviewMessage, settings *UserSettings) (*Panelist, error) {
+	chain, err := uc.resolveLLM(interview, settings)
+	if err != nil {
+		return nil, err
+	}
+	recent := conversation(messages)
+	if len(recent) > moderatorHistorySize {
+		recent = recent[len(recent)-moderatorHistorySize:]
+	}
+	prompt, err := uc.prompts.Moderator(interview, recent)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := uc.chatStream(ctx, chain, prompt, 0)
+	if err != nil {
+		return nil, err
+	}
+	content, err := collectStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	if p := matchPanelist(interview.Panel, content); p != nil {
+		return p, nil
+	}
+	return nil, errors.New("moderator chose no panelist")
+}
+
+// matchPanelist 从主持人的回复中找出面试官 ID：完全匹配优先，其次取最先出现的 ID
+func matchPanelist(panel []*Panelist, reply string) *Panelist {
+	reply = strings.TrimSpace(reply)
+	if p := panelistByID(panel, reply); p != nil {
+		return p
+	}
+	var found *Panelist
+	at := len(reply)
+	for _, p := range panel {
+		if i := strings.Index(reply, p.ID); i >= 0 && i < at {
+			found, at = p, i
+		}
+	}
+	return found
+}
+
+// rotate 按顺序轮换发言人：候选人最新消息点名了某位面试官时由其发言；
+// 否则上一位发言人连续发言不足 turns 轮时继续，满 turns 轮后交给下一位；第一轮由第一位面试官发言。
+func rotate(panel []*Panelist, messages []*InterviewMessage, turns int) *Panelist {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			if p := addressedPanelist(panel, messages[i].Content); p != nil {
+				return p
+			}
+			break
+		}
+	}
+
+	last, streak := "", 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		m := messages[i]
+		if m.Role != "assistant" {
+			continue
+		}
+		if last == "" {
+			last = m.Speaker
+		} else if m.Speaker != last {
+			break
+		}
+		streak++
+	}
+	idx := panelistIndex(panel, last)
+	if idx < 0 {
+		return panel[0]
+	}
+	if streak < max(turns, 1) {
+		return panel[idx]
+	}
+	return panel[(idx+1)%len(panel)]
+}
+
+// addressedPanelist 返回 text 中最先被点名的面试官 (按名字匹配，忽略大小写)
+func addressedPanelist(panel []*Panelist, text string) *Panelist {
+	text = strings.ToLower(text)
+	var found *Panelist
+	at := len(text)
+	for _, p := range panel {
+		if p.Name == "" {
+			continue
+		}
+		if i := strings.Index(text, strings.ToLower(p.Name)); i >= 0 && i < at {
+			found, at = p, i
+		}
+	}
+	return found
+}
+
+func panelistIndex(panel []*Panelist, id string) int {
+	for i, p := range panel {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func panelistByID(panel []*Panelist, id string) *Panelist {
+	if i := panelistIndex(panel, id); i >= 0 {
+		return panel[i]
+	}
+	return nil
+}
+
+// speakerLLM 返回发言人使用的 LLM 备用链及按其覆盖了 LLM 设置的面试副本。
+// 用户的 API Key 只属于其设置的 provider：面试官使用其他 provider 时改用系统备用链中同名条目的凭据。
+func (uc *InterviewUsecase) speakerLLM(interview *Interview, speaker *Panelist, settings *UserSettings) (*Interview, *llm.Failover, error) {
+	if speaker == nil || (speaker.LLMProvider == "" && speaker.LLMModel == "") {
+		chain, err := uc.resolveLLM(interview, settings)
+		return interview, chain, err
+	}
+	iv := *interview
+	if speaker.LLMModel != "" {
+		iv.LLMModel = speaker.LLMModel
+	}
+	if speaker.LLMProvider != "" && speaker.LLMProvider != interview.LLMProvider {
+		iv.LLMProvider = speaker.LLMProvider
+		if speaker.LLMModel == "" {
+			iv.LLMModel = ""
+		}
+		if settings != nil && settings.LLMProvider != speaker.LLMProvider {
+			s := *settings
+			s.LLMAPIKey, s.LLMBaseURL = "", ""
+			for _, fb := range uc.llmFallbackConf {
+				if fb.Provider == speaker.LLMProvider {
+					s.LLMAPIKey, s.LLMBaseURL = fb.ApiKey, fb.BaseUrl
+					if iv.LLMModel == "" {
+						iv.LLMModel = fb.Model
+					}
+					break
+				}
+			}
+			settings = &s
+		}
+	}
+	chain, err := uc.resolveLLM(&iv, settings)
+	return &iv, chain, err
+}
+
+// speakerPersona 返回发言人的人设：面试官指定的人设优先，其次为面试的人设
+func (uc *InterviewUsecase) speakerPersona(interview *Interview, speaker *Panelist) *Persona {
+	if speaker != nil && speaker.Persona != "" {
+		p, err := uc.personas.Get(speaker.Persona)
+		if err == nil {
+			return p
+		}
+		uc.log.Warnf("interview %d panelist %q: %v", interview.ID, speaker.ID, err)
+	}
+	return uc.persona(interview)
+}
@@ -0,0 +1,218 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+	"ai-interview/internal/provider/resilience"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+func testPanel() []*Panelist {
+	return []*Panelist{
+		{ID: "tech", Name: "Alice", Role: "Tech lead", Focus: "Go concurrency"},
+		{ID: "hm", Name: "Bob", Role: "Hiring manager", Focus: "team fit"},
+		{ID: "sre", Name: "Carol"},
+	}
+}
+
+func TestRotate(t *testing.T) {
+	panel := testPanel()
+	msg := func(role, speaker, content string) *InterviewMessage {
+		return &InterviewMessage{Role: role, Speaker: speaker, Content: content}
+	}
+
+	tests := []struct {
+		name     string
+		messages []*InterviewMessage
+		want     string
+	}{
+		{"first turn", []*InterviewMessage{msg("user", "", "hi")}, "tech"},
+		{"continue streak", []*InterviewMessage{msg("assistant", "tech", "q1"), msg("user", "", "a1")}, "tech"},
+		{"hand over after turns", []*InterviewMessage{
+			msg("assistant", "tech", "q1"), msg("user", "", "a1"), msg("assistant", "tech", "q2"), msg("user", "", "a2"),
+		}, "hm"},
+		{"wrap around", []*InterviewMessage{
+			msg("assistant", "sre", "q1"), msg("user", "", "a1"), msg("assistant", "sre", "q2"), msg("user", "", "a2"),
+		}, "tech"},
+		{"addressed by name", []*InterviewMessage{msg("assistant", "tech", "q1"), msg("user", "", "Thanks! carol, a question for you")}, "sre"},
+		{"first name mentioned wins", []*InterviewMessage{msg("user", "", "Bob and Alice both asked")}, "hm"},
+		{"unknown speaker restarts", []*InterviewMessage{msg("assistant", "gone", "q1"), msg("user", "", "a1")}, "tech"},
+	}
+	for _, tt := range tests {
+		if got := rotate(panel, tt.messages, 2); got.ID != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got.ID, tt.want)
+		}
+	}
+}
+
+func TestMatchPanelist(t *testing.T) {
+	panel := testPanel()
+	for reply, want := range map[string]string{"hm": "hm", " sre\n": "sre", "Next: tech, then hm": "tech", "nobody": ""} {
+		got := matchPanelist(panel, reply)
+		if (got == nil && want != "") || (got != nil && got.ID != want) {
+			t.Errorf("matchPanelist(%q) = %v, want %q", reply, got, want)
+		}
+	}
+}
+
+func TestValidatePanel(t *testing.T) {
+	personas, _ := NewPersonas(testPersonaConf())
+	registry := llm.NewRegistry()
+	registry.Register(&summaryLLM{})
+	uc := &InterviewUsecase{
+		personas:    personas,
+		llmRegistry: registry,
+		panel:       newPanelPolicy(&conf.Interview{Panel: &conf.Interview_Panel{MaxPanelists: 3}}),
+	}
+
+	panel := []*Panelist{{Name: " Alice ", LLMProvider: "fake"}, {ID: "hm", Persona: "bar-raiser"}}
+	if err := uc.validatePanel(panel, nil); err != nil {
+		t.Fatalf("validatePanel error: %v", err)
+	}
+	if panel[0].ID != "p1" || panel[0].Name != "Alice" || panel[1].Name != "hm" {
+		t.Errorf("defaults not applied: %+v %+v", panel[0], panel[1])
+	}
+	if err := uc.validatePanel(nil, nil); err != nil {
+		t.Errorf("empty panel should be allowed: %v", err)
+	}
+	custom := []*CustomProvider{{Name: "my-vllm", BaseURL: "http://localhost:8000/v1"}}
+	if err := uc.validatePanel([]*Panelist{{ID: "a", LLMProvider: "my-vllm"}, {ID: "b"}}, custom); err != nil {
+		t.Errorf("user provider should be accepted: %v", err)
+	}
+
+	invalid := map[string][]*Panelist{
+		"single":           {{ID: "a"}},
+		"too many":         {{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}},
+		"duplicate id":     {{ID: "a"}, {ID: "a"}},
+		"unknown persona":  {{ID: "a"}, {ID: "b", Persona: "nope"}},
+		"unknown provider": {{ID: "a", LLMProvider: "nope"}, {ID: "b"}},
+		"long id":          {{ID: strings.Repeat("x", 65)}, {ID: "b"}},
+	}
+	for name, panel := range invalid {
+		if err := uc.validatePanel(panel, nil); !errors.Is(err, ErrInvalidPanel) {
+			t.Errorf("%s: expected ErrInvalidPanel, got %v", name, err)
+		}
+	}
+}
+
+func TestPrompts_SystemWithPanel(t *testing.T) {
+	p := newTestPrompts(t, nil)
+	interview := &Interview{Position: "Go", Panel: testPanel()}
+
+	tests := []struct {
+		language string
+		want     []string
+	}{
+		{"zh-CN", []string{"共有 3 位面试官。你是Alice，担任Tech lead。", "你负责考察：Go concurrency", "- Bob (Hiring manager)：team fit", "- Carol\n"}},
+		{"en-US", []string{"panel interview with 3 interviewers. You are Alice, the Tech lead.", "Your focus: Go concurrency", "- Bob (Hiring manager): team fit"}},
+	}
+	for _, tt := range tests {
+		interview.Language = tt.language
+		got, _, err := p.System(interview, interview.Panel[0], nil, nil, nil, 0, 0)
+		if err != nil {
+			t.Fatalf("%s: System error: %v", tt.language, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: missing %q:\n%s", tt.language, want, got)
+			}
+		}
+		if strings.Contains(got, "- Alice") {
+			t.Errorf("%s: speaker should not be listed among the others", tt.language)
+		}
+	}
+}
+
+func TestPrompts_PanelTranscript(t *testing.T) {
+	p := newTestPrompts(t, nil)
+	interview := &Interview{Position: "Go", Language: "en-US", Panel: testPanel()}
+	msgs := []*InterviewMessage{
+		{Role: "assistant", Speaker: "tech", Content: "Explain the scheduler."},
+		{Role: "user", Content: "M:N."},
+	}
+
+	eval, err := p.Evaluation(interview, nil, msgs, nil, 0)
+	if err != nil {
+		t.Fatalf("Evaluation error: %v", err)
+	}
+	if !strings.Contains(eval[1].Content, "Interviewer Alice: Explain the scheduler.") {
+		t.Errorf("transcript should name the panelist:\n%s", eval[1].Content)
+	}
+
+	mod, err := p.Moderator(interview, msgs)
+	if err != nil {
+		t.Fatalf("Moderator error: %v", err)
+	}
+	if !strings.Contains(mod[1].Content, "- hm: Bob (Hiring manager), focusing on team fit") || !strings.Contains(mod[1].Content, "Candidate: M:N.") {
+		t.Errorf("unexpected moderation request:\n%s", mod[1].Content)
+	}
+}
+
+func TestBuildLLMMessages_PanelHistory(t *testing.T) {
+	prompts := newTestPrompts(t, nil)
+	personas, _ := NewPersonas(nil)
+	uc := &InterviewUsecase{prompts: prompts, personas: personas, log: log.NewHelper(log.DefaultLogger)}
+	interview := &Interview{Position: "Go", Language: "en-US", Panel: testPanel()}
+	msgs := []*InterviewMessage{
+		{Role: "assistant", Speaker: "tech", Content: "Explain the scheduler."},
+		{Role: "user", Content: "M:N."},
+		{Role: "assistant", Speaker: "hm", Content: "Why this team?"},
+		{Role: "user", Content: "Impact."},
+	}
+
+	built, err := uc.buildLLMMessages(interview, interview.Panel[1], nil, msgs, 2, 0)
+	if err != nil {
+		t.Fatalf("buildLLMMessages error: %v", err)
+	}
+	if built[1].Content != "[Alice] Explain the scheduler." || built[3].Content != "Why this team?" {
+		t.Errorf("other panelists should be prefixed: %q / %q", built[1].Content, built[3].Content)
+	}
+	if !strings.Contains(built[0].Content, "You are Bob, the Hiring manager.") {
+		t.Errorf("system prompt should be written for the speaker:\n%s", built[0].Content)
+	}
+}
+
+// moderatorLLM 总是选择固定的面试官
+type moderatorLLM struct{ reply string }
+
+func (p *moderatorLLM) Name() string { return "moderator" }
+
+func (p *moderatorLLM) ChatStream(context.Context, *llm.ChatRequest) (<-chan llm.StreamEvent, error) {
+	ch := make(chan llm.StreamEvent, 1)
+	ch <- llm.StreamEvent{Content: p.reply}
+	close(ch)
+	return ch, nil
+}
+
+func TestNextSpeaker_LLMModerator(t *testing.T) {
+	registry := llm.NewRegistry()
+	provider := &moderatorLLM{reply: "sre"}
+	registry.Register(provider)
+	uc := &InterviewUsecase{
+		llmRegistry: registry,
+		llmGuard:    resilience.NewGuard(resilience.Config{}),
+		prompts:     newTestPrompts(t, nil),
+		panel:       newPanelPolicy(&conf.Interview{Panel: &conf.Interview_Panel{Moderator: ModeratorLLM}}),
+		log:         log.NewHelper(log.DefaultLogger),
+	}
+	interview := &Interview{LLMProvider: "moderator", Panel: testPanel()}
+	msgs := []*InterviewMessage{{Role: "user", Content: "hi"}}
+
+	if got := uc.nextSpeaker(context.Background(), interview, msgs, nil); got.ID != "sre" {
+		t.Errorf("expected moderator's choice, got %s", got.ID)
+	}
+	// 无法识别的回复退回 rotation
+	provider.reply = "I think the next speaker should be someone else."
+	if got := uc.nextSpeaker(context.Background(), interview, msgs, nil); got.ID != "tech" {
+		t.Errorf("expected rotation fallback, got %s", got.ID)
+	}
+	if got := uc.nextSpeaker(context.Background(), &Interview{}, msgs, nil); got != nil {
+		t.Errorf("single interviewer should have no speaker, got %v", got)
+	}
+}
@@ -0,0 +1,145 @@
+package biz
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"ai-interview/internal/conf"
+)
+
+// 面试节奏
+const (
+	PacingRelaxed = "relaxed"
+	PacingNormal  = "normal"
+	PacingBrisk   = "brisk"
+)
+
+// 追问力度
+const (
+	FollowUpGentle     = "gentle"
+	FollowUpNormal     = "normal"
+	FollowUpAggressive = "aggressive"
+)
+
+// Persona 面试官人设：提示词片段、节奏、追问力度与默认语音
+type Persona struct {
+	ID           string
+	Name         string
+	Description  string
+	Pacing       string  // relaxed, normal, brisk
+	FollowUp     string  // gentle, normal, aggressive
+	Speed        float64 // TTS 语速
+	MaxQuestions int     // 覆盖 interview.max_questions，0 表示沿用
+	prompts      map[string]string
+	languages    []string // prompts 的 key (小写，已排序)
+	voices       map[string]string
+}
+
+// Prompt 返回与面试语言匹配的提示词片段，匹配规则同提示词模板
+func (p *Persona) Prompt(language, defaultLanguage string) string {
+	return p.prompts[matchLanguage(p.languages, language, defaultLanguage, fallbackLanguage)]
+}
+
+// Voice 返回人设在 provider 上的默认音色，未配置时为空
+func (p *Persona) Voice(provider string) string {
+	return p.voices[provider]
+}
+
+// Personas 面试官人设目录
+type Personas struct {
+	list      []*Persona
+	byID      map[string]*Persona
+	defaultID string
+}
+
+// NewPersonas 从配置加载人设目录
+func NewPersonas(c *conf.Interview) (*Personas, error) {
+	ps := &Personas{byID: map[string]*Persona{}}
+	if c == nil {
+		return ps, nil
+	}
+	for _, pc := range c.Personas {
+		p, err := newPersona(pc)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := ps.byID[p.ID]; ok {
+			return nil, fmt.Errorf("persona %q: duplicate id", p.ID)
+		}
+		ps.byID[p.ID] = p
+		ps.list = append(ps.list, p)
+	}
+	if c.DefaultPersona != "" {
+		if _, ok := ps.byID[c.DefaultPersona]; !ok {
+			return nil, fmt.Errorf("default persona %q: %w", c.DefaultPersona, ErrPersonaNotFound)
+		}
+		ps.defaultID = c.DefaultPersona
+	}
+	return ps, nil
+}
+
+func newPersona(c *conf.Interview_Persona) (*Persona, error) {
+	p := &Persona{
+		ID:           c.Id,
+		Name:         c.Name,
+		Description:  c.Description,
+		Pacing:       c.Pacing,
+		FollowUp:     c.FollowUp,
+		Speed:        math.Round(float64(c.Speed)*100) / 100, // 去掉 float32 的精度误差
+		MaxQuestions: int(c.MaxQuestions),
+		prompts:      map[string]string{},
+		voices:       c.Voices,
+	}
+	if p.ID == "" {
+		return nil, fmt.Errorf("persona %q: id is required", c.Name)
+	}
+	if p.Name == "" {
+		p.Name = p.ID
+	}
+	if p.Pacing == "" {
+		p.Pacing = PacingNormal
+	}
+	if p.FollowUp == "" {
+		p.FollowUp = FollowUpNormal
+	}
+	if p.Speed == 0 {
+		p.Speed = 1.0
+	}
+	if !slices.Contains([]string{PacingRelaxed, PacingNormal, PacingBrisk}, p.Pacing) {
+		return nil, fmt.Errorf("persona %q: unknown pacing %q", p.ID, p.Pacing)
+	}
+	if !slices.Contains([]string{FollowUpGentle, FollowUpNormal, FollowUpAggressive}, p.FollowUp) {
+		return nil, fmt.Errorf("persona %q: unknown follow_up %q", p.ID, p.FollowUp)
+	}
+	if p.Speed < 0.5 || p.Speed > 2.0 {
+		return nil, fmt.Errorf("persona %q: speed %.2f out of range [0.5, 2.0]", p.ID, p.Speed)
+	}
+	for lang, text := range c.Prompts {
+		key := strings.ToLower(lang)
+		p.prompts[key] = strings.TrimSpace(text)
+		p.languages = append(p.languages, key)
+	}
+	slices.Sort(p.languages)
+	return p, nil
+}
+
+// List 返回全部人设 (按配置顺序)
+func (ps *Personas) List() []*Persona {
+	return ps.list
+}
+
+// Get 按 ID 查找人设
+func (ps *Personas) Get(id string) (*Persona, error) {
+	p, ok := ps.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrPersonaNotFound, id)
+	}
+	return p, nil
+}
+
+// DefaultID 创建面试未指定人设时使用的人设，为空表示中立面试官
+func (ps *Personas) DefaultID() string {
+	return ps.defaultID
+}
@@ -0,0 +1,132 @@
+package biz
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-interview/internal/conf"
+)
+
+func testPersonaConf() *conf.Interview {
+	return &conf.Interview{
+		DefaultPersona: "screener",
+		Personas: []*conf.Interview_Persona{
+			{Id: "screener", Name: "友好的初筛面试官", Pacing: PacingRelaxed, FollowUp: FollowUpGentle},
+			{
+				Id:           "bar-raiser",
+				Prompts:      map[string]string{"zh-CN": "你是 Bar Raiser。", "en-US": "  You are the bar raiser.  "},
+				FollowUp:     FollowUpAggressive,
+				Voices:       map[string]string{"openai": "onyx"},
+				Speed:        0.9,
+				MaxQuestions: 8,
+			},
+		},
+	}
+}
+
+func TestNewPersonas(t *testing.T) {
+	ps, err := NewPersonas(testPersonaConf())
+	if err != nil {
+		t.Fatalf("NewPersonas error: %v", err)
+	}
+	if len(ps.List()) != 2 || ps.DefaultID() != "screener" {
+		t.Fatalf("unexpected catalog: %d personas, default %q", len(ps.List()), ps.DefaultID())
+	}
+	p, err := ps.Get("bar-raiser")
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if p.Name != "bar-raiser" || p.Pacing != PacingNormal || p.Speed != 0.9 {
+		t.Errorf("defaults not applied: %+v", p)
+	}
+	if p.Voice("openai") != "onyx" || p.Voice("edgetts") != "" {
+		t.Errorf("unexpected voices")
+	}
+	if s, _ := ps.Get("screener"); s.Speed != 1.0 {
+		t.Errorf("speed should default to 1.0, got %v", s.Speed)
+	}
+	if _, err := ps.Get("unknown"); !errors.Is(err, ErrPersonaNotFound) {
+		t.Errorf("expected ErrPersonaNotFound, got %v", err)
+	}
+
+	empty, err := NewPersonas(nil)
+	if err != nil || len(empty.List()) != 0 || empty.DefaultID() != "" {
+		t.Errorf("nil config should give an empty catalog: %v", err)
+	}
+}
+
+func TestNewPersonas_Invalid(t *testing.T) {
+	tests := map[string]*conf.Interview{
+		"missing id":      {Personas: []*conf.Interview_Persona{{Name: "x"}}},
+		"duplicate id":    {Personas: []*conf.Interview_Persona{{Id: "a"}, {Id: "a"}}},
+		"unknown pacing":  {Personas: []*conf.Interview_Persona{{Id: "a", Pacing: "slow"}}},
+		"unknown follow":  {Personas: []*conf.Interview_Persona{{Id: "a", FollowUp: "relentless"}}},
+		"speed too fast":  {Personas: []*conf.Interview_Persona{{Id: "a", Speed: 3}}},
+		"unknown default": {DefaultPersona: "b", Personas: []*conf.Interview_Persona{{Id: "a"}}},
+	}
+	for name, c := range tests {
+		if _, err := NewPersonas(c); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestPersona_Prompt(t *testing.T) {
+	ps, _ := NewPersonas(testPersonaConf())
+	p, _ := ps.Get("bar-raiser")
+
+	tests := []struct{ language, defaultLanguage, want string }{
+		{"en-US", "zh-CN", "You are the bar raiser."},
+		{"en-GB", "zh-CN", "You are the bar raiser."},
+		{"zh-CN", "en-US", "你是 Bar Raiser。"},
+		{"fr-FR", "en-US", "You are the bar raiser."},
+		{"fr-FR", "", "你是 Bar Raiser。"},
+	}
+	for _, tt := range tests {
+		if got := p.Prompt(tt.language, tt.defaultLanguage); got != tt.want {
+			t.Errorf("Prompt(%q, %q) = %q, want %q", tt.language, tt.defaultLanguage, got, tt.want)
+		}
+	}
+	if s, _ := ps.Get("screener"); s.Prompt("zh-CN", "") != "" {
+		t.Error("persona without prompts should return empty fragment")
+	}
+}
+
+func TestPrompts_SystemWithPersona(t *testing.T) {
+	prompts := newTestPrompts(t, &conf.Interview{MaxQuestions: 15})
+	ps, _ := NewPersonas(testPersonaConf())
+	barRaiser, _ := ps.Get("bar-raiser")
+	screener, _ := ps.Get("screener")
+
+	tests := []struct {
+		persona  *Persona
+		language string
+		asked    int
+		want     []string
+		unwanted []string
+	}{
+		{barRaiser, "en-US", 8, []string{"You are the bar raiser.", "Follow-ups: press on", "limit for this interview (8)"}, []string{"Pacing:"}},
+		{barRaiser, "zh-CN", 7, []string{"你是 Bar Raiser。", "追问：对笼统"}, []string{"节奏：", "不要再提出新问题"}},
+		{screener, "zh-CN", 8, []string{"节奏：放慢节奏", "追问：候选人回答不完整时给出提示"}, []string{"不要再提出新问题"}},
+		{screener, "en-US", 15, []string{"Pacing: take your time", "Follow-ups: when an answer is incomplete", "limit for this interview (15)"}, nil},
+		{nil, "zh-CN", 0, nil, []string{"节奏：", "追问："}},
+	}
+	for _, tt := range tests {
+		system, turn, err := prompts.System(&Interview{Position: "Go", Language: tt.language}, nil, tt.persona, nil, nil, tt.asked, 0)
+		if err != nil {
+			t.Fatalf("System error: %v", err)
+		}
+		got := system + "\n" + turn
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: missing %q:\n%s", tt.language, want, got)
+			}
+		}
+		for _, unwanted := range tt.unwanted {
+			if strings.Contains(got, unwanted) {
+				t.Errorf("%s: unexpected %q:\n%s", tt.language, unwanted, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,486 @@
+package biz
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+)
+
+// 内置提示词模板，每种语言一个文件，文件名即语言代码
+//
+//go:embed prompts/*.tmpl
+var promptFiles embed.FS
+
+// fallbackLanguage 所有语言都匹配不到时使用的内置模板
+const fallbackLanguage = "zh-CN"
+
+// 每种语言必须提供的模板
+var promptNames = []string{
+	"interviewer",       // 面试官角色与提问方式，可被 interview.system_prompt 覆盖
+	"system",            // 面试官的系统提示：interviewer + persona + panel + 简历 + 编程题 + tools + 摘要 + 已问问题，按变化频率排列
+	"turn",              // 本轮的要求 (difficulty 或 closing)，放在对话之后，不影响可缓存的前缀
+	"persona",           // 人设的提示词片段、节奏与追问力度 (数据为 personaPromptData)
+	"panel",             // 小组面试中本轮发言的面试官与其他面试官 (数据为 panelPromptData)
+	"coding",            // 编程面试的题目介绍 (不含隐藏用例)
+	"difficulty",        // 自适应难度下一个问题的难度要求
+	"tools",             // 开启工具调用时各工具的使用时机
+	"closing",           // 达到问题上限后的结束语要求
+	"transcript",        // 对话记录 (数据为 []transcriptLine)
+	"evaluator",         // 评估的系统提示
+	"evaluation",        // 评估请求
+	"coding_evaluation", // 评估请求中的编程题部分
+	"submission",        // 交给面试官的代码提交结果 (数据为 *CodeSubmission)，作为候选人消息保存
+	"summarizer",        // 滚动摘要的系统提示
+	"summary",           // 滚动摘要请求
+	"moderator",         // 小组面试主持人的系统提示
+	"moderation",        // 选择下一位发言人的请求
+	"grader",            // 自适应难度评分的系统提示
+	"grading",           // 为候选人的一个回答评分的请求
+}
+
+// systemPromptData system / turn / interviewer / coding / closing 模板的数据
+type systemPromptData struct {
+	Position     string
+	Language     string
+	Resume       string
+	Persona      *personaPromptData // 未选择人设时为 nil
+	Panel        *panelPromptData   // 单面试官时为 nil
+	Problem      *Problem           // 编程面试的题目，对话面试为 nil
+	Summary      string             // 已压缩的早期对话摘要
+	Questions    []string           // 已经问过的问题
+	Asked        int                // 面试官已发言的轮数
+	Difficulty   int                // 自适应难度下一个问题的难度 (1-5)，0 表示不分级
+	Tools        bool               // 开启了工具调用
+	MaxQuestions int
+	Closing      bool // 已达到问题上限，应结束面试
+}
+
+// personaPromptData persona 模板的数据
+type personaPromptData struct {
+	Name     string
+	Prompt   string // 与面试语言匹配的提示词片段
+	Pacing   string // relaxed, normal, brisk
+	FollowUp string // gentle, normal, aggressive
+}
+
+// panelPromptData panel 模板的数据
+type panelPromptData struct {
+	Self   *Panelist
+	Others []*Panelist
+}
+
+// transcriptLine 对话记录中的一条发言
+type transcriptLine struct {
+	Role       string // user, assistant
+	Speaker    string // 小组面试中面试官的名字
+	Difficulty int    // 自适应难度下面试官问题的难度
+	Content    string
+	Images     []int // 候选人附带的图片编号，从 1 开始
+}
+
+// evaluationPromptData evaluation 模板的数据
+type evaluationPromptData struct {
+	Position string
+	Language string
+	Summary  string           // 早期对话摘要，Messages 为摘要之后的对话
+	Messages []transcriptLine // 不含系统消息
+	Coding   *codingReport    // 对话面试为 nil
+	Level    float64          // 自适应难度估计的能力等级，0 表示未启用
+	Images   int              // 附在请求之后的图片数
+}
+
+// codingReport coding_evaluation 模板的数据
+type codingReport struct {
+	Problem     *Problem
+	Submissions []*CodeSubmission
+	Best        *CodeSubmission // 通过用例最多的提交
+	Last        *CodeSubmission
+}
+
+// summaryPromptData summary 模板的数据
+type summaryPromptData struct {
+	Position string
+	Language string
+	Previous string // 已有摘要
+	Messages []transcriptLine
+}
+
+// moderatorPromptData moderator / moderation 模板的数据
+type moderatorPromptData struct {
+	Position string
+	Language string
+	Panel    []*Panelist
+	Messages []transcriptLine // 最近的对话
+}
+
+// gradingPromptData grader / grading 模板的数据
+type gradingPromptData struct {
+	Position   string
+	Language   string
+	Question   string
+	Difficulty int
+	Answer     string
+}
+
+var promptFuncs = template.FuncMap{
+	"inc":      func(i int) int { return i + 1 },
+	"truncate": truncate,
+}
+
+// Prompts 按面试语言选择的提示词模板。
+// 语言匹配顺序：面试语言 → 同一主语言的其他地区 (en-GB → en-US) → interview.default_language → zh-CN。
+type Prompts struct {
+	sets            map[string]*template.Template // 小写语言代码 -> 模板集
+	languages       []string                      // sets 的 key (已排序)，用于按主语言匹配
+	defaultLanguage string
+	maxQuestions    int
+	tools           bool
+}
+
+// NewPrompts 加载内置模板，再依次应用 interview.prompt_dir 下的 <语言>.tmpl 和 interview.system_prompt。
+// 覆盖文件只需定义要替换的模板，其余沿用该语言 (或默认语言) 的内置模板；新语言的文件同样以默认语言为基础。
+func NewPrompts(c *conf.Interview) (*Prompts, error) {
+	p := &Prompts{sets: map[string]*template.Template{}, defaultLanguage: fallbackLanguage}
+	if c != nil {
+		if c.DefaultLanguage != "" {
+			p.defaultLanguage = c.DefaultLanguage
+		}
+		p.maxQuestions = int(c.MaxQuestions)
+		p.tools = c.Tools != nil && c.Tools.Enabled
+	}
+
+	if err := p.load(promptFiles, "prompts"); err != nil {
+		return nil, fmt.Errorf("load builtin prompts: %w", err)
+	}
+	for _, name := range promptNames {
+		if p.sets[strings.ToLower(fallbackLanguage)].Lookup(name) == nil {
+			return nil, fmt.Errorf("builtin prompts: %s missing template %q", fallbackLanguage, name)
+		}
+	}
+	if c != nil && c.PromptDir != "" {
+		if err := p.load(os.DirFS(c.PromptDir), "."); err != nil {
+			return nil, fmt.Errorf("load prompts from %s: %w", c.PromptDir, err)
+		}
+	}
+	if c != nil && strings.TrimSpace(c.SystemPrompt) != "" {
+		for lang, set := range p.sets {
+			if _, err := set.New("interviewer").Parse(c.SystemPrompt); err != nil {
+				return nil, fmt.Errorf("parse system_prompt for %s: %w", lang, err)
+			}
+		}
+	}
+
+	// 用示例数据渲染一遍，让覆盖模板中的错误在启动时暴露
+	for _, lang := range p.languages {
+		if err := p.check(lang); err != nil {
+			return nil, fmt.Errorf("prompts %s: %w", lang, err)
+		}
+	}
+	return p, nil
+}
+
+// load 读取 dir 下的 *.tmpl，每个文件以同语言已有的模板集 (没有时为匹配到的默认语言) 为基础解析
+func (p *Prompts) load(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	// 先加载兜底语言，其他语言缺少的模板从它继承
+	if i := slices.IndexFunc(files, func(f string) bool { return languageOf(f) == fallbackLanguage }); i > 0 {
+		files[0], files[i] = files[i], files[0]
+	}
+	for _, file := range files {
+		text, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		lang := languageOf(file)
+		var set *template.Template
+		if base := p.lookup(lang); base != nil {
+			if set, err = base.Clone(); err != nil {
+				return err
+			}
+		} else {
+			set = template.New(lang).Funcs(promptFuncs)
+		}
+		if _, err := set.Parse(string(text)); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		p.add(lang, set)
+	}
+	return nil
+}
+
+func (p *Prompts) add(lang string, set *template.Template) {
+	key := strings.ToLower(lang)
+	if _, ok := p.sets[key]; !ok {
+		p.languages = append(p.languages, key)
+		slices.Sort(p.languages)
+	}
+	p.sets[key] = set
+}
+
+// lookup 按面试语言选择模板集，见 Prompts 的匹配顺序；尚未加载任何模板时返回 nil
+func (p *Prompts) lookup(language string) *template.Template {
+	return p.sets[matchLanguage(p.languages, language, p.defaultLanguage, fallbackLanguage)]
+}
+
+// matchLanguage 依次为每个候选语言在 keys (小写，已排序) 中查找：完全匹配优先，其次是同一主语言的第一个 key。
+// 都匹配不到时返回空字符串。
+func matchLanguage(keys []string, languages ...string) string {
+	for _, lang := range languages {
+		key := strings.ToLower(strings.TrimSpace(lang))
+		if key == "" {
+			continue
+		}
+		if slices.Contains(keys, key) {
+			return key
+		}
+		primary, _, _ := strings.Cut(key, "-")
+		for _, k := range keys {
+			if k == primary || strings.HasPrefix(k, primary+"-") {
+				return k
+			}
+		}
+	}
+	return ""
+}
+
+func (p *Prompts) render(language, name string, data any) (string, error) {
+	var sb strings.Builder
+	if err := p.lookup(language).ExecuteTemplate(&sb, name, data); err != nil {
+		return "", fmt.Errorf("render prompt %q (%s): %w", name, language, err)
+	}
+	return sb.String(), nil
+}
+
+// check 用覆盖所有分支的示例数据渲染 lang 的各个模板
+func (p *Prompts) check(lang string) error {
+	if p.sets[lang].Lookup("system") == nil {
+		return errors.New(`missing template "system"`)
+	}
+	problem := &Problem{Title: "t", Difficulty: "easy", Description: "d", Tests: []TestCase{{Input: "1", Output: "1"}}}
+	sub := &CodeSubmission{Language: "go", Code: "c", Status: SubmissionAccepted, Passed: 1, Total: 1}
+	panel := []*Panelist{{ID: "a", Name: "A", Role: "r", Focus: "f"}, {ID: "b", Name: "B"}}
+	msgs := []transcriptLine{{Role: "assistant", Speaker: "A", Difficulty: 3, Content: "q?"}, {Role: "user", Content: "a", Images: []int{1}}}
+
+	system := systemPromptData{
+		Position: "p", Language: lang, Resume: "r", Problem: problem, Summary: "s",
+		Persona:   &personaPromptData{Name: "n", Prompt: "p", Pacing: PacingBrisk, FollowUp: FollowUpAggressive},
+		Panel:     &panelPromptData{Self: panel[0], Others: panel[1:]},
+		Questions: []string{"q?"}, Asked: 1, Difficulty: 3, Tools: true, MaxQuestions: 1, Closing: true,
+	}
+	for _, closing := range []bool{true, false} {
+		system.Closing = closing
+		for _, name := range []string{"system", "turn"} {
+			if _, err := p.render(lang, name, system); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := p.request(lang, "moderator", "moderation", moderatorPromptData{Position: "p", Language: lang, Panel: panel, Messages: msgs}); err != nil {
+		return err
+	}
+	if _, err := p.request(lang, "grader", "grading", gradingPromptData{Position: "p", Language: lang, Question: "q?", Difficulty: 3, Answer: "a"}); err != nil {
+		return err
+	}
+	for _, name := range []string{"evaluator", "summarizer"} {
+		if _, err := p.render(lang, name, nil); err != nil {
+			return err
+		}
+	}
+	if _, err := p.render(lang, "evaluation", evaluationPromptData{
+		Position: "p", Language: lang, Summary: "s", Messages: msgs,
+		Coding: &codingReport{Problem: problem, Submissions: []*CodeSubmission{sub}, Best: sub, Last: sub}, Level: 3.5, Images: 1,
+	}); err != nil {
+		return err
+	}
+	if _, err := p.render(lang, "coding_evaluation", &codingReport{Problem: problem}); err != nil {
+		return err
+	}
+	failed := &CodeSubmission{Language: "go", Code: "c", Status: SubmissionCompileError, CompileOutput: "e",
+		Results: []TestResult{{Index: 1, Status: TestWrongAnswer}, {Index: 2, Hidden: true, Status: TestTimeout}}}
+	for _, s := range []*CodeSubmission{sub, failed} {
+		if _, err := p.render(lang, "submission", s); err != nil {
+			return err
+		}
+	}
+	_, err := p.render(lang, "summary", summaryPromptData{Position: "p", Language: lang, Previous: "s", Messages: msgs})
+	return err
+}
+
+// DefaultLanguage 未指定语言的面试使用的语言
+func (p *Prompts) DefaultLanguage() string {
+	return p.defaultLanguage
+}
+
+// System 渲染面试官的系统提示与本轮要求；speaker 为小组面试中本轮发言的面试官，persona 为其人设；
+// asked 为面试官已发言的轮数，达到问题上限 (人设或 interview.max_questions) 时要求结束；
+// difficulty 为自适应难度下一个问题的难度，0 表示不分级。
+// 系统提示在面试中基本不变，便于缓存；每轮变化的要求放在 turn 中，没有要求时为空
+func (p *Prompts) System(interview *Interview, speaker *Panelist, persona *Persona, problem *Problem, summary *InterviewSummary, asked, difficulty int) (system, turn string, err error) {
+	data := systemPromptData{
+		Position:     interview.Position,
+		Language:     interview.Language,
+		Resume:       interview.Resume,
+		Problem:      problem,
+		Asked:        asked,
+		Difficulty:   difficulty,
+		Tools:        p.tools,
+		MaxQuestions: p.maxQuestions,
+	}
+	if persona != nil {
+		data.Persona = &personaPromptData{
+			Name:     persona.Name,
+			Prompt:   persona.Prompt(interview.Language, p.defaultLanguage),
+			Pacing:   persona.Pacing,
+			FollowUp: persona.FollowUp,
+		}
+		if persona.MaxQuestions > 0 {
+			data.MaxQuestions = persona.MaxQuestions
+		}
+	}
+	if speaker != nil {
+		data.Panel = &panelPromptData{Self: speaker}
+		for _, other := range interview.Panel {
+			if other.ID != speaker.ID {
+				data.Panel.Others = append(data.Panel.Others, other)
+			}
+		}
+	}
+	data.Closing = data.MaxQuestions > 0 && asked >= data.MaxQuestions
+	if summary != nil {
+		data.Summary, data.Questions = summary.Content, summary.Questions
+	}
+	if system, err = p.render(interview.Language, "system", data); err != nil {
+		return "", "", err
+	}
+	if turn, err = p.render(interview.Language, "turn", data); err != nil {
+		return "", "", err
+	}
+	return system, strings.TrimSpace(turn), nil
+}
+
+// Evaluation 渲染评估请求；summary 不为 nil 时 messages 为摘要之后的对话，level 为自适应难度估计的能力等级。
+// 候选人附带的图片按对话记录中的编号顺序附在请求之后
+func (p *Prompts) Evaluation(interview *Interview, summary *InterviewSummary, messages []*InterviewMessage, coding *codingReport, level float64) ([]llm.Message, error) {
+	var images []llm.ContentPart
+	for _, m := range conversation(messages) {
+		images = append(images, imageParts(m.Attachments)...)
+	}
+	data := evaluationPromptData{
+		Position: interview.Position,
+		Language: interview.Language,
+		Messages: transcript(interview, messages),
+		Coding:   coding,
+		Level:    level,
+		Images:   len(images),
+	}
+	if summary != nil {
+		data.Summary = summary.Content
+	}
+	msgs, err := p.request(interview.Language, "evaluator", "evaluation", data)
+	if err != nil || len(images) == 0 {
+		return msgs, err
+	}
+	user := &msgs[len(msgs)-1]
+	user.Content, user.Parts = "", append([]llm.ContentPart{llm.TextPart(user.Content)}, images...)
+	return msgs, nil
+}
+
+// Submission 渲染交给面试官的代码提交结果，语言与面试一致
+func (p *Prompts) Submission(interview *Interview, sub *CodeSubmission) (string, error) {
+	s, err := p.render(interview.Language, "submission", sub)
+	return strings.TrimSpace(s), err
+}
+
+// Summary 渲染滚动摘要请求
+func (p *Prompts) Summary(interview *Interview, prev string, older []*InterviewMessage) ([]llm.Message, error) {
+	return p.request(interview.Language, "summarizer", "summary", summaryPromptData{
+		Position: interview.Position,
+		Language: interview.Language,
+		Previous: prev,
+		Messages: transcript(interview, older),
+	})
+}
+
+// Moderator 渲染小组面试选择下一位发言人的请求，messages 为最近的对话
+func (p *Prompts) Moderator(interview *Interview, messages []*InterviewMessage) ([]llm.Message, error) {
+	return p.request(interview.Language, "moderator", "moderation", moderatorPromptData{
+		Position: interview.Position,
+		Language: interview.Language,
+		Panel:    interview.Panel,
+		Messages: transcript(interview, messages),
+	})
+}
+
+// Grading 渲染为候选人对 question 的回答评分的请求
+func (p *Prompts) Grading(interview *Interview, question *InterviewMessage, answer string) ([]llm.Message, error) {
+	return p.request(interview.Language, "grader", "grading", gradingPromptData{
+		Position:   interview.Position,
+		Language:   interview.Language,
+		Question:   question.Content,
+		Difficulty: question.Difficulty,
+		Answer:     answer,
+	})
+}
+
+// request 组装 system + user 两条消息的请求
+func (p *Prompts) request(language, system, user string, data any) ([]llm.Message, error) {
+	sys, err := p.render(language, system, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.render(language, user, data)
+	if err != nil {
+		return nil, err
+	}
+	return []llm.Message{
+		{Role: "system", Content: sys},
+		{Role: "user", Content: body},
+	}, nil
+}
+
+// conversation 去掉系统消息
+func conversation(messages []*InterviewMessage) []*InterviewMessage {
+	out := make([]*InterviewMessage, 0, len(messages))
+	for _, m := range messages {
+		if m.Role != "system" {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// transcript 将对话转换为对话记录，小组面试的面试官发言带上名字，自适应难度下带上问题难度，
+// 已加载的图片依次编号
+func transcript(interview *Interview, messages []*InterviewMessage) []transcriptLine {
+	lines := make([]transcriptLine, 0, len(messages))
+	images := 0
+	for _, m := range conversation(messages) {
+		line := transcriptLine{Role: m.Role, Difficulty: m.Difficulty, Content: m.Content}
+		for range imageParts(m.Attachments) {
+			images++
+			line.Images = append(line.Images, images)
+		}
+		if p := panelistByID(interview.Panel, m.Speaker); p != nil {
+			line.Speaker = p.Name
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// languageOf 从文件名取语言代码：prompts/en-US.tmpl -> en-US
+func languageOf(file string) string {
+	return strings.TrimSuffix(path.Base(file), ".tmpl")
+}
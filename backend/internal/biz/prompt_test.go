@@ -0,0 +1,210 @@
+package biz
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"ai-interview/internal/conf"
+)
+
+func newTestPrompts(t *testing.T, c *conf.Interview) *Prompts {
+	t.Helper()
+	p, err := NewPrompts(c)
+	if err != nil {
+		t.Fatalf("NewPrompts error: %v", err)
+	}
+	return p
+}
+
+func TestBuiltinPrompts_Complete(t *testing.T) {
+	files, err := fs.Glob(promptFiles, "prompts/*.tmpl")
+	if err != nil || len(files) < 2 {
+		t.Fatalf("builtin prompts: %v %v", files, err)
+	}
+	for _, file := range files {
+		text, _ := fs.ReadFile(promptFiles, file)
+		set, err := template.New(file).Funcs(promptFuncs).Parse(string(text))
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		for _, name := range promptNames {
+			if set.Lookup(name) == nil {
+				t.Errorf("%s missing template %q", file, name)
+			}
+		}
+	}
+}
+
+func TestPrompts_System(t *testing.T) {
+	p := newTestPrompts(t, &conf.Interview{MaxQuestions: 5})
+	summary := &InterviewSummary{Content: "已考察并发", Questions: []string{"什么是 goroutine？"}}
+
+	tests := []struct {
+		language string
+		want     []string
+		unwanted []string
+	}{
+		{"zh-CN", []string{"面试后端工程师岗位", "候选人简历：\n熟悉 Go", "此前面试内容摘要：\n已考察并发", "- 什么是 goroutine？"}, []string{"interviewer", "不要再提出新问题"}},
+		{"en-US", []string{"for the 后端工程师 position", "Candidate resume:\n熟悉 Go", "Summary of the interview so far:\n已考察并发", "- 什么是 goroutine？"}, []string{"面试官", "Do not ask any new questions"}},
+	}
+	for _, tt := range tests {
+		interview := &Interview{Position: "后端工程师", Language: tt.language, Resume: "熟悉 Go"}
+		got, _, err := p.System(interview, nil, nil, nil, summary, 3, 0)
+		if err != nil {
+			t.Fatalf("%s: System error: %v", tt.language, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: missing %q:\n%s", tt.language, want, got)
+			}
+		}
+		for _, unwanted := range tt.unwanted {
+			if strings.Contains(got, unwanted) {
+				t.Errorf("%s: unexpected %q:\n%s", tt.language, unwanted, got)
+			}
+		}
+	}
+}
+
+func TestPrompts_Closing(t *testing.T) {
+	p := newTestPrompts(t, &conf.Interview{MaxQuestions: 5})
+	interview := &Interview{Position: "Backend Engineer", Language: "en-US"}
+
+	system, turn, _ := p.System(interview, nil, nil, nil, nil, 5, 0)
+	if !strings.HasPrefix(turn, "You have asked 5 questions") || !strings.Contains(turn, "Do not ask any new questions") {
+		t.Errorf("expected closing instructions:\n%s", turn)
+	}
+	if strings.Contains(system, "Do not ask any new questions") {
+		t.Errorf("closing instructions should not change the system prompt:\n%s", system)
+	}
+
+	unlimited := newTestPrompts(t, nil)
+	if _, turn, _ := unlimited.System(interview, nil, nil, nil, nil, 100, 0); turn != "" {
+		t.Errorf("max_questions 0 should not close the interview:\n%s", turn)
+	}
+}
+
+func TestPrompts_Evaluation(t *testing.T) {
+	p := newTestPrompts(t, nil)
+	msgs := []*InterviewMessage{
+		{Role: "system", Content: "hidden"},
+		{Role: "assistant", Content: "Tell me about yourself?"},
+		{Role: "user", Content: "I build backends."},
+	}
+	coding := newCodingReport(&Problem{Title: "Two Sum", Difficulty: "easy"}, nil)
+
+	tests := []struct {
+		language string
+		want     []string
+	}{
+		{"zh-CN", []string{"面试官: Tell me about yourself?", "候选人: I build backends.", "=== 编程题 ===", "6. 编程能力", `"overall_score"`}},
+		{"en-US", []string{"Interviewer: Tell me about yourself?", "Candidate: I build backends.", "=== Coding problem ===", "6. Coding", `"overall_score"`, "in English"}},
+	}
+	for _, tt := range tests {
+		req, err := p.Evaluation(&Interview{Position: "Backend", Language: tt.language}, nil, msgs, coding, 0)
+		if err != nil {
+			t.Fatalf("%s: Evaluation error: %v", tt.language, err)
+		}
+		if len(req) != 2 || req[0].Role != "system" || req[1].Role != "user" {
+			t.Fatalf("%s: unexpected request %+v", tt.language, req)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(req[1].Content, want) {
+				t.Errorf("%s: missing %q:\n%s", tt.language, want, req[1].Content)
+			}
+		}
+		if strings.Contains(req[1].Content, "hidden") {
+			t.Errorf("%s: system messages should be excluded", tt.language)
+		}
+	}
+}
+
+func TestPrompts_LanguageFallback(t *testing.T) {
+	zh := newTestPrompts(t, nil)
+	en := newTestPrompts(t, &conf.Interview{DefaultLanguage: "en-US"})
+
+	tests := []struct {
+		p        *Prompts
+		language string
+		want     string
+	}{
+		{zh, "en-US", "Conduct the interview in English."},
+		{zh, "EN-us", "Conduct the interview in English."},
+		{zh, "en-GB", "Conduct the interview in English."}, // 同一主语言
+		{zh, "en", "Conduct the interview in English."},
+		{zh, "zh-TW", "请使用中文进行面试。"},
+		{zh, "fr-FR", "请使用中文进行面试。"}, // 默认语言 zh-CN
+		{zh, "", "请使用中文进行面试。"},
+		{en, "fr-FR", "Conduct the interview in English."}, // 配置的默认语言
+		{en, "zh-CN", "请使用中文进行面试。"},
+	}
+	for _, tt := range tests {
+		got, _, err := tt.p.System(&Interview{Position: "Go", Language: tt.language}, nil, nil, nil, nil, 0, 0)
+		if err != nil {
+			t.Fatalf("%q: System error: %v", tt.language, err)
+		}
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("%q (default %s): want %q in:\n%s", tt.language, tt.p.DefaultLanguage(), tt.want, got)
+		}
+	}
+}
+
+func TestPrompts_SystemPromptOverride(t *testing.T) {
+	p := newTestPrompts(t, &conf.Interview{
+		SystemPrompt: `{{if eq .Language "en-US"}}Grill the {{.Position}} candidate.{{else}}请严格考察{{.Position}}候选人。{{end}}`,
+	})
+	summary := &InterviewSummary{Content: "covered concurrency"}
+
+	got, _, _ := p.System(&Interview{Position: "SRE", Language: "en-US"}, nil, nil, nil, summary, 0, 0)
+	if !strings.HasPrefix(got, "Grill the SRE candidate.") || !strings.Contains(got, "covered concurrency") {
+		t.Errorf("override should replace only the interviewer template:\n%s", got)
+	}
+	got, _, _ = p.System(&Interview{Position: "SRE", Language: "zh-CN"}, nil, nil, nil, nil, 0, 0)
+	if !strings.HasPrefix(got, "请严格考察SRE候选人。") {
+		t.Errorf("override should apply to every language:\n%s", got)
+	}
+
+	if _, err := NewPrompts(&conf.Interview{SystemPrompt: "{{.Missing}}"}); err == nil {
+		t.Error("expected error for system_prompt referencing unknown field")
+	}
+}
+
+func TestPrompts_PromptDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// 只覆盖 evaluator，其余沿用内置 en-US
+		"en-US.tmpl": `{{define "evaluator"}}You are a strict bar raiser.{{end}}`,
+		// 新语言只提供 interviewer，其余沿用默认语言
+		"ja-JP.tmpl": `{{define "interviewer"}}あなたは{{.Position}}の面接官です。{{end}}`,
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := newTestPrompts(t, &conf.Interview{PromptDir: dir})
+
+	req, err := p.Evaluation(&Interview{Language: "en-US"}, nil, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("Evaluation error: %v", err)
+	}
+	if req[0].Content != "You are a strict bar raiser." || !strings.Contains(req[1].Content, "=== Transcript ===") {
+		t.Errorf("unexpected en-US evaluation: %+v", req)
+	}
+
+	got, _, _ := p.System(&Interview{Position: "SRE", Language: "ja"}, nil, nil, nil, &InterviewSummary{Content: "s"}, 0, 0)
+	if !strings.HasPrefix(got, "あなたはSREの面接官です。") || !strings.Contains(got, "此前面试内容摘要") {
+		t.Errorf("unexpected ja-JP system prompt:\n%s", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "de-DE.tmpl"), []byte(`{{define "system"}}{{template "nope" .}}{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPrompts(&conf.Interview{PromptDir: dir}); err == nil {
+		t.Error("expected error for broken override")
+	}
+}
@@ -0,0 +1,83 @@
+package biz
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+)
+
+// 推理模型：思考过程与回答分开输出，只有回答会推送、朗读和保存为消息内容；开启审计时思考过程单独保存
+
+// reasoningPolicy 推理模型的思考设置
+type reasoningPolicy struct {
+	options llm.Reasoning
+	audit   bool
+}
+
+func newReasoningPolicy(c *conf.LLM_Reasoning) reasoningPolicy {
+	if c == nil {
+		return reasoningPolicy{}
+	}
+	return reasoningPolicy{
+		options: llm.Reasoning{Effort: c.Effort, BudgetTokens: int(c.BudgetTokens)},
+		audit:   c.Audit,
+	}
+}
+
+// thinkStream 与 chatStream 相同，但带上思考设置与可选的输出格式；只用于面试官回复与评估，评分、摘要等辅助调用不开启思考
+func (uc *InterviewUsecase) thinkStream(ctx context.Context, p llm.Provider, messages []llm.Message, temperature float64, format *llm.ResponseFormat) (<-chan llm.StreamEvent, error) {
+	return p.ChatStream(ctx, &llm.ChatRequest{
+		Messages:       messages,
+		MaxTokens:      maxOutputTokens,
+		Temperature:    temperature,
+		Reasoning:      uc.reasoning.options,
+		ResponseFormat: format,
+	})
+}
+
+// thoughts 累积流中的思考过程，可在流读取过程中并发读取
+type thoughts struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+func (t *thoughts) write(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sb.WriteString(s)
+}
+
+func (t *thoughts) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sb.String()
+}
+
+// answerStream 转发 stream 中的回答，思考过程写入 t 而不转发
+func answerStream(stream <-chan llm.StreamEvent, t *thoughts) <-chan llm.StreamEvent {
+	out := make(chan llm.StreamEvent, 32)
+	go func() {
+		defer close(out)
+		for event := range stream {
+			if event.Reasoning != "" {
+				t.write(event.Reasoning)
+				if event.Content == "" && !event.Done && event.Err == nil {
+					continue
+				}
+				event.Reasoning = ""
+			}
+			out <- event
+		}
+	}()
+	return out
+}
+
+// collectReasoning 读取完整的流式回复，分别返回回答与思考过程
+func collectReasoning(stream <-chan llm.StreamEvent) (content, reasoning string, err error) {
+	var t thoughts
+	content, err = collectStream(answerStream(stream, &t))
+	return content, t.String(), err
+}
@@ -0,0 +1,73 @@
+package biz
+
+import (
+	"context"
+	"testing"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+)
+
+// thinkingLLM 先输出思考过程再输出回答，并记录收到的请求
+type thinkingLLM struct{ last *llm.ChatRequest }
+
+func (p *thinkingLLM) Name() string { return "thinking" }
+
+func (p *thinkingLLM) ChatStream(_ context.Context, req *llm.ChatRequest) (<-chan llm.StreamEvent, error) {
+	p.last = req
+	ch := make(chan llm.StreamEvent, 4)
+	ch <- llm.StreamEvent{Reasoning: "候选人提到了 Redis，"}
+	ch <- llm.StreamEvent{Reasoning: "追问持久化。", Content: "Redis 的"}
+	ch <- llm.StreamEvent{Content: "持久化方式有哪些？"}
+	ch <- llm.StreamEvent{Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func TestAnswerStream_SeparatesReasoning(t *testing.T) {
+	p := &thinkingLLM{}
+	stream, _ := p.ChatStream(context.Background(), &llm.ChatRequest{})
+
+	var th thoughts
+	var events []llm.StreamEvent
+	for event := range answerStream(stream, &th) {
+		events = append(events, event)
+	}
+	// 纯思考事件不转发，混合事件只保留回答
+	if len(events) != 3 || events[0].Content != "Redis 的" || !events[2].Done {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	for _, event := range events {
+		if event.Reasoning != "" {
+			t.Errorf("reasoning leaked into answer stream: %+v", event)
+		}
+	}
+	if got := th.String(); got != "候选人提到了 Redis，追问持久化。" {
+		t.Errorf("reasoning = %q", got)
+	}
+}
+
+func TestThinkStream_ReasoningOptions(t *testing.T) {
+	uc := &InterviewUsecase{reasoning: newReasoningPolicy(&conf.LLM_Reasoning{Effort: "medium", Audit: true})}
+	p := &thinkingLLM{}
+
+	stream, err := uc.thinkStream(context.Background(), p, nil, 0.3, nil)
+	if err != nil {
+		t.Fatalf("thinkStream error: %v", err)
+	}
+	content, reasoning, err := collectReasoning(stream)
+	if err != nil || content != "Redis 的持久化方式有哪些？" || reasoning != "候选人提到了 Redis，追问持久化。" {
+		t.Errorf("collectReasoning = %q, %q, %v", content, reasoning, err)
+	}
+	if p.last.Reasoning.Effort != "medium" {
+		t.Errorf("interviewer turns should carry reasoning options, got %+v", p.last.Reasoning)
+	}
+
+	// 评分、摘要等辅助调用不开启思考
+	if _, err := uc.chatStream(context.Background(), p, nil, 0); err != nil {
+		t.Fatalf("chatStream error: %v", err)
+	}
+	if p.last.Reasoning != (llm.Reasoning{}) {
+		t.Errorf("auxiliary calls should not enable reasoning, got %+v", p.last.Reasoning)
+	}
+}
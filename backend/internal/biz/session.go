@@ -0,0 +1,68 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	// SessionEventAudio 音频帧事件类型，通过 WebSocket 二进制帧推送
+	SessionEventAudio = "audio"
+	// SessionEventTakeover 实例间的控制事件：候选人连接已被 Data 中的 owner 接管，不推送给客户端
+	SessionEventTakeover = "takeover"
+)
+
+// ErrSessionTakenOver 候选人连接的归属已被其他连接接管 (或租约已过期)
+var ErrSessionTakenOver = errors.New("session taken over by another connection")
+
+// SessionEvent 面试会话中服务端推送的一个事件，Seq 在同一场面试内单调递增
+type SessionEvent struct {
+	Seq   int64           `json:"seq"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data,omitempty"`  // JSON 事件的 data
+	Audio []byte          `json:"audio,omitempty"` // 音频帧 (Type 为 audio)
+}
+
+// SessionEventStore 按面试缓存最近的会话事件，供断线重连时补发
+type SessionEventStore interface {
+	// Append 为事件分配序号并写入缓存，返回序号
+	Append(ctx context.Context, interviewID int64, ev *SessionEvent) (int64, error)
+	// Since 返回缓存中 seq > after 的事件 (按序) 以及当前最大序号
+	Since(ctx context.Context, interviewID int64, after int64) ([]*SessionEvent, int64, error)
+}
+
+// ReplayGap 判断从 after 之后补发是否有缺口：缓存已被裁剪 / 过期，或序号已重置
+func ReplayGap(after, latest int64, events []*SessionEvent) bool {
+	if after > latest {
+		return true
+	}
+	if after == latest {
+		return false
+	}
+	return len(events) == 0 || events[0].Seq > after+1
+}
+
+// SessionRegistry 在多个实例间协调面试会话：候选人连接的归属 (同一时间只有一个)、
+// 回复轮次锁 (同一时间只有一个) 以及会话事件的广播。owner 为 "实例/连接" 标识。
+type SessionRegistry interface {
+	// Claim 把面试的候选人连接归属到 owner，覆盖之前的归属
+	Claim(ctx context.Context, interviewID int64, owner string, ttl time.Duration) error
+	// Owner 返回当前持有候选人连接的 owner，没有时为空
+	Owner(ctx context.Context, interviewID int64) (string, error)
+	// Renew 延长归属租约，已被接管时返回 ErrSessionTakenOver
+	Renew(ctx context.Context, interviewID int64, owner string, ttl time.Duration) error
+	// Release 释放归属 (仍为 owner 时)
+	Release(ctx context.Context, interviewID int64, owner string) error
+	// LockTurn 获取回复轮次锁，已被占用时返回 false
+	LockTurn(ctx context.Context, interviewID int64, owner string, ttl time.Duration) (bool, error)
+	// UnlockTurn 释放回复轮次锁 (仍为 owner 时)
+	UnlockTurn(ctx context.Context, interviewID int64, owner string) error
+	// TurnLocked 是否有进行中的回复轮次
+	TurnLocked(ctx context.Context, interviewID int64) (bool, error)
+	// Publish 向所有实例广播会话事件
+	Publish(ctx context.Context, interviewID int64, ev *SessionEvent) error
+	// Subscribe 订阅会话事件，返回时订阅已生效；ctx 结束或消费过慢时关闭 channel
+	Subscribe(ctx context.Context, interviewID int64) (<-chan *SessionEvent, error)
+}
@@ -0,0 +1,32 @@
+package biz
+
+import "testing"
+
+func TestReplayGap(t *testing.T) {
+	events := func(seqs ...int64) []*SessionEvent {
+		var out []*SessionEvent
+		for _, seq := range seqs {
+			out = append(out, &SessionEvent{Seq: seq})
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		after  int64
+		latest int64
+		events []*SessionEvent
+		want   bool
+	}{
+		{"up to date", 5, 5, nil, false},
+		{"contiguous", 3, 5, events(4, 5), false},
+		{"trimmed", 1, 5, events(4, 5), true},
+		{"expired", 3, 5, nil, true},
+		{"counter reset", 9, 2, events(1, 2), true},
+	}
+	for _, tt := range tests {
+		if got := ReplayGap(tt.after, tt.latest, tt.events); got != tt.want {
+			t.Errorf("%s: ReplayGap = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+	"ai-interview/internal/sandbox"
+)
+
+// 工具调用：面试官在回复中通过工具记录问题、为回答评分、结束面试与运行代码，代替从回复文本中解析。
+// 工具调用不会推送或朗读，只有回答文本会。
+
+// 面试官可用的工具
+const (
+	ToolNextQuestion = "next_question"
+	ToolRecordScore  = "record_score"
+	ToolEndInterview = "end_interview"
+	ToolRunCode      = "run_code"
+)
+
+const defaultToolRounds = 3
+
+// 工具定义：描述与参数名面向模型，不随面试语言变化
+var (
+	nextQuestionTool = llm.Tool{
+		Name:        ToolNextQuestion,
+		Description: "Record the new main question you are asking in this reply (not needed for follow-ups on the same topic). Still say the question in your reply text.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"question":{"type":"string","description":"The question, in one sentence"}},"required":["question"]}`),
+	}
+	recordScoreTool = llm.Tool{
+		Name:        ToolRecordScore,
+		Description: "Score the candidate's latest answer from 0 to 100 (60 means just competent for the question). Call it once per answer, before replying.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"score":{"type":"integer","minimum":0,"maximum":100}},"required":["score"]}`),
+	}
+	endInterviewTool = llm.Tool{
+		Name:        ToolEndInterview,
+		Description: "End the interview after this reply and generate the evaluation report. Say goodbye in your reply text.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"reason":{"type":"string"}}}`),
+	}
+	runCodeTool = llm.Tool{
+		Name:        ToolRunCode,
+		Description: "Run a program in the sandbox with the given stdin and get stdout, stderr and the exit code. Use it to check the candidate's code against your own inputs.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"language":{"type":"string","enum":["go","python","javascript"]},"code":{"type":"string"},"stdin":{"type":"string"}},"required":["language","code"]}`),
+	}
+)
+
+// toolPolicy 工具调用配置
+type toolPolicy struct {
+	enabled   bool
+	maxRounds int
+}
+
+func newToolPolicy(c *conf.Interview) toolPolicy {
+	p := toolPolicy{maxRounds: defaultToolRounds}
+	if c == nil || c.Tools == nil {
+		return p
+	}
+	p.enabled = c.Tools.Enabled
+	if c.Tools.MaxRounds > 0 {
+		p.maxRounds = int(c.Tools.MaxRounds)
+	}
+	return p
+}
+
+// interviewTools 返回本场面试可用的工具，run_code 只用于沙箱可用的编程面试
+func (uc *InterviewUsecase) interviewTools(interview *Interview) []llm.Tool {
+	tools := []llm.Tool{nextQuestionTool, recordScoreTool, endInterviewTool}
+	if interview.Mode == InterviewModeCoding && uc.coding.Enabled() {
+		tools = append(tools, runCodeTool)
+	}
+	return tools
+}
+
+// toolStream 带工具的面试官回复：转发模型输出，执行工具调用；需要结果才能继续的调用 (run_code)，
+// 或模型只调用了工具而没有回答时，把结果交回模型继续生成，最多 maxRounds 轮。answer 为本轮候选人的回答。
+func (uc *InterviewUsecase) toolStream(ctx context.Context, chain llm.Provider, interview *Interview, answer *InterviewMessage, messages []llm.Message, reply *Reply) (<-chan llm.StreamEvent, error) {
+	req := &llm.ChatRequest{
+		Messages:    messages,
+		MaxTokens:   maxOutputTokens,
+		Temperature: 0.7,
+		Reasoning:   uc.reasoning.options,
+		Tools:       uc.interviewTools(interview),
+	}
+	stream, err := chain.ChatStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan llm.StreamEvent, 32)
+	go func() {
+		defer close(out)
+		// 消费方停止读取 (取消轮次、断线) 后随 ctx 退出，读空当前上游让 provider 的 goroutine 结束
+		send := func(event llm.StreamEvent) bool {
+			select {
+			case out <- event:
+				return true
+			case <-ctx.Done():
+				for range stream {
+				}
+				return false
+			}
+		}
+		answered := false
+		var usage *llm.Usage // 各轮用量之和
+		for round := 1; ; round++ {
+			var calls llm.ToolCalls
+			var text strings.Builder
+			for event := range stream {
+				if event.ToolCall != nil {
+					calls.Add(event.ToolCall)
+					continue
+				}
+				if event.Err != nil {
+					send(event)
+					return
+				}
+				if event.Done {
+					if event.Usage != nil {
+						if usage == nil {
+							usage = &llm.Usage{}
+						}
+						usage.Add(event.Usage)
+					}
+					break
+				}
+				text.WriteString(event.Content)
+				if !send(event) {
+					return
+				}
+			}
+			answered = answered || strings.TrimSpace(text.String()) != ""
+
+			list := calls.List()
+			if len(list) == 0 {
+				send(llm.StreamEvent{Usage: usage, Done: true})
+				return
+			}
+			results, more := uc.runTools(ctx, interview, answer, reply, list)
+			if (!more && answered) || round >= uc.tools.maxRounds {
+				send(llm.StreamEvent{Usage: usage, Done: true})
+				return
+			}
+			// 已取消时不再开启新一轮生成
+			if err := ctx.Err(); err != nil {
+				send(llm.StreamEvent{Err: err})
+				return
+			}
+
+			// 继续生成时不再开启思考：Anthropic 要求开启思考时回传上一轮的思考块
+			req.Messages = append(req.Messages, llm.Message{Role: "assistant", Content: text.String(), ToolCalls: list})
+			req.Messages = append(req.Messages, results...)
+			req.Reasoning = llm.Reasoning{}
+			if stream, err = chain.ChatStream(ctx, req); err != nil {
+				send(llm.StreamEvent{Err: err})
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// runTools 依次执行工具调用，返回 tool 消息；more 表示有调用的结果需要模型继续处理
+func (uc *InterviewUsecase) runTools(ctx context.Context, interview *Interview, answer *InterviewMessage, reply *Reply, calls []llm.ToolCall) (results []llm.Message, more bool) {
+	for _, call := range calls {
+		result, err := uc.runTool(ctx, interview, answer, reply, call)
+		if err != nil {
+			uc.log.Warnf("tool %s of interview %d: %v", call.Name, interview.ID, err)
+			result, more = "error: "+err.Error(), true
+		}
+		if call.Name == ToolRunCode {
+			more = true
+		}
+		results = append(results, llm.Message{Role: "tool", ToolCallID: call.ID, Content: result})
+	}
+	return results, more
+}
+
+func (uc *InterviewUsecase) runTool(ctx context.Context, interview *Interview, answer *InterviewMessage, reply *Reply, call llm.ToolCall) (string, error) {
+	switch call.Name {
+	case ToolNextQuestion:
+		var args struct {
+			Question string `json:"question"`
+		}
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		reply.question = strings.TrimSpace(args.Question)
+		return "ok", nil
+
+	case ToolRecordScore:
+		var args struct {
+			Score *int32 `json:"score"`
+		}
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil || args.Score == nil {
+			return "", errors.New("invalid arguments: score is required")
+		}
+		score := clampScore(*args.Score)
+		if err := uc.repo.UpdateMessageScore(ctx, answer.ID, score); err != nil {
+			return "", fmt.Errorf("save score: %w", err)
+		}
+		answer.Score = &score
+		return "ok", nil
+
+	case ToolEndInterview:
+		reply.end = true
+		return "ok, the interview will end after this reply", nil
+
+	case ToolRunCode:
+		var args struct {
+			Language string `json:"language"`
+			Code     string `json:"code"`
+			Stdin    string `json:"stdin"`
+		}
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		res, err := uc.coding.Run(ctx, args.Language, args.Code, args.Stdin)
+		var compileErr *sandbox.CompileError
+		if errors.As(err, &compileErr) {
+			return "compile error:\n" + truncate(compileErr.Output, 2000), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return formatRunResult(res), nil
+	}
+	return "", fmt.Errorf("unknown tool %q", call.Name)
+}
+
+// formatRunResult 把运行结果格式化为 tool 消息
+func formatRunResult(res *sandbox.Result) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "exit code: %d", res.ExitCode)
+	if res.TimedOut {
+		sb.WriteString(" (timed out)")
+	}
+	fmt.Fprintf(&sb, "\nstdout:\n%s", truncate(res.Stdout, 2000))
+	if res.Stderr != "" {
+		fmt.Fprintf(&sb, "\nstderr:\n%s", truncate(res.Stderr, 2000))
+	}
+	return sb.String()
+}
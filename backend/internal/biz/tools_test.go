@@ -0,0 +1,210 @@
+package biz
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// toolLLM 按调用次序输出预设的事件，并记录每次请求
+type toolLLM struct {
+	rounds   [][]llm.StreamEvent
+	requests []*llm.ChatRequest
+}
+
+func (p *toolLLM) Name() string { return "tools" }
+
+func (p *toolLLM) ChatStream(_ context.Context, req *llm.ChatRequest) (<-chan llm.StreamEvent, error) {
+	r := *req
+	r.Messages = append([]llm.Message(nil), req.Messages...)
+	p.requests = append(p.requests, &r)
+	events := p.rounds[min(len(p.requests), len(p.rounds))-1]
+	ch := make(chan llm.StreamEvent, len(events)+1)
+	for _, ev := range events {
+		ch <- ev
+	}
+	ch <- llm.StreamEvent{Done: true}
+	close(ch)
+	return ch, nil
+}
+
+// scoreRepo 只实现评分更新的 InterviewRepo
+type scoreRepo struct {
+	InterviewRepo
+	scores map[int64]int32
+}
+
+func (r *scoreRepo) UpdateMessageScore(_ context.Context, id int64, score int32) error {
+	r.scores[id] = score
+	return nil
+}
+
+func call(index int, id, name, args string) llm.StreamEvent {
+	return llm.StreamEvent{ToolCall: &llm.ToolCall{Index: index, ID: id, Name: name, Arguments: args}}
+}
+
+func newToolUsecase(repo InterviewRepo) *InterviewUsecase {
+	return &InterviewUsecase{
+		repo:  repo,
+		tools: newToolPolicy(&conf.Interview{Tools: &conf.Interview_Tools{Enabled: true}}),
+		log:   log.NewHelper(log.DefaultLogger),
+	}
+}
+
+func TestToolStream_RecordsScoreAndQuestion(t *testing.T) {
+	repo := &scoreRepo{scores: map[int64]int32{}}
+	uc := newToolUsecase(repo)
+	// 第一轮只调用工具没有回答，结果交回模型后第二轮给出回答
+	p := &toolLLM{rounds: [][]llm.StreamEvent{
+		{call(0, "c1", ToolRecordScore, `{"score":`), call(0, "", "", `130}`), call(1, "c2", ToolNextQuestion, `{"question":"How does GC work?"}`)},
+		{{Content: "Good. "}, {Content: "How does GC work?"}},
+	}}
+	answer := &InterviewMessage{ID: 7, Role: "user"}
+	reply := &Reply{}
+
+	stream, err := uc.toolStream(context.Background(), p, &Interview{Mode: InterviewModeChat}, answer, []llm.Message{{Role: "user", Content: "hi"}}, reply)
+	if err != nil {
+		t.Fatalf("toolStream error: %v", err)
+	}
+	content, err := collectStream(stream)
+	if err != nil || content != "Good. How does GC work?" {
+		t.Fatalf("content = %q, %v", content, err)
+	}
+	if repo.scores[7] != 100 || answer.Score == nil || *answer.Score != 100 {
+		t.Errorf("score should be clamped and saved, got %v", repo.scores)
+	}
+	if reply.question != "How does GC work?" || reply.EndRequested() {
+		t.Errorf("unexpected reply state: %+v", reply)
+	}
+
+	if len(p.requests) != 2 {
+		t.Fatalf("expected a continuation request, got %d requests", len(p.requests))
+	}
+	if tools := p.requests[0].Tools; len(tools) != 3 {
+		t.Errorf("chat interviews should not offer run_code, got %d tools", len(tools))
+	}
+	msgs := p.requests[1].Messages
+	if len(msgs) != 4 || len(msgs[1].ToolCalls) != 2 || msgs[2].Role != "tool" || msgs[2].ToolCallID != "c1" || msgs[3].ToolCallID != "c2" {
+		t.Errorf("unexpected continuation messages: %+v", msgs)
+	}
+}
+
+func TestToolStream_EndInterview(t *testing.T) {
+	uc := newToolUsecase(&scoreRepo{scores: map[int64]int32{}})
+	// 回答与工具调用在同一轮，不需要继续生成
+	p := &toolLLM{rounds: [][]llm.StreamEvent{
+		{{Content: "Thanks, goodbye."}, call(0, "c1", ToolEndInterview, `{"reason":"done"}`)},
+	}}
+	reply := &Reply{}
+
+	stream, err := uc.toolStream(context.Background(), p, &Interview{}, &InterviewMessage{ID: 1}, nil, reply)
+	if err != nil {
+		t.Fatalf("toolStream error: %v", err)
+	}
+	if content, err := collectStream(stream); err != nil || content != "Thanks, goodbye." {
+		t.Fatalf("content = %q, %v", content, err)
+	}
+	if !reply.EndRequested() || len(p.requests) != 1 {
+		t.Errorf("end requested %v after %d requests", reply.EndRequested(), len(p.requests))
+	}
+}
+
+func TestToolStream_MaxRounds(t *testing.T) {
+	uc := newToolUsecase(&scoreRepo{scores: map[int64]int32{}})
+	// 模型一直调用未知工具，达到轮数上限后结束
+	p := &toolLLM{rounds: [][]llm.StreamEvent{{call(0, "c", "unknown", `{}`)}}}
+
+	stream, err := uc.toolStream(context.Background(), p, &Interview{}, &InterviewMessage{ID: 1}, nil, &Reply{})
+	if err != nil {
+		t.Fatalf("toolStream error: %v", err)
+	}
+	if _, err := collectStream(stream); err != nil {
+		t.Fatalf("stream error: %v", err)
+	}
+	if len(p.requests) != defaultToolRounds {
+		t.Errorf("expected %d rounds, got %d", defaultToolRounds, len(p.requests))
+	}
+	last := p.requests[len(p.requests)-1].Messages
+	if len(last) == 0 || last[len(last)-1].Content != `error: unknown tool "unknown"` {
+		t.Errorf("tool errors should be reported to the model: %+v", last)
+	}
+}
+
+func TestToolStream_Cancel(t *testing.T) {
+	uc := newToolUsecase(&scoreRepo{scores: map[int64]int32{}})
+	// 回答超过转发缓冲，且工具结果需要模型继续生成
+	events := []llm.StreamEvent{call(0, "c", "unknown", `{}`)}
+	for range 64 {
+		events = append(events, llm.StreamEvent{Content: "x"})
+	}
+	p := &toolLLM{rounds: [][]llm.StreamEvent{events}}
+
+	// 消费方不再读取时取消：goroutine 不能阻塞在发送上，也不能再开启新一轮生成
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := uc.toolStream(ctx, p, &Interview{}, &InterviewMessage{ID: 1}, nil, &Reply{})
+	if err != nil {
+		t.Fatalf("toolStream error: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond) // 等转发缓冲填满
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+
+	buffered := len(stream)
+	timeout := time.After(2 * time.Second)
+	for n := 0; ; n++ {
+		select {
+		case _, ok := <-stream:
+			if !ok {
+				if n > buffered {
+					t.Errorf("received %d events after cancel, want at most %d buffered", n, buffered)
+				}
+				if len(p.requests) != 1 {
+					t.Errorf("expected no continuation after cancel, got %d requests", len(p.requests))
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatal("stream not closed after cancel")
+		}
+	}
+}
+
+func TestToolStream_NoRoundAfterCancel(t *testing.T) {
+	uc := newToolUsecase(&scoreRepo{scores: map[int64]int32{}})
+	// 只调用了工具，未取消时会把结果交回模型继续生成
+	p := &toolLLM{rounds: [][]llm.StreamEvent{{call(0, "c", "unknown", `{}`)}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stream, err := uc.toolStream(ctx, p, &Interview{}, &InterviewMessage{ID: 1}, nil, &Reply{})
+	if err != nil {
+		t.Fatalf("toolStream error: %v", err)
+	}
+	for range stream {
+	}
+	if len(p.requests) != 1 {
+		t.Errorf("expected no continuation after cancel, got %d requests", len(p.requests))
+	}
+}
+
+func TestPrompts_Tools(t *testing.T) {
+	p := newTestPrompts(t, &conf.Interview{Tools: &conf.Interview_Tools{Enabled: true}})
+	got, _, err := p.System(&Interview{Position: "Go", Language: "en-US"}, nil, nil, nil, nil, 0, 0)
+	if err != nil {
+		t.Fatalf("System error: %v", err)
+	}
+	for _, want := range []string{"record_score", "next_question", "end_interview"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("system prompt missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "run_code") {
+		t.Errorf("run_code should only be described in coding interviews")
+	}
+}
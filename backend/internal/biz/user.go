@@ -2,8 +2,13 @@ package biz
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/llm"
+
 	"github.com/go-kratos/kratos/v2/log"
 )
 
@@ -19,17 +24,32 @@ type User struct {
 
 // UserSettings 是用户配置
 type UserSettings struct {
-	UserID      int64
-	LLMProvider string
-	LLMAPIKey   string // 已加密
-	LLMBaseURL  string
-	LLMModel    string
-	TTSProvider string
-	TTSAPIKey   string // 已加密
-	TTSVoice    string
-	TTSEnabled  bool
-	STTProvider string
-	STTAPIKey   string // 已加密
+	UserID       int64
+	LLMProvider  string
+	LLMAPIKey    string // 已加密
+	LLMBaseURL   string
+	LLMModel     string
+	LLMFallbacks []string // 首选 LLM 不可用时依次尝试的 provider，为空时使用系统备用链
+	TTSProvider  string
+	TTSAPIKey    string // 已加密
+	TTSVoice     string
+	TTSSpeed     float64 // 用户语速，与人设语速相乘，0 表示 1.0
+	TTSEnabled   bool
+	TTSFallbacks []string // 首选 TTS 不可用时依次尝试的 provider，为空时使用系统备用链
+	STTProvider  string
+	STTAPIKey    string // 已加密
+	// Azure OpenAI 资源，对话、语音合成与语音识别选择 azure 时共用端点与 Key，各自使用独立的部署
+	AzureEndpoint       string
+	AzureAPIKey         string // 已加密
+	AzureAPIVersion     string
+	AzureChatDeployment string
+	AzureTTSDeployment  string
+	AzureSTTDeployment  string
+	// Microsoft Speech 资源，语音合成与识别选择 azurespeech 时使用
+	SpeechRegion string
+	SpeechAPIKey string // 已加密
+	// CustomProviders 用户注册的 LLM provider，不随设置保存，由服务层按需加载
+	CustomProviders []*CustomProvider
 }
 
 // UserRepo 用户仓储接口 (由 data 层实现)
@@ -39,19 +59,38 @@ type UserRepo interface {
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	UpdateSettings(ctx context.Context, settings *UserSettings) error
 	GetSettings(ctx context.Context, userID int64) (*UserSettings, error)
+	// ListSettings 按 user_id 升序分页列出 user_id > afterUserID 的设置
+	ListSettings(ctx context.Context, afterUserID int64, limit int) ([]*UserSettings, error)
+	// ReplaceAPIKeys 仅当各 API Key 仍等于 old 中的值时写入 updated 中的值，返回是否写入
+	ReplaceAPIKeys(ctx context.Context, old, updated *UserSettings) (bool, error)
+
+	ListCustomProviders(ctx context.Context, userID int64) ([]*CustomProvider, error)
+	// SaveCustomProvider 按 (UserID, Name) 新增或覆盖
+	SaveCustomProvider(ctx context.Context, cp *CustomProvider) error
+	// DeleteCustomProvider 返回是否删除了记录
+	DeleteCustomProvider(ctx context.Context, userID int64, name string) (bool, error)
+	// ListAllCustomProviders 按 id 升序分页列出 id > afterID 的所有用户的 provider
+	ListAllCustomProviders(ctx context.Context, afterID int64, limit int) ([]*CustomProvider, error)
+	// ReplaceCustomProviderKey 仅当 API Key 仍等于 old 时写入 updated，返回是否写入
+	ReplaceCustomProviderKey(ctx context.Context, id int64, old, updated string) (bool, error)
 }
 
 // UserUsecase 用户业务逻辑
 type UserUsecase struct {
-	repo UserRepo
-	log  *log.Helper
+	repo        UserRepo
+	llmRegistry *llm.Registry
+	// 系统级自定义 provider，列表中标记来源
+	systemProviders map[string]*conf.LLM_CustomProvider
+	log             *log.Helper
 }
 
 // NewUserUsecase 创建用户 UseCase
-func NewUserUsecase(repo UserRepo, logger log.Logger) *UserUsecase {
+func NewUserUsecase(repo UserRepo, llmRegistry *llm.Registry, llmConf *conf.LLM, logger log.Logger) *UserUsecase {
 	return &UserUsecase{
-		repo: repo,
-		log:  log.NewHelper(logger),
+		repo:            repo,
+		llmRegistry:     llmRegistry,
+		systemProviders: systemProviderSet(llmConf),
+		log:             log.NewHelper(logger),
 	}
 }
 
@@ -98,10 +137,63 @@ func (uc *UserUsecase) GetProfile(ctx context.Context, userID int64) (*User, err
 
 // UpdateSettings 更新用户设置
 func (uc *UserUsecase) UpdateSettings(ctx context.Context, settings *UserSettings) error {
+	var err error
+	if settings.LLMFallbacks, err = normalizeFallbacks(settings.LLMFallbacks); err != nil {
+		return err
+	}
+	if settings.TTSFallbacks, err = normalizeFallbacks(settings.TTSFallbacks); err != nil {
+		return err
+	}
+	if settings.TTSSpeed == 0 {
+		settings.TTSSpeed = 1
+	}
+	if settings.TTSSpeed < minTTSSpeed || settings.TTSSpeed > maxTTSSpeed {
+		return fmt.Errorf("%w: %.2f out of range [%.1f, %.1f]", ErrInvalidTTSSpeed, settings.TTSSpeed, minTTSSpeed, maxTTSSpeed)
+	}
 	return uc.repo.UpdateSettings(ctx, settings)
 }
 
+// 语速范围
+const (
+	minTTSSpeed = 0.5
+	maxTTSSpeed = 2.0
+)
+
+// maxFallbacks 用户备用链的最大长度
+const maxFallbacks = 4
+
+// normalizeFallbacks 去除空白和重复的 provider 名称，并检查长度
+func normalizeFallbacks(names []string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if strings.Contains(name, ",") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidFallbacks, name)
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	if len(out) > maxFallbacks {
+		return nil, fmt.Errorf("%w: at most %d providers", ErrInvalidFallbacks, maxFallbacks)
+	}
+	return out, nil
+}
+
 // GetSettings 获取用户设置
 func (uc *UserUsecase) GetSettings(ctx context.Context, userID int64) (*UserSettings, error) {
 	return uc.repo.GetSettings(ctx, userID)
 }
+
+// ListSettings 分页列出用户设置 (供密钥轮换使用)
+func (uc *UserUsecase) ListSettings(ctx context.Context, afterUserID int64, limit int) ([]*UserSettings, error) {
+	return uc.repo.ListSettings(ctx, afterUserID, limit)
+}
+
+// ReplaceAPIKeys 以比较后写入的方式替换 API Key 密文
+func (uc *UserUsecase) ReplaceAPIKeys(ctx context.Context, old, updated *UserSettings) (bool, error) {
+	return uc.repo.ReplaceAPIKeys(ctx, old, updated)
+}
@@ -0,0 +1,34 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeFallbacks(t *testing.T) {
+	got, err := normalizeFallbacks([]string{" openai", "", "deepseek", "openai"})
+	if err != nil {
+		t.Fatalf("normalizeFallbacks error: %v", err)
+	}
+	if want := []string{"openai", "deepseek"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := normalizeFallbacks([]string{"openai,deepseek"}); !errors.Is(err, ErrInvalidFallbacks) {
+		t.Errorf("comma in name should be rejected, got %v", err)
+	}
+	if _, err := normalizeFallbacks([]string{"a", "b", "c", "d", "e"}); !errors.Is(err, ErrInvalidFallbacks) {
+		t.Errorf("too many fallbacks should be rejected, got %v", err)
+	}
+}
+
+func TestUpdateSettings_TTSSpeed(t *testing.T) {
+	uc := &UserUsecase{}
+	for _, speed := range []float64{0.3, 2.5} {
+		if err := uc.UpdateSettings(context.Background(), &UserSettings{TTSSpeed: speed}); !errors.Is(err, ErrInvalidTTSSpeed) {
+			t.Errorf("speed %.1f: expected ErrInvalidTTSSpeed, got %v", speed, err)
+		}
+	}
+}
@@ -75,12 +75,15 @@ type Bootstrap struct {
 	Tts       *TTS       `yaml:"tts"`
 	Llm       *LLM       `yaml:"llm"`
 	Interview *Interview `yaml:"interview"`
+	Demo      *Demo      `yaml:"demo"`
+	Coding    *Coding    `yaml:"coding"`
 }
 
 // Server 服务器配置
 type Server struct {
-	Http *Server_HTTP `yaml:"http"`
-	Grpc *Server_GRPC `yaml:"grpc"`
+	Http      *Server_HTTP      `yaml:"http"`
+	Grpc      *Server_GRPC      `yaml:"grpc"`
+	Websocket *Server_WebSocket `yaml:"websocket"`
 }
 
 type Server_HTTP struct {
@@ -95,15 +98,26 @@ type Server_GRPC struct {
 	Timeout *Duration `yaml:"timeout"`
 }
 
+// Server_WebSocket 面试会话：事件缓存用于断线重连后补发，多实例部署时经 Redis 协调
+type Server_WebSocket struct {
+	EventBuffer int32     `yaml:"event_buffer" json:"event_buffer"` // 每场面试缓存的最近事件数
+	EventTtl    *Duration `yaml:"event_ttl" json:"event_ttl"`       // 最后一个事件之后缓存保留多久
+	TurnTimeout *Duration `yaml:"turn_timeout" json:"turn_timeout"` // 单轮回复 (LLM + TTS) 的最长时间，断线期间继续执行
+	OwnerTtl    *Duration `yaml:"owner_ttl" json:"owner_ttl"`       // 候选人连接归属的租约时长，实例崩溃后过期释放
+	NodeId      string    `yaml:"node_id" json:"node_id"`           // 实例标识，为空时使用主机名
+}
+
 // Data 数据层配置
 type Data struct {
 	Database *Data_Database `yaml:"database"`
 	Redis    *Data_Redis    `yaml:"redis"`
+	Audio    *Data_Audio    `yaml:"audio"`
 }
 
 type Data_Database struct {
-	Driver string `yaml:"driver"`
-	Source string `yaml:"source"`
+	Driver      string `yaml:"driver"`
+	Source      string `yaml:"source"`
+	AutoMigrate bool   `yaml:"auto_migrate" json:"auto_migrate"` // 启动时自动执行内嵌迁移
 }
 
 type Data_Redis struct {
@@ -114,32 +128,210 @@ type Data_Redis struct {
 	WriteTimeout *Duration `yaml:"write_timeout"`
 }
 
+// Data_Audio 面试录音存储：双方每一段语音按消息保存
+type Data_Audio struct {
+	Enabled         bool           `yaml:"enabled"`
+	Backend         string         `yaml:"backend"`                                  // local, s3
+	Dir             string         `yaml:"dir"`                                      // local 存储根目录
+	S3              *Data_Audio_S3 `yaml:"s3"`                                       // S3 兼容存储 (AWS S3 / MinIO / R2 等)
+	Retention       *Duration      `yaml:"retention"`                                // 保留时长，0 表示永久保留
+	CleanupInterval *Duration      `yaml:"cleanup_interval" json:"cleanup_interval"` // 过期清理间隔
+	MaxUploadBytes  int64          `yaml:"max_upload_bytes" json:"max_upload_bytes"` // 单段候选人录音上限
+}
+
+type Data_Audio_S3 struct {
+	Endpoint        string `yaml:"endpoint"` // 如 https://s3.us-east-1.amazonaws.com、http://minio:9000
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	PathStyle       bool   `yaml:"path_style" json:"path_style"`               // MinIO 等使用 path-style 寻址
+	AccessKeyId     string `yaml:"access_key_id" json:"access_key_id"`         // 环境变量 S3_ACCESS_KEY_ID
+	SecretAccessKey string `yaml:"secret_access_key" json:"secret_access_key"` // 环境变量 S3_SECRET_ACCESS_KEY
+}
+
 // Auth 认证配置
 type Auth struct {
-	JwtSecret     string    `yaml:"jwt_secret"`
-	TokenExpire   *Duration `yaml:"token_expire"`
-	EncryptionKey string    `yaml:"encryption_key"`
+	JwtSecret     string          `yaml:"jwt_secret"`
+	TokenExpire   *Duration       `yaml:"token_expire"`
+	EncryptionKey string          `yaml:"encryption_key"`
+	KeySource     *Auth_KeySource `yaml:"key_source" json:"key_source"`
+}
+
+// Auth_KeySource API Key 加密的主密钥来源
+type Auth_KeySource struct {
+	Type      string   `yaml:"type"`                         // env (默认) | file | localkms
+	File      string   `yaml:"file"`                         // file: JSON 密钥文件路径
+	KeyIds    []string `yaml:"key_ids" json:"key_ids"`       // localkms: 启用的密钥 ID，第一个为 primary
+	MasterKey string   `yaml:"master_key" json:"master_key"` // localkms: 从环境变量 LOCAL_KMS_MASTER_KEY 读取
 }
 
 // TTS 配置
 type TTS struct {
-	DefaultProvider string `yaml:"default_provider"`
-	DefaultVoice    string `yaml:"default_voice"`
-	AudioFormat     string `yaml:"audio_format"`
-	SampleRate      int32  `yaml:"sample_rate"`
+	DefaultProvider string          `yaml:"default_provider"`
+	DefaultVoice    string          `yaml:"default_voice"`
+	AudioFormat     string          `yaml:"audio_format"`
+	SampleRate      int32           `yaml:"sample_rate"`
+	Fallbacks       []*TTS_Fallback `yaml:"fallbacks"`  // 系统备用链，首选 provider 不可用时依次尝试
+	Resilience      *Resilience     `yaml:"resilience"` // 重试与熔断
+}
+
+// TTS_Fallback TTS 备用链中的一个 provider
+type TTS_Fallback struct {
+	Provider string `yaml:"provider"`
+	Voice    string `yaml:"voice"`
+	BaseUrl  string `yaml:"base_url" json:"base_url"`
+	ApiKey   string `yaml:"api_key" json:"api_key"` // 从环境变量 <PROVIDER>_API_KEY 读取
 }
 
 // LLM 配置
 type LLM struct {
-	DefaultProvider string  `yaml:"default_provider"`
-	DefaultModel    string  `yaml:"default_model"`
-	MaxTokens       int32   `yaml:"max_tokens"`
-	Temperature     float32 `yaml:"temperature"`
+	DefaultProvider string          `yaml:"default_provider"`
+	DefaultModel    string          `yaml:"default_model"`
+	MaxTokens       int32           `yaml:"max_tokens"`
+	Temperature     float32         `yaml:"temperature"`
+	Fallbacks       []*LLM_Fallback `yaml:"fallbacks"`  // 系统备用链，首选 provider 不可用时依次尝试
+	Resilience      *Resilience     `yaml:"resilience"` // 重试与熔断
+	Context         *LLM_Context    `yaml:"context"`    // 对话上下文管理
+	Reasoning       *LLM_Reasoning  `yaml:"reasoning"`  // 推理模型的思考设置
+	// 系统级自定义 OpenAI 兼容服务，对所有用户可用
+	CustomProviders []*LLM_CustomProvider `yaml:"custom_providers" json:"custom_providers"`
+}
+
+// LLM_CustomProvider 自定义 OpenAI 兼容服务 (API Key 从环境变量 <NAME>_API_KEY 读取)
+type LLM_CustomProvider struct {
+	Name         string            `yaml:"name"`
+	BaseUrl      string            `yaml:"base_url" json:"base_url"`
+	Auth         string            `yaml:"auth"`                           // bearer (默认) / header / none
+	AuthHeader   string            `yaml:"auth_header" json:"auth_header"` // auth 为 header 时携带 Key 的请求头
+	ApiKey       string            `yaml:"api_key" json:"api_key"`
+	DefaultModel string            `yaml:"default_model" json:"default_model"` // 面试未指定模型时使用
+	Headers      map[string]string `yaml:"headers"`                            // 每个请求附带的请求头
+}
+
+// LLM_Reasoning 推理模型的思考设置，只用于面试官回复与评估
+type LLM_Reasoning struct {
+	Effort       string `yaml:"effort"`                             // 思考强度 low / medium / high，空表示使用模型默认
+	BudgetTokens int32  `yaml:"budget_tokens" json:"budget_tokens"` // 思考 token 预算 (Anthropic 扩展思考)，0 表示按 effort 换算
+	Audit        bool   `yaml:"audit"`                              // 保存思考过程，供评估审计 (不会朗读或推送给候选人)
+}
+
+// LLM_Context 对话上下文管理：历史超出预算时将早期对话压缩为滚动摘要
+type LLM_Context struct {
+	MaxHistoryTokens int32            `yaml:"max_history_tokens" json:"max_history_tokens"` // 每轮发送的历史上限 (控制成本)，0 表示只受模型上下文窗口限制
+	SummarizeRatio   float32          `yaml:"summarize_ratio" json:"summarize_ratio"`       // 历史超过预算的该比例时压缩，默认 0.75
+	KeepRecent       int32            `yaml:"keep_recent" json:"keep_recent"`               // 原样保留的最近消息条数，默认 6
+	Windows          map[string]int32 `yaml:"windows"`                                      // 按模型名前缀覆盖上下文窗口 (token)
+}
+
+// LLM_Fallback LLM 备用链中的一个 provider
+type LLM_Fallback struct {
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+	BaseUrl  string `yaml:"base_url" json:"base_url"`
+	ApiKey   string `yaml:"api_key" json:"api_key"` // 从环境变量 <PROVIDER>_API_KEY 读取
+}
+
+// Resilience 供应商调用的重试与熔断，零值使用默认值
+type Resilience struct {
+	MaxAttempts      int32     `yaml:"max_attempts" json:"max_attempts"`           // 单个 provider 的最大尝试次数 (含首次)
+	InitialBackoff   *Duration `yaml:"initial_backoff" json:"initial_backoff"`     // 首次重试前的最大退避
+	MaxBackoff       *Duration `yaml:"max_backoff" json:"max_backoff"`             // 退避上限
+	FailureThreshold int32     `yaml:"failure_threshold" json:"failure_threshold"` // 连续失败多少次后熔断
+	Cooldown         *Duration `yaml:"cooldown"`                                   // 熔断持续时间
 }
 
 // Interview 面试配置
 type Interview struct {
-	MaxQuestions    int32  `yaml:"max_questions"`
-	DefaultLanguage string `yaml:"default_language"`
-	SystemPrompt    string `yaml:"system_prompt"`
+	MaxQuestions    int32                  `yaml:"max_questions" json:"max_questions"`       // 面试官提问轮数上限，达到后收尾，0 表示不限
+	DefaultLanguage string                 `yaml:"default_language" json:"default_language"` // 未指定语言的面试使用的语言，也是提示词模板的回退语言
+	SystemPrompt    string                 `yaml:"system_prompt" json:"system_prompt"`       // 覆盖所有语言的 interviewer 模板 (text/template)
+	PromptDir       string                 `yaml:"prompt_dir" json:"prompt_dir"`             // 提示词覆盖目录，<语言>.tmpl 覆盖或新增该语言的模板
+	DefaultPersona  string                 `yaml:"default_persona" json:"default_persona"`   // 创建面试未指定人设时使用，留空为中立面试官
+	Personas        []*Interview_Persona   `yaml:"personas"`                                 // 面试官人设，见 configs/personas.yaml
+	Panel           *Interview_Panel       `yaml:"panel"`                                    // 小组面试
+	Difficulty      *Interview_Difficulty  `yaml:"difficulty"`                               // 自适应难度
+	Tools           *Interview_Tools       `yaml:"tools"`                                    // 面试官工具调用
+	Attachments     *Interview_Attachments `yaml:"attachments"`                              // 候选人消息附带的图片
+}
+
+// Interview_Attachments 候选人在消息中附带白板、截图等图片，交给面试官与评估的模型查看
+type Interview_Attachments struct {
+	Enabled       bool  `yaml:"enabled"`
+	MaxBytes      int64 `yaml:"max_bytes" json:"max_bytes"`             // 单张图片上限，0 为 5 MiB
+	MaxPerMessage int32 `yaml:"max_per_message" json:"max_per_message"` // 每条消息的图片数上限，0 为 4
+}
+
+// Interview_Tools 面试官通过工具调用记录问题、评分、结束面试与运行代码，代替解析回复文本
+type Interview_Tools struct {
+	Enabled   bool  `yaml:"enabled"`
+	MaxRounds int32 `yaml:"max_rounds" json:"max_rounds"` // 每轮回复中模型调用工具的最大轮数，0 为 3
+}
+
+// Interview_Difficulty 自适应难度：按候选人回答的评分调整下一个问题的难度 (1-5 级)
+type Interview_Difficulty struct {
+	Enabled bool  `yaml:"enabled"`
+	Initial int32 `yaml:"initial"` // 第一个问题的难度，0 为 3；编程面试按题目难度
+}
+
+// Interview_Panel 小组面试：多位面试官轮流发言，由主持策略决定下一位发言人
+type Interview_Panel struct {
+	Moderator    string `yaml:"moderator"`                          // rotation | llm
+	Turns        int32  `yaml:"turns"`                              // rotation：同一位面试官连续发言的轮数，0 为 2
+	MaxPanelists int32  `yaml:"max_panelists" json:"max_panelists"` // 一场面试的面试官人数上限，0 为 4
+}
+
+// Interview_Persona 面试官人设
+type Interview_Persona struct {
+	Id           string            `yaml:"id"`
+	Name         string            `yaml:"name"`
+	Description  string            `yaml:"description"`
+	Prompts      map[string]string `yaml:"prompts"`                            // 语言 -> 提示词片段，按面试语言匹配
+	Pacing       string            `yaml:"pacing"`                             // relaxed | normal | brisk
+	FollowUp     string            `yaml:"follow_up" json:"follow_up"`         // 追问力度：gentle | normal | aggressive
+	Voices       map[string]string `yaml:"voices"`                             // TTS provider -> 默认音色
+	Speed        float32           `yaml:"speed"`                              // TTS 语速，0 为 1.0
+	MaxQuestions int32             `yaml:"max_questions" json:"max_questions"` // 覆盖 interview.max_questions
+}
+
+// Demo 演示模式配置：所有面试使用 mock LLM / TTS / STT，无需 API Key
+type Demo struct {
+	Enabled     bool      `yaml:"enabled"`
+	Questions   []string  `yaml:"questions"`                      // mock LLM 依次提出的问题
+	Evaluation  string    `yaml:"evaluation"`                     // mock LLM 输出的评估 JSON
+	TokenDelay  *Duration `yaml:"token_delay" json:"token_delay"` // mock LLM token 间隔
+	Transcripts []string  `yaml:"transcripts"`                    // mock STT 依次返回的转写文本
+	Tone        bool      `yaml:"tone"`                           // mock TTS 输出提示音而非静音
+}
+
+// Coding 编程面试配置
+type Coding struct {
+	Enabled         bool              `yaml:"enabled"`
+	WorkDir         string            `yaml:"work_dir" json:"work_dir"`                 // 沙箱临时目录
+	Timeout         *Duration         `yaml:"timeout"`                                  // 单个测试用例的运行超时
+	CompileTimeout  *Duration         `yaml:"compile_timeout" json:"compile_timeout"`   // 编译超时 (Go)
+	MemoryMb        int32             `yaml:"memory_mb" json:"memory_mb"`               // 内存上限
+	MaxOutputKb     int32             `yaml:"max_output_kb" json:"max_output_kb"`       // 输出上限
+	MaxConcurrent   int32             `yaml:"max_concurrent" json:"max_concurrent"`     // 同时运行的程序数
+	MaxProcesses    int32             `yaml:"max_processes" json:"max_processes"`       // 单次运行的进程 / 线程数上限
+	Uid             int32             `yaml:"uid"`                                      // 运行程序的专用用户
+	Gid             int32             `yaml:"gid"`                                      // 运行程序的专用用户组
+	AllowUnisolated bool              `yaml:"allow_unisolated" json:"allow_unisolated"` // 命名空间不可用时仍运行 (仅限本地开发)
+	Problems        []*Coding_Problem `yaml:"problems"`                                 // 题库，见 configs/problems.yaml
+}
+
+// Coding_Problem 编程题
+type Coding_Problem struct {
+	Id          string             `yaml:"id"`
+	Title       string             `yaml:"title"`
+	Difficulty  string             `yaml:"difficulty"`
+	Description string             `yaml:"description"`
+	Templates   map[string]string  `yaml:"templates"` // 语言 -> 初始代码
+	Tests       []*Coding_TestCase `yaml:"tests"`
+}
+
+// Coding_TestCase 测试用例：stdin 输入与期望 stdout，hidden 用例不向候选人展示
+type Coding_TestCase struct {
+	Input  string `yaml:"input"`
+	Output string `yaml:"output"`
+	Hidden bool   `yaml:"hidden"`
 }
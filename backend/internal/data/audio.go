@@ -0,0 +1,108 @@
+package data
+
+import (
+	"ai-interview/internal/biz"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+type audioRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewAudioRepo 创建录音元数据仓储
+func NewAudioRepo(data *Data, logger log.Logger) biz.AudioRepo {
+	return &audioRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+const audioRecordingColumns = `id, interview_id, message_id, role, seq, storage_key,
+	format, duration_ms, size_bytes, created_at`
+
+func (r *audioRepo) CreateRecording(ctx context.Context, rec *biz.AudioRecording) (*biz.AudioRecording, error) {
+	result, err := r.data.db.ExecContext(ctx,
+		`INSERT INTO audio_recordings (interview_id, message_id, role, seq, storage_key,
+			format, duration_ms, size_bytes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.InterviewID, rec.MessageID, rec.Role, rec.Seq, rec.StorageKey,
+		rec.Format, rec.DurationMs, rec.SizeBytes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	rec.ID = id
+	rec.CreatedAt = time.Now()
+
+	return rec, nil
+}
+
+func (r *audioRepo) GetRecording(ctx context.Context, id int64) (*biz.AudioRecording, error) {
+	row := r.data.db.QueryRowContext(ctx,
+		`SELECT `+audioRecordingColumns+` FROM audio_recordings WHERE id = ?`, id,
+	)
+	rec, err := scanAudioRecording(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, biz.ErrRecordingNotFound
+	}
+	return rec, err
+}
+
+func (r *audioRepo) ListRecordings(ctx context.Context, interviewID int64) ([]*biz.AudioRecording, error) {
+	return r.list(ctx,
+		`SELECT `+audioRecordingColumns+` FROM audio_recordings
+		WHERE interview_id = ? ORDER BY message_id ASC, seq ASC`, interviewID,
+	)
+}
+
+func (r *audioRepo) ListRecordingsBefore(ctx context.Context, before time.Time, limit int) ([]*biz.AudioRecording, error) {
+	// 数据库时间戳按 UTC 存储
+	return r.list(ctx,
+		`SELECT `+audioRecordingColumns+` FROM audio_recordings
+		WHERE created_at < ? ORDER BY id ASC LIMIT ?`, before.UTC(), limit,
+	)
+}
+
+func (r *audioRepo) DeleteRecording(ctx context.Context, id int64) error {
+	_, err := r.data.db.ExecContext(ctx, `DELETE FROM audio_recordings WHERE id = ?`, id)
+	return err
+}
+
+func (r *audioRepo) list(ctx context.Context, query string, args ...any) ([]*biz.AudioRecording, error) {
+	rows, err := r.data.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recs []*biz.AudioRecording
+	for rows.Next() {
+		rec, err := scanAudioRecording(rows)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, rows.Err()
+}
+
+func scanAudioRecording(s interface{ Scan(...any) error }) (*biz.AudioRecording, error) {
+	rec := &biz.AudioRecording{}
+	err := s.Scan(&rec.ID, &rec.InterviewID, &rec.MessageID, &rec.Role, &rec.Seq, &rec.StorageKey,
+		&rec.Format, &rec.DurationMs, &rec.SizeBytes, &rec.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
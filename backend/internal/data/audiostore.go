@@ -0,0 +1,120 @@
+package data
+
+import (
+	"ai-interview/internal/biz"
+	"ai-interview/internal/conf"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// NewAudioStore 按 data.audio.backend 创建录音存储，未启用录音时返回 nil
+func NewAudioStore(c *conf.Data, logger log.Logger) (biz.AudioStore, error) {
+	if c == nil || c.Audio == nil || !c.Audio.Enabled {
+		return nil, nil
+	}
+	helper := log.NewHelper(logger)
+
+	switch c.Audio.Backend {
+	case "", "local":
+		dir := c.Audio.Dir
+		if dir == "" {
+			dir = "data/audio"
+		}
+		helper.Infof("audio recordings stored in %s", dir)
+		return NewLocalAudioStore(dir)
+	case "s3":
+		if c.Audio.S3 == nil {
+			return nil, errors.New("data.audio.s3 is required for s3 backend")
+		}
+		helper.Infof("audio recordings stored in s3 bucket %s", c.Audio.S3.Bucket)
+		return NewS3AudioStore(c.Audio.S3)
+	default:
+		return nil, fmt.Errorf("unsupported audio backend %q", c.Audio.Backend)
+	}
+}
+
+// LocalAudioStore 把录音保存在本地目录，key 即相对路径
+type LocalAudioStore struct {
+	dir string
+}
+
+// NewLocalAudioStore 创建本地录音存储，目录不存在时自动创建
+func NewLocalAudioStore(dir string) (*LocalAudioStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create audio dir: %w", err)
+	}
+	return &LocalAudioStore{dir: dir}, nil
+}
+
+// path 把 key 映射为存储目录下的路径，拒绝越出目录的 key
+func (s *LocalAudioStore) path(key string) (string, error) {
+	p := filepath.FromSlash(key)
+	if !filepath.IsLocal(p) {
+		return "", fmt.Errorf("invalid audio key %q", key)
+	}
+	return filepath.Join(s.dir, p), nil
+}
+
+// Put 先写临时文件再重命名，读者不会看到写了一半的文件
+func (s *LocalAudioStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+func (s *LocalAudioStore) Open(_ context.Context, key string) (io.ReadCloser, int64, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, biz.ErrAudioNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+func (s *LocalAudioStore) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package data
+
+import (
+	"ai-interview/internal/biz"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+type codingRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewCodingRepo 创建代码提交仓储
+func NewCodingRepo(data *Data, logger log.Logger) biz.CodingRepo {
+	return &codingRepo{
+		data: data,
+		log:  log.NewHelper(logger),
+	}
+}
+
+func (r *codingRepo) CreateSubmission(ctx context.Context, sub *biz.CodeSubmission) (*biz.CodeSubmission, error) {
+	resultsJSON, err := json.Marshal(sub.Results)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.data.db.ExecContext(ctx,
+		`INSERT INTO code_submissions (interview_id, problem_id, language, code, status,
+			passed, total, compile_output, results)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sub.InterviewID, sub.ProblemID, sub.Language, sub.Code, sub.Status,
+		sub.Passed, sub.Total, sub.CompileOutput, string(resultsJSON),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	sub.ID = id
+	sub.CreatedAt = time.Now()
+
+	return sub, nil
+}
+
+func (r *codingRepo) ListSubmissions(ctx context.Context, interviewID int64) ([]*biz.CodeSubmission, error) {
+	rows, err := r.data.db.QueryContext(ctx,
+		`SELECT id, interview_id, problem_id, language, code, status,
+			passed, total, compile_output, results, created_at
+		FROM code_submissions WHERE interview_id = ? ORDER BY id ASC`, interviewID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*biz.CodeSubmission
+	for rows.Next() {
+		s := &biz.CodeSubmission{}
+		var resultsJSON sql.NullString
+		if err := rows.Scan(&s.ID, &s.InterviewID, &s.ProblemID, &s.Language, &s.Code, &s.Status,
+			&s.Passed, &s.Total, &s.CompileOutput, &resultsJSON, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		if resultsJSON.Valid {
+			_ = json.Unmarshal([]byte(resultsJSON.String), &s.Results)
+		}
+		subs = append(subs, s)
+	}
+
+	return subs, rows.Err()
+}
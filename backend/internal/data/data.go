@@ -2,6 +2,7 @@ package data
 
 import (
 	"ai-interview/internal/conf"
+	"context"
 
 	"database/sql"
 
@@ -10,44 +11,89 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
 )
 
 // ProviderSet is data providers.
-var ProviderSet = wire.NewSet(NewData, NewUserRepo, NewInterviewRepo)
+var ProviderSet = wire.NewSet(NewData, NewUserRepo, NewInterviewRepo, NewCodingRepo, NewAudioRepo, NewAudioStore, NewSessionEventStore, NewSessionRegistry)
 
 // Data 封装数据库和缓存客户端
 type Data struct {
-	db  *sql.DB
-	rdb *redis.Client
+	db      *sql.DB
+	dialect dialect
+	rdb     *redis.Client // 未配置 Redis 时为 nil
 }
 
 // NewData 初始化数据层
 func NewData(c *conf.Data, logger log.Logger) (*Data, func(), error) {
 	helper := log.NewHelper(logger)
 
-	db, err := sql.Open(c.Database.Driver, c.Database.Source)
+	db, err := OpenDB(c.Database)
 	if err != nil {
 		return nil, nil, err
 	}
-	db.SetMaxOpenConns(50)
-	db.SetMaxIdleConns(10)
+	d, _ := dialectFor(c.Database.Driver)
+	helper.Infof("connected to %s", d)
 
-	if err := db.Ping(); err != nil {
-		return nil, nil, err
+	if c.Database.AutoMigrate {
+		migrator, err := NewMigrator(db, c.Database.Driver, logger)
+		if err != nil {
+			_ = db.Close()
+			return nil, nil, err
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			_ = db.Close()
+			return nil, nil, err
+		}
+		helper.Infof("auto migrate: %d migration(s) applied", applied)
 	}
-	helper.Info("connected to mysql")
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     c.Redis.Addr,
-		Password: c.Redis.Password,
-		DB:       int(c.Redis.Db),
-	})
+	// Redis 为可选依赖 (单节点 / 本地开发可不配置)
+	var rdb *redis.Client
+	if c.Redis != nil && c.Redis.Addr != "" {
+		rdb = redis.NewClient(&redis.Options{
+			Addr:     c.Redis.Addr,
+			Password: c.Redis.Password,
+			DB:       int(c.Redis.Db),
+		})
+	} else {
+		helper.Info("redis not configured, running without cache")
+	}
 
 	cleanup := func() {
 		helper.Info("closing the data resources")
 		_ = db.Close()
-		_ = rdb.Close()
+		if rdb != nil {
+			_ = rdb.Close()
+		}
+	}
+
+	return &Data{db: db, dialect: d, rdb: rdb}, cleanup, nil
+}
+
+// OpenDB 按 driver (mysql / sqlite) 打开数据库连接并检查连通性
+func OpenDB(c *conf.Data_Database) (*sql.DB, error) {
+	d, err := dialectFor(c.Driver)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Data{db: db, rdb: rdb}, cleanup, nil
+	db, err := sql.Open(d.driverName(), d.dsn(c.Source))
+	if err != nil {
+		return nil, err
+	}
+	if d == dialectSQLite {
+		// SQLite 同一时刻只允许一个写者，单连接避免 "database is locked"
+		db.SetMaxOpenConns(1)
+	} else {
+		db.SetMaxOpenConns(50)
+		db.SetMaxIdleConns(10)
+	}
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
 }
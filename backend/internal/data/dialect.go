@@ -0,0 +1,83 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// dialect 封装 MySQL / SQLite 之间不兼容的 SQL 片段
+type dialect string
+
+const (
+	dialectMySQL  dialect = "mysql"
+	dialectSQLite dialect = "sqlite"
+)
+
+// dialectFor 根据 data.database.driver 返回方言
+func dialectFor(driver string) (dialect, error) {
+	switch driver {
+	case "mysql":
+		return dialectMySQL, nil
+	case "sqlite", "sqlite3":
+		return dialectSQLite, nil
+	default:
+		return "", fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
+
+// driverName 返回 database/sql 注册的驱动名
+func (d dialect) driverName() string {
+	if d == dialectSQLite {
+		return "sqlite" // modernc.org/sqlite
+	}
+	return "mysql"
+}
+
+// dsn 补全方言需要的连接参数。SQLite 的外键约束和忙等待需要逐连接开启。
+func (d dialect) dsn(source string) string {
+	if d != dialectSQLite {
+		return source
+	}
+	sep := "?"
+	if strings.Contains(source, "?") {
+		sep = "&"
+	}
+	return source + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+}
+
+// upsert 生成 "存在则更新" 子句：MySQL 用 ON DUPLICATE KEY UPDATE，SQLite 用 ON CONFLICT
+func (d dialect) upsert(conflictColumn string, columns ...string) string {
+	sets := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if d == dialectSQLite {
+			sets = append(sets, fmt.Sprintf("%s = excluded.%s", c, c))
+		} else {
+			sets = append(sets, fmt.Sprintf("%s = VALUES(%s)", c, c))
+		}
+	}
+	if d == dialectSQLite {
+		return "ON CONFLICT(" + conflictColumn + ") DO UPDATE SET " + strings.Join(sets, ", ")
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+// lock 获取迁移用的命名锁。MySQL 使用 GET_LOCK 跨副本互斥；
+// SQLite 仅用于单节点部署，写事务本身由数据库文件锁串行化，无需额外加锁。
+func (d dialect) lock(ctx context.Context, conn *sql.Conn, name string, timeoutSec int) (func(), error) {
+	if d == dialectSQLite {
+		return func() {}, nil
+	}
+
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, timeoutSec).Scan(&got); err != nil {
+		return nil, fmt.Errorf("acquire lock %q: %w", name, err)
+	}
+	if !got.Valid || got.Int64 != 1 {
+		return nil, fmt.Errorf("acquire lock %q: timeout after %ds", name, timeoutSec)
+	}
+	return func() {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", name)
+	}, nil
+}
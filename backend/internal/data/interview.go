@@ -5,6 +5,8 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 )
@@ -23,13 +25,23 @@ func NewInterviewRepo(data *Data, logger log.Logger) biz.InterviewRepo {
 }
 
 func (r *interviewRepo) Create(ctx context.Context, interview *biz.Interview) (*biz.Interview, error) {
+	var panelJSON sql.NullString
+	if len(interview.Panel) > 0 {
+		b, err := json.Marshal(interview.Panel)
+		if err != nil {
+			return nil, err
+		}
+		panelJSON = sql.NullString{String: string(b), Valid: true}
+	}
+
 	result, err := r.data.db.ExecContext(ctx,
 		`INSERT INTO interviews (user_id, title, position, status, language,
-			llm_provider, llm_model, tts_provider, tts_voice, resume)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			llm_provider, llm_model, tts_provider, tts_voice, resume, mode, problem_id, persona, panel)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		interview.UserID, interview.Title, interview.Position, interview.Status,
 		interview.Language, interview.LLMProvider, interview.LLMModel,
 		interview.TTSProvider, interview.TTSVoice, interview.Resume,
+		interview.Mode, interview.ProblemID, interview.Persona, panelJSON,
 	)
 	if err != nil {
 		return nil, err
@@ -46,18 +58,22 @@ func (r *interviewRepo) Create(ctx context.Context, interview *biz.Interview) (*
 
 func (r *interviewRepo) GetByID(ctx context.Context, id int64) (*biz.Interview, error) {
 	interview := &biz.Interview{}
+	var panelJSON sql.NullString
 	err := r.data.db.QueryRowContext(ctx,
 		`SELECT id, user_id, title, position, status, language,
-			llm_provider, llm_model, tts_provider, tts_voice, resume, created_at, updated_at
+			llm_provider, llm_model, tts_provider, tts_voice, resume, mode, problem_id, persona, panel, created_at, updated_at
 		FROM interviews WHERE id = ?`, id,
 	).Scan(&interview.ID, &interview.UserID, &interview.Title, &interview.Position,
 		&interview.Status, &interview.Language, &interview.LLMProvider, &interview.LLMModel,
 		&interview.TTSProvider, &interview.TTSVoice, &interview.Resume,
-		&interview.CreatedAt, &interview.UpdatedAt,
+		&interview.Mode, &interview.ProblemID, &interview.Persona, &panelJSON, &interview.CreatedAt, &interview.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if panelJSON.Valid {
+		_ = json.Unmarshal([]byte(panelJSON.String), &interview.Panel)
+	}
 	return interview, nil
 }
 
@@ -72,7 +88,7 @@ func (r *interviewRepo) ListByUserID(ctx context.Context, userID int64, page, pa
 
 	offset := (page - 1) * pageSize
 	rows, err := r.data.db.QueryContext(ctx,
-		`SELECT id, user_id, title, position, status, language, created_at, updated_at
+		`SELECT id, user_id, title, position, status, language, mode, created_at, updated_at
 		FROM interviews WHERE user_id = ? ORDER BY created_at DESC LIMIT ? OFFSET ?`,
 		userID, pageSize, offset,
 	)
@@ -85,7 +101,7 @@ func (r *interviewRepo) ListByUserID(ctx context.Context, userID int64, page, pa
 	for rows.Next() {
 		i := &biz.Interview{}
 		if err := rows.Scan(&i.ID, &i.UserID, &i.Title, &i.Position, &i.Status,
-			&i.Language, &i.CreatedAt, &i.UpdatedAt); err != nil {
+			&i.Language, &i.Mode, &i.CreatedAt, &i.UpdatedAt); err != nil {
 			return nil, 0, err
 		}
 		interviews = append(interviews, i)
@@ -103,8 +119,8 @@ func (r *interviewRepo) UpdateStatus(ctx context.Context, id int64, status strin
 
 func (r *interviewRepo) CreateMessage(ctx context.Context, msg *biz.InterviewMessage) (*biz.InterviewMessage, error) {
 	result, err := r.data.db.ExecContext(ctx,
-		"INSERT INTO interview_messages (interview_id, role, content) VALUES (?, ?, ?)",
-		msg.InterviewID, msg.Role, msg.Content,
+		"INSERT INTO interview_messages (interview_id, role, speaker, content, difficulty, score, reasoning, question) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		msg.InterviewID, msg.Role, msg.Speaker, msg.Content, msg.Difficulty, msg.Score, msg.Reasoning, msg.Question,
 	)
 	if err != nil {
 		return nil, err
@@ -119,9 +135,16 @@ func (r *interviewRepo) CreateMessage(ctx context.Context, msg *biz.InterviewMes
 	return msg, nil
 }
 
+func (r *interviewRepo) UpdateMessageScore(ctx context.Context, id int64, score int32) error {
+	_, err := r.data.db.ExecContext(ctx,
+		"UPDATE interview_messages SET score = ? WHERE id = ?", score, id,
+	)
+	return err
+}
+
 func (r *interviewRepo) ListMessages(ctx context.Context, interviewID int64) ([]*biz.InterviewMessage, error) {
 	rows, err := r.data.db.QueryContext(ctx,
-		"SELECT id, interview_id, role, content, created_at FROM interview_messages WHERE interview_id = ? ORDER BY id ASC",
+		"SELECT id, interview_id, role, speaker, content, difficulty, score, reasoning, question, created_at FROM interview_messages WHERE interview_id = ? ORDER BY id ASC",
 		interviewID,
 	)
 	if err != nil {
@@ -132,15 +155,75 @@ func (r *interviewRepo) ListMessages(ctx context.Context, interviewID int64) ([]
 	var messages []*biz.InterviewMessage
 	for rows.Next() {
 		m := &biz.InterviewMessage{}
-		if err := rows.Scan(&m.ID, &m.InterviewID, &m.Role, &m.Content, &m.CreatedAt); err != nil {
+		var score sql.NullInt32
+		var reasoning, question sql.NullString
+		if err := rows.Scan(&m.ID, &m.InterviewID, &m.Role, &m.Speaker, &m.Content, &m.Difficulty, &score, &reasoning, &question, &m.CreatedAt); err != nil {
 			return nil, err
 		}
+		m.Reasoning, m.Question = reasoning.String, question.String
+		if score.Valid {
+			m.Score = &score.Int32
+		}
 		messages = append(messages, m)
 	}
 
 	return messages, nil
 }
 
+func (r *interviewRepo) CreateAttachment(ctx context.Context, a *biz.Attachment) (*biz.Attachment, error) {
+	result, err := r.data.db.ExecContext(ctx,
+		"INSERT INTO message_attachments (interview_id, message_id, content_type, data, size_bytes) VALUES (?, ?, ?, ?, ?)",
+		a.InterviewID, a.MessageID, a.ContentType, a.Data, a.SizeBytes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	a.ID = id
+	a.CreatedAt = time.Now()
+
+	return a, nil
+}
+
+func (r *interviewRepo) GetAttachment(ctx context.Context, id int64) (*biz.Attachment, error) {
+	a := &biz.Attachment{}
+	err := r.data.db.QueryRowContext(ctx,
+		"SELECT id, interview_id, message_id, content_type, data, size_bytes, created_at FROM message_attachments WHERE id = ?", id,
+	).Scan(&a.ID, &a.InterviewID, &a.MessageID, &a.ContentType, &a.Data, &a.SizeBytes, &a.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, biz.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *interviewRepo) ListAttachments(ctx context.Context, interviewID int64) ([]*biz.Attachment, error) {
+	rows, err := r.data.db.QueryContext(ctx,
+		"SELECT id, interview_id, message_id, content_type, size_bytes, created_at FROM message_attachments WHERE interview_id = ? ORDER BY id ASC",
+		interviewID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*biz.Attachment
+	for rows.Next() {
+		a := &biz.Attachment{}
+		if err := rows.Scan(&a.ID, &a.InterviewID, &a.MessageID, &a.ContentType, &a.SizeBytes, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
 func (r *interviewRepo) CreateEvaluation(ctx context.Context, eval *biz.Evaluation) (*biz.Evaluation, error) {
 	categoriesJSON, err := json.Marshal(eval.Categories)
 	if err != nil {
@@ -148,10 +231,10 @@ func (r *interviewRepo) CreateEvaluation(ctx context.Context, eval *biz.Evaluati
 	}
 
 	result, err := r.data.db.ExecContext(ctx,
-		`INSERT INTO evaluations (interview_id, overall_score, summary, categories, strengths, weaknesses, suggestions)
-		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO evaluations (interview_id, overall_score, summary, categories, strengths, weaknesses, suggestions, level, reasoning)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		eval.InterviewID, eval.OverallScore, eval.Summary,
-		string(categoriesJSON), eval.Strengths, eval.Weaknesses, eval.Suggestions,
+		string(categoriesJSON), eval.Strengths, eval.Weaknesses, eval.Suggestions, eval.Level, eval.Reasoning,
 	)
 	if err != nil {
 		return nil, err
@@ -168,14 +251,17 @@ func (r *interviewRepo) CreateEvaluation(ctx context.Context, eval *biz.Evaluati
 
 func (r *interviewRepo) GetEvaluation(ctx context.Context, interviewID int64) (*biz.Evaluation, error) {
 	eval := &biz.Evaluation{}
-	var categoriesJSON sql.NullString
+	var categoriesJSON, reasoning sql.NullString
 
 	err := r.data.db.QueryRowContext(ctx,
-		`SELECT id, interview_id, overall_score, summary, categories, strengths, weaknesses, suggestions, created_at
+		`SELECT id, interview_id, overall_score, summary, categories, strengths, weaknesses, suggestions, level, reasoning, created_at
 		FROM evaluations WHERE interview_id = ?`, interviewID,
 	).Scan(&eval.ID, &eval.InterviewID, &eval.OverallScore, &eval.Summary,
-		&categoriesJSON, &eval.Strengths, &eval.Weaknesses, &eval.Suggestions, &eval.CreatedAt,
+		&categoriesJSON, &eval.Strengths, &eval.Weaknesses, &eval.Suggestions, &eval.Level, &reasoning, &eval.CreatedAt,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, biz.ErrEvaluationNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -183,6 +269,40 @@ func (r *interviewRepo) GetEvaluation(ctx context.Context, interviewID int64) (*
 	if categoriesJSON.Valid {
 		_ = json.Unmarshal([]byte(categoriesJSON.String), &eval.Categories)
 	}
+	eval.Reasoning = reasoning.String
 
 	return eval, nil
 }
+
+func (r *interviewRepo) SaveSummary(ctx context.Context, summary *biz.InterviewSummary) error {
+	questionsJSON, err := json.Marshal(summary.Questions)
+	if err != nil {
+		return err
+	}
+	_, err = r.data.db.ExecContext(ctx,
+		`INSERT INTO interview_summaries (interview_id, content, questions, through_message_id)
+		VALUES (?, ?, ?, ?)
+		`+r.data.dialect.upsert("interview_id", "content", "questions", "through_message_id"),
+		summary.InterviewID, summary.Content, string(questionsJSON), summary.ThroughMessageID,
+	)
+	return err
+}
+
+func (r *interviewRepo) GetSummary(ctx context.Context, interviewID int64) (*biz.InterviewSummary, error) {
+	s := &biz.InterviewSummary{}
+	var questionsJSON sql.NullString
+	err := r.data.db.QueryRowContext(ctx,
+		`SELECT interview_id, content, questions, through_message_id, updated_at
+		FROM interview_summaries WHERE interview_id = ?`, interviewID,
+	).Scan(&s.InterviewID, &s.Content, &questionsJSON, &s.ThroughMessageID, &s.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, biz.ErrSummaryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if questionsJSON.Valid {
+		_ = json.Unmarshal([]byte(questionsJSON.String), &s.Questions)
+	}
+	return s, nil
+}
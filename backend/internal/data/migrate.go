@@ -0,0 +1,353 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-interview/sql/migrations"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	// migrationLockName 是迁移命名锁，多副本同时启动时只有一个执行迁移
+	migrationLockName = "ai_interview_schema_migrations"
+	// migrationLockTimeout 等待迁移锁的最长时间 (秒)
+	migrationLockTimeout = 60
+)
+
+// ErrDirtyDatabase 表示上次迁移执行到一半失败，需要人工修复后再继续
+var ErrDirtyDatabase = errors.New("database is dirty")
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-zA-Z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration 是一个版本的迁移脚本
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus 是迁移版本的执行状态
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+// Migrator 执行内嵌的 schema 迁移，版本记录在 schema_migrations 表
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []Migration
+	log        *log.Helper
+}
+
+// NewMigrator 创建迁移器，按 driver 加载对应方言目录下内嵌的迁移文件
+func NewMigrator(db *sql.DB, driver string, logger log.Logger) (*Migrator, error) {
+	d, err := dialectFor(driver)
+	if err != nil {
+		return nil, err
+	}
+	fsys, err := fs.Sub(migrations.FS, string(d))
+	if err != nil {
+		return nil, err
+	}
+	ms, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: d, migrations: ms, log: log.NewHelper(logger)}, nil
+}
+
+// Up 执行所有未应用的迁移，返回本次应用的数量
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mg := range m.migrations {
+			if _, ok := current[mg.Version]; ok {
+				continue
+			}
+			m.log.Infof("applying migration %06d_%s", mg.Version, mg.Name)
+			if err := m.apply(ctx, conn, mg); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down 回滚最近 steps 个已应用的迁移，返回本次回滚的数量
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	rolledBack := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+			mg := m.migrations[i]
+			if _, ok := current[mg.Version]; !ok {
+				continue
+			}
+			m.log.Infof("rolling back migration %06d_%s", mg.Version, mg.Name)
+			if err := m.revert(ctx, conn, mg); err != nil {
+				return err
+			}
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status 列出所有内嵌迁移及其执行状态
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := m.ensureVersionTable(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, dirty, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	type record struct {
+		dirty     bool
+		appliedAt time.Time
+	}
+	records := make(map[int64]record)
+	for rows.Next() {
+		var version int64
+		var r record
+		if err := rows.Scan(&version, &r.dirty, &r.appliedAt); err != nil {
+			return nil, err
+		}
+		records[version] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		s := MigrationStatus{Version: mg.Version, Name: mg.Name}
+		if r, ok := records[mg.Version]; ok {
+			s.Applied = true
+			s.Dirty = r.dirty
+			s.AppliedAt = r.appliedAt
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// withLock 在同一连接上持有命名锁执行 fn，避免多副本并发迁移
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := m.dialect.lock(ctx, conn, migrationLockName, migrationLockTimeout)
+	if err != nil {
+		return fmt.Errorf("migration lock: %w", err)
+	}
+	defer unlock()
+
+	if err := m.ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL DEFAULT '',
+		dirty BOOLEAN NOT NULL DEFAULT FALSE,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// appliedVersions 返回已应用的版本；存在 dirty 版本时返回 ErrDirtyDatabase
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]struct{}, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, dirty FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[int64]struct{})
+	for rows.Next() {
+		var version int64
+		var dirty bool
+		if err := rows.Scan(&version, &dirty); err != nil {
+			return nil, err
+		}
+		if dirty {
+			return nil, fmt.Errorf("%w at version %d, fix it manually and delete the row from schema_migrations", ErrDirtyDatabase, version)
+		}
+		versions[version] = struct{}{}
+	}
+	return versions, rows.Err()
+}
+
+// apply 执行 up 脚本。MySQL DDL 会隐式提交，无法放进事务，
+// 因此先写入 dirty 记录，全部语句成功后再清除标记。
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mg Migration) error {
+	if _, err := conn.ExecContext(ctx,
+		"INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, ?)",
+		mg.Version, mg.Name, true,
+	); err != nil {
+		return fmt.Errorf("record migration %d: %w", mg.Version, err)
+	}
+	for _, stmt := range splitStatements(mg.Up) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migration %06d_%s up: %w", mg.Version, mg.Name, err)
+		}
+	}
+	if _, err := conn.ExecContext(ctx,
+		"UPDATE schema_migrations SET dirty = ? WHERE version = ?", false, mg.Version,
+	); err != nil {
+		return fmt.Errorf("record migration %d: %w", mg.Version, err)
+	}
+	return nil
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, mg Migration) error {
+	if mg.Down == "" {
+		return fmt.Errorf("migration %06d_%s has no down script", mg.Version, mg.Name)
+	}
+	if _, err := conn.ExecContext(ctx,
+		"UPDATE schema_migrations SET dirty = ? WHERE version = ?", true, mg.Version,
+	); err != nil {
+		return fmt.Errorf("record migration %d: %w", mg.Version, err)
+	}
+	for _, stmt := range splitStatements(mg.Down) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migration %06d_%s down: %w", mg.Version, mg.Name, err)
+		}
+	}
+	if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", mg.Version); err != nil {
+		return fmt.Errorf("record migration %d: %w", mg.Version, err)
+	}
+	return nil
+}
+
+// loadMigrations 从文件系统读取迁移文件，按版本号升序排列
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		match := migrationFileRe.FindStringSubmatch(e.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse migration version %q: %w", e.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(".", e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %q: %w", e.Name(), err)
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mg
+		} else if mg.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, mg.Name, match[2])
+		}
+		if match[3] == "up" {
+			mg.Up = string(content)
+		} else {
+			mg.Down = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.Up == "" {
+			return nil, fmt.Errorf("migration %06d_%s has no up script", mg.Version, mg.Name)
+		}
+		result = append(result, *mg)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// splitStatements 按行尾分号切分 SQL 脚本，忽略 -- 注释行。
+// MySQL 驱动默认不允许一次 Exec 多条语句。
+// 单独一行的 BEGIN 到 END; 之间视为一个整体 (SQLite 触发器体)。
+func splitStatements(script string) []string {
+	var stmts []string
+	var sb strings.Builder
+	inBlock := false
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+		upper := strings.ToUpper(trimmed)
+		if upper == "BEGIN" {
+			inBlock = true
+			continue
+		}
+		if inBlock {
+			if upper != "END;" {
+				continue
+			}
+			inBlock = false
+		}
+		if strings.HasSuffix(trimmed, ";") {
+			stmt := strings.TrimSuffix(strings.TrimSpace(sb.String()), ";")
+			stmts = append(stmts, stmt)
+			sb.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(sb.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
@@ -0,0 +1,115 @@
+package data
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"ai-interview/sql/migrations"
+)
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	var versions [][]int64
+	for _, d := range []dialect{dialectMySQL, dialectSQLite} {
+		sub, err := fs.Sub(migrations.FS, string(d))
+		if err != nil {
+			t.Fatalf("fs.Sub(%s) error: %v", d, err)
+		}
+		ms, err := loadMigrations(sub)
+		if err != nil {
+			t.Fatalf("loadMigrations(%s) error: %v", d, err)
+		}
+		if len(ms) == 0 {
+			t.Fatalf("expected embedded %s migrations", d)
+		}
+		if ms[0].Version != 1 || ms[0].Name != "init" {
+			t.Errorf("%s: expected first migration 000001_init, got %06d_%s", d, ms[0].Version, ms[0].Name)
+		}
+		var vs []int64
+		for _, m := range ms {
+			if m.Up == "" || m.Down == "" {
+				t.Errorf("%s: migration %06d_%s should have both up and down scripts", d, m.Version, m.Name)
+			}
+			vs = append(vs, m.Version)
+		}
+		versions = append(versions, vs)
+	}
+
+	// 各方言的迁移版本必须一一对应
+	if len(versions[0]) != len(versions[1]) {
+		t.Fatalf("mysql has %d migrations, sqlite has %d", len(versions[0]), len(versions[1]))
+	}
+	for i := range versions[0] {
+		if versions[0][i] != versions[1][i] {
+			t.Errorf("migration #%d version mismatch: mysql %d, sqlite %d", i, versions[0][i], versions[1][i])
+		}
+	}
+}
+
+func TestLoadMigrations_Ordering(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000010_later.up.sql":    {Data: []byte("SELECT 10;")},
+		"000002_second.up.sql":   {Data: []byte("SELECT 2;")},
+		"000002_second.down.sql": {Data: []byte("SELECT -2;")},
+		"README.md":              {Data: []byte("ignored")},
+	}
+	ms, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("loadMigrations error: %v", err)
+	}
+	if len(ms) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(ms))
+	}
+	if ms[0].Version != 2 || ms[1].Version != 10 {
+		t.Errorf("unexpected order: %d, %d", ms[0].Version, ms[1].Version)
+	}
+	if ms[1].Down != "" {
+		t.Error("migration 10 should have no down script")
+	}
+}
+
+func TestLoadMigrations_MissingUp(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000003_orphan.down.sql": {Data: []byte("SELECT 1;")},
+	}
+	if _, err := loadMigrations(fsys); err == nil {
+		t.Error("expected error for migration without up script")
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment
+CREATE TABLE a (
+    id BIGINT
+);
+
+INSERT INTO a VALUES (1);
+SELECT 1`
+	stmts := splitStatements(script)
+	if len(stmts) != 3 {
+		t.Fatalf("expected 3 statements, got %d: %q", len(stmts), stmts)
+	}
+	if stmts[1] != "INSERT INTO a VALUES (1)" {
+		t.Errorf("unexpected statement: %q", stmts[1])
+	}
+	if stmts[2] != "SELECT 1" {
+		t.Errorf("unexpected trailing statement: %q", stmts[2])
+	}
+}
+
+func TestSplitStatements_TriggerBlock(t *testing.T) {
+	script := `CREATE TABLE a (id INTEGER);
+CREATE TRIGGER trg AFTER UPDATE ON a
+BEGIN
+    UPDATE a SET id = id;
+    UPDATE a SET id = id;
+END;
+CREATE INDEX idx ON a (id);`
+	stmts := splitStatements(script)
+	if len(stmts) != 3 {
+		t.Fatalf("expected 3 statements, got %d: %q", len(stmts), stmts)
+	}
+	if want := "UPDATE a SET id = id;\n    UPDATE a SET id = id;\nEND"; len(stmts[1]) < len(want) || stmts[1][len(stmts[1])-len(want):] != want {
+		t.Errorf("trigger body not kept intact: %q", stmts[1])
+	}
+}
@@ -0,0 +1,302 @@
+package data
+
+import (
+	"ai-interview/internal/biz"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// subscriberBuffer 每个订阅者未消费事件的上限，超出时关闭订阅，由客户端重连补发
+const subscriberBuffer = 256
+
+// NewSessionRegistry 创建会话协调器：配置了 Redis 时跨实例协调，否则只在本进程内
+func NewSessionRegistry(d *Data, logger log.Logger) biz.SessionRegistry {
+	if d.rdb != nil {
+		return NewRedisSessionRegistry(d.rdb, logger)
+	}
+	log.NewHelper(logger).Info("redis not configured, sessions coordinated in process only")
+	return NewMemorySessionRegistry()
+}
+
+// RedisSessionRegistry 归属和轮次锁为带过期时间的字符串键，事件通过 Pub/Sub 广播
+type RedisSessionRegistry struct {
+	rdb *redis.Client
+	log *log.Helper
+}
+
+// NewRedisSessionRegistry 创建 Redis 会话协调器
+func NewRedisSessionRegistry(rdb *redis.Client, logger log.Logger) *RedisSessionRegistry {
+	return &RedisSessionRegistry{rdb: rdb, log: log.NewHelper(logger)}
+}
+
+// renewScript / releaseScript 仅当键的值仍为 owner 时续期 / 删除
+var (
+	renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+	releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+)
+
+func (r *RedisSessionRegistry) Claim(ctx context.Context, interviewID int64, owner string, ttl time.Duration) error {
+	if err := r.rdb.Set(ctx, sessionKey(interviewID, "owner"), owner, ttl).Err(); err != nil {
+		return fmt.Errorf("claim session: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisSessionRegistry) Owner(ctx context.Context, interviewID int64) (string, error) {
+	owner, err := r.rdb.Get(ctx, sessionKey(interviewID, "owner")).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get session owner: %w", err)
+	}
+	return owner, nil
+}
+
+func (r *RedisSessionRegistry) Renew(ctx context.Context, interviewID int64, owner string, ttl time.Duration) error {
+	ok, err := renewScript.Run(ctx, r.rdb, []string{sessionKey(interviewID, "owner")}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("renew session: %w", err)
+	}
+	if ok == 0 {
+		return biz.ErrSessionTakenOver
+	}
+	return nil
+}
+
+func (r *RedisSessionRegistry) Release(ctx context.Context, interviewID int64, owner string) error {
+	if err := releaseScript.Run(ctx, r.rdb, []string{sessionKey(interviewID, "owner")}, owner).Err(); err != nil {
+		return fmt.Errorf("release session: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisSessionRegistry) LockTurn(ctx context.Context, interviewID int64, owner string, ttl time.Duration) (bool, error) {
+	ok, err := r.rdb.SetNX(ctx, sessionKey(interviewID, "turn"), owner, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("lock turn: %w", err)
+	}
+	return ok, nil
+}
+
+func (r *RedisSessionRegistry) UnlockTurn(ctx context.Context, interviewID int64, owner string) error {
+	if err := releaseScript.Run(ctx, r.rdb, []string{sessionKey(interviewID, "turn")}, owner).Err(); err != nil {
+		return fmt.Errorf("unlock turn: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisSessionRegistry) TurnLocked(ctx context.Context, interviewID int64) (bool, error) {
+	n, err := r.rdb.Exists(ctx, sessionKey(interviewID, "turn")).Result()
+	if err != nil {
+		return false, fmt.Errorf("check turn lock: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (r *RedisSessionRegistry) Publish(ctx context.Context, interviewID int64, ev *biz.SessionEvent) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if err := r.rdb.Publish(ctx, sessionKey(interviewID, "pubsub"), payload).Err(); err != nil {
+		return fmt.Errorf("publish session event: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisSessionRegistry) Subscribe(ctx context.Context, interviewID int64) (<-chan *biz.SessionEvent, error) {
+	ps := r.rdb.Subscribe(ctx, sessionKey(interviewID, "pubsub"))
+	// 等待订阅确认，保证返回后发布的事件都能收到
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, fmt.Errorf("subscribe session events: %w", err)
+	}
+
+	out := make(chan *biz.SessionEvent, subscriberBuffer)
+	stop := context.AfterFunc(ctx, func() { _ = ps.Close() })
+	go func() {
+		defer close(out)
+		defer stop()
+		defer ps.Close()
+		for {
+			msg, err := ps.ReceiveMessage(ctx)
+			if err != nil {
+				return
+			}
+			ev := &biz.SessionEvent{}
+			if err := json.Unmarshal([]byte(msg.Payload), ev); err != nil {
+				r.log.Errorf("decode session event: %v", err)
+				continue
+			}
+			select {
+			case out <- ev:
+			default:
+				r.log.Warnf("session subscriber for interview %d is too slow, dropping subscription", interviewID)
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// MemorySessionRegistry 进程内的会话协调器，用于单实例部署
+type MemorySessionRegistry struct {
+	mu     sync.Mutex
+	owners map[int64]memoryLease
+	turns  map[int64]memoryLease
+	subs   map[int64]map[chan *biz.SessionEvent]struct{}
+	now    func() time.Time
+}
+
+type memoryLease struct {
+	owner   string
+	expires time.Time
+}
+
+// NewMemorySessionRegistry 创建内存会话协调器
+func NewMemorySessionRegistry() *MemorySessionRegistry {
+	return &MemorySessionRegistry{
+		owners: map[int64]memoryLease{},
+		turns:  map[int64]memoryLease{},
+		subs:   map[int64]map[chan *biz.SessionEvent]struct{}{},
+		now:    time.Now,
+	}
+}
+
+// lease 返回未过期的租约
+func (r *MemorySessionRegistry) lease(leases map[int64]memoryLease, interviewID int64) (memoryLease, bool) {
+	l, ok := leases[interviewID]
+	if ok && r.now().After(l.expires) {
+		delete(leases, interviewID)
+		return memoryLease{}, false
+	}
+	return l, ok
+}
+
+func (r *MemorySessionRegistry) Claim(_ context.Context, interviewID int64, owner string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.owners[interviewID] = memoryLease{owner: owner, expires: r.now().Add(ttl)}
+	return nil
+}
+
+func (r *MemorySessionRegistry) Owner(_ context.Context, interviewID int64) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, _ := r.lease(r.owners, interviewID)
+	return l.owner, nil
+}
+
+func (r *MemorySessionRegistry) Renew(_ context.Context, interviewID int64, owner string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l, ok := r.lease(r.owners, interviewID); !ok || l.owner != owner {
+		return biz.ErrSessionTakenOver
+	}
+	r.owners[interviewID] = memoryLease{owner: owner, expires: r.now().Add(ttl)}
+	return nil
+}
+
+func (r *MemorySessionRegistry) Release(_ context.Context, interviewID int64, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l, ok := r.lease(r.owners, interviewID); ok && l.owner == owner {
+		delete(r.owners, interviewID)
+	}
+	return nil
+}
+
+func (r *MemorySessionRegistry) LockTurn(_ context.Context, interviewID int64, owner string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.lease(r.turns, interviewID); ok {
+		return false, nil
+	}
+	r.turns[interviewID] = memoryLease{owner: owner, expires: r.now().Add(ttl)}
+	return true, nil
+}
+
+func (r *MemorySessionRegistry) UnlockTurn(_ context.Context, interviewID int64, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l, ok := r.lease(r.turns, interviewID); ok && l.owner == owner {
+		delete(r.turns, interviewID)
+	}
+	return nil
+}
+
+func (r *MemorySessionRegistry) TurnLocked(_ context.Context, interviewID int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.lease(r.turns, interviewID)
+	return ok, nil
+}
+
+func (r *MemorySessionRegistry) Publish(_ context.Context, interviewID int64, ev *biz.SessionEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for ch := range r.subs[interviewID] {
+		select {
+		case ch <- ev:
+		default:
+			// 订阅者消费过慢：关闭订阅，由客户端重连补发
+			r.unsubscribe(interviewID, ch)
+		}
+	}
+	return nil
+}
+
+func (r *MemorySessionRegistry) Subscribe(ctx context.Context, interviewID int64) (<-chan *biz.SessionEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ch := make(chan *biz.SessionEvent, subscriberBuffer)
+	if r.subs[interviewID] == nil {
+		r.subs[interviewID] = map[chan *biz.SessionEvent]struct{}{}
+	}
+	r.subs[interviewID][ch] = struct{}{}
+	context.AfterFunc(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.unsubscribe(interviewID, ch)
+	})
+	return ch, nil
+}
+
+// unsubscribe 移除并关闭订阅，调用方需持有 r.mu
+func (r *MemorySessionRegistry) unsubscribe(interviewID int64, ch chan *biz.SessionEvent) {
+	subs := r.subs[interviewID]
+	if _, ok := subs[ch]; !ok {
+		return
+	}
+	delete(subs, ch)
+	close(ch)
+	if len(subs) == 0 {
+		delete(r.subs, interviewID)
+	}
+}
@@ -0,0 +1,125 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"ai-interview/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMemorySessionRegistry(t *testing.T) {
+	testSessionRegistry(t, NewMemorySessionRegistry())
+
+	r := NewMemorySessionRegistry()
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	ctx := context.Background()
+	_ = r.Claim(ctx, 1, "node-a/1", time.Second)
+	_, _ = r.LockTurn(ctx, 1, "node-a/2", time.Second)
+	now = now.Add(2 * time.Second)
+	if owner, _ := r.Owner(ctx, 1); owner != "" {
+		t.Errorf("expired owner should be released, got %q", owner)
+	}
+	if locked, _ := r.TurnLocked(ctx, 1); locked {
+		t.Error("expired turn lock should be released")
+	}
+}
+
+// 需要真实 Redis：TEST_REDIS_ADDR=127.0.0.1:6379 go test -run Integration ./internal/data/
+func TestRedisIntegration_SessionRegistry(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = rdb.Close() })
+	rdb.Del(context.Background(), sessionKey(1, "owner"), sessionKey(1, "turn"))
+
+	testSessionRegistry(t, NewRedisSessionRegistry(rdb, log.NewStdLogger(io.Discard)))
+}
+
+// testSessionRegistry 对任意 SessionRegistry 实现执行相同的检查
+func testSessionRegistry(t *testing.T, r biz.SessionRegistry) {
+	t.Helper()
+	ctx := context.Background()
+
+	// 新连接接管旧连接，旧连接无法续约，也不能释放新连接的归属
+	if err := r.Claim(ctx, 1, "node-a/1", time.Minute); err != nil {
+		t.Fatalf("Claim error: %v", err)
+	}
+	if err := r.Renew(ctx, 1, "node-a/1", time.Minute); err != nil {
+		t.Fatalf("Renew error: %v", err)
+	}
+	_ = r.Claim(ctx, 1, "node-b/1", time.Minute)
+	if err := r.Renew(ctx, 1, "node-a/1", time.Minute); !errors.Is(err, biz.ErrSessionTakenOver) {
+		t.Errorf("Renew by old owner: %v, want ErrSessionTakenOver", err)
+	}
+	_ = r.Release(ctx, 1, "node-a/1")
+	if owner, _ := r.Owner(ctx, 1); owner != "node-b/1" {
+		t.Errorf("owner = %q, want node-b/1", owner)
+	}
+	_ = r.Release(ctx, 1, "node-b/1")
+	if owner, _ := r.Owner(ctx, 1); owner != "" {
+		t.Errorf("owner after release = %q", owner)
+	}
+
+	// 轮次锁同一时间只有一个持有者
+	if ok, err := r.LockTurn(ctx, 1, "node-a/2", time.Minute); !ok || err != nil {
+		t.Fatalf("LockTurn = %v, %v", ok, err)
+	}
+	if ok, _ := r.LockTurn(ctx, 1, "node-b/2", time.Minute); ok {
+		t.Error("second LockTurn should fail while locked")
+	}
+	_ = r.UnlockTurn(ctx, 1, "node-b/2")
+	if locked, _ := r.TurnLocked(ctx, 1); !locked {
+		t.Error("unlock by non-owner should not release the lock")
+	}
+	_ = r.UnlockTurn(ctx, 1, "node-a/2")
+	if locked, _ := r.TurnLocked(ctx, 1); locked {
+		t.Error("turn should be unlocked")
+	}
+
+	// 事件广播给所有订阅者，取消订阅后 channel 关闭
+	subCtx, cancel := context.WithCancel(ctx)
+	sub1, err := r.Subscribe(subCtx, 1)
+	if err != nil {
+		t.Fatalf("Subscribe error: %v", err)
+	}
+	sub2, _ := r.Subscribe(ctx, 1)
+	other, _ := r.Subscribe(subCtx, 2)
+	if err := r.Publish(ctx, 1, &biz.SessionEvent{Seq: 7, Type: "text_delta", Data: []byte(`"hi"`)}); err != nil {
+		t.Fatalf("Publish error: %v", err)
+	}
+	for _, sub := range []<-chan *biz.SessionEvent{sub1, sub2} {
+		select {
+		case ev := <-sub:
+			if ev.Seq != 7 || ev.Type != "text_delta" || string(ev.Data) != `"hi"` {
+				t.Errorf("unexpected event: %+v", ev)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("event not delivered")
+		}
+	}
+	select {
+	case ev := <-other:
+		t.Errorf("event leaked to another interview: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case _, ok := <-sub1:
+		if ok {
+			t.Error("expected closed subscription")
+		}
+	case <-time.After(time.Second):
+		t.Error("subscription not closed after cancel")
+	}
+}
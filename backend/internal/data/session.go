@@ -0,0 +1,176 @@
+package data
+
+import (
+	"ai-interview/internal/biz"
+	"ai-interview/internal/conf"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultEventBuffer = 256
+	defaultEventTTL    = 10 * time.Minute
+)
+
+// NewSessionEventStore 创建会话事件缓存：配置了 Redis 时使用 Redis (多实例共享)，否则使用进程内存
+func NewSessionEventStore(d *Data, c *conf.Server, logger log.Logger) biz.SessionEventStore {
+	size, ttl := defaultEventBuffer, defaultEventTTL
+	if c != nil && c.Websocket != nil {
+		if c.Websocket.EventBuffer > 0 {
+			size = int(c.Websocket.EventBuffer)
+		}
+		if c.Websocket.EventTtl.AsDuration() > 0 {
+			ttl = c.Websocket.EventTtl.AsDuration()
+		}
+	}
+	if d.rdb != nil {
+		return NewRedisSessionEventStore(d.rdb, size, ttl)
+	}
+	log.NewHelper(logger).Info("redis not configured, session events buffered in memory")
+	return NewMemorySessionEventStore(size, ttl)
+}
+
+// RedisSessionEventStore 每场面试一个计数器 (分配 seq) 和一个以 seq 为 score 的有序集合
+type RedisSessionEventStore struct {
+	rdb  *redis.Client
+	size int
+	ttl  time.Duration
+}
+
+// NewRedisSessionEventStore 创建 Redis 会话事件缓存
+func NewRedisSessionEventStore(rdb *redis.Client, size int, ttl time.Duration) *RedisSessionEventStore {
+	return &RedisSessionEventStore{rdb: rdb, size: size, ttl: ttl}
+}
+
+// appendEventScript 原子地分配序号、写入事件、裁剪到最近 N 条并刷新过期时间。
+// 成员为 "seq:payload"，保证相同内容的事件不会被有序集合去重。
+var appendEventScript = redis.NewScript(`
+local seq = redis.call('INCR', KEYS[1])
+redis.call('ZADD', KEYS[2], seq, seq .. ':' .. ARGV[1])
+redis.call('ZREMRANGEBYRANK', KEYS[2], 0, -tonumber(ARGV[2]) - 1)
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+redis.call('PEXPIRE', KEYS[2], ARGV[3])
+return seq
+`)
+
+// sessionKey 面试会话相关的 Redis 键：interview:{id}:session:{name}
+func sessionKey(interviewID int64, name string) string {
+	return fmt.Sprintf("interview:%d:session:%s", interviewID, name)
+}
+
+func (s *RedisSessionEventStore) Append(ctx context.Context, interviewID int64, ev *biz.SessionEvent) (int64, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return 0, err
+	}
+	seqKey, eventsKey := sessionKey(interviewID, "seq"), sessionKey(interviewID, "events")
+	seq, err := appendEventScript.Run(ctx, s.rdb, []string{seqKey, eventsKey},
+		string(payload), s.size, s.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("append session event: %w", err)
+	}
+	ev.Seq = seq
+	return seq, nil
+}
+
+func (s *RedisSessionEventStore) Since(ctx context.Context, interviewID int64, after int64) ([]*biz.SessionEvent, int64, error) {
+	seqKey, eventsKey := sessionKey(interviewID, "seq"), sessionKey(interviewID, "events")
+	pipe := s.rdb.Pipeline()
+	latestCmd := pipe.Get(ctx, seqKey)
+	membersCmd := pipe.ZRangeByScore(ctx, eventsKey, &redis.ZRangeBy{Min: "(" + strconv.FormatInt(after, 10), Max: "+inf"})
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, fmt.Errorf("read session events: %w", err)
+	}
+
+	latest, _ := latestCmd.Int64() // 计数器已过期时为 0
+	members, _ := membersCmd.Result()
+	events := make([]*biz.SessionEvent, 0, len(members))
+	for _, m := range members {
+		seq, payload, ok := strings.Cut(m, ":")
+		if !ok {
+			continue
+		}
+		ev := &biz.SessionEvent{}
+		if err := json.Unmarshal([]byte(payload), ev); err != nil {
+			return nil, 0, fmt.Errorf("decode session event: %w", err)
+		}
+		ev.Seq, _ = strconv.ParseInt(seq, 10, 64)
+		events = append(events, ev)
+	}
+	return events, latest, nil
+}
+
+// MemorySessionEventStore 进程内的会话事件缓存，用于单实例部署
+type MemorySessionEventStore struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	buffers map[int64]*memoryEventBuffer
+	now     func() time.Time
+}
+
+type memoryEventBuffer struct {
+	seq     int64
+	events  []*biz.SessionEvent
+	expires time.Time
+}
+
+// NewMemorySessionEventStore 创建内存会话事件缓存
+func NewMemorySessionEventStore(size int, ttl time.Duration) *MemorySessionEventStore {
+	return &MemorySessionEventStore{size: size, ttl: ttl, buffers: map[int64]*memoryEventBuffer{}, now: time.Now}
+}
+
+func (s *MemorySessionEventStore) Append(_ context.Context, interviewID int64, ev *biz.SessionEvent) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, b := range s.buffers {
+		if now.After(b.expires) {
+			delete(s.buffers, id)
+		}
+	}
+	b, ok := s.buffers[interviewID]
+	if !ok {
+		b = &memoryEventBuffer{}
+		s.buffers[interviewID] = b
+	}
+
+	b.seq++
+	stored := *ev
+	stored.Seq = b.seq
+	b.events = append(b.events, &stored)
+	if over := len(b.events) - s.size; over > 0 {
+		b.events = append(b.events[:0:0], b.events[over:]...)
+	}
+	b.expires = now.Add(s.ttl)
+
+	ev.Seq = b.seq
+	return b.seq, nil
+}
+
+func (s *MemorySessionEventStore) Since(_ context.Context, interviewID int64, after int64) ([]*biz.SessionEvent, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buffers[interviewID]
+	if !ok || s.now().After(b.expires) {
+		return nil, 0, nil
+	}
+	var events []*biz.SessionEvent
+	for _, ev := range b.events {
+		if ev.Seq > after {
+			events = append(events, ev)
+		}
+	}
+	return events, b.seq, nil
+}
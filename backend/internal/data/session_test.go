@@ -0,0 +1,93 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"ai-interview/internal/biz"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMemorySessionEventStore(t *testing.T) {
+	testSessionEventStore(t, NewMemorySessionEventStore(3, time.Minute))
+
+	s := NewMemorySessionEventStore(3, time.Minute)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	_, _ = s.Append(context.Background(), 1, &biz.SessionEvent{Type: "text_start"})
+	now = now.Add(2 * time.Minute)
+	if events, latest, _ := s.Since(context.Background(), 1, 0); len(events) != 0 || latest != 0 {
+		t.Errorf("expired buffer should be empty, got %d events, latest %d", len(events), latest)
+	}
+}
+
+// 需要真实 Redis：TEST_REDIS_ADDR=127.0.0.1:6379 go test -run Integration ./internal/data/
+func TestRedisIntegration_SessionEventStore(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = rdb.Close() })
+	eventsKey := sessionKey(1, "events")
+	rdb.Del(context.Background(), sessionKey(1, "seq"), eventsKey, sessionKey(2, "seq"), sessionKey(2, "events"))
+
+	testSessionEventStore(t, NewRedisSessionEventStore(rdb, 3, time.Minute))
+	if ttl := rdb.PTTL(context.Background(), eventsKey).Val(); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("events key ttl = %v", ttl)
+	}
+}
+
+// testSessionEventStore 对任意 SessionEventStore 实现执行相同的检查 (缓存上限为 3)
+func testSessionEventStore(t *testing.T, s biz.SessionEventStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	for i := 1; i <= 4; i++ {
+		ev := &biz.SessionEvent{Type: "text_delta", Data: json.RawMessage(fmt.Sprintf("%q", "同样的内容"))}
+		if i == 4 {
+			ev = &biz.SessionEvent{Type: biz.SessionEventAudio, Audio: []byte{0xFF, 0xFB, 0x90}}
+		}
+		seq, err := s.Append(ctx, 1, ev)
+		if err != nil {
+			t.Fatalf("Append error: %v", err)
+		}
+		if seq != int64(i) || ev.Seq != seq {
+			t.Fatalf("seq = %d (event %d), want %d", seq, ev.Seq, i)
+		}
+	}
+	if seq, _ := s.Append(ctx, 2, &biz.SessionEvent{Type: "text_start"}); seq != 1 {
+		t.Errorf("seq should be per interview, got %d", seq)
+	}
+
+	events, latest, err := s.Since(ctx, 1, 2)
+	if err != nil {
+		t.Fatalf("Since error: %v", err)
+	}
+	if latest != 4 || len(events) != 2 || events[0].Seq != 3 || events[1].Seq != 4 {
+		t.Fatalf("Since(2) = %+v, latest %d", events, latest)
+	}
+	if string(events[0].Data) != `"同样的内容"` || string(events[1].Audio) != "\xFF\xFB\x90" {
+		t.Errorf("event payload not round-tripped: %+v", events)
+	}
+	if biz.ReplayGap(2, latest, events) {
+		t.Error("replay after 2 should have no gap")
+	}
+
+	// 只缓存最近 3 条，seq 1 已被裁剪
+	events, latest, _ = s.Since(ctx, 1, 0)
+	if len(events) != 3 || !biz.ReplayGap(0, latest, events) {
+		t.Errorf("expected trimmed buffer with gap, got %d events", len(events))
+	}
+	if events, latest, _ = s.Since(ctx, 1, 4); len(events) != 0 || biz.ReplayGap(4, latest, events) {
+		t.Errorf("up-to-date client should get nothing and no gap")
+	}
+	if events, latest, _ = s.Since(ctx, 1, 9); !biz.ReplayGap(9, latest, events) {
+		t.Error("last_seq beyond latest (counter reset) should be a gap")
+	}
+}
@@ -0,0 +1,504 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ai-interview/internal/biz"
+	"ai-interview/internal/conf"
+	"ai-interview/internal/provider/tts"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// newSQLiteData 创建基于临时 SQLite 文件的数据层，并执行全部迁移
+func newSQLiteData(t *testing.T) (*Data, log.Logger) {
+	t.Helper()
+	logger := log.NewStdLogger(io.Discard)
+	c := &conf.Data{
+		Database: &conf.Data_Database{
+			Driver:      "sqlite",
+			Source:      filepath.Join(t.TempDir(), "test.db"),
+			AutoMigrate: true,
+		},
+	}
+	d, cleanup, err := NewData(c, logger)
+	if err != nil {
+		t.Fatalf("NewData error: %v", err)
+	}
+	t.Cleanup(cleanup)
+	return d, logger
+}
+
+func createTestUser(t *testing.T, repo biz.UserRepo, email string) *biz.User {
+	t.Helper()
+	u, err := repo.Create(context.Background(), &biz.User{Email: email, Nickname: "tester", PasswordHash: "hash"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return u
+}
+
+func TestSQLiteIntegration_Migrator(t *testing.T) {
+	d, logger := newSQLiteData(t)
+	ctx := context.Background()
+
+	m, err := NewMigrator(d.db, "sqlite", logger)
+	if err != nil {
+		t.Fatalf("NewMigrator error: %v", err)
+	}
+
+	// NewData 已自动迁移，再次 Up 应为空操作
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up error: %v", err)
+	}
+	if applied != 0 {
+		t.Errorf("expected 0 migrations applied, got %d", applied)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status error: %v", err)
+	}
+	for _, s := range statuses {
+		if !s.Applied || s.Dirty {
+			t.Errorf("migration %06d_%s: applied=%v dirty=%v", s.Version, s.Name, s.Applied, s.Dirty)
+		}
+	}
+
+	reverted, err := m.Down(ctx, len(statuses))
+	if err != nil {
+		t.Fatalf("Down error: %v", err)
+	}
+	if reverted != len(statuses) {
+		t.Errorf("expected %d migrations rolled back, got %d", len(statuses), reverted)
+	}
+
+	applied, err = m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up after Down error: %v", err)
+	}
+	if applied != len(statuses) {
+		t.Errorf("expected %d migrations re-applied, got %d", len(statuses), applied)
+	}
+}
+
+func TestSQLiteIntegration_UserRepo(t *testing.T) {
+	d, logger := newSQLiteData(t)
+	repo := NewUserRepo(d, logger)
+	ctx := context.Background()
+
+	user := createTestUser(t, repo, "a@example.com")
+	if user.ID == 0 {
+		t.Fatal("expected user id to be set")
+	}
+
+	got, err := repo.GetByEmail(ctx, "a@example.com")
+	if err != nil {
+		t.Fatalf("GetByEmail error: %v", err)
+	}
+	if got.ID != user.ID || got.Nickname != "tester" {
+		t.Errorf("unexpected user: %+v", got)
+	}
+	if got.CreatedAt.IsZero() {
+		t.Error("created_at should be scanned as time")
+	}
+
+	if _, err := repo.Create(ctx, &biz.User{Email: "a@example.com", PasswordHash: "x"}); err == nil {
+		t.Error("expected unique constraint violation for duplicate email")
+	}
+
+	if _, err := repo.GetSettings(ctx, user.ID); err == nil {
+		t.Error("expected error for missing settings")
+	}
+
+	settings := &biz.UserSettings{
+		UserID:      user.ID,
+		LLMProvider: "openai",
+		LLMAPIKey:   "enc-llm",
+		TTSProvider: "edgetts",
+		TTSEnabled:  true,
+		STTProvider: "browser",
+	}
+	if err := repo.UpdateSettings(ctx, settings); err != nil {
+		t.Fatalf("UpdateSettings (insert) error: %v", err)
+	}
+
+	// 第二次写入走 upsert 更新分支
+	settings.LLMProvider = "anthropic"
+	settings.LLMFallbacks = []string{"openai", "deepseek"}
+	settings.TTSEnabled, settings.TTSSpeed = false, 1.25
+	settings.AzureEndpoint, settings.AzureAPIKey, settings.AzureTTSDeployment = "https://acme.openai.azure.com", "enc-azure", "tts-prod"
+	settings.SpeechRegion, settings.SpeechAPIKey = "eastus", "enc-speech"
+	if err := repo.UpdateSettings(ctx, settings); err != nil {
+		t.Fatalf("UpdateSettings (update) error: %v", err)
+	}
+
+	s, err := repo.GetSettings(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetSettings error: %v", err)
+	}
+	if s.LLMProvider != "anthropic" || s.TTSEnabled || s.TTSSpeed != 1.25 || s.LLMAPIKey != "enc-llm" {
+		t.Errorf("unexpected settings after upsert: %+v", s)
+	}
+	if len(s.LLMFallbacks) != 2 || s.LLMFallbacks[1] != "deepseek" || s.TTSFallbacks != nil {
+		t.Errorf("unexpected fallbacks after upsert: %v / %v", s.LLMFallbacks, s.TTSFallbacks)
+	}
+	if s.AzureEndpoint != settings.AzureEndpoint || s.AzureAPIKey != "enc-azure" || s.AzureTTSDeployment != "tts-prod" ||
+		s.SpeechRegion != "eastus" || s.SpeechAPIKey != "enc-speech" {
+		t.Errorf("unexpected azure settings after upsert: %+v", s)
+	}
+}
+
+func TestSQLiteIntegration_CustomProviders(t *testing.T) {
+	d, logger := newSQLiteData(t)
+	repo := NewUserRepo(d, logger)
+	ctx := context.Background()
+	user := createTestUser(t, repo, "a@example.com")
+	other := createTestUser(t, repo, "b@example.com")
+
+	cp := &biz.CustomProvider{UserID: user.ID, Name: "my-vllm", BaseURL: "http://localhost:8000/v1", Auth: "none", APIKey: "enc-1"}
+	if err := repo.SaveCustomProvider(ctx, cp); err != nil {
+		t.Fatalf("SaveCustomProvider (insert) error: %v", err)
+	}
+	// 同一用户同名走 upsert 更新分支，其他用户可以使用相同名称
+	cp.DefaultModel, cp.Headers = "qwen2.5-72b", map[string]string{"X-Tenant": "acme"}
+	if err := repo.SaveCustomProvider(ctx, cp); err != nil {
+		t.Fatalf("SaveCustomProvider (update) error: %v", err)
+	}
+	if err := repo.SaveCustomProvider(ctx, &biz.CustomProvider{UserID: other.ID, Name: "my-vllm", BaseURL: "http://gpu:8000/v1", Auth: "bearer"}); err != nil {
+		t.Fatalf("SaveCustomProvider (other user) error: %v", err)
+	}
+
+	list, err := repo.ListCustomProviders(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("ListCustomProviders error: %v", err)
+	}
+	if len(list) != 1 || list[0].DefaultModel != "qwen2.5-72b" || list[0].Headers["X-Tenant"] != "acme" || list[0].CreatedAt.IsZero() {
+		t.Fatalf("unexpected providers: %+v", list)
+	}
+
+	all, err := repo.ListAllCustomProviders(ctx, 0, 10)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("ListAllCustomProviders = %d, %v", len(all), err)
+	}
+	if ok, err := repo.ReplaceCustomProviderKey(ctx, list[0].ID, "stale", "enc-2"); ok || err != nil {
+		t.Errorf("replace with stale key should not write: %v %v", ok, err)
+	}
+	if ok, err := repo.ReplaceCustomProviderKey(ctx, list[0].ID, "enc-1", "enc-2"); !ok || err != nil {
+		t.Errorf("ReplaceCustomProviderKey = %v, %v", ok, err)
+	}
+
+	if ok, err := repo.DeleteCustomProvider(ctx, user.ID, "my-vllm"); !ok || err != nil {
+		t.Errorf("DeleteCustomProvider = %v, %v", ok, err)
+	}
+	if ok, _ := repo.DeleteCustomProvider(ctx, user.ID, "my-vllm"); ok {
+		t.Error("deleting a missing provider should report false")
+	}
+	if list, _ := repo.ListCustomProviders(ctx, other.ID); len(list) != 1 {
+		t.Errorf("other user's provider should remain: %+v", list)
+	}
+}
+
+func TestSQLiteIntegration_InterviewRepo(t *testing.T) {
+	d, logger := newSQLiteData(t)
+	userRepo := NewUserRepo(d, logger)
+	repo := NewInterviewRepo(d, logger)
+	ctx := context.Background()
+
+	user := createTestUser(t, userRepo, "b@example.com")
+
+	var lastID int64
+	for i := 0; i < 3; i++ {
+		created, err := repo.Create(ctx, &biz.Interview{
+			UserID:   user.ID,
+			Title:    "Go 面试",
+			Position: "Backend",
+			Status:   "pending",
+			Language: "zh-CN",
+			Resume:   "5 年 Go 经验",
+		})
+		if err != nil {
+			t.Fatalf("Create interview error: %v", err)
+		}
+		lastID = created.ID
+	}
+
+	list, total, err := repo.ListByUserID(ctx, user.ID, 1, 2)
+	if err != nil {
+		t.Fatalf("ListByUserID error: %v", err)
+	}
+	if total != 3 || len(list) != 2 {
+		t.Errorf("expected total=3 page=2, got total=%d page=%d", total, len(list))
+	}
+
+	before, err := repo.GetByID(ctx, lastID)
+	if err != nil {
+		t.Fatalf("GetByID error: %v", err)
+	}
+
+	// 触发器在 updated_at 未显式修改时刷新时间戳 (CURRENT_TIMESTAMP 精度为秒)
+	time.Sleep(1100 * time.Millisecond)
+	if err := repo.UpdateStatus(ctx, lastID, "in_progress"); err != nil {
+		t.Fatalf("UpdateStatus error: %v", err)
+	}
+	after, err := repo.GetByID(ctx, lastID)
+	if err != nil {
+		t.Fatalf("GetByID error: %v", err)
+	}
+	if after.Status != "in_progress" {
+		t.Errorf("expected status in_progress, got %q", after.Status)
+	}
+	if after.Panel != nil {
+		t.Errorf("single-interviewer interview should have no panel: %+v", after.Panel)
+	}
+	if !after.UpdatedAt.After(before.UpdatedAt) {
+		t.Errorf("updated_at not refreshed: before=%v after=%v", before.UpdatedAt, after.UpdatedAt)
+	}
+
+	if err := repo.UpdateStatus(ctx, lastID, "unknown"); err == nil {
+		t.Error("expected CHECK constraint violation for invalid status")
+	}
+
+	score := int32(70)
+	for _, m := range []*biz.InterviewMessage{
+		{InterviewID: lastID, Role: "assistant", Speaker: "tech", Content: "请介绍一下自己", Difficulty: 3, Reasoning: "先了解背景", Question: "请介绍一下自己"},
+		{InterviewID: lastID, Role: "user", Content: "我是一名后端工程师", Score: &score},
+	} {
+		if _, err := repo.CreateMessage(ctx, m); err != nil {
+			t.Fatalf("CreateMessage error: %v", err)
+		}
+	}
+	msgs, err := repo.ListMessages(ctx, lastID)
+	if err != nil {
+		t.Fatalf("ListMessages error: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].Role != "assistant" || msgs[0].Speaker != "tech" || msgs[1].Speaker != "" || msgs[1].Content != "我是一名后端工程师" {
+		t.Errorf("unexpected messages: %+v", msgs)
+	}
+	if msgs[0].Difficulty != 3 || msgs[0].Score != nil || msgs[1].Score == nil || *msgs[1].Score != 70 {
+		t.Errorf("difficulty / score not round-tripped: %+v %+v", msgs[0], msgs[1])
+	}
+	if msgs[0].Reasoning != "先了解背景" || msgs[1].Reasoning != "" || msgs[0].Question != "请介绍一下自己" {
+		t.Errorf("reasoning / question not round-tripped: %+v %+v", msgs[0], msgs[1])
+	}
+	if err := repo.UpdateMessageScore(ctx, msgs[1].ID, 85); err != nil {
+		t.Fatalf("UpdateMessageScore error: %v", err)
+	}
+	if msgs, _ := repo.ListMessages(ctx, lastID); msgs[1].Score == nil || *msgs[1].Score != 85 {
+		t.Errorf("score not updated: %+v", msgs[1])
+	}
+
+	if _, err := repo.CreateMessage(ctx, &biz.InterviewMessage{InterviewID: 9999, Role: "user", Content: "x"}); err == nil {
+		t.Error("expected foreign key violation for unknown interview")
+	}
+
+	eval, err := repo.CreateEvaluation(ctx, &biz.Evaluation{
+		InterviewID:  lastID,
+		OverallScore: 82,
+		Summary:      "表现良好",
+		Level:        3.5,
+		Reasoning:    "回答整体扎实",
+		Categories:   []biz.CategoryScore{{Category: "技术能力", Score: 85, Comment: "扎实"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluation error: %v", err)
+	}
+	got, err := repo.GetEvaluation(ctx, lastID)
+	if err != nil {
+		t.Fatalf("GetEvaluation error: %v", err)
+	}
+	if got.ID != eval.ID || got.OverallScore != 82 || len(got.Categories) != 1 || got.Categories[0].Score != 85 || got.Level != 3.5 || got.Reasoning != "回答整体扎实" {
+		t.Errorf("unexpected evaluation: %+v", got)
+	}
+
+	if _, err := repo.GetSummary(ctx, lastID); !errors.Is(err, biz.ErrSummaryNotFound) {
+		t.Errorf("expected ErrSummaryNotFound, got %v", err)
+	}
+	for _, through := range []int64{msgs[0].ID, msgs[1].ID} {
+		if err := repo.SaveSummary(ctx, &biz.InterviewSummary{
+			InterviewID:      lastID,
+			Content:          "候选人介绍了自己",
+			Questions:        []string{"请介绍一下自己？"},
+			ThroughMessageID: through,
+		}); err != nil {
+			t.Fatalf("SaveSummary error: %v", err)
+		}
+	}
+	summary, err := repo.GetSummary(ctx, lastID)
+	if err != nil {
+		t.Fatalf("GetSummary error: %v", err)
+	}
+	if summary.ThroughMessageID != msgs[1].ID || len(summary.Questions) != 1 || summary.Content != "候选人介绍了自己" {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	png := []byte("\x89PNG\r\n\x1a\nwhiteboard")
+	a, err := repo.CreateAttachment(ctx, &biz.Attachment{InterviewID: lastID, MessageID: msgs[1].ID, ContentType: "image/png", Data: png, SizeBytes: int64(len(png))})
+	if err != nil {
+		t.Fatalf("CreateAttachment error: %v", err)
+	}
+	attachments, err := repo.ListAttachments(ctx, lastID)
+	if err != nil || len(attachments) != 1 || attachments[0].MessageID != msgs[1].ID || attachments[0].Data != nil || attachments[0].SizeBytes != int64(len(png)) {
+		t.Fatalf("ListAttachments = %+v, %v", attachments, err)
+	}
+	if full, err := repo.GetAttachment(ctx, a.ID); err != nil || string(full.Data) != string(png) || full.ContentType != "image/png" {
+		t.Errorf("GetAttachment = %+v, %v", full, err)
+	}
+	if _, err := repo.GetAttachment(ctx, 9999); !errors.Is(err, biz.ErrAttachmentNotFound) {
+		t.Errorf("expected ErrAttachmentNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteIntegration_CodingRepo(t *testing.T) {
+	d, logger := newSQLiteData(t)
+	userRepo := NewUserRepo(d, logger)
+	interviewRepo := NewInterviewRepo(d, logger)
+	repo := NewCodingRepo(d, logger)
+	ctx := context.Background()
+
+	user := createTestUser(t, userRepo, "c@example.com")
+	interview, err := interviewRepo.Create(ctx, &biz.Interview{
+		UserID:    user.ID,
+		Title:     "编程面试",
+		Status:    "pending",
+		Language:  "zh-CN",
+		Mode:      biz.InterviewModeCoding,
+		ProblemID: "two-sum",
+		Persona:   "bar-raiser",
+		Panel: []*biz.Panelist{
+			{ID: "tech", Name: "张工", Role: "技术面试官", Focus: "并发", LLMProvider: "claude"},
+			{ID: "hm", Name: "李经理", Persona: "hiring-manager", TTSVoice: "onyx"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create interview error: %v", err)
+	}
+	got, err := interviewRepo.GetByID(ctx, interview.ID)
+	if err != nil {
+		t.Fatalf("GetByID error: %v", err)
+	}
+	if got.Mode != biz.InterviewModeCoding || got.ProblemID != "two-sum" || got.Persona != "bar-raiser" {
+		t.Errorf("mode/problem/persona not persisted: %+v", got)
+	}
+	if len(got.Panel) != 2 || *got.Panel[0] != *interview.Panel[0] || got.Panel[1].TTSVoice != "onyx" {
+		t.Errorf("panel not persisted: %+v", got.Panel)
+	}
+
+	for _, sub := range []*biz.CodeSubmission{
+		{InterviewID: interview.ID, ProblemID: "two-sum", Language: "go", Code: "package main", Status: biz.SubmissionCompileError, Total: 2, CompileOutput: "syntax error"},
+		{InterviewID: interview.ID, ProblemID: "two-sum", Language: "python", Code: "print(1)", Status: biz.SubmissionWrongAnswer, Passed: 1, Total: 2,
+			Results: []biz.TestResult{
+				{Index: 1, Status: biz.TestPassed, Input: "1", Expected: "1", Actual: "1"},
+				{Index: 2, Hidden: true, Status: biz.TestWrongAnswer},
+			}},
+	} {
+		if _, err := repo.CreateSubmission(ctx, sub); err != nil {
+			t.Fatalf("CreateSubmission error: %v", err)
+		}
+	}
+
+	subs, err := repo.ListSubmissions(ctx, interview.ID)
+	if err != nil {
+		t.Fatalf("ListSubmissions error: %v", err)
+	}
+	if len(subs) != 2 || subs[0].CompileOutput != "syntax error" || subs[1].Passed != 1 {
+		t.Fatalf("unexpected submissions: %+v", subs)
+	}
+	if len(subs[1].Results) != 2 || !subs[1].Results[1].Hidden || subs[1].Results[0].Actual != "1" {
+		t.Errorf("results not round-tripped: %+v", subs[1].Results)
+	}
+
+	if _, err := repo.CreateSubmission(ctx, &biz.CodeSubmission{InterviewID: 9999, ProblemID: "x", Language: "go", Status: biz.SubmissionAccepted}); err == nil {
+		t.Error("expected foreign key violation for unknown interview")
+	}
+}
+
+func TestSQLiteIntegration_AudioRecordings(t *testing.T) {
+	d, logger := newSQLiteData(t)
+	userRepo := NewUserRepo(d, logger)
+	interviewRepo := NewInterviewRepo(d, logger)
+	ctx := context.Background()
+
+	store, err := NewLocalAudioStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalAudioStore error: %v", err)
+	}
+	uc := biz.NewAudioUsecase(NewAudioRepo(d, logger), store, &conf.Data{
+		Audio: &conf.Data_Audio{Enabled: true, Retention: conf.NewDuration(time.Hour)},
+	}, logger)
+
+	user := createTestUser(t, userRepo, "a@example.com")
+	interview, err := interviewRepo.Create(ctx, &biz.Interview{UserID: user.ID, Title: "录音", Status: "in_progress", Language: "zh-CN"})
+	if err != nil {
+		t.Fatalf("Create interview error: %v", err)
+	}
+	question, _ := interviewRepo.CreateMessage(ctx, &biz.InterviewMessage{InterviewID: interview.ID, Role: "assistant", Content: "你好。请介绍一下自己。"})
+	answer, _ := interviewRepo.CreateMessage(ctx, &biz.InterviewMessage{InterviewID: interview.ID, Role: "user", Content: "我是后端工程师"})
+
+	var mp3 bytes.Buffer
+	if err := tts.NewMockProvider(false).Synthesize(ctx, &tts.Request{Text: "你好。", Format: "mp3"}, &mp3); err != nil {
+		t.Fatalf("synthesize: %v", err)
+	}
+	if _, err := uc.SaveRecordings(ctx, interview.ID, question.ID, "assistant", []*biz.AudioClip{
+		{Format: "mp3", Data: mp3.Bytes()},
+		{Format: "mp3", Data: mp3.Bytes()},
+	}); err != nil {
+		t.Fatalf("SaveRecordings error: %v", err)
+	}
+	if _, err := uc.SaveRecordings(ctx, interview.ID, answer.ID, "user", []*biz.AudioClip{
+		{Format: "webm", Data: []byte("candidate audio"), Duration: 1500 * time.Millisecond},
+	}); err != nil {
+		t.Fatalf("SaveRecordings error: %v", err)
+	}
+
+	recs, err := uc.ListRecordings(ctx, interview.ID)
+	if err != nil {
+		t.Fatalf("ListRecordings error: %v", err)
+	}
+	if len(recs) != 3 || recs[1].Seq != 1 || recs[2].Role != "user" || recs[2].MessageID != answer.ID {
+		t.Fatalf("unexpected recordings: %+v", recs)
+	}
+	if recs[0].DurationMs < 500 || recs[0].SizeBytes != int64(mp3.Len()) || recs[2].DurationMs != 1500 {
+		t.Errorf("unexpected duration/size: %+v %+v", recs[0], recs[2])
+	}
+
+	rec, err := uc.GetRecording(ctx, recs[2].ID)
+	if err != nil {
+		t.Fatalf("GetRecording error: %v", err)
+	}
+	rc, _, err := uc.OpenRecording(ctx, rec)
+	if err != nil {
+		t.Fatalf("OpenRecording error: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "candidate audio" {
+		t.Errorf("unexpected audio content %q", data)
+	}
+	if _, err := uc.GetRecording(ctx, 9999); !errors.Is(err, biz.ErrRecordingNotFound) {
+		t.Errorf("expected ErrRecordingNotFound, got %v", err)
+	}
+
+	// 超过保留时长的录音被清理，对象与元数据一并删除
+	if _, err := d.db.ExecContext(ctx, `UPDATE audio_recordings SET created_at = ? WHERE message_id = ?`,
+		time.Now().Add(-2*time.Hour).UTC(), question.ID); err != nil {
+		t.Fatalf("backdate recordings: %v", err)
+	}
+	deleted, err := uc.CleanupExpired(ctx)
+	if err != nil || deleted != 2 {
+		t.Fatalf("CleanupExpired = %d, %v; want 2", deleted, err)
+	}
+	if _, _, err := store.Open(ctx, recs[0].StorageKey); !errors.Is(err, biz.ErrAudioNotFound) {
+		t.Errorf("expired audio object should be deleted, got %v", err)
+	}
+	if recs, _ := uc.ListRecordings(ctx, interview.ID); len(recs) != 1 {
+		t.Errorf("expected 1 recording left, got %d", len(recs))
+	}
+}
@@ -3,6 +3,9 @@ package data
 import (
 	"ai-interview/internal/biz"
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/go-kratos/kratos/v2/log"
 )
@@ -62,38 +65,183 @@ func (r *userRepo) GetByEmail(ctx context.Context, email string) (*biz.User, err
 
 func (r *userRepo) UpdateSettings(ctx context.Context, settings *biz.UserSettings) error {
 	_, err := r.data.db.ExecContext(ctx,
-		`INSERT INTO user_settings (user_id, llm_provider, llm_api_key, llm_base_url, llm_model,
-			tts_provider, tts_api_key, tts_voice, tts_enabled, stt_provider, stt_api_key)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON DUPLICATE KEY UPDATE
-			llm_provider = VALUES(llm_provider),
-			llm_api_key = VALUES(llm_api_key),
-			llm_base_url = VALUES(llm_base_url),
-			llm_model = VALUES(llm_model),
-			tts_provider = VALUES(tts_provider),
-			tts_api_key = VALUES(tts_api_key),
-			tts_voice = VALUES(tts_voice),
-			tts_enabled = VALUES(tts_enabled),
-			stt_provider = VALUES(stt_provider),
-			stt_api_key = VALUES(stt_api_key)`,
+		`INSERT INTO user_settings (user_id, llm_provider, llm_api_key, llm_base_url, llm_model, llm_fallbacks,
+			tts_provider, tts_api_key, tts_voice, tts_speed, tts_enabled, tts_fallbacks, stt_provider, stt_api_key,
+			azure_endpoint, azure_api_key, azure_api_version, azure_chat_deployment, azure_tts_deployment, azure_stt_deployment,
+			speech_region, speech_api_key)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`+r.data.dialect.upsert("user_id",
+			"llm_provider", "llm_api_key", "llm_base_url", "llm_model", "llm_fallbacks",
+			"tts_provider", "tts_api_key", "tts_voice", "tts_speed", "tts_enabled", "tts_fallbacks",
+			"stt_provider", "stt_api_key",
+			"azure_endpoint", "azure_api_key", "azure_api_version",
+			"azure_chat_deployment", "azure_tts_deployment", "azure_stt_deployment",
+			"speech_region", "speech_api_key",
+		),
 		settings.UserID, settings.LLMProvider, settings.LLMAPIKey, settings.LLMBaseURL, settings.LLMModel,
-		settings.TTSProvider, settings.TTSAPIKey, settings.TTSVoice, settings.TTSEnabled,
+		strings.Join(settings.LLMFallbacks, ","),
+		settings.TTSProvider, settings.TTSAPIKey, settings.TTSVoice, settings.TTSSpeed, settings.TTSEnabled,
+		strings.Join(settings.TTSFallbacks, ","),
 		settings.STTProvider, settings.STTAPIKey,
+		settings.AzureEndpoint, settings.AzureAPIKey, settings.AzureAPIVersion,
+		settings.AzureChatDeployment, settings.AzureTTSDeployment, settings.AzureSTTDeployment,
+		settings.SpeechRegion, settings.SpeechAPIKey,
 	)
 	return err
 }
 
 func (r *userRepo) GetSettings(ctx context.Context, userID int64) (*biz.UserSettings, error) {
 	s := &biz.UserSettings{}
+	var llmFallbacks, ttsFallbacks string
 	err := r.data.db.QueryRowContext(ctx,
-		`SELECT user_id, llm_provider, llm_api_key, llm_base_url, llm_model,
-			tts_provider, tts_api_key, tts_voice, tts_enabled, stt_provider, stt_api_key
+		`SELECT user_id, llm_provider, llm_api_key, llm_base_url, llm_model, llm_fallbacks,
+			tts_provider, tts_api_key, tts_voice, tts_speed, tts_enabled, tts_fallbacks, stt_provider, stt_api_key,
+			azure_endpoint, azure_api_key, azure_api_version, azure_chat_deployment, azure_tts_deployment, azure_stt_deployment,
+			speech_region, speech_api_key
 		FROM user_settings WHERE user_id = ?`, userID,
-	).Scan(&s.UserID, &s.LLMProvider, &s.LLMAPIKey, &s.LLMBaseURL, &s.LLMModel,
-		&s.TTSProvider, &s.TTSAPIKey, &s.TTSVoice, &s.TTSEnabled, &s.STTProvider, &s.STTAPIKey,
+	).Scan(&s.UserID, &s.LLMProvider, &s.LLMAPIKey, &s.LLMBaseURL, &s.LLMModel, &llmFallbacks,
+		&s.TTSProvider, &s.TTSAPIKey, &s.TTSVoice, &s.TTSSpeed, &s.TTSEnabled, &ttsFallbacks, &s.STTProvider, &s.STTAPIKey,
+		&s.AzureEndpoint, &s.AzureAPIKey, &s.AzureAPIVersion, &s.AzureChatDeployment, &s.AzureTTSDeployment, &s.AzureSTTDeployment,
+		&s.SpeechRegion, &s.SpeechAPIKey,
 	)
 	if err != nil {
 		return nil, err
 	}
+	s.LLMFallbacks, s.TTSFallbacks = splitList(llmFallbacks), splitList(ttsFallbacks)
 	return s, nil
 }
+
+func (r *userRepo) ListSettings(ctx context.Context, afterUserID int64, limit int) ([]*biz.UserSettings, error) {
+	rows, err := r.data.db.QueryContext(ctx,
+		`SELECT user_id, llm_provider, llm_api_key, llm_base_url, llm_model, llm_fallbacks,
+			tts_provider, tts_api_key, tts_voice, tts_speed, tts_enabled, tts_fallbacks, stt_provider, stt_api_key,
+			azure_endpoint, azure_api_key, azure_api_version, azure_chat_deployment, azure_tts_deployment, azure_stt_deployment,
+			speech_region, speech_api_key
+		FROM user_settings WHERE user_id > ? ORDER BY user_id LIMIT ?`, afterUserID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*biz.UserSettings
+	for rows.Next() {
+		s := &biz.UserSettings{}
+		var llmFallbacks, ttsFallbacks string
+		if err := rows.Scan(&s.UserID, &s.LLMProvider, &s.LLMAPIKey, &s.LLMBaseURL, &s.LLMModel, &llmFallbacks,
+			&s.TTSProvider, &s.TTSAPIKey, &s.TTSVoice, &s.TTSSpeed, &s.TTSEnabled, &ttsFallbacks, &s.STTProvider, &s.STTAPIKey,
+			&s.AzureEndpoint, &s.AzureAPIKey, &s.AzureAPIVersion, &s.AzureChatDeployment, &s.AzureTTSDeployment, &s.AzureSTTDeployment,
+			&s.SpeechRegion, &s.SpeechAPIKey,
+		); err != nil {
+			return nil, err
+		}
+		s.LLMFallbacks, s.TTSFallbacks = splitList(llmFallbacks), splitList(ttsFallbacks)
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+func (r *userRepo) ReplaceAPIKeys(ctx context.Context, old, updated *biz.UserSettings) (bool, error) {
+	result, err := r.data.db.ExecContext(ctx,
+		`UPDATE user_settings SET llm_api_key = ?, tts_api_key = ?, stt_api_key = ?, azure_api_key = ?, speech_api_key = ?
+		WHERE user_id = ? AND llm_api_key = ? AND tts_api_key = ? AND stt_api_key = ? AND azure_api_key = ? AND speech_api_key = ?`,
+		updated.LLMAPIKey, updated.TTSAPIKey, updated.STTAPIKey, updated.AzureAPIKey, updated.SpeechAPIKey,
+		old.UserID, old.LLMAPIKey, old.TTSAPIKey, old.STTAPIKey, old.AzureAPIKey, old.SpeechAPIKey,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+const customProviderColumns = `id, user_id, name, base_url, auth, auth_header, api_key, default_model, headers, created_at, updated_at`
+
+func (r *userRepo) ListCustomProviders(ctx context.Context, userID int64) ([]*biz.CustomProvider, error) {
+	return r.queryCustomProviders(ctx,
+		`SELECT `+customProviderColumns+` FROM llm_providers WHERE user_id = ? ORDER BY name`, userID,
+	)
+}
+
+func (r *userRepo) SaveCustomProvider(ctx context.Context, cp *biz.CustomProvider) error {
+	headers, err := json.Marshal(cp.Headers)
+	if err != nil {
+		return fmt.Errorf("marshal headers: %w", err)
+	}
+	_, err = r.data.db.ExecContext(ctx,
+		`INSERT INTO llm_providers (user_id, name, base_url, auth, auth_header, api_key, default_model, headers)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`+r.data.dialect.upsert("user_id, name",
+			"base_url", "auth", "auth_header", "api_key", "default_model", "headers",
+		),
+		cp.UserID, cp.Name, cp.BaseURL, cp.Auth, cp.AuthHeader, cp.APIKey, cp.DefaultModel, string(headers),
+	)
+	return err
+}
+
+func (r *userRepo) DeleteCustomProvider(ctx context.Context, userID int64, name string) (bool, error) {
+	result, err := r.data.db.ExecContext(ctx,
+		"DELETE FROM llm_providers WHERE user_id = ? AND name = ?", userID, name,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *userRepo) ListAllCustomProviders(ctx context.Context, afterID int64, limit int) ([]*biz.CustomProvider, error) {
+	return r.queryCustomProviders(ctx,
+		`SELECT `+customProviderColumns+` FROM llm_providers WHERE id > ? ORDER BY id LIMIT ?`, afterID, limit,
+	)
+}
+
+func (r *userRepo) ReplaceCustomProviderKey(ctx context.Context, id int64, old, updated string) (bool, error) {
+	result, err := r.data.db.ExecContext(ctx,
+		"UPDATE llm_providers SET api_key = ? WHERE id = ? AND api_key = ?", updated, id, old,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *userRepo) queryCustomProviders(ctx context.Context, query string, args ...any) ([]*biz.CustomProvider, error) {
+	rows, err := r.data.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*biz.CustomProvider
+	for rows.Next() {
+		cp := &biz.CustomProvider{}
+		var headers string
+		if err := rows.Scan(&cp.ID, &cp.UserID, &cp.Name, &cp.BaseURL, &cp.Auth, &cp.AuthHeader, &cp.APIKey,
+			&cp.DefaultModel, &headers, &cp.CreatedAt, &cp.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(headers), &cp.Headers)
+		list = append(list, cp)
+	}
+	return list, rows.Err()
+}
+
+// splitList 解析逗号分隔的列表列，空字符串返回 nil
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,139 @@
+// Package export 把面试记录导出为 Markdown 文字稿、版本化 JSON 归档、HTML 报告和 PDF 报告。
+//
+// 所有格式都按 Interview.Language 本地化标签和日期格式；PDF 由纯 Go 生成，
+// 中日韩文本使用 PDF 阅读器内置的 CID 字体，不需要嵌入字体文件。
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-interview/internal/biz"
+)
+
+// Format 导出格式
+type Format string
+
+const (
+	Markdown Format = "markdown"
+	JSON     Format = "json"
+	HTML     Format = "html"
+	PDF      Format = "pdf"
+)
+
+// ParseFormat 解析 format 查询参数，空值默认为 Markdown
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "md", "markdown":
+		return Markdown, nil
+	case "json":
+		return JSON, nil
+	case "html":
+		return HTML, nil
+	case "pdf":
+		return PDF, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q, want markdown, json, html or pdf", s)
+	}
+}
+
+// ContentType 返回格式对应的 MIME 类型
+func (f Format) ContentType() string {
+	switch f {
+	case JSON:
+		return "application/json; charset=utf-8"
+	case HTML:
+		return "text/html; charset=utf-8"
+	case PDF:
+		return "application/pdf"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+// Ext 返回文件扩展名
+func (f Format) Ext() string {
+	if f == Markdown {
+		return "md"
+	}
+	return string(f)
+}
+
+// Report 一次导出的全部数据
+type Report struct {
+	Interview   *biz.Interview
+	Messages    []*biz.InterviewMessage
+	Evaluation  *biz.Evaluation       // 尚未评估时为 nil
+	Submissions []*biz.CodeSubmission // 仅编程面试
+	ExportedAt  time.Time
+}
+
+// transcript 返回候选人可见的对话 (不含 system 消息)
+func (r *Report) transcript() []*biz.InterviewMessage {
+	msgs := make([]*biz.InterviewMessage, 0, len(r.Messages))
+	for _, m := range r.Messages {
+		if m.Role == "user" || m.Role == "assistant" {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+// who 返回发言人标签，小组面试的面试官带上名字，自适应难度下带上问题难度
+func (r *Report) who(l *labels, m *biz.InterviewMessage) string {
+	who := l.role(m.Role)
+	if m.Role != "assistant" {
+		return who
+	}
+	for _, p := range r.Interview.Panel {
+		if p.ID == m.Speaker {
+			who += " " + p.Name
+			break
+		}
+	}
+	if m.Difficulty > 0 {
+		who += fmt.Sprintf(" · %s %d", l.Difficulty, m.Difficulty)
+	}
+	return who
+}
+
+// File 渲染结果
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Render 按格式渲染报告
+func Render(format Format, r *Report) (*File, error) {
+	if r.ExportedAt.IsZero() {
+		r.ExportedAt = time.Now()
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	switch format {
+	case Markdown:
+		data = renderMarkdown(r)
+	case JSON:
+		data, err = renderJSON(r)
+	case HTML:
+		data, err = renderHTML(r)
+	case PDF:
+		data, err = renderPDF(r)
+	default:
+		err = fmt.Errorf("unsupported export format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", format, err)
+	}
+
+	return &File{
+		Name:        fmt.Sprintf("interview-%d.%s", r.Interview.ID, format.Ext()),
+		ContentType: format.ContentType(),
+		Data:        data,
+	}, nil
+}
@@ -0,0 +1,264 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-interview/internal/biz"
+)
+
+func testReport(language string) *Report {
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	return &Report{
+		Interview: &biz.Interview{
+			ID: 7, Title: "Go 后端 <script>", Position: "Backend", Status: "completed",
+			Language: language, Mode: biz.InterviewModeCoding, ProblemID: "two-sum", CreatedAt: at,
+		},
+		Messages: []*biz.InterviewMessage{
+			{Role: "system", Content: "系统提示词不应导出", CreatedAt: at},
+			{Role: "assistant", Content: "请介绍一下你自己。", CreatedAt: at},
+			{Role: "user", Content: "I have five years of Go experience (mostly gRPC | Kafka).", CreatedAt: at.Add(time.Minute)},
+		},
+		Evaluation: &biz.Evaluation{
+			OverallScore: 82, Summary: "基础扎实",
+			Categories: []biz.CategoryScore{{Category: "技术能力", Score: 90, Comment: "熟悉 | 并发"}},
+			Strengths:  "表达清晰", Weaknesses: "系统设计", Suggestions: "多练习",
+		},
+		Submissions: []*biz.CodeSubmission{
+			{ProblemID: "two-sum", Language: "go", Code: "package main\n// ``` fence\n", Status: biz.SubmissionAccepted, Passed: 4, Total: 4},
+		},
+		ExportedAt: at.Add(time.Hour),
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": Markdown, "md": Markdown, "JSON": JSON, "html": HTML, "pdf": PDF} {
+		got, err := ParseFormat(in)
+		if err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("docx"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestRender_Markdown(t *testing.T) {
+	f, err := Render(Markdown, testReport("zh-CN"))
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	md := string(f.Data)
+	if f.Name != "interview-7.md" || !strings.HasPrefix(f.ContentType, "text/markdown") {
+		t.Errorf("unexpected file: %s %s", f.Name, f.ContentType)
+	}
+	for _, want := range []string{"面试报告", "综合得分: 82 / 100", `熟悉 \| 并发`, "**面试官**", "> 请介绍一下你自己。", "````go", "编程面试"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "系统提示词") {
+		t.Error("system messages must not be exported")
+	}
+
+	en, _ := Render(Markdown, testReport("en-US"))
+	for _, want := range []string{"Interview Report", "Overall score: 82 / 100", "**Interviewer**", "Mar 1, 2026 10:00"} {
+		if !strings.Contains(string(en.Data), want) {
+			t.Errorf("english markdown missing %q", want)
+		}
+	}
+}
+
+func TestRender_JSON(t *testing.T) {
+	f, err := Render(JSON, testReport("zh-CN"))
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	var a Archive
+	if err := json.Unmarshal(f.Data, &a); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if a.Version != ArchiveVersion || a.Interview.ID != 7 || len(a.Messages) != 2 {
+		t.Errorf("unexpected archive: %+v", a)
+	}
+	if a.Evaluation == nil || a.Evaluation.OverallScore != 82 || a.Evaluation.Scores[0].Score != 90 {
+		t.Errorf("unexpected evaluation: %+v", a.Evaluation)
+	}
+	if len(a.Submissions) != 1 || a.Submissions[0].Passed != 4 {
+		t.Errorf("unexpected submissions: %+v", a.Submissions)
+	}
+
+	r := testReport("zh-CN")
+	r.Evaluation = nil
+	f, _ = Render(JSON, r)
+	if !bytes.Contains(f.Data, []byte(`"evaluation": null`)) {
+		t.Errorf("missing evaluation should be null:\n%s", f.Data)
+	}
+}
+
+func TestRender_PanelSpeakers(t *testing.T) {
+	r := testReport("en-US")
+	r.Interview.Panel = []*biz.Panelist{{ID: "tech", Name: "Alice", Role: "Tech lead"}, {ID: "hm", Name: "Bob"}}
+	r.Messages = []*biz.InterviewMessage{
+		{Role: "assistant", Speaker: "tech", Content: "How does the scheduler work?"},
+		{Role: "user", Content: "M:N threading."},
+		{Role: "assistant", Speaker: "hm", Content: "Why this team?"},
+	}
+
+	md, _ := Render(Markdown, r)
+	for _, want := range []string{"**Interviewer Alice**", "**Interviewer Bob**", "**Candidate**"} {
+		if !strings.Contains(string(md.Data), want) {
+			t.Errorf("markdown missing %q:\n%s", want, md.Data)
+		}
+	}
+
+	f, _ := Render(JSON, r)
+	var a Archive
+	if err := json.Unmarshal(f.Data, &a); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(a.Interview.Panel) != 2 || a.Interview.Panel[0].Name != "Alice" || a.Messages[2].Speaker != "hm" || a.Messages[1].Speaker != "" {
+		t.Errorf("unexpected panel archive: %+v %+v", a.Interview.Panel, a.Messages)
+	}
+}
+
+func TestRender_HTML(t *testing.T) {
+	f, err := Render(HTML, testReport("zh-CN"))
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	html := string(f.Data)
+	if strings.Contains(html, "<script>") {
+		t.Error("title must be escaped")
+	}
+	for _, want := range []string{`<html lang="zh-CN">`, "综合得分", "width: 90%", "请介绍一下你自己。", "通过"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html missing %q", want)
+		}
+	}
+}
+
+func TestRender_PDF(t *testing.T) {
+	for _, lang := range []string{"zh-CN", "en-US"} {
+		t.Run(lang, func(t *testing.T) {
+			r := testReport(lang)
+			// 长对话触发换页
+			for i := 0; i < 60; i++ {
+				r.Messages = append(r.Messages, &biz.InterviewMessage{Role: "user", Content: strings.Repeat("并发模型 goroutine ", 10)})
+			}
+			f, err := Render(PDF, r)
+			if err != nil {
+				t.Fatalf("Render error: %v", err)
+			}
+			pages, content := checkPDF(t, f.Data)
+			if pages < 2 {
+				t.Errorf("expected multiple pages, got %d", pages)
+			}
+			// 中文内容使用 CID 字体，按 UCS-2 hex 编码
+			if !strings.Contains(string(f.Data), "/STSong-Light") {
+				t.Error("expected STSong-Light for CJK content")
+			}
+			if want := fmt.Sprintf("%04X%04X", '并', '发'); !strings.Contains(content, want) {
+				t.Errorf("content stream missing encoded text %s", want)
+			}
+		})
+	}
+}
+
+func TestRender_PDFLatin(t *testing.T) {
+	r := &Report{
+		Interview: &biz.Interview{ID: 1, Title: "Backend (Go)", Language: "en", Status: "completed"},
+		Messages:  []*biz.InterviewMessage{{Role: "assistant", Content: "Tell me about yourself — briefly."}},
+	}
+	f, err := Render(PDF, r)
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	_, content := checkPDF(t, f.Data)
+	if !strings.Contains(string(f.Data), "/Helvetica") {
+		t.Error("expected Helvetica for latin-only report")
+	}
+	if !strings.Contains(content, `(Backend \(Go\) - Interview Report)`) || !strings.Contains(content, "yourself \x97 briefly.") {
+		t.Errorf("unexpected content stream:\n%s", content)
+	}
+}
+
+// checkPDF 校验文件结构与 xref 偏移，返回页数与解压后的全部内容流
+func checkPDF(t *testing.T, data []byte) (int, string) {
+	t.Helper()
+	s := string(data)
+	if !strings.HasPrefix(s, "%PDF-1.4") || !strings.HasSuffix(s, "%%EOF\n") {
+		t.Fatal("missing PDF header or trailer")
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(s)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(m[1])
+	if !strings.HasPrefix(s[xref:], "xref\n") {
+		t.Fatalf("startxref %d does not point to xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(s[xref:], -1)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(e[1])
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !strings.HasPrefix(s[off:], want) {
+			t.Fatalf("xref entry %d points to %q", i+1, s[off:off+10])
+		}
+	}
+
+	var content strings.Builder
+	streams := regexp.MustCompile(`(?s)/Length (\d+) /Filter /FlateDecode >>\nstream\n`)
+	for _, loc := range streams.FindAllStringSubmatchIndex(s, -1) {
+		n, _ := strconv.Atoi(s[loc[2]:loc[3]])
+		zr, err := zlib.NewReader(strings.NewReader(s[loc[1] : loc[1]+n]))
+		if err != nil {
+			t.Fatalf("invalid stream: %v", err)
+		}
+		raw, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("inflate stream: %v", err)
+		}
+		if !strings.HasPrefix(s[loc[1]+n:], "\nendstream") {
+			t.Fatal("stream length mismatch")
+		}
+		content.Write(raw)
+	}
+
+	pages := strings.Count(s, "/Type /Page ")
+	if c := regexp.MustCompile(`/Count (\d+)`).FindStringSubmatch(s); c == nil || c[1] != strconv.Itoa(pages) {
+		t.Errorf("page count mismatch: %v vs %d", c, pages)
+	}
+	return pages, content.String()
+}
+
+func TestPDFFont_Wrap(t *testing.T) {
+	lines := fontHelvetica.wrap("the quick brown fox jumps over the lazy dog", 10, 80)
+	for _, l := range lines {
+		if fontHelvetica.textWidth(l, 10) > 80 {
+			t.Errorf("line %q exceeds width", l)
+		}
+		if strings.HasPrefix(l, " ") || strings.HasSuffix(l, " ") {
+			t.Errorf("line %q has surrounding spaces", l)
+		}
+	}
+	if strings.Join(lines, " ") != "the quick brown fox jumps over the lazy dog" {
+		t.Errorf("words lost while wrapping: %q", lines)
+	}
+
+	cjk := fontSC.wrap("面试官请候选人介绍并发模型", 10, 50)
+	if len(cjk) != 3 || cjk[0] != "面试官请候" {
+		t.Errorf("unexpected CJK wrapping: %q", cjk)
+	}
+	if got := fontSC.wrap("a\n\nb", 10, 100); len(got) != 3 || got[1] != "" {
+		t.Errorf("blank lines should be kept: %q", got)
+	}
+}
@@ -0,0 +1,104 @@
+package export
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+
+	"ai-interview/internal/biz"
+)
+
+//go:embed report.html.tmpl
+var reportHTML string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"scoreColor": scoreColor,
+}).Parse(reportHTML))
+
+// htmlView 模板数据，所有文案已按语言本地化
+type htmlView struct {
+	L           *labels
+	Title       string
+	Position    string
+	Mode        string
+	Status      string
+	CreatedAt   string
+	ExportedAt  string
+	Evaluation  *biz.Evaluation
+	Submissions []htmlSubmission
+	Messages    []htmlMessage
+}
+
+type htmlSubmission struct {
+	Title    string
+	Language string
+	Verdict  string
+	Color    string
+	Passed   int
+	Total    int
+	Code     string
+}
+
+type htmlMessage struct {
+	Role    string
+	Who     string
+	Time    string
+	Content string
+}
+
+func renderHTML(r *Report) ([]byte, error) {
+	l := labelsFor(r.Interview.Language)
+	iv := r.Interview
+	v := htmlView{
+		L:          l,
+		Title:      reportTitle(iv.Title, l),
+		Position:   orDash(iv.Position),
+		Mode:       l.mode(iv.Mode),
+		Status:     l.status(iv.Status),
+		CreatedAt:  l.date(iv.CreatedAt),
+		ExportedAt: l.date(r.ExportedAt),
+		Evaluation: r.Evaluation,
+	}
+	for i, s := range r.Submissions {
+		color := scoreColor(0)
+		if s.Status == biz.SubmissionAccepted {
+			color = scoreColor(100)
+		}
+		v.Submissions = append(v.Submissions, htmlSubmission{
+			Title:    fmt.Sprintf(l.Submission, i+1),
+			Language: s.Language,
+			Verdict:  l.verdict(s.Status),
+			Color:    color,
+			Passed:   s.Passed,
+			Total:    s.Total,
+			Code:     s.Code,
+		})
+	}
+	for _, m := range r.transcript() {
+		v.Messages = append(v.Messages, htmlMessage{
+			Role:    m.Role,
+			Who:     r.who(l, m),
+			Time:    l.clock(m.CreatedAt),
+			Content: m.Content,
+		})
+	}
+
+	var b bytes.Buffer
+	if err := reportTemplate.Execute(&b, v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// scoreColor 分数对应的颜色：>=80 绿，>=60 橙，其余红
+func scoreColor(score int32) string {
+	switch {
+	case score >= 80:
+		return "#2f9e44"
+	case score >= 60:
+		return "#e67700"
+	default:
+		return "#c92a2a"
+	}
+}
@@ -0,0 +1,176 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// labels 报告中的本地化文案
+type labels struct {
+	Lang         string // HTML lang 属性
+	Report       string
+	Position     string
+	Status       string
+	Mode         string
+	CreatedAt    string
+	ExportedAt   string
+	Evaluation   string
+	OverallScore string
+	Level        string
+	NoEvaluation string
+	Category     string
+	Score        string
+	Comment      string
+	Strengths    string
+	Weaknesses   string
+	Suggestions  string
+	Coding       string
+	Submission   string
+	Language     string
+	Result       string
+	Passed       string
+	Transcript   string
+	NoMessages   string
+	Interviewer  string
+	Candidate    string
+	Difficulty   string
+	Page         string
+	dateLayout   string
+	timeLayout   string
+	statusNames  map[string]string
+	modeNames    map[string]string
+	verdictNames map[string]string
+}
+
+var zhLabels = &labels{
+	Lang:         "zh-CN",
+	Report:       "面试报告",
+	Position:     "岗位",
+	Status:       "状态",
+	Mode:         "模式",
+	CreatedAt:    "面试时间",
+	ExportedAt:   "导出时间",
+	Evaluation:   "评估",
+	OverallScore: "综合得分",
+	Level:        "能力等级",
+	NoEvaluation: "面试尚未结束，暂无评估。",
+	Category:     "维度",
+	Score:        "得分",
+	Comment:      "评语",
+	Strengths:    "优势",
+	Weaknesses:   "不足",
+	Suggestions:  "建议",
+	Coding:       "编程题提交",
+	Submission:   "第 %d 次提交",
+	Language:     "语言",
+	Result:       "结果",
+	Passed:       "通过用例",
+	Transcript:   "面试记录",
+	NoMessages:   "暂无对话。",
+	Interviewer:  "面试官",
+	Candidate:    "候选人",
+	Difficulty:   "难度",
+	Page:         "第 %d / %d 页",
+	dateLayout:   "2006年01月02日 15:04",
+	timeLayout:   "15:04:05",
+	statusNames:  map[string]string{"pending": "未开始", "in_progress": "进行中", "completed": "已完成"},
+	modeNames:    map[string]string{"chat": "对话面试", "coding": "编程面试"},
+	verdictNames: map[string]string{
+		"accepted": "通过", "wrong_answer": "答案错误", "compile_error": "编译错误",
+	},
+}
+
+var enLabels = &labels{
+	Lang:         "en",
+	Report:       "Interview Report",
+	Position:     "Position",
+	Status:       "Status",
+	Mode:         "Mode",
+	CreatedAt:    "Date",
+	ExportedAt:   "Exported",
+	Evaluation:   "Evaluation",
+	OverallScore: "Overall score",
+	Level:        "Estimated level",
+	NoEvaluation: "The interview has not ended yet; no evaluation available.",
+	Category:     "Category",
+	Score:        "Score",
+	Comment:      "Comment",
+	Strengths:    "Strengths",
+	Weaknesses:   "Weaknesses",
+	Suggestions:  "Suggestions",
+	Coding:       "Code submissions",
+	Submission:   "Submission #%d",
+	Language:     "Language",
+	Result:       "Result",
+	Passed:       "Tests passed",
+	Transcript:   "Transcript",
+	NoMessages:   "No messages.",
+	Interviewer:  "Interviewer",
+	Candidate:    "Candidate",
+	Difficulty:   "Difficulty",
+	Page:         "Page %d of %d",
+	dateLayout:   "Jan 2, 2006 15:04",
+	timeLayout:   "15:04:05",
+	statusNames:  map[string]string{"pending": "Pending", "in_progress": "In progress", "completed": "Completed"},
+	modeNames:    map[string]string{"chat": "Conversation", "coding": "Coding"},
+	verdictNames: map[string]string{
+		"accepted": "Accepted", "wrong_answer": "Wrong answer", "compile_error": "Compile error",
+	},
+}
+
+// labelsFor 按面试语言选择文案，zh* 使用中文，其余使用英文
+func labelsFor(language string) *labels {
+	if strings.HasPrefix(strings.ToLower(language), "zh") {
+		return zhLabels
+	}
+	return enLabels
+}
+
+func (l *labels) date(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(l.dateLayout)
+}
+
+func (l *labels) clock(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(l.timeLayout)
+}
+
+func (l *labels) role(role string) string {
+	if role == "assistant" {
+		return l.Interviewer
+	}
+	return l.Candidate
+}
+
+// level 自适应难度估计的能力等级，如 "能力等级 3.5 / 5"
+func (l *labels) level(v float64) string {
+	return fmt.Sprintf("%s %.1f / 5", l.Level, v)
+}
+
+func (l *labels) status(s string) string {
+	return lookup(l.statusNames, s)
+}
+
+func (l *labels) mode(m string) string {
+	if m == "" {
+		m = "chat"
+	}
+	return lookup(l.modeNames, m)
+}
+
+func (l *labels) verdict(v string) string {
+	return lookup(l.verdictNames, v)
+}
+
+func lookup(m map[string]string, key string) string {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	return key
+}
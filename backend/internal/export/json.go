@@ -0,0 +1,151 @@
+package export
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ArchiveVersion JSON 归档格式版本，字段有不兼容变更时递增
+const ArchiveVersion = 1
+
+// Archive 版本化的 JSON 归档
+type Archive struct {
+	Version     int                 `json:"version"`
+	ExportedAt  time.Time           `json:"exported_at"`
+	Interview   ArchiveInterview    `json:"interview"`
+	Messages    []ArchiveMessage    `json:"messages"`
+	Evaluation  *ArchiveEvaluation  `json:"evaluation"`
+	Submissions []ArchiveSubmission `json:"code_submissions,omitempty"`
+}
+
+// ArchiveInterview 面试基本信息
+type ArchiveInterview struct {
+	ID          int64             `json:"id"`
+	Title       string            `json:"title"`
+	Position    string            `json:"position"`
+	Status      string            `json:"status"`
+	Language    string            `json:"language"`
+	Mode        string            `json:"mode"`
+	ProblemID   string            `json:"problem_id,omitempty"`
+	Panel       []ArchivePanelist `json:"panel,omitempty"`
+	LLMProvider string            `json:"llm_provider"`
+	LLMModel    string            `json:"llm_model"`
+	Resume      string            `json:"resume"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// ArchivePanelist 小组面试的面试官
+type ArchivePanelist struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Role  string `json:"role,omitempty"`
+	Focus string `json:"focus,omitempty"`
+}
+
+// ArchiveMessage 对话消息 (不含 system 消息)
+type ArchiveMessage struct {
+	Role       string    `json:"role"`
+	Speaker    string    `json:"speaker,omitempty"` // 小组面试中发言的面试官 ID
+	Content    string    `json:"content"`
+	Difficulty int       `json:"difficulty,omitempty"` // 自适应难度下面试官问题的难度
+	Score      *int32    `json:"score,omitempty"`      // 自适应难度下候选人回答的评分
+	Reasoning  string    `json:"reasoning,omitempty"`  // 推理模型的思考过程 (开启审计时保存)
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ArchiveEvaluation 评估结果
+type ArchiveEvaluation struct {
+	OverallScore int32             `json:"overall_score"`
+	Summary      string            `json:"summary"`
+	Scores       []ArchiveCategory `json:"scores"`
+	Strengths    string            `json:"strengths"`
+	Weaknesses   string            `json:"weaknesses"`
+	Suggestions  string            `json:"suggestions"`
+	Level        float64           `json:"level,omitempty"`     // 自适应难度估计的能力等级
+	Reasoning    string            `json:"reasoning,omitempty"` // 推理模型生成评估时的思考过程 (开启审计时保存)
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// ArchiveCategory 分项得分
+type ArchiveCategory struct {
+	Category string `json:"category"`
+	Score    int32  `json:"score"`
+	Comment  string `json:"comment"`
+}
+
+// ArchiveSubmission 代码提交 (不含逐个用例结果)
+type ArchiveSubmission struct {
+	ProblemID string    `json:"problem_id"`
+	Language  string    `json:"language"`
+	Code      string    `json:"code"`
+	Status    string    `json:"status"`
+	Passed    int       `json:"passed"`
+	Total     int       `json:"total"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewArchive 由报告构建 JSON 归档
+func NewArchive(r *Report) *Archive {
+	iv := r.Interview
+	a := &Archive{
+		Version:    ArchiveVersion,
+		ExportedAt: r.ExportedAt,
+		Interview: ArchiveInterview{
+			ID:          iv.ID,
+			Title:       iv.Title,
+			Position:    iv.Position,
+			Status:      iv.Status,
+			Language:    iv.Language,
+			Mode:        iv.Mode,
+			ProblemID:   iv.ProblemID,
+			LLMProvider: iv.LLMProvider,
+			LLMModel:    iv.LLMModel,
+			Resume:      iv.Resume,
+			CreatedAt:   iv.CreatedAt,
+			UpdatedAt:   iv.UpdatedAt,
+		},
+		Messages: make([]ArchiveMessage, 0, len(r.Messages)),
+	}
+	for _, p := range iv.Panel {
+		a.Interview.Panel = append(a.Interview.Panel, ArchivePanelist{ID: p.ID, Name: p.Name, Role: p.Role, Focus: p.Focus})
+	}
+	for _, m := range r.transcript() {
+		a.Messages = append(a.Messages, ArchiveMessage{
+			Role: m.Role, Speaker: m.Speaker, Content: m.Content, Difficulty: m.Difficulty, Score: m.Score,
+			Reasoning: m.Reasoning, CreatedAt: m.CreatedAt,
+		})
+	}
+	if e := r.Evaluation; e != nil {
+		a.Evaluation = &ArchiveEvaluation{
+			OverallScore: e.OverallScore,
+			Summary:      e.Summary,
+			Scores:       make([]ArchiveCategory, 0, len(e.Categories)),
+			Strengths:    e.Strengths,
+			Weaknesses:   e.Weaknesses,
+			Suggestions:  e.Suggestions,
+			Level:        e.Level,
+			Reasoning:    e.Reasoning,
+			CreatedAt:    e.CreatedAt,
+		}
+		for _, c := range e.Categories {
+			a.Evaluation.Scores = append(a.Evaluation.Scores, ArchiveCategory{Category: c.Category, Score: c.Score, Comment: c.Comment})
+		}
+	}
+	for _, s := range r.Submissions {
+		a.Submissions = append(a.Submissions, ArchiveSubmission{
+			ProblemID: s.ProblemID,
+			Language:  s.Language,
+			Code:      s.Code,
+			Status:    s.Status,
+			Passed:    s.Passed,
+			Total:     s.Total,
+			CreatedAt: s.CreatedAt,
+		})
+	}
+	return a
+}
+
+func renderJSON(r *Report) ([]byte, error) {
+	return json.MarshalIndent(NewArchive(r), "", "  ")
+}
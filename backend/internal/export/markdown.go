@@ -0,0 +1,117 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// renderMarkdown 渲染 Markdown 文字稿：基本信息、评估、编程题提交、完整对话
+func renderMarkdown(r *Report) []byte {
+	l := labelsFor(r.Interview.Language)
+	iv := r.Interview
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "# %s\n\n", mdInline(reportTitle(iv.Title, l)))
+	fmt.Fprintf(&b, "- **%s**: %s\n", l.Position, mdInline(orDash(iv.Position)))
+	fmt.Fprintf(&b, "- **%s**: %s\n", l.Mode, l.mode(iv.Mode))
+	fmt.Fprintf(&b, "- **%s**: %s\n", l.Status, l.status(iv.Status))
+	fmt.Fprintf(&b, "- **%s**: %s\n", l.CreatedAt, l.date(iv.CreatedAt))
+	fmt.Fprintf(&b, "- **%s**: %s\n\n", l.ExportedAt, l.date(r.ExportedAt))
+
+	fmt.Fprintf(&b, "## %s\n\n", l.Evaluation)
+	if e := r.Evaluation; e == nil {
+		fmt.Fprintf(&b, "%s\n\n", l.NoEvaluation)
+	} else {
+		fmt.Fprintf(&b, "**%s: %d / 100**\n\n", l.OverallScore, e.OverallScore)
+		if e.Level > 0 {
+			fmt.Fprintf(&b, "%s\n\n", l.level(e.Level))
+		}
+		if e.Summary != "" {
+			fmt.Fprintf(&b, "%s\n\n", e.Summary)
+		}
+		if len(e.Categories) > 0 {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n|---|---:|---|\n", l.Category, l.Score, l.Comment)
+			for _, c := range e.Categories {
+				fmt.Fprintf(&b, "| %s | %d | %s |\n", mdCell(c.Category), c.Score, mdCell(c.Comment))
+			}
+			b.WriteString("\n")
+		}
+		for _, s := range []struct{ title, text string }{
+			{l.Strengths, e.Strengths}, {l.Weaknesses, e.Weaknesses}, {l.Suggestions, e.Suggestions},
+		} {
+			if s.text != "" {
+				fmt.Fprintf(&b, "### %s\n\n%s\n\n", s.title, s.text)
+			}
+		}
+	}
+
+	if len(r.Submissions) > 0 {
+		fmt.Fprintf(&b, "## %s\n\n", l.Coding)
+		for i, s := range r.Submissions {
+			fmt.Fprintf(&b, "### %s\n\n", fmt.Sprintf(l.Submission, i+1))
+			fmt.Fprintf(&b, "- **%s**: %s\n", l.Language, s.Language)
+			fmt.Fprintf(&b, "- **%s**: %s\n", l.Result, l.verdict(s.Status))
+			fmt.Fprintf(&b, "- **%s**: %d / %d\n\n", l.Passed, s.Passed, s.Total)
+			fmt.Fprintf(&b, "%s%s\n%s\n%s\n\n", codeFence(s.Code), s.Language, strings.TrimRight(s.Code, "\n"), codeFence(s.Code))
+		}
+	}
+
+	fmt.Fprintf(&b, "## %s\n\n", l.Transcript)
+	msgs := r.transcript()
+	if len(msgs) == 0 {
+		fmt.Fprintf(&b, "%s\n", l.NoMessages)
+	}
+	for _, m := range msgs {
+		fmt.Fprintf(&b, "**%s**", r.who(l, m))
+		if t := l.clock(m.CreatedAt); t != "" {
+			fmt.Fprintf(&b, " · %s", t)
+		}
+		b.WriteString("\n\n")
+		for _, line := range strings.Split(strings.TrimSpace(m.Content), "\n") {
+			fmt.Fprintf(&b, "> %s\n", line)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.Bytes()
+}
+
+func reportTitle(title string, l *labels) string {
+	if title == "" {
+		return l.Report
+	}
+	return title + " - " + l.Report
+}
+
+func orDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
+
+// mdInline 把单行文本中的换行折叠为空格
+func mdInline(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// mdCell 转义表格单元格中的竖线与换行
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "<br>")
+}
+
+// codeFence 返回比代码中最长反引号串更长的围栏
+func codeFence(code string) string {
+	longest, run := 0, 0
+	for _, r := range code {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
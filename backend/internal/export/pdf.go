@@ -0,0 +1,474 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+
+	"ai-interview/internal/biz"
+)
+
+// A4 页面布局，单位 pt
+const (
+	pageWidth    = 595.28
+	pageHeight   = 841.89
+	pageMargin   = 56.0
+	footerHeight = 24.0
+	contentWidth = pageWidth - 2*pageMargin
+)
+
+type rgb [3]float64
+
+var (
+	colorText    = rgb{0.12, 0.16, 0.2}
+	colorMuted   = rgb{0.4, 0.45, 0.5}
+	colorTitle   = rgb{0.1, 0.25, 0.55}
+	colorRule    = rgb{0.85, 0.87, 0.9}
+	colorBarBack = rgb{0.9, 0.91, 0.93}
+)
+
+// pdfFont PDF 阅读器内置的字体 (不嵌入字体文件)
+type pdfFont struct {
+	name       string
+	cid        bool   // CID 字体按 UCS-2 编码，用于中日韩文本
+	encoding   string // CMap 名称
+	ordering   string
+	supplement int
+}
+
+var (
+	fontHelvetica = &pdfFont{name: "Helvetica"}
+	fontSC        = &pdfFont{name: "STSong-Light", cid: true, encoding: "UniGB-UCS2-H", ordering: "GB1", supplement: 2}
+	fontTC        = &pdfFont{name: "MSung-Light", cid: true, encoding: "UniCNS-UCS2-H", ordering: "CNS1", supplement: 1}
+	fontJA        = &pdfFont{name: "KozMinPro-Regular-Acro", cid: true, encoding: "UniJIS-UCS2-H", ordering: "Japan1", supplement: 2}
+	fontKO        = &pdfFont{name: "HYSMyeongJo-Medium", cid: true, encoding: "UniKS-UCS2-H", ordering: "Korea1", supplement: 1}
+)
+
+// pdfFontFor 按面试语言选择字体；非中日韩语言但内容含中日韩字符时按字符选择
+func pdfFontFor(language string, content string) *pdfFont {
+	lang := strings.ToLower(language)
+	switch {
+	case strings.HasPrefix(lang, "zh-tw"), strings.HasPrefix(lang, "zh-hk"), strings.HasPrefix(lang, "zh-hant"):
+		return fontTC
+	case strings.HasPrefix(lang, "zh"):
+		return fontSC
+	case strings.HasPrefix(lang, "ja"):
+		return fontJA
+	case strings.HasPrefix(lang, "ko"):
+		return fontKO
+	}
+	for _, r := range content {
+		switch {
+		case unicode.Is(unicode.Hangul, r):
+			return fontKO
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			return fontJA
+		case unicode.Is(unicode.Han, r):
+			return fontSC
+		}
+	}
+	return fontHelvetica
+}
+
+// helveticaWidths Helvetica 中 ASCII 32..126 的字宽 (1/1000 em)
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// winAnsi WinAnsiEncoding 中与 Latin-1 不同的常用字符
+var winAnsi = map[rune]byte{
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '…': 0x85, '€': 0x80,
+}
+
+// width 返回字宽 (1/1000 em)
+func (f *pdfFont) width(r rune) float64 {
+	if f.cid {
+		// CID 字体声明了 /W [1 95 500]：ASCII 半角，其余全角
+		if r >= 0x20 && r < 0x7f {
+			return 500
+		}
+		return 1000
+	}
+	if r >= 32 && r <= 126 {
+		return float64(helveticaWidths[r-32])
+	}
+	return 556
+}
+
+func (f *pdfFont) textWidth(s string, size float64) float64 {
+	var w float64
+	for _, r := range s {
+		w += f.width(r)
+	}
+	return w * size / 1000
+}
+
+// encode 把文本编码为 PDF 字符串
+func (f *pdfFont) encode(s string) string {
+	var b strings.Builder
+	if f.cid {
+		b.WriteByte('<')
+		for _, r := range s {
+			if r > 0xffff || utf16.IsSurrogate(r) {
+				r = '?'
+			}
+			fmt.Fprintf(&b, "%04X", r)
+		}
+		b.WriteByte('>')
+		return b.String()
+	}
+
+	b.WriteByte('(')
+	for _, r := range s {
+		c, ok := winAnsi[r]
+		switch {
+		case ok:
+		case r < 0x20:
+			c = ' '
+		case r <= 0xff && (r < 0x80 || r >= 0xa0):
+			c = byte(r)
+		default:
+			c = '?'
+		}
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// wrap 按宽度折行：拉丁文本在空格处断开，中日韩字符之间可任意断开
+func (f *pdfFont) wrap(s string, size, maxWidth float64) []string {
+	var lines []string
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\t", "    ")
+	for _, para := range strings.Split(s, "\n") {
+		runes := []rune(para)
+		start, lastBreak := 0, -1
+		var width float64
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			wide := r >= 0x2e80
+			if wide {
+				lastBreak = i
+			}
+			width += f.width(r) * size / 1000
+			if width > maxWidth && i > start {
+				end := i
+				if lastBreak > start {
+					end = lastBreak
+				}
+				lines = append(lines, strings.TrimRight(string(runes[start:end]), " "))
+				start = end
+				for start < i && runes[start] == ' ' {
+					start++
+				}
+				width = f.textWidth(string(runes[start:i+1]), size)
+				lastBreak = -1
+			}
+			if r == ' ' || wide {
+				lastBreak = i + 1
+			}
+		}
+		lines = append(lines, string(runes[start:]))
+	}
+	return lines
+}
+
+// pdfDoc 简单的流式排版：从上到下写入文本，空间不足时换页
+type pdfDoc struct {
+	font  *pdfFont
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+}
+
+func newPDFDoc(font *pdfFont) *pdfDoc {
+	d := &pdfDoc{font: font}
+	d.newPage()
+	return d
+}
+
+func (d *pdfDoc) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pageHeight - pageMargin
+}
+
+// ensure 剩余空间不足 h 时换页
+func (d *pdfDoc) ensure(h float64) {
+	if d.y-h < pageMargin+footerHeight {
+		d.newPage()
+	}
+}
+
+func (d *pdfDoc) space(h float64) {
+	d.y -= h
+}
+
+// textAt 在指定基线位置写一行文本
+func (d *pdfDoc) textAt(x, y, size float64, color rgb, s string) {
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f rg BT /F1 %.1f Tf %.2f %.2f Td %s Tj ET\n",
+		color[0], color[1], color[2], size, x, y, d.font.encode(s))
+}
+
+// paragraph 写入自动折行的段落
+func (d *pdfDoc) paragraph(s string, size, indent float64, color rgb) {
+	leading := size * 1.5
+	for _, line := range d.font.wrap(s, size, contentWidth-indent) {
+		d.ensure(leading)
+		d.y -= leading
+		if line != "" {
+			d.textAt(pageMargin+indent, d.y+size*0.3, size, color, line)
+		}
+	}
+}
+
+func (d *pdfDoc) heading(s string, size float64) {
+	d.ensure(size*2.5 + 30) // 避免标题落在页尾
+	d.space(size * 0.8)
+	d.paragraph(s, size, 0, colorTitle)
+	d.space(size * 0.3)
+}
+
+func (d *pdfDoc) rule() {
+	d.ensure(10)
+	d.y -= 6
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f RG 0.8 w %.2f %.2f m %.2f %.2f l S\n",
+		colorRule[0], colorRule[1], colorRule[2], pageMargin, d.y, pageWidth-pageMargin, d.y)
+	d.y -= 6
+}
+
+// scoreBar 在当前行右侧画得分条
+func (d *pdfDoc) scoreBar(x, y, width float64, score int32) {
+	c := hexColor(scoreColor(score))
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f rg %.2f %.2f %.2f 5 re f\n", colorBarBack[0], colorBarBack[1], colorBarBack[2], x, y, width)
+	fill := width * float64(min(max(score, 0), 100)) / 100
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f rg %.2f %.2f %.2f 5 re f\n", c[0], c[1], c[2], x, y, fill)
+}
+
+// bytes 输出完整的 PDF 文件，footer 为每页页脚文案 (含页码格式)
+func (d *pdfDoc) bytes(title, footer string, created time.Time) ([]byte, error) {
+	w := &pdfObjects{}
+	catalog := w.reserve()
+	pagesObj := w.reserve()
+	fontObj := w.reserve()
+	infoObj := w.reserve()
+
+	var kids []string
+	for i, p := range d.pages {
+		text := fmt.Sprintf(footer, i+1, len(d.pages))
+		x := (pageWidth - d.font.textWidth(text, 8)) / 2
+		fmt.Fprintf(p, "%.3f %.3f %.3f rg BT /F1 8 Tf %.2f %.2f Td %s Tj ET\n",
+			colorMuted[0], colorMuted[1], colorMuted[2], x, pageMargin/2, d.font.encode(text))
+
+		content, err := deflate(p.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		contentObj := w.add(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(content), content))
+		pageObj := w.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesObj, pageWidth, pageHeight, fontObj, contentObj))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObj))
+	}
+
+	if d.font.cid {
+		descriptor := w.add(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 6 /FontBBox [-25 -254 1000 880] "+
+			"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>", d.font.name))
+		descendant := w.add(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /%s "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (%s) /Supplement %d >> /FontDescriptor %d 0 R /DW 1000 /W [1 95 500] >>",
+			d.font.name, d.font.ordering, d.font.supplement, descriptor))
+		w.set(fontObj, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /%s /DescendantFonts [%d 0 R] >>",
+			d.font.name, d.font.encoding, descendant))
+	} else {
+		w.set(fontObj, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", d.font.name))
+	}
+
+	w.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+	w.set(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	w.set(infoObj, fmt.Sprintf("<< /Title %s /Producer (ai-interview) /CreationDate (D:%s) >>",
+		utf16Text(title), created.UTC().Format("20060102150405Z")))
+
+	return w.bytes(catalog, infoObj), nil
+}
+
+// pdfObjects 按编号保存间接对象并生成 xref 表
+type pdfObjects struct {
+	objs []string
+}
+
+func (w *pdfObjects) reserve() int {
+	w.objs = append(w.objs, "")
+	return len(w.objs)
+}
+
+func (w *pdfObjects) add(body string) int {
+	w.objs = append(w.objs, body)
+	return len(w.objs)
+}
+
+func (w *pdfObjects) set(id int, body string) {
+	w.objs[id-1] = body
+}
+
+func (w *pdfObjects) bytes(root, info int) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(w.objs))
+	for i, body := range w.objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(w.objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.objs)+1, root, info, xref)
+	return b.Bytes()
+}
+
+func deflate(p []byte) ([]byte, error) {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	if _, err := zw.Write(p); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// utf16Text 文档信息字典中的 UTF-16BE 文本
+func utf16Text(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+func hexColor(s string) rgb {
+	var r, g, b uint8
+	_, _ = fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b)
+	return rgb{float64(r) / 255, float64(g) / 255, float64(b) / 255}
+}
+
+// renderPDF 渲染 PDF 报告，内容与 HTML 报告一致
+func renderPDF(r *Report) ([]byte, error) {
+	l := labelsFor(r.Interview.Language)
+	iv := r.Interview
+	title := reportTitle(iv.Title, l)
+
+	var sample strings.Builder
+	sample.WriteString(title)
+	for _, m := range r.Messages {
+		sample.WriteString(m.Content)
+	}
+	d := newPDFDoc(pdfFontFor(iv.Language, sample.String()))
+
+	d.paragraph(title, 18, 0, colorTitle)
+	d.space(4)
+	for _, kv := range [][2]string{
+		{l.Position, orDash(iv.Position)},
+		{l.Mode, l.mode(iv.Mode)},
+		{l.Status, l.status(iv.Status)},
+		{l.CreatedAt, l.date(iv.CreatedAt)},
+	} {
+		d.paragraph(kv[0]+": "+kv[1], 10, 0, colorMuted)
+	}
+	d.rule()
+
+	d.heading(l.Evaluation, 14)
+	if e := r.Evaluation; e == nil {
+		d.paragraph(l.NoEvaluation, 10.5, 0, colorText)
+	} else {
+		pdfEvaluation(d, l, e)
+	}
+
+	if len(r.Submissions) > 0 {
+		d.heading(l.Coding, 14)
+		for i, s := range r.Submissions {
+			d.paragraph(fmt.Sprintf(l.Submission, i+1), 11.5, 0, colorText)
+			d.paragraph(fmt.Sprintf("%s: %s · %s: %s · %s: %d / %d",
+				l.Language, s.Language, l.Result, l.verdict(s.Status), l.Passed, s.Passed, s.Total), 9.5, 0, colorMuted)
+			d.space(2)
+			d.paragraph(strings.TrimRight(s.Code, "\n"), 9, 12, colorText)
+			d.space(6)
+		}
+	}
+
+	d.heading(l.Transcript, 14)
+	msgs := r.transcript()
+	if len(msgs) == 0 {
+		d.paragraph(l.NoMessages, 10.5, 0, colorText)
+	}
+	for _, m := range msgs {
+		who := r.who(l, m)
+		if t := l.clock(m.CreatedAt); t != "" {
+			who += "  " + t
+		}
+		color := colorMuted
+		if m.Role == "assistant" {
+			color = colorTitle
+		}
+		d.ensure(40)
+		d.paragraph(who, 9.5, 0, color)
+		d.paragraph(strings.TrimSpace(m.Content), 10.5, 12, colorText)
+		d.space(6)
+	}
+
+	return d.bytes(title, l.Page, r.ExportedAt)
+}
+
+func pdfEvaluation(d *pdfDoc, l *labels, e *biz.Evaluation) {
+	d.ensure(40)
+	d.y -= 30
+	score := fmt.Sprintf("%d", e.OverallScore)
+	d.textAt(pageMargin, d.y, 28, hexColor(scoreColor(e.OverallScore)), score)
+	d.textAt(pageMargin+d.font.textWidth(score, 28)+8, d.y, 11, colorMuted, "/ 100 · "+l.OverallScore)
+	d.space(10)
+	if e.Level > 0 {
+		d.paragraph(l.level(e.Level), 10.5, 0, colorMuted)
+		d.space(6)
+	}
+
+	if e.Summary != "" {
+		d.paragraph(e.Summary, 10.5, 0, colorText)
+		d.space(6)
+	}
+
+	for _, c := range e.Categories {
+		d.ensure(36)
+		d.y -= 16
+		d.textAt(pageMargin, d.y, 10.5, colorText, c.Category)
+		d.textAt(pageMargin+160, d.y, 10.5, colorText, fmt.Sprintf("%d", c.Score))
+		d.scoreBar(pageMargin+190, d.y+1, 120, c.Score)
+		if c.Comment != "" {
+			d.paragraph(c.Comment, 9.5, 12, colorMuted)
+		}
+	}
+
+	for _, s := range []struct{ title, text string }{
+		{l.Strengths, e.Strengths}, {l.Weaknesses, e.Weaknesses}, {l.Suggestions, e.Suggestions},
+	} {
+		if s.text != "" {
+			d.space(4)
+			d.paragraph(s.title, 11.5, 0, colorTitle)
+			d.paragraph(s.text, 10.5, 0, colorText)
+		}
+	}
+}
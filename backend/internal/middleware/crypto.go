@@ -5,75 +5,162 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 )
 
-// Encryptor API Key 加密工具 (AES-GCM)
+// 密文格式: v1:<keyID>:<hex(包裹后的数据密钥)>:<hex(nonce|密文)>
+//
+// 信封加密：每条密文使用随机生成的数据密钥 (DEK) 做 AES-256-GCM 加密，
+// DEK 再由 keyID 对应的主密钥 (KEK) 包裹。keyID 同时作为两次加密的附加数据，
+// 篡改 keyID 会导致解密失败。
+const ciphertextVersion = "v1"
+
+var (
+	// ErrMalformedCiphertext 密文不是合法的版本化格式
+	ErrMalformedCiphertext = errors.New("malformed ciphertext")
+	// ErrUnknownKeyID 密文使用的主密钥不在当前密钥环中
+	ErrUnknownKeyID = errors.New("unknown encryption key id")
+)
+
+// Encryptor API Key 加密工具：持有一组主密钥，新密文总是使用 primary 密钥
 type Encryptor struct {
-	key []byte
+	primary string
+	keys    map[string][]byte
 }
 
-// NewEncryptor 创建加密器，key 为 hex 编码的 32 字节密钥
-func NewEncryptor(hexKey string) (*Encryptor, error) {
-	key, err := hex.DecodeString(hexKey)
-	if err != nil {
-		return nil, fmt.Errorf("decode encryption key: %w", err)
+// NewEncryptor 由密钥集合创建加密器
+func NewEncryptor(set *KeySet) (*Encryptor, error) {
+	if err := set.validate(); err != nil {
+		return nil, err
 	}
-	if len(key) != 32 {
-		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	keys := make(map[string][]byte, len(set.Keys))
+	for id, key := range set.Keys {
+		keys[id] = key
 	}
-	return &Encryptor{key: key}, nil
+	return &Encryptor{primary: set.Primary, keys: keys}, nil
+}
+
+// PrimaryKeyID 返回加密新数据使用的主密钥 ID
+func (e *Encryptor) PrimaryKeyID() string {
+	return e.primary
 }
 
-// Encrypt 加密明文，返回 hex 编码的密文
+// Encrypt 使用 primary 主密钥加密明文
 func (e *Encryptor) Encrypt(plaintext string) (string, error) {
-	block, err := aes.NewCipher(e.key)
+	kek := e.keys[e.primary]
+	aad := []byte(ciphertextVersion + ":" + e.primary)
+
+	dek := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return "", err
+	}
+	wrapped, err := seal(kek, dek, aad)
+	if err != nil {
+		return "", fmt.Errorf("wrap data key: %w", err)
+	}
+	sealed, err := seal(dek, []byte(plaintext), aad)
 	if err != nil {
 		return "", err
 	}
 
-	aesGCM, err := cipher.NewGCM(block)
+	return strings.Join([]string{
+		ciphertextVersion, e.primary, hex.EncodeToString(wrapped), hex.EncodeToString(sealed),
+	}, ":"), nil
+}
+
+// Decrypt 解密版本化密文，keyID 不在密钥环中时返回 ErrUnknownKeyID
+func (e *Encryptor) Decrypt(ciphertext string) (string, error) {
+	keyID, wrapped, sealed, err := parseCiphertext(ciphertext)
 	if err != nil {
 		return "", err
 	}
+	kek, ok := e.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKeyID, keyID)
+	}
+	aad := []byte(ciphertextVersion + ":" + keyID)
 
-	nonce := make([]byte, aesGCM.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+	dek, err := open(kek, wrapped, aad)
+	if err != nil {
+		return "", fmt.Errorf("unwrap data key with %q: %w", keyID, err)
 	}
+	plaintext, err := open(dek, sealed, aad)
+	if err != nil {
+		return "", fmt.Errorf("decrypt with %q: %w", keyID, err)
+	}
+	return string(plaintext), nil
+}
 
-	ciphertext := aesGCM.Seal(nonce, nonce, []byte(plaintext), nil)
-	return hex.EncodeToString(ciphertext), nil
+// KeyID 返回密文使用的主密钥 ID (不解密)
+func KeyID(ciphertext string) (string, error) {
+	keyID, _, _, err := parseCiphertext(ciphertext)
+	return keyID, err
 }
 
-// Decrypt 解密 hex 编码的密文
-func (e *Encryptor) Decrypt(ciphertextHex string) (string, error) {
-	ciphertext, err := hex.DecodeString(ciphertextHex)
+// DecryptLegacy 解密旧版无 keyID 的 hex 密文，仅供 keys reencrypt 迁移使用
+func DecryptLegacy(hexKey, ciphertextHex string) (string, error) {
+	key, err := parseHexKey(hexKey)
 	if err != nil {
 		return "", err
 	}
-
-	block, err := aes.NewCipher(e.key)
+	ciphertext, err := hex.DecodeString(ciphertextHex)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMalformedCiphertext, err)
+	}
+	plaintext, err := open(key, ciphertext, nil)
 	if err != nil {
 		return "", err
 	}
+	return string(plaintext), nil
+}
+
+func parseCiphertext(s string) (keyID string, wrapped, sealed []byte, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 4 || parts[0] != ciphertextVersion || parts[1] == "" {
+		return "", nil, nil, ErrMalformedCiphertext
+	}
+	if wrapped, err = hex.DecodeString(parts[2]); err != nil {
+		return "", nil, nil, fmt.Errorf("%w: %v", ErrMalformedCiphertext, err)
+	}
+	if sealed, err = hex.DecodeString(parts[3]); err != nil {
+		return "", nil, nil, fmt.Errorf("%w: %v", ErrMalformedCiphertext, err)
+	}
+	return parts[1], wrapped, sealed, nil
+}
 
-	aesGCM, err := cipher.NewGCM(block)
+// seal AES-GCM 加密，输出 nonce|密文
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	aesGCM, err := newGCM(key)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	nonce := make([]byte, aesGCM.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
 	}
+	return aesGCM.Seal(nonce, nonce, plaintext, aad), nil
+}
 
+// open 解密 seal 的输出
+func open(key, data, aad []byte) ([]byte, error) {
+	aesGCM, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
 	nonceSize := aesGCM.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("%w: too short", ErrMalformedCiphertext)
 	}
+	return aesGCM.Open(nil, data[:nonceSize], data[nonceSize:], aad)
+}
 
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, nil)
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	return string(plaintext), nil
+	return cipher.NewGCM(block)
 }
@@ -1,16 +1,37 @@
 package middleware
 
 import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
-func TestEncryptor_EncryptDecrypt(t *testing.T) {
-	// 32 字节密钥 (AES-256)
-	// 64 hex chars = 32 bytes for AES-256
-	enc, err := NewEncryptor("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+const (
+	testKeyA = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	testKeyB = "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+)
+
+func newTestEncryptor(t *testing.T, spec string) *Encryptor {
+	t.Helper()
+	set, err := NewEnvKeySource(spec).Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load(%q) error: %v", spec, err)
+	}
+	enc, err := NewEncryptor(set)
 	if err != nil {
 		t.Fatalf("NewEncryptor error: %v", err)
 	}
+	return enc
+}
+
+func TestEncryptor_EncryptDecrypt(t *testing.T) {
+	enc := newTestEncryptor(t, testKeyA)
 
 	plaintext := "sk-my-secret-api-key-12345"
 
@@ -19,8 +40,8 @@ func TestEncryptor_EncryptDecrypt(t *testing.T) {
 		t.Fatalf("Encrypt error: %v", err)
 	}
 
-	if encrypted == plaintext {
-		t.Error("encrypted should not equal plaintext")
+	if !strings.HasPrefix(encrypted, "v1:default:") {
+		t.Errorf("ciphertext should carry version and key id, got %q", encrypted)
 	}
 
 	decrypted, err := enc.Decrypt(encrypted)
@@ -34,26 +55,26 @@ func TestEncryptor_EncryptDecrypt(t *testing.T) {
 }
 
 func TestEncryptor_DifferentCiphertexts(t *testing.T) {
-	enc, _ := NewEncryptor("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	enc := newTestEncryptor(t, testKeyA)
 
 	a, _ := enc.Encrypt("hello")
 	b, _ := enc.Encrypt("hello")
 
-	// AES-GCM 使用随机 nonce，相同明文应产生不同密文
+	// 每次加密使用新的数据密钥和 nonce，相同明文应产生不同密文
 	if a == b {
 		t.Error("two encryptions of same plaintext should produce different ciphertexts")
 	}
 }
 
 func TestEncryptor_InvalidKey(t *testing.T) {
-	_, err := NewEncryptor("0123456789abcdef") // only 8 bytes
+	_, err := NewEnvKeySource("0123456789abcdef").Load(context.Background()) // only 8 bytes
 	if err == nil {
 		t.Error("expected error for short key")
 	}
 }
 
 func TestEncryptor_EmptyString(t *testing.T) {
-	enc, _ := NewEncryptor("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	enc := newTestEncryptor(t, testKeyA)
 
 	encrypted, err := enc.Encrypt("")
 	if err != nil {
@@ -69,3 +90,127 @@ func TestEncryptor_EmptyString(t *testing.T) {
 		t.Errorf("expected empty string, got %q", decrypted)
 	}
 }
+
+func TestEncryptor_Rotation(t *testing.T) {
+	old := newTestEncryptor(t, "k1:"+testKeyA)
+	encrypted, _ := old.Encrypt("sk-old")
+
+	// 新密钥为 primary，旧密钥仍可解密
+	rotated := newTestEncryptor(t, "k2:"+testKeyB+",k1:"+testKeyA)
+	if rotated.PrimaryKeyID() != "k2" {
+		t.Errorf("primary = %q, want k2", rotated.PrimaryKeyID())
+	}
+	if got, err := rotated.Decrypt(encrypted); err != nil || got != "sk-old" {
+		t.Errorf("Decrypt with rotated keyring = %q, %v", got, err)
+	}
+	fresh, _ := rotated.Encrypt("sk-new")
+	if id, _ := KeyID(fresh); id != "k2" {
+		t.Errorf("new ciphertext key id = %q, want k2", id)
+	}
+
+	// 旧密钥移除后返回明确错误
+	onlyNew := newTestEncryptor(t, "k2:"+testKeyB)
+	if _, err := onlyNew.Decrypt(encrypted); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("expected ErrUnknownKeyID, got %v", err)
+	}
+}
+
+func TestEncryptor_TamperedKeyID(t *testing.T) {
+	// 两个 ID 对应同一密钥，仅替换 keyID 也必须解密失败
+	enc := newTestEncryptor(t, "a:"+testKeyA+",b:"+testKeyA)
+	encrypted, _ := enc.Encrypt("sk-secret")
+	tampered := strings.Replace(encrypted, "v1:a:", "v1:b:", 1)
+	if _, err := enc.Decrypt(tampered); err == nil {
+		t.Error("decrypt should fail when key id is tampered")
+	}
+}
+
+func TestEncryptor_Malformed(t *testing.T) {
+	enc := newTestEncryptor(t, testKeyA)
+	for _, s := range []string{"sk-plaintext", "abcdef0123", "v1:default:zz:00", "v2:default:00:00", "v1::00:00"} {
+		if _, err := enc.Decrypt(s); !errors.Is(err, ErrMalformedCiphertext) {
+			t.Errorf("Decrypt(%q) error = %v, want ErrMalformedCiphertext", s, err)
+		}
+	}
+}
+
+func TestDecryptLegacy(t *testing.T) {
+	// 旧格式: hex(nonce|密文)，无附加数据
+	key, _ := hex.DecodeString(testKeyA)
+	block, _ := aes.NewCipher(key)
+	aesGCM, _ := cipher.NewGCM(block)
+	nonce := make([]byte, aesGCM.NonceSize())
+	legacy := hex.EncodeToString(aesGCM.Seal(nonce, nonce, []byte("sk-legacy"), nil))
+
+	got, err := DecryptLegacy(testKeyA, legacy)
+	if err != nil || got != "sk-legacy" {
+		t.Errorf("DecryptLegacy = %q, %v", got, err)
+	}
+	if _, err := DecryptLegacy(testKeyB, legacy); err == nil {
+		t.Error("DecryptLegacy with wrong key should fail")
+	}
+	if _, err := DecryptLegacy(testKeyA, "sk-plaintext"); !errors.Is(err, ErrMalformedCiphertext) {
+		t.Errorf("expected ErrMalformedCiphertext for non-hex value, got %v", err)
+	}
+}
+
+func TestEnvKeySource_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"k1:" + testKeyA + ",k1:" + testKeyB, // 重复 ID
+		"k1:" + testKeyA + "," + testKeyB,    // 缺少 ID
+		"bad id:" + testKeyA,                 // 非法 ID
+		"k1:abcd",                            // 密钥长度错误
+	} {
+		if _, err := NewEnvKeySource(spec).Load(context.Background()); err == nil {
+			t.Errorf("Load(%q) should fail", spec)
+		}
+	}
+}
+
+func TestFileKeySource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	content := `{"primary": "2026-10", "keys": {"2026-10": "` + testKeyB + `", "2026-01": "` + testKeyA + `"}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := NewFileKeySource(path).Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if set.Primary != "2026-10" || len(set.Keys) != 2 {
+		t.Errorf("unexpected key set: primary=%q keys=%d", set.Primary, len(set.Keys))
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	_ = os.WriteFile(bad, []byte(`{"primary": "missing", "keys": {"k1": "`+testKeyA+`"}}`), 0o600)
+	if _, err := NewFileKeySource(bad).Load(context.Background()); err == nil {
+		t.Error("expected error when primary key is not in key set")
+	}
+}
+
+func TestLocalKMSKeySource(t *testing.T) {
+	v1, err := NewLocalKMSKeySource(testKeyA, []string{"v1"}).Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	v2, err := NewLocalKMSKeySource(testKeyA, []string{"v2", "v1"}).Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	// 同一 master key 派生的同 ID 密钥稳定，不同 ID 互不相同
+	if hex.EncodeToString(v1.Keys["v1"]) != hex.EncodeToString(v2.Keys["v1"]) {
+		t.Error("derived key should be stable for the same id")
+	}
+	if hex.EncodeToString(v2.Keys["v1"]) == hex.EncodeToString(v2.Keys["v2"]) {
+		t.Error("different ids should derive different keys")
+	}
+	if v2.Primary != "v2" {
+		t.Errorf("primary = %q, want v2", v2.Primary)
+	}
+
+	if _, err := NewLocalKMSKeySource(testKeyA, nil).Load(context.Background()); err == nil {
+		t.Error("expected error for empty key_ids")
+	}
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"ai-interview/internal/conf"
+)
+
+// defaultKeyID 是 ENCRYPTION_KEY 只给出单个 hex 密钥时使用的密钥 ID
+const defaultKeyID = "default"
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// KeySet 一组主密钥：Primary 用于加密新数据，其余密钥仅用于解密
+type KeySet struct {
+	Primary string
+	Keys    map[string][]byte
+}
+
+func (s *KeySet) validate() error {
+	if s == nil || len(s.Keys) == 0 {
+		return errors.New("key set is empty")
+	}
+	for id, key := range s.Keys {
+		if !keyIDPattern.MatchString(id) {
+			return fmt.Errorf("invalid key id %q: only letters, digits, '.', '_' and '-' allowed", id)
+		}
+		if len(key) != 32 {
+			return fmt.Errorf("key %q must be 32 bytes, got %d", id, len(key))
+		}
+	}
+	if _, ok := s.Keys[s.Primary]; !ok {
+		return fmt.Errorf("primary key %q not in key set", s.Primary)
+	}
+	return nil
+}
+
+// KeySource 提供主密钥集合 (env / file / localkms)
+type KeySource interface {
+	Name() string
+	Load(ctx context.Context) (*KeySet, error)
+}
+
+// NewKeySource 按配置创建密钥来源，未配置任何密钥时返回 nil
+func NewKeySource(c *conf.Auth) (KeySource, error) {
+	ks := c.KeySource
+	if ks == nil {
+		ks = &conf.Auth_KeySource{}
+	}
+	switch ks.Type {
+	case "", "env":
+		if c.EncryptionKey == "" {
+			return nil, nil
+		}
+		return NewEnvKeySource(c.EncryptionKey), nil
+	case "file":
+		if ks.File == "" {
+			return nil, errors.New("key_source.file is required for file key source")
+		}
+		return NewFileKeySource(ks.File), nil
+	case "localkms":
+		return NewLocalKMSKeySource(ks.MasterKey, ks.KeyIds), nil
+	default:
+		return nil, fmt.Errorf("unknown key source type %q", ks.Type)
+	}
+}
+
+// EnvKeySource 从环境变量 ENCRYPTION_KEY 解析密钥：
+// "id1:hex,id2:hex" (第一个为 primary)，或单个 hex 密钥 (ID 为 "default")
+type EnvKeySource struct {
+	spec string
+}
+
+func NewEnvKeySource(spec string) *EnvKeySource {
+	return &EnvKeySource{spec: spec}
+}
+
+func (s *EnvKeySource) Name() string {
+	return "env"
+}
+
+func (s *EnvKeySource) Load(_ context.Context) (*KeySet, error) {
+	spec := strings.TrimSpace(s.spec)
+	if !strings.Contains(spec, ":") {
+		key, err := parseHexKey(spec)
+		if err != nil {
+			return nil, err
+		}
+		return &KeySet{Primary: defaultKeyID, Keys: map[string][]byte{defaultKeyID: key}}, nil
+	}
+
+	set := &KeySet{Keys: make(map[string][]byte)}
+	for i, entry := range strings.Split(spec, ",") {
+		id, hexKey, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid key entry #%d: want id:hex", i+1)
+		}
+		if _, dup := set.Keys[id]; dup {
+			return nil, fmt.Errorf("duplicate key id %q", id)
+		}
+		key, err := parseHexKey(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		if i == 0 {
+			set.Primary = id
+		}
+		set.Keys[id] = key
+	}
+	return set, set.validate()
+}
+
+// FileKeySource 从 JSON 文件读取密钥，便于挂载 Kubernetes Secret：
+//
+//	{"primary": "2026-10", "keys": {"2026-10": "<hex>", "2026-01": "<hex>"}}
+type FileKeySource struct {
+	path string
+}
+
+func NewFileKeySource(path string) *FileKeySource {
+	return &FileKeySource{path: path}
+}
+
+func (s *FileKeySource) Name() string {
+	return "file"
+}
+
+func (s *FileKeySource) Load(_ context.Context) (*KeySet, error) {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	var f struct {
+		Primary string            `json:"primary"`
+		Keys    map[string]string `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("parse key file %s: %w", s.path, err)
+	}
+
+	set := &KeySet{Primary: f.Primary, Keys: make(map[string][]byte, len(f.Keys))}
+	for id, hexKey := range f.Keys {
+		key, err := parseHexKey(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		set.Keys[id] = key
+	}
+	return set, set.validate()
+}
+
+// LocalKMSKeySource 是 KMS 的本地替身：各版本主密钥由 master key 经 HKDF 按 key ID 派生，
+// 只需保管一个 master key (环境变量 LOCAL_KMS_MASTER_KEY)，轮换时在 key_ids 头部追加新 ID。
+type LocalKMSKeySource struct {
+	masterKey string
+	keyIDs    []string
+}
+
+func NewLocalKMSKeySource(masterKey string, keyIDs []string) *LocalKMSKeySource {
+	return &LocalKMSKeySource{masterKey: masterKey, keyIDs: keyIDs}
+}
+
+func (s *LocalKMSKeySource) Name() string {
+	return "localkms"
+}
+
+func (s *LocalKMSKeySource) Load(_ context.Context) (*KeySet, error) {
+	master, err := parseHexKey(s.masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("local kms master key: %w", err)
+	}
+	if len(s.keyIDs) == 0 {
+		return nil, errors.New("local kms: key_ids is empty")
+	}
+
+	set := &KeySet{Primary: s.keyIDs[0], Keys: make(map[string][]byte, len(s.keyIDs))}
+	for _, id := range s.keyIDs {
+		key, err := hkdf.Key(sha256.New, master, nil, "ai-interview/kek/"+id, 32)
+		if err != nil {
+			return nil, fmt.Errorf("derive key %q: %w", id, err)
+		}
+		set.Keys[id] = key
+	}
+	return set, set.validate()
+}
+
+// parseHexKey 解析 hex 编码的 32 字节密钥
+func parseHexKey(hexKey string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(hexKey))
+	if err != nil {
+		return nil, fmt.Errorf("decode encryption key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
@@ -3,11 +3,14 @@ package llm
 import (
 	"bufio"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+
+	"ai-interview/internal/provider/resilience"
 )
 
 // AnthropicProvider 实现 Anthropic Claude LLM
@@ -31,21 +34,99 @@ type anthropicRequest struct {
 	Model     string             `json:"model"`
 	MaxTokens int                `json:"max_tokens"`
 	Messages  []anthropicMessage `json:"messages"`
-	System    string             `json:"system,omitempty"`
+	System    []anthropicBlock   `json:"system,omitempty"`
 	Stream    bool               `json:"stream"`
+	Thinking  *anthropicThinking `json:"thinking,omitempty"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+	// ToolChoice 只用于结构化输出，强制调用输出工具
+	ToolChoice *anthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// anthropicThinking 扩展思考设置，budget_tokens 计入 max_tokens
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
 }
 
+// anthropicMessage 的 Content 为字符串，或工具调用相关的 []anthropicBlock
 type anthropicMessage struct {
 	Role    string `json:"role"`
-	Content string `json:"content"`
+	Content any    `json:"content"`
+}
+
+type anthropicBlock struct {
+	Type      string          `json:"type"` // text, image, tool_use, tool_result
+	Text      string          `json:"text,omitempty"`
+	Source    *anthropicImage `json:"source,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	// CacheControl 缓存断点：请求从开头到该块为止的前缀写入缓存，之后以相同前缀开头的请求命中
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+type anthropicCacheControl struct {
+	Type string `json:"type"` // ephemeral
+}
+
+// anthropicUsage 用量，input_tokens 不含命中与写入缓存的部分
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+}
+
+// anthropicImage 图片来源：内联的 base64 数据或 URL
+type anthropicImage struct {
+	Type      string `json:"type"` // base64, url
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
 }
 
 type anthropicStreamEvent struct {
-	Type  string `json:"type"`
-	Delta *struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message *struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message,omitempty"` // message_start
+	Usage        *anthropicUsage `json:"usage,omitempty"` // message_delta，只有 output_tokens 是累计值
+	ContentBlock *struct {
 		Type string `json:"type"`
-		Text string `json:"text"`
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"content_block,omitempty"`
+	Delta *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		PartialJSON string `json:"partial_json"`
 	} `json:"delta,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// anthropicErrorStatus 流内错误事件对应的 HTTP 状态，用于判断能否重试
+var anthropicErrorStatus = map[string]int{
+	"rate_limit_error": http.StatusTooManyRequests,
+	"api_error":        http.StatusInternalServerError,
+	"overloaded_error": 529,
 }
 
 func (p *AnthropicProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamEvent, error) {
@@ -58,19 +139,7 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req *ChatRequest) (<
 		baseURL = req.BaseURL
 	}
 
-	// 分离 system 消息和对话消息
-	var systemPrompt string
-	messages := make([]anthropicMessage, 0, len(req.Messages))
-	for _, m := range req.Messages {
-		if m.Role == "system" {
-			systemPrompt = m.Content
-			continue
-		}
-		messages = append(messages, anthropicMessage{
-			Role:    m.Role,
-			Content: m.Content,
-		})
-	}
+	system, messages := anthropicMessages(req.Messages)
 
 	model := req.Model
 	if model == "" {
@@ -86,9 +155,29 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req *ChatRequest) (<
 		Model:     model,
 		MaxTokens: maxTokens,
 		Messages:  messages,
-		System:    systemPrompt,
+		System:    system,
 		Stream:    true,
 	}
+	for _, tool := range req.Tools {
+		apiReq.Tools = append(apiReq.Tools, anthropicTool{Name: tool.Name, Description: tool.Description, InputSchema: tool.Parameters})
+	}
+	// 结构化输出：有 Schema 时强制调用同名工具，工具参数即输出；否则预填 "{" 让回答从 JSON 对象开始
+	var formatTool, prefill string
+	if f := req.ResponseFormat; f != nil {
+		if f.Type == FormatJSONSchema && len(f.Schema) > 0 {
+			formatTool = f.Name
+			apiReq.Tools = append(apiReq.Tools, anthropicTool{Name: f.Name, Description: "Respond with the result.", InputSchema: f.Schema})
+			apiReq.ToolChoice = &anthropicToolChoice{Type: "tool", Name: f.Name}
+		} else {
+			prefill = "{"
+			apiReq.Messages = append(apiReq.Messages, anthropicMessage{Role: "assistant", Content: prefill})
+		}
+	}
+	// 强制调用工具与预填都不能与扩展思考同时使用
+	if budget := req.Reasoning.budget(); budget > 0 && req.ResponseFormat == nil {
+		apiReq.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: budget}
+		apiReq.MaxTokens = maxTokens + budget
+	}
 
 	body, err := json.Marshal(apiReq)
 	if err != nil {
@@ -112,7 +201,7 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req *ChatRequest) (<
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("anthropic llm: status %d: %s", resp.StatusCode, string(respBody))
+		return nil, &resilience.StatusError{Op: "anthropic llm", Code: resp.StatusCode, Body: string(respBody)}
 	}
 
 	ch := make(chan StreamEvent, 32)
@@ -120,6 +209,11 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req *ChatRequest) (<
 		defer close(ch)
 		defer resp.Body.Close()
 
+		if prefill != "" {
+			ch <- StreamEvent{Content: prefill}
+		}
+		formatIndex := -1 // 输出工具所在的内容块
+		var usage *Usage
 		scanner := bufio.NewScanner(resp.Body)
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -139,15 +233,50 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req *ChatRequest) (<
 			}
 
 			switch event.Type {
+			case "message_start":
+				if event.Message != nil {
+					u := event.Message.Usage
+					usage = &Usage{
+						InputTokens:      u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens,
+						CachedTokens:     u.CacheReadInputTokens,
+						CacheWriteTokens: u.CacheCreationInputTokens,
+						OutputTokens:     u.OutputTokens,
+					}
+				}
+			case "message_delta":
+				if usage != nil && event.Usage != nil {
+					usage.OutputTokens = event.Usage.OutputTokens
+				}
+			case "content_block_start":
+				b := event.ContentBlock
+				if b == nil || b.Type != "tool_use" {
+					continue
+				}
+				if formatTool != "" && b.Name == formatTool {
+					formatIndex = event.Index
+					continue
+				}
+				ch <- StreamEvent{ToolCall: &ToolCall{Index: event.Index, ID: b.ID, Name: b.Name}}
 			case "content_block_delta":
-				if event.Delta != nil && event.Delta.Text != "" {
+				// thinking_delta 是扩展思考的内容，signature_delta 只用于校验，忽略
+				if event.Delta == nil {
+					continue
+				}
+				switch {
+				case event.Delta.Type == "thinking_delta" && event.Delta.Thinking != "":
+					ch <- StreamEvent{Reasoning: event.Delta.Thinking}
+				case event.Delta.Type == "input_json_delta" && event.Index == formatIndex:
+					ch <- StreamEvent{Content: event.Delta.PartialJSON}
+				case event.Delta.Type == "input_json_delta" && event.Delta.PartialJSON != "":
+					ch <- StreamEvent{ToolCall: &ToolCall{Index: event.Index, Arguments: event.Delta.PartialJSON}}
+				case event.Delta.Text != "":
 					ch <- StreamEvent{Content: event.Delta.Text}
 				}
 			case "message_stop":
-				ch <- StreamEvent{Done: true}
+				ch <- StreamEvent{Usage: usage, Done: true}
 				return
 			case "error":
-				ch <- StreamEvent{Err: fmt.Errorf("anthropic llm: stream error")}
+				ch <- StreamEvent{Err: anthropicStreamError(event)}
 				return
 			}
 		}
@@ -159,3 +288,122 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req *ChatRequest) (<
 
 	return ch, nil
 }
+
+// anthropicMessages 分离 system 消息，并把工具调用转换为内容块：assistant 的调用为 tool_use，
+// 连续的 tool 消息合并为一条带 tool_result 的 user 消息。
+// 系统提示与对话的末尾各设一个缓存断点，下一轮请求以相同的系统提示与历史开头，可以命中缓存；
+// 对话之后的 system 消息是本轮的要求，放在断点之后，作为文本块附在最后一条 user 消息中
+func anthropicMessages(in []Message) ([]anthropicBlock, []anthropicMessage) {
+	var system []anthropicBlock
+	messages := make([]anthropicMessage, 0, len(in))
+	cached := false
+	for _, m := range in {
+		switch {
+		case m.Role == "system" && len(messages) == 0:
+			if m.Content != "" {
+				system = append(system, anthropicBlock{Type: "text", Text: m.Content})
+			}
+		case m.Role == "system":
+			cached = cached || anthropicCache(messages)
+			messages = appendUserBlock(messages, anthropicBlock{Type: "text", Text: "<instructions>\n" + m.Content + "\n</instructions>"})
+		case m.Role == "tool":
+			messages = appendUserBlock(messages, anthropicBlock{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content})
+		case len(m.ToolCalls) > 0:
+			var blocks []anthropicBlock
+			if m.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: m.Content})
+			}
+			for _, call := range m.ToolCalls {
+				input := json.RawMessage(call.Arguments)
+				if len(input) == 0 {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+			}
+			messages = append(messages, anthropicMessage{Role: m.Role, Content: blocks})
+		case len(m.Parts) > 0:
+			messages = append(messages, anthropicMessage{Role: m.Role, Content: anthropicParts(m.Parts)})
+		default:
+			messages = append(messages, anthropicMessage{Role: m.Role, Content: m.Content})
+		}
+	}
+	if n := len(system); n > 0 {
+		system[n-1].CacheControl = &anthropicCacheControl{Type: "ephemeral"}
+	}
+	if !cached {
+		anthropicCache(messages)
+	}
+	return system, messages
+}
+
+// anthropicCache 在最后一条消息的最后一个内容块上设置缓存断点，字符串内容先转换为文本块；
+// 没有可设置的内容块时返回 false
+func anthropicCache(messages []anthropicMessage) bool {
+	n := len(messages)
+	if n == 0 {
+		return false
+	}
+	blocks := anthropicBlocks(messages[n-1].Content)
+	if len(blocks) == 0 {
+		return false
+	}
+	blocks[len(blocks)-1].CacheControl = &anthropicCacheControl{Type: "ephemeral"}
+	messages[n-1].Content = blocks
+	return true
+}
+
+// appendUserBlock 把 block 加到最后一条 user 消息中，最后一条不是 user 消息时新建一条
+func appendUserBlock(messages []anthropicMessage, block anthropicBlock) []anthropicMessage {
+	if n := len(messages); n > 0 && messages[n-1].Role == "user" {
+		messages[n-1].Content = append(anthropicBlocks(messages[n-1].Content), block)
+		return messages
+	}
+	return append(messages, anthropicMessage{Role: "user", Content: []anthropicBlock{block}})
+}
+
+// anthropicBlocks 返回消息内容的内容块，字符串内容转换为一个文本块 (空字符串没有内容块)
+func anthropicBlocks(content any) []anthropicBlock {
+	switch c := content.(type) {
+	case []anthropicBlock:
+		return c
+	case string:
+		if c != "" {
+			return []anthropicBlock{{Type: "text", Text: c}}
+		}
+	}
+	return nil
+}
+
+// anthropicParts 把多模态内容转换为 text / image 内容块
+func anthropicParts(parts []ContentPart) []anthropicBlock {
+	blocks := make([]anthropicBlock, 0, len(parts))
+	for _, part := range parts {
+		if part.Image == nil && part.Text == "" {
+			continue
+		}
+		img := part.Image
+		switch {
+		case img == nil:
+			blocks = append(blocks, anthropicBlock{Type: "text", Text: part.Text})
+		case img.URL != "":
+			blocks = append(blocks, anthropicBlock{Type: "image", Source: &anthropicImage{Type: "url", URL: img.URL}})
+		default:
+			blocks = append(blocks, anthropicBlock{Type: "image", Source: &anthropicImage{
+				Type:      "base64",
+				MediaType: img.MIMEType,
+				Data:      base64.StdEncoding.EncodeToString(img.Data),
+			}})
+		}
+	}
+	return blocks
+}
+
+func anthropicStreamError(event anthropicStreamEvent) error {
+	if event.Error == nil {
+		return fmt.Errorf("anthropic llm: stream error")
+	}
+	if code, ok := anthropicErrorStatus[event.Error.Type]; ok {
+		return &resilience.StatusError{Op: "anthropic llm", Code: code, Body: event.Error.Message}
+	}
+	return fmt.Errorf("anthropic llm: stream error: %s: %s", event.Error.Type, event.Error.Message)
+}
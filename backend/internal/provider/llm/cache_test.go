@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+// finalEvent 读取流直到 Done，返回 Done 事件
+func finalEvent(t *testing.T, stream <-chan StreamEvent) StreamEvent {
+	t.Helper()
+	for ev := range stream {
+		if ev.Err != nil {
+			t.Fatalf("stream error: %v", ev.Err)
+		}
+		if ev.Done {
+			return ev
+		}
+	}
+	t.Fatal("stream closed without Done")
+	return StreamEvent{}
+}
+
+// cacheControl 返回内容块的缓存设置
+func cacheControl(block any) any {
+	return block.(map[string]any)["cache_control"]
+}
+
+func TestAnthropicProvider_Cache(t *testing.T) {
+	var body map[string]any
+	srv := sseServer(t, &body,
+		`{"type":"message_start","message":{"usage":{"input_tokens":20,"cache_creation_input_tokens":100,"cache_read_input_tokens":3000,"output_tokens":1}}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Next question."}}`,
+		`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":42}}`,
+		`{"type":"message_stop"}`,
+	)
+	stream, err := NewAnthropicProvider().ChatStream(context.Background(), &ChatRequest{
+		Messages: []Message{
+			{Role: "system", Content: "You are an interviewer. Resume: ..."},
+			{Role: "assistant", Content: "Tell me about goroutines."},
+			{Role: "user", Content: "They are lightweight threads."},
+			{Role: "system", Content: "The next question should be at level 4."},
+		},
+		APIKey:  "k",
+		BaseURL: srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+
+	usage := finalEvent(t, stream).Usage
+	if usage == nil || *usage != (Usage{InputTokens: 3120, CachedTokens: 3000, CacheWriteTokens: 100, OutputTokens: 42}) {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+
+	system, _ := body["system"].([]any)
+	if len(system) != 1 || cacheControl(system[0]) == nil {
+		t.Errorf("system prompt should be a cached block: %v", body["system"])
+	}
+	messages, _ := body["messages"].([]any)
+	if len(messages) != 2 {
+		t.Fatalf("turn instructions should be merged into the last user message: %v", messages)
+	}
+	if content, ok := messages[0].(map[string]any)["content"].(string); !ok || content != "Tell me about goroutines." {
+		t.Errorf("history before the breakpoint should be unchanged: %v", messages[0])
+	}
+	blocks := contentOf(t, map[string]any{"messages": messages[1:]})
+	if len(blocks) != 2 || cacheControl(blocks[0]) == nil || cacheControl(blocks[1]) != nil {
+		t.Errorf("breakpoint should end at the history, before the turn instructions: %v", blocks)
+	}
+}
+
+func TestOpenAIProvider_Usage(t *testing.T) {
+	tests := []struct {
+		provider *OpenAIProvider
+		usage    bool
+	}{
+		{NewOpenAIProvider(), true},
+		{NewCustomProvider(CustomConfig{Name: "local"}), false},
+	}
+	for _, tt := range tests {
+		var body map[string]any
+		srv := sseServer(t, &body,
+			`{"choices":[{"index":0,"delta":{"content":"Hi"}}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":2048,"completion_tokens":5,"prompt_tokens_details":{"cached_tokens":1920}}}`,
+			`[DONE]`,
+		)
+		stream, err := tt.provider.ChatStream(context.Background(), &ChatRequest{
+			Messages: []Message{{Role: "user", Content: "hi"}}, APIKey: "k", BaseURL: srv.URL,
+		})
+		if err != nil {
+			t.Fatalf("ChatStream: %v", err)
+		}
+		usage := finalEvent(t, stream).Usage
+		if usage == nil || *usage != (Usage{InputTokens: 2048, CachedTokens: 1920, OutputTokens: 5}) {
+			t.Errorf("%s: unexpected usage: %+v", tt.provider.Name(), usage)
+		}
+		if _, ok := body["stream_options"]; ok != tt.usage {
+			t.Errorf("%s: stream_options sent = %v, want %v", tt.provider.Name(), ok, tt.usage)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// imageTokens 每张图片的估算 token 数 (约 1000x1000 像素)，只用于上下文预算
+const imageTokens = 1600
+
+// ContentPart 是多模态消息的一段内容：文本或图片
+type ContentPart struct {
+	Text  string
+	Image *Image
+}
+
+// Image 是消息中的图片，Data 与 URL 二选一
+type Image struct {
+	MIMEType string // image/png, image/jpeg, image/gif, image/webp
+	Data     []byte
+	URL      string
+}
+
+// TextPart 创建文本内容
+func TextPart(text string) ContentPart {
+	return ContentPart{Text: text}
+}
+
+// ImagePart 创建图片内容
+func ImagePart(img Image) ContentPart {
+	return ContentPart{Image: &img}
+}
+
+// Text 返回消息的文本内容，多模态消息为各文本段按顺序拼接
+func (m Message) Text() string {
+	if len(m.Parts) == 0 {
+		return m.Content
+	}
+	var sb strings.Builder
+	for _, part := range m.Parts {
+		sb.WriteString(part.Text)
+	}
+	return sb.String()
+}
+
+// images 返回消息中的图片数
+func (m Message) images() int {
+	n := 0
+	for _, part := range m.Parts {
+		if part.Image != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// url 返回图片的 URL，内联的图片为 data URL
+func (img *Image) url() string {
+	if img.URL != "" {
+		return img.URL
+	}
+	return "data:" + img.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+}
@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+var whiteboard = []Message{{Role: "user", Parts: []ContentPart{
+	TextPart("Here is my design."),
+	ImagePart(Image{MIMEType: "image/png", Data: []byte("png")}),
+	ImagePart(Image{URL: "https://example.com/diagram.jpg"}),
+}}}
+
+func TestMessage_Text(t *testing.T) {
+	if got := whiteboard[0].Text(); got != "Here is my design." {
+		t.Errorf("Text() = %q", got)
+	}
+	if got := (Message{Content: "plain"}).Text(); got != "plain" {
+		t.Errorf("Text() = %q", got)
+	}
+	text := EstimateMessages([]Message{{Content: "Here is my design."}})
+	if got := EstimateMessages(whiteboard); got != text+2*imageTokens {
+		t.Errorf("EstimateMessages = %d, want %d", got, text+2*imageTokens)
+	}
+}
+
+// contentOf 取请求体中第一条消息的 content
+func contentOf(t *testing.T, body map[string]any) []any {
+	t.Helper()
+	messages, _ := body["messages"].([]any)
+	if len(messages) == 0 {
+		t.Fatalf("no messages in request: %v", body)
+	}
+	parts, ok := messages[0].(map[string]any)["content"].([]any)
+	if !ok {
+		t.Fatalf("content should be a list of parts: %v", messages[0])
+	}
+	return parts
+}
+
+func TestOpenAIProvider_Images(t *testing.T) {
+	tests := []struct {
+		provider *OpenAIProvider
+		image    string // 第二段内容的类型
+	}{
+		{NewOpenAIProvider(), "image_url"},
+		{NewDeepSeekProvider(), "text"},
+	}
+	for _, tt := range tests {
+		var body map[string]any
+		srv := sseServer(t, &body, `[DONE]`)
+		stream, err := tt.provider.ChatStream(context.Background(), &ChatRequest{Messages: whiteboard, APIKey: "k", BaseURL: srv.URL})
+		if err != nil {
+			t.Fatalf("ChatStream: %v", err)
+		}
+		readToolCalls(t, stream)
+
+		parts := contentOf(t, body)
+		if len(parts) != 3 || parts[0].(map[string]any)["text"] != "Here is my design." || parts[1].(map[string]any)["type"] != tt.image {
+			t.Fatalf("%s: unexpected parts %v", tt.provider.Name(), parts)
+		}
+		if tt.image == "image_url" {
+			url := parts[1].(map[string]any)["image_url"].(map[string]any)["url"]
+			if url != "data:image/png;base64,cG5n" {
+				t.Errorf("inline image should be a data URL, got %v", url)
+			}
+		}
+	}
+}
+
+func TestAnthropicProvider_Images(t *testing.T) {
+	var body map[string]any
+	srv := sseServer(t, &body, `{"type":"message_stop"}`)
+	stream, err := NewAnthropicProvider().ChatStream(context.Background(), &ChatRequest{Messages: whiteboard, APIKey: "k", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	readToolCalls(t, stream)
+
+	parts := contentOf(t, body)
+	if len(parts) != 3 {
+		t.Fatalf("unexpected blocks: %v", parts)
+	}
+	inline := parts[1].(map[string]any)["source"].(map[string]any)
+	if inline["type"] != "base64" || inline["media_type"] != "image/png" || inline["data"] != "cG5n" {
+		t.Errorf("unexpected inline image: %v", inline)
+	}
+	if remote := parts[2].(map[string]any)["source"].(map[string]any); remote["type"] != "url" {
+		t.Errorf("unexpected url image: %v", remote)
+	}
+}
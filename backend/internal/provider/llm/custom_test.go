@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// headerServer 返回 OpenAI 兼容的空回复，记录请求头与请求体
+func headerServer(t *testing.T, header *http.Header, body *map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*header = r.Header.Clone()
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCustomProvider_Auth(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        CustomConfig
+		requestKey    string
+		authorization string
+		headers       map[string]string
+	}{
+		{"bearer", CustomConfig{}, "user-key", "Bearer user-key", nil},
+		{"system key", CustomConfig{APIKey: "system-key"}, "", "Bearer system-key", nil},
+		{"header", CustomConfig{Auth: AuthHeader, AuthHeader: "api-key"}, "user-key", "", map[string]string{"Api-Key": "user-key"}},
+		{"none", CustomConfig{Auth: AuthNone, Headers: map[string]string{"X-Tenant": "acme"}}, "", "", map[string]string{"X-Tenant": "acme"}},
+	}
+	for _, tt := range tests {
+		var header http.Header
+		var body map[string]any
+		srv := headerServer(t, &header, &body)
+		tt.config.Name, tt.config.BaseURL, tt.config.DefaultModel = "gateway", srv.URL, "qwen-max"
+
+		stream, err := NewCustomProvider(tt.config).ChatStream(context.Background(), &ChatRequest{
+			Messages: []Message{{Role: "user", Content: "hi"}}, APIKey: tt.requestKey,
+		})
+		if err != nil {
+			t.Fatalf("%s: ChatStream: %v", tt.name, err)
+		}
+		finalEvent(t, stream)
+
+		if got := header.Get("Authorization"); got != tt.authorization {
+			t.Errorf("%s: Authorization = %q, want %q", tt.name, got, tt.authorization)
+		}
+		for k, v := range tt.headers {
+			if got := header.Get(k); got != v {
+				t.Errorf("%s: %s = %q, want %q", tt.name, k, got, v)
+			}
+		}
+		if body["model"] != "qwen-max" {
+			t.Errorf("%s: default model not used: %v", tt.name, body["model"])
+		}
+	}
+
+	if _, err := NewCustomProvider(CustomConfig{Name: "gateway"}).ChatStream(context.Background(), &ChatRequest{}); err == nil {
+		t.Error("bearer auth without any key should fail")
+	}
+}
+
+func TestAzureProvider(t *testing.T) {
+	var r *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r = req.Clone(context.Background())
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	stream, err := NewAzureProvider("", "gpt-4o-prod").ChatStream(context.Background(), &ChatRequest{
+		Messages: []Message{{Role: "user", Content: "hi"}}, APIKey: "azure-key", BaseURL: srv.URL + "/",
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	finalEvent(t, stream)
+
+	if r.URL.Path != "/openai/deployments/gpt-4o-prod/chat/completions" {
+		t.Errorf("unexpected path %q", r.URL.Path)
+	}
+	if got := r.URL.Query().Get("api-version"); got != AzureAPIVersion {
+		t.Errorf("api-version = %q, want %q", got, AzureAPIVersion)
+	}
+	if r.Header.Get("api-key") != "azure-key" || r.Header.Get("Authorization") != "" {
+		t.Errorf("key should be sent in the api-key header: %v", r.Header)
+	}
+
+	if _, err := NewAzureProvider("", "gpt-4o-prod").ChatStream(context.Background(), &ChatRequest{APIKey: "k"}); err == nil {
+		t.Error("azure without endpoint should fail")
+	}
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ai-interview/internal/provider/resilience"
+)
+
+// Target 备用链中的一个候选：provider 及调用它使用的模型与凭据
+type Target struct {
+	Provider Provider
+	Model    string
+	APIKey   string
+	BaseURL  string
+}
+
+// key 熔断器 key：同一 provider 的不同端点分别熔断
+func (t Target) key() string {
+	return t.Provider.Name() + "|" + t.BaseURL
+}
+
+// Failover 按备用链依次调用多个 Provider。每个候选在输出首个 token 前遇到暂时性错误时退避重试，
+// 熔断中的候选直接跳过；已经输出内容后的错误原样返回，不再切换，避免重复输出。
+// 模型与凭据取自 Target，忽略请求中的 Model / APIKey / BaseURL。
+type Failover struct {
+	guard      *resilience.Guard
+	targets    []Target
+	onFailover func(from Target, err error)
+}
+
+// NewFailover 创建备用链；onFailover 在放弃某个候选时调用 (可为 nil)
+func NewFailover(guard *resilience.Guard, targets []Target, onFailover func(from Target, err error)) *Failover {
+	return &Failover{guard: guard, targets: targets, onFailover: onFailover}
+}
+
+// Name 返回首选 provider 的名称
+func (f *Failover) Name() string {
+	return f.targets[0].Provider.Name()
+}
+
+func (f *Failover) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamEvent, error) {
+	var errs []error
+	for _, target := range f.targets {
+		stream, err := f.start(ctx, target, req)
+		if err == nil {
+			return stream, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", target.Provider.Name(), err))
+		if f.onFailover != nil {
+			f.onFailover(target, err)
+		}
+	}
+	return nil, fmt.Errorf("all llm providers failed: %w", errors.Join(errs...))
+}
+
+// start 调用单个候选直到收到首个事件，首个事件之后的内容原样转发
+func (f *Failover) start(ctx context.Context, target Target, req *ChatRequest) (<-chan StreamEvent, error) {
+	r := *req
+	r.Model, r.APIKey, r.BaseURL = target.Model, target.APIKey, target.BaseURL
+
+	var stream <-chan StreamEvent
+	var first StreamEvent
+	err := f.guard.Do(ctx, target.key(), func() (bool, error) {
+		ch, err := target.Provider.ChatStream(ctx, &r)
+		if err != nil {
+			return false, err
+		}
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return false, errors.New("stream closed without events")
+			}
+			if ev.Err != nil {
+				return false, ev.Err
+			}
+			stream, first = ch, ev
+			return false, nil
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan StreamEvent, 32)
+	go func() {
+		defer close(out)
+		// 消费方停止读取 (取消轮次、断线) 后随 ctx 退出，并读空上游，让 provider 的 goroutine 也能结束
+		send := func(ev StreamEvent) bool {
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				for range stream {
+				}
+				return false
+			}
+		}
+		if !send(first) {
+			return
+		}
+		for ev := range stream {
+			if !send(ev) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
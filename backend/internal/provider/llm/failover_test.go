@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-interview/internal/provider/resilience"
+)
+
+// scriptedProvider 按调用次序返回预设的错误，之后输出 events
+type scriptedProvider struct {
+	name   string
+	errs   []error       // 第 i 次调用返回 errs[i]
+	events []StreamEvent // 调用成功时输出的事件
+	calls  int
+	last   *ChatRequest
+}
+
+func (p *scriptedProvider) Name() string { return p.name }
+
+func (p *scriptedProvider) ChatStream(_ context.Context, req *ChatRequest) (<-chan StreamEvent, error) {
+	p.calls++
+	p.last = req
+	if p.calls <= len(p.errs) && p.errs[p.calls-1] != nil {
+		return nil, p.errs[p.calls-1]
+	}
+	ch := make(chan StreamEvent, len(p.events))
+	for _, ev := range p.events {
+		ch <- ev
+	}
+	close(ch)
+	return ch, nil
+}
+
+// endlessProvider 像真实 provider 一样不带缓冲地持续输出，ctx 取消后发送错误并结束；done 在其 goroutine 退出时关闭
+type endlessProvider struct {
+	done chan struct{}
+}
+
+func (p *endlessProvider) Name() string { return "endless" }
+
+func (p *endlessProvider) ChatStream(ctx context.Context, _ *ChatRequest) (<-chan StreamEvent, error) {
+	ch := make(chan StreamEvent)
+	go func() {
+		defer close(p.done)
+		defer close(ch)
+		for ctx.Err() == nil {
+			ch <- StreamEvent{Content: "x"}
+		}
+		ch <- StreamEvent{Err: ctx.Err()}
+	}()
+	return ch, nil
+}
+
+func testGuard() *resilience.Guard {
+	return resilience.NewGuard(resilience.Config{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+}
+
+func readAll(t *testing.T, stream <-chan StreamEvent) (string, error) {
+	t.Helper()
+	var sb strings.Builder
+	for ev := range stream {
+		if ev.Err != nil {
+			return sb.String(), ev.Err
+		}
+		sb.WriteString(ev.Content)
+	}
+	return sb.String(), nil
+}
+
+func TestFailover_RetriesThenFallsBack(t *testing.T) {
+	overloaded := &resilience.StatusError{Op: "anthropic llm", Code: 529}
+	primary := &scriptedProvider{name: "anthropic", errs: []error{overloaded, overloaded}}
+	backup := &scriptedProvider{name: "openai", events: []StreamEvent{{Content: "你好"}, {Done: true}}}
+
+	var failed []string
+	f := NewFailover(testGuard(), []Target{
+		{Provider: primary, Model: "claude", APIKey: "user-key"},
+		{Provider: backup, Model: "gpt-4o", APIKey: "system-key"},
+	}, func(from Target, err error) { failed = append(failed, from.Provider.Name()) })
+
+	stream, err := f.ChatStream(context.Background(), &ChatRequest{Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("ChatStream error: %v", err)
+	}
+	if text, err := readAll(t, stream); err != nil || text != "你好" {
+		t.Fatalf("got %q, %v", text, err)
+	}
+	if primary.calls != 2 {
+		t.Errorf("primary should be retried once, got %d calls", primary.calls)
+	}
+	if backup.last.Model != "gpt-4o" || backup.last.APIKey != "system-key" {
+		t.Errorf("fallback should use its own model and key, got %+v", backup.last)
+	}
+	if len(failed) != 1 || failed[0] != "anthropic" {
+		t.Errorf("onFailover calls = %v", failed)
+	}
+}
+
+func TestFailover_StreamErrorBeforeFirstToken(t *testing.T) {
+	// 首个事件即为错误 (例如 Anthropic 流内 overloaded_error) 时仍可切换
+	primary := &scriptedProvider{name: "anthropic", events: []StreamEvent{{Err: &resilience.StatusError{Code: 529}}}}
+	backup := &scriptedProvider{name: "openai", events: []StreamEvent{{Content: "ok"}, {Done: true}}}
+	f := NewFailover(testGuard(), []Target{{Provider: primary}, {Provider: backup}}, nil)
+
+	stream, err := f.ChatStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("ChatStream error: %v", err)
+	}
+	if text, _ := readAll(t, stream); text != "ok" {
+		t.Fatalf("got %q", text)
+	}
+}
+
+func TestFailover_NoFailoverAfterFirstToken(t *testing.T) {
+	midStream := errors.New("connection reset")
+	primary := &scriptedProvider{name: "openai", events: []StreamEvent{{Content: "部分"}, {Err: midStream}}}
+	backup := &scriptedProvider{name: "deepseek", events: []StreamEvent{{Content: "重复"}, {Done: true}}}
+	f := NewFailover(testGuard(), []Target{{Provider: primary}, {Provider: backup}}, nil)
+
+	stream, err := f.ChatStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("ChatStream error: %v", err)
+	}
+	text, err := readAll(t, stream)
+	if text != "部分" || !errors.Is(err, midStream) {
+		t.Fatalf("got %q, %v", text, err)
+	}
+	if backup.calls != 0 {
+		t.Error("fallback must not be called once output has started")
+	}
+}
+
+func TestFailover_AllFail(t *testing.T) {
+	auth := &resilience.StatusError{Op: "openai llm", Code: 401}
+	primary := &scriptedProvider{name: "openai", errs: []error{auth}}
+	backup := &scriptedProvider{name: "deepseek", errs: []error{errors.New("deepseek llm: api key is required")}}
+	f := NewFailover(testGuard(), []Target{{Provider: primary}, {Provider: backup}}, nil)
+
+	_, err := f.ChatStream(context.Background(), &ChatRequest{})
+	if !errors.Is(err, auth) || !strings.Contains(err.Error(), "api key is required") {
+		t.Fatalf("expected joined errors, got %v", err)
+	}
+	if primary.calls != 1 {
+		t.Errorf("non-retryable error should not be retried, got %d calls", primary.calls)
+	}
+	if f.Name() != "openai" {
+		t.Errorf("Name() = %q", f.Name())
+	}
+}
+
+func TestFailover_CancelMidStream(t *testing.T) {
+	provider := &endlessProvider{done: make(chan struct{})}
+	f := NewFailover(testGuard(), []Target{{Provider: provider}}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := f.ChatStream(ctx, &ChatRequest{Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("ChatStream error: %v", err)
+	}
+	<-stream
+	time.Sleep(20 * time.Millisecond) // 等转发缓冲填满
+	cancel()
+
+	// 消费方不再读取，provider 的 goroutine 与转发 goroutine 都必须退出
+	select {
+	case <-provider.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("provider goroutine still blocked after cancel")
+	}
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-stream:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("stream not closed after cancel")
+		}
+	}
+}
@@ -36,6 +36,8 @@ func (p *GeminiProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-ch
 	proxy := &OpenAIProvider{
 		name:           "gemini",
 		defaultBaseURL: baseURL,
+		jsonSchema:     true,
+		streamUsage:    true,
 	}
 
 	model := req.Model
@@ -45,12 +47,15 @@ func (p *GeminiProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-ch
 
 	// 创建带覆盖 model 的新 request
 	proxyReq := &ChatRequest{
-		Messages:    req.Messages,
-		Model:       model,
-		MaxTokens:   req.MaxTokens,
-		Temperature: req.Temperature,
-		APIKey:      req.APIKey,
-		BaseURL:     baseURL,
+		Messages:       req.Messages,
+		Model:          model,
+		MaxTokens:      req.MaxTokens,
+		Temperature:    req.Temperature,
+		APIKey:         req.APIKey,
+		BaseURL:        baseURL,
+		Reasoning:      req.Reasoning,
+		Tools:          req.Tools,
+		ResponseFormat: req.ResponseFormat,
 	}
 
 	// 使用 DefaultConfig 并通过 APIKey 认证
@@ -1,7 +1,10 @@
 package llm
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestRegistry_RegisterAndGet(t *testing.T) {
@@ -34,6 +37,7 @@ func TestProviderNames(t *testing.T) {
 		{NewDeepSeekProvider(), "deepseek"},
 		{NewAnthropicProvider(), "anthropic"},
 		{NewGeminiProvider(), "gemini"},
+		{NewMockProvider(MockScript{}), "mock"},
 	}
 
 	for _, tc := range tests {
@@ -42,3 +46,95 @@ func TestProviderNames(t *testing.T) {
 		}
 	}
 }
+
+func collectStream(t *testing.T, p Provider, req *ChatRequest) string {
+	t.Helper()
+	stream, err := p.ChatStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ChatStream error: %v", err)
+	}
+	var sb strings.Builder
+	done := false
+	for ev := range stream {
+		if ev.Err != nil {
+			t.Fatalf("stream error: %v", ev.Err)
+		}
+		if ev.Done {
+			done = true
+		}
+		sb.WriteString(ev.Content)
+	}
+	if !done {
+		t.Error("stream should end with Done event")
+	}
+	return sb.String()
+}
+
+func TestMockProvider_ScriptedTurns(t *testing.T) {
+	p := NewMockProvider(MockScript{
+		Questions:  []string{"Question one?", "第二个问题？"},
+		Evaluation: `{"overall_score":90}`,
+	})
+
+	history := []Message{{Role: "system", Content: "sys"}, {Role: "user", Content: "hi"}}
+	if got := collectStream(t, p, &ChatRequest{Messages: history}); got != "Question one?" {
+		t.Errorf("turn 1 = %q", got)
+	}
+
+	history = append(history, Message{Role: "assistant", Content: "Question one?"}, Message{Role: "user", Content: "answer"})
+	if got := collectStream(t, p, &ChatRequest{Messages: history}); got != "第二个问题？" {
+		t.Errorf("turn 2 = %q", got)
+	}
+
+	// 超出脚本后重复最后一条
+	history = append(history, Message{Role: "assistant", Content: "第二个问题？"}, Message{Role: "user", Content: "answer"})
+	if got := collectStream(t, p, &ChatRequest{Messages: history}); got != "第二个问题？" {
+		t.Errorf("turn 3 = %q", got)
+	}
+
+	evalReq := &ChatRequest{Messages: []Message{{Role: "user", Content: `输出 {"overall_score": 0}`}}}
+	if got := collectStream(t, p, evalReq); got != `{"overall_score":90}` {
+		t.Errorf("evaluation = %q", got)
+	}
+}
+
+func TestMockProvider_CancelWithoutReader(t *testing.T) {
+	// 回复的 token 数超过 channel 缓冲，调用方不读取时 goroutine 必须随 ctx 取消退出
+	p := NewMockProvider(MockScript{Questions: []string{strings.Repeat("word ", 100)}})
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := p.ChatStream(ctx, &ChatRequest{Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond) // 等 goroutine 填满缓冲
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+
+	// 缓冲已满时取消：goroutine 不应阻塞在发送上等待读取，channel 中只剩缓冲内的事件
+	buffered := len(ch)
+	timeout := time.After(2 * time.Second)
+	for n := 0; ; n++ {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				if n > buffered {
+					t.Errorf("received %d events after cancel, want at most %d buffered", n, buffered)
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatal("stream not closed after cancel")
+		}
+	}
+}
+
+func TestSplitMockTokens(t *testing.T) {
+	text := "你好，世界 hello world"
+	tokens := splitMockTokens(text)
+	if strings.Join(tokens, "") != text {
+		t.Errorf("tokens should reassemble to original text, got %q", tokens)
+	}
+	if len(tokens) < 4 {
+		t.Errorf("expected text to be split into several tokens, got %q", tokens)
+	}
+}
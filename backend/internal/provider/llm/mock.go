@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 默认脚本：无需 API Key 即可跑通一场完整面试 (演示模式 / 端到端测试)
+var (
+	defaultMockQuestions = []string{
+		"你好，欢迎参加今天的面试。请先简单介绍一下你自己，以及最近参与的项目。",
+		"在这个项目中你遇到的最大技术挑战是什么？你是如何解决的？",
+		"如果系统的请求量增长十倍，你会从哪些方面进行优化？",
+		"感谢你的回答，今天的面试就到这里，稍后会给出评估结果。",
+	}
+	defaultMockEvaluation = `{"overall_score":78,"summary":"候选人基础扎实，表达清晰，对项目细节有较好的掌握。",` +
+		`"categories":[{"category":"技术能力","score":80,"comment":"技术基础扎实"},` +
+		`{"category":"沟通表达","score":78,"comment":"表达清晰有条理"},` +
+		`{"category":"逻辑思维","score":76,"comment":"分析问题思路清楚"},` +
+		`{"category":"问题解决","score":77,"comment":"能给出可行方案"},` +
+		`{"category":"学习潜力","score":79,"comment":"学习意愿强"}],` +
+		`"strengths":"项目经验丰富，表达清晰","weaknesses":"系统设计深度有待加强","suggestions":"多做大规模系统的容量规划练习"}`
+)
+
+// MockScript 定义 mock LLM 的脚本输出
+type MockScript struct {
+	Questions  []string      // 按轮次依次输出，超出后重复最后一条
+	Evaluation string        // 评估请求的 JSON 输出
+	TokenDelay time.Duration // 相邻 token 的输出间隔，模拟真实的流式节奏
+}
+
+// MockProvider 是确定性的脚本化 LLM，用于演示模式和端到端测试
+type MockProvider struct {
+	script MockScript
+}
+
+// NewMockProvider 创建 mock LLM Provider，脚本字段为空时使用内置默认值
+func NewMockProvider(script MockScript) *MockProvider {
+	if len(script.Questions) == 0 {
+		script.Questions = defaultMockQuestions
+	}
+	if script.Evaluation == "" {
+		script.Evaluation = defaultMockEvaluation
+	}
+	return &MockProvider{script: script}
+}
+
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+func (p *MockProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamEvent, error) {
+	reply := p.reply(req)
+
+	ch := make(chan StreamEvent, 32)
+	go func() {
+		defer close(ch)
+
+		// 调用方停止读取后取消 ctx，所有发送都要能随 ctx 退出，避免 goroutine 阻塞泄漏
+		send := func(ev StreamEvent) bool {
+			select {
+			case ch <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for _, token := range splitMockTokens(reply) {
+			if p.script.TokenDelay > 0 {
+				select {
+				case <-ctx.Done():
+					send(StreamEvent{Err: ctx.Err()})
+					return
+				case <-time.After(p.script.TokenDelay):
+				}
+			}
+			if ctx.Err() != nil {
+				send(StreamEvent{Err: ctx.Err()})
+				return
+			}
+			if !send(StreamEvent{Content: token}) {
+				return
+			}
+		}
+		send(StreamEvent{Done: true})
+	}()
+
+	return ch, nil
+}
+
+// reply 根据请求选择脚本输出：评估请求返回评估 JSON，
+// 否则按历史中面试官已发言的次数返回下一个问题。
+func (p *MockProvider) reply(req *ChatRequest) string {
+	if isEvaluationRequest(req) {
+		return p.script.Evaluation
+	}
+
+	turn := 0
+	for _, m := range req.Messages {
+		if m.Role == "assistant" {
+			turn++
+		}
+	}
+	if turn >= len(p.script.Questions) {
+		turn = len(p.script.Questions) - 1
+	}
+	return p.script.Questions[turn]
+}
+
+// isEvaluationRequest 通过评估 JSON 的字段名识别评估请求 (字段名不随语言变化)
+func isEvaluationRequest(req *ChatRequest) bool {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == "user" {
+			return strings.Contains(req.Messages[i].Text(), `"overall_score"`)
+		}
+	}
+	return false
+}
+
+// splitMockTokens 把文本切成近似真实 LLM 的 token：
+// CJK 字符每 2 个一组，其余按单词 (连同前导空白) 切分。
+func splitMockTokens(text string) []string {
+	var tokens []string
+	var cur []rune
+	cjk := 0
+
+	flush := func() {
+		if len(cur) > 0 {
+			tokens = append(tokens, string(cur))
+			cur = cur[:0]
+		}
+		cjk = 0
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if cjk == 0 && len(cur) > 0 {
+				flush()
+			}
+			cur = append(cur, r)
+			cjk++
+			if cjk == 2 {
+				flush()
+			}
+		case unicode.IsSpace(r):
+			flush()
+			cur = append(cur, r)
+		default:
+			if cjk > 0 {
+				flush()
+			}
+			cur = append(cur, r)
+		}
+	}
+	flush()
+	return tokens
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"strings"
 
 	openai "github.com/sashabaranov/go-openai"
 )
@@ -13,11 +15,49 @@ import (
 type OpenAIProvider struct {
 	name           string
 	defaultBaseURL string
+	// completionTokens 开启思考时用 max_completion_tokens 代替 max_tokens (OpenAI o 系列不接受 max_tokens)
+	completionTokens bool
+	// jsonSchema 支持 json_schema 输出格式，否则降级为 json_object (DeepSeek)
+	jsonSchema bool
+	// noVision 模型不接受图片 (DeepSeek)，图片以文字占位代替
+	noVision bool
+	// streamUsage 请求在流末尾返回用量 (stream_options.include_usage)，部分兼容服务不接受该参数
+	streamUsage bool
+	// azure 为 Azure OpenAI：BaseURL 为资源端点，模型名为部署名，使用 api-key 认证
+	azure      bool
+	apiVersion string
+	// 以下为自定义兼容服务的设置
+	defaultModel string
+	apiKey       string // 请求未携带 Key 时使用
+	auth         string
+	authHeader   string
+	headers      map[string]string
+}
+
+// AzureAPIVersion Azure OpenAI 的默认 API 版本
+const AzureAPIVersion = "2024-10-21"
+
+// 自定义兼容服务的认证方式
+const (
+	AuthBearer = "bearer" // Authorization: Bearer <key>
+	AuthHeader = "header" // Key 放在指定请求头中，如 api-key
+	AuthNone   = "none"   // 不需要 Key，如本地 vLLM / Ollama
+)
+
+// CustomConfig 自定义 OpenAI 兼容服务
+type CustomConfig struct {
+	Name         string
+	BaseURL      string
+	Auth         string // 默认 bearer
+	AuthHeader   string // Auth 为 header 时携带 Key 的请求头
+	APIKey       string
+	DefaultModel string
+	Headers      map[string]string // 每个请求附带的请求头
 }
 
 // NewOpenAIProvider 创建 OpenAI LLM Provider
 func NewOpenAIProvider() *OpenAIProvider {
-	return &OpenAIProvider{name: "openai"}
+	return &OpenAIProvider{name: "openai", completionTokens: true, jsonSchema: true, streamUsage: true}
 }
 
 // NewDeepSeekProvider 创建 DeepSeek LLM Provider (OpenAI 兼容)
@@ -25,14 +65,41 @@ func NewDeepSeekProvider() *OpenAIProvider {
 	return &OpenAIProvider{
 		name:           "deepseek",
 		defaultBaseURL: "https://api.deepseek.com/v1",
+		noVision:       true,
+		streamUsage:    true,
+	}
+}
+
+// NewAzureProvider 创建 Azure OpenAI LLM Provider，deployment 为未指定模型时使用的部署
+func NewAzureProvider(apiVersion, deployment string) *OpenAIProvider {
+	if apiVersion == "" {
+		apiVersion = AzureAPIVersion
+	}
+	return &OpenAIProvider{
+		name:             "azure",
+		azure:            true,
+		apiVersion:       apiVersion,
+		defaultModel:     deployment,
+		completionTokens: true,
+		jsonSchema:       true,
+		streamUsage:      true,
 	}
 }
 
 // NewCustomProvider 创建自定义 OpenAI 兼容 LLM Provider
-func NewCustomProvider(name, baseURL string) *OpenAIProvider {
+func NewCustomProvider(c CustomConfig) *OpenAIProvider {
+	auth := c.Auth
+	if auth == "" {
+		auth = AuthBearer
+	}
 	return &OpenAIProvider{
-		name:           name,
-		defaultBaseURL: baseURL,
+		name:           c.Name,
+		defaultBaseURL: c.BaseURL,
+		defaultModel:   c.DefaultModel,
+		apiKey:         c.APIKey,
+		auth:           auth,
+		authHeader:     c.AuthHeader,
+		headers:        c.Headers,
 	}
 }
 
@@ -41,27 +108,58 @@ func (p *OpenAIProvider) Name() string {
 }
 
 func (p *OpenAIProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamEvent, error) {
-	if req.APIKey == "" {
+	apiKey := req.APIKey
+	if apiKey == "" {
+		apiKey = p.apiKey
+	}
+	if apiKey == "" && p.auth != AuthNone {
 		return nil, fmt.Errorf("%s llm: api key is required", p.name)
 	}
 
-	config := openai.DefaultConfig(req.APIKey)
-	if req.BaseURL != "" {
-		config.BaseURL = req.BaseURL
-	} else if p.defaultBaseURL != "" {
-		config.BaseURL = p.defaultBaseURL
+	baseURL := req.BaseURL
+	if baseURL == "" {
+		baseURL = p.defaultBaseURL
+	}
+	config := openai.DefaultConfig(apiKey)
+	if p.azure {
+		if baseURL == "" {
+			return nil, fmt.Errorf("%s llm: endpoint is required", p.name)
+		}
+		config = openai.DefaultAzureConfig(apiKey, baseURL)
+		config.APIVersion = p.apiVersion
+		config.AzureModelMapperFunc = func(model string) string { return model }
+	} else if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+	if transport := p.transport(apiKey); transport != nil {
+		config.HTTPClient = &http.Client{Transport: transport}
 	}
 	client := openai.NewClientWithConfig(config)
 
 	messages := make([]openai.ChatCompletionMessage, 0, len(req.Messages))
 	for _, m := range req.Messages {
-		messages = append(messages, openai.ChatCompletionMessage{
-			Role:    m.Role,
-			Content: m.Content,
-		})
+		msg := openai.ChatCompletionMessage{
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCallID: m.ToolCallID,
+		}
+		if len(m.Parts) > 0 {
+			msg.Content, msg.MultiContent = "", p.contentParts(m.Parts)
+		}
+		for _, call := range m.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:       call.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: call.Name, Arguments: call.Arguments},
+			})
+		}
+		messages = append(messages, msg)
 	}
 
 	model := req.Model
+	if model == "" {
+		model = p.defaultModel
+	}
 	if model == "" {
 		model = "gpt-4o"
 	}
@@ -76,13 +174,39 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-ch
 		temp = 0.7
 	}
 
-	stream, err := client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+	creq := openai.ChatCompletionRequest{
 		Model:       model,
 		Messages:    messages,
 		MaxTokens:   maxTokens,
 		Temperature: temp,
 		Stream:      true,
-	})
+	}
+	for _, tool := range req.Tools {
+		creq.Tools = append(creq.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	if f := req.ResponseFormat; f != nil {
+		creq.ResponseFormat = p.responseFormat(f)
+	}
+	if p.streamUsage {
+		creq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+	if effort := req.Reasoning.effort(); effort != "" {
+		// 推理模型只接受默认温度
+		creq.ReasoningEffort = effort
+		creq.Temperature = 0
+		if p.completionTokens {
+			creq.MaxTokens, creq.MaxCompletionTokens = 0, maxTokens+req.Reasoning.budget()
+		}
+	}
+
+	stream, err := client.CreateChatCompletionStream(ctx, creq)
 	if err != nil {
 		return nil, fmt.Errorf("%s llm: create stream: %w", p.name, err)
 	}
@@ -92,21 +216,48 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-ch
 		defer close(ch)
 		defer stream.Close()
 
+		var think thinkSplitter
+		var usage *Usage
+		calls := 0 // 不带 index 的工具调用 (部分兼容服务) 各自完整输出，依次编号
 		for {
 			resp, err := stream.Recv()
 			if errors.Is(err, io.EOF) {
-				ch <- StreamEvent{Done: true}
+				if content, reasoning := think.flush(); content != "" || reasoning != "" {
+					ch <- StreamEvent{Content: content, Reasoning: reasoning}
+				}
+				ch <- StreamEvent{Usage: usage, Done: true}
 				return
 			}
 			if err != nil {
 				ch <- StreamEvent{Err: fmt.Errorf("%s llm: stream recv: %w", p.name, err)}
 				return
 			}
+			// 用量在最后一个不带 choices 的片段中返回；OpenAI 自动缓存请求前缀，命中数在 cached_tokens 中
+			if u := resp.Usage; u != nil {
+				usage = &Usage{InputTokens: u.PromptTokens, OutputTokens: u.CompletionTokens}
+				if u.PromptTokensDetails != nil {
+					usage.CachedTokens = u.PromptTokensDetails.CachedTokens
+				}
+			}
 
 			if len(resp.Choices) > 0 {
-				delta := resp.Choices[0].Delta.Content
-				if delta != "" {
-					ch <- StreamEvent{Content: delta}
+				// DeepSeek 等通过 reasoning_content 返回思考过程，部分兼容服务则在正文中用 <think> 包裹
+				delta := resp.Choices[0].Delta
+				content, reasoning := think.split(delta.Content)
+				reasoning = delta.ReasoningContent + reasoning
+				if content != "" || reasoning != "" {
+					ch <- StreamEvent{Content: content, Reasoning: reasoning}
+				}
+				for _, call := range delta.ToolCalls {
+					index := calls
+					if call.Index != nil {
+						index = *call.Index
+					} else {
+						calls++
+					}
+					ch <- StreamEvent{ToolCall: &ToolCall{
+						Index: index, ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments,
+					}}
 				}
 			}
 		}
@@ -114,3 +265,149 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-ch
 
 	return ch, nil
 }
+
+// transport 按认证方式改写请求头 (SDK 总是发送 Authorization: Bearer)，无需改写时返回 nil
+func (p *OpenAIProvider) transport(apiKey string) http.RoundTripper {
+	dropAuth := p.auth == AuthHeader || p.auth == AuthNone
+	if !dropAuth && len(p.headers) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(p.headers)+1)
+	for k, v := range p.headers {
+		headers[k] = v
+	}
+	if p.auth == AuthHeader {
+		headers[p.authHeader] = apiKey
+	}
+	return &headerTransport{base: http.DefaultTransport, headers: headers, dropAuth: dropAuth}
+}
+
+// headerTransport 去掉 Bearer 认证并附加请求头
+type headerTransport struct {
+	base     http.RoundTripper
+	headers  map[string]string
+	dropAuth bool
+}
+
+func (t *headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	if t.dropAuth {
+		r.Header.Del("Authorization")
+	}
+	for k, v := range t.headers {
+		r.Header.Set(k, v)
+	}
+	return t.base.RoundTrip(r)
+}
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// thinkSplitter 把回答开头 <think>...</think> 包裹的思考过程从正文中分离出来，标签可能跨片段
+type thinkSplitter struct {
+	thinking bool   // 处于 <think> 内
+	answered bool   // 已输出非空白正文，之后不再识别 <think>
+	pending  string // 可能是标签前缀的未决尾部
+}
+
+// split 处理一个正文片段，返回其中的回答与思考部分
+func (s *thinkSplitter) split(delta string) (content, reasoning string) {
+	buf := s.pending + delta
+	s.pending = ""
+	var answer, thought strings.Builder
+	for buf != "" {
+		if s.answered {
+			answer.WriteString(buf)
+			break
+		}
+		tag := thinkOpen
+		if s.thinking {
+			tag = thinkClose
+		}
+		var text string
+		if i := strings.Index(buf, tag); i >= 0 {
+			if !s.thinking && strings.TrimSpace(buf[:i]) != "" {
+				// 正文之后的标签不是思考过程
+				s.answered = true
+				continue
+			}
+			text, buf = buf[:i], buf[i+len(tag):]
+			s.emit(text, &answer, &thought)
+			s.thinking = !s.thinking
+			continue
+		}
+		text, s.pending = buf, ""
+		if n := tagPrefix(buf, tag); n > 0 {
+			text, s.pending = buf[:len(buf)-n], buf[len(buf)-n:]
+		}
+		s.emit(text, &answer, &thought)
+		break
+	}
+	return answer.String(), thought.String()
+}
+
+func (s *thinkSplitter) emit(text string, answer, thought *strings.Builder) {
+	if s.thinking {
+		thought.WriteString(text)
+		return
+	}
+	answer.WriteString(text)
+	if strings.TrimSpace(text) != "" {
+		s.answered = true
+	}
+}
+
+// flush 在流结束时输出未决的尾部
+func (s *thinkSplitter) flush() (content, reasoning string) {
+	rest := s.pending
+	s.pending = ""
+	if s.thinking {
+		return "", rest
+	}
+	return rest, ""
+}
+
+// tagPrefix 返回 s 的尾部与 tag 前缀重合的最大长度 (不含完整的 tag)
+func tagPrefix(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// responseFormat 映射结构化输出要求，不支持 json_schema 的服务只要求输出 JSON 对象
+func (p *OpenAIProvider) responseFormat(f *ResponseFormat) *openai.ChatCompletionResponseFormat {
+	if f.Type == FormatJSONSchema && p.jsonSchema {
+		return &openai.ChatCompletionResponseFormat{
+			Type:       openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{Name: f.Name, Schema: f.Schema},
+		}
+	}
+	return &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+}
+
+// contentParts 映射多模态内容，图片以 image_url 发送 (内联图片为 data URL)
+func (p *OpenAIProvider) contentParts(parts []ContentPart) []openai.ChatMessagePart {
+	out := make([]openai.ChatMessagePart, 0, len(parts))
+	for _, part := range parts {
+		if part.Image == nil && part.Text == "" {
+			continue
+		}
+		switch {
+		case part.Image == nil:
+			out = append(out, openai.ChatMessagePart{Type: openai.ChatMessagePartTypeText, Text: part.Text})
+		case p.noVision:
+			out = append(out, openai.ChatMessagePart{Type: openai.ChatMessagePartTypeText, Text: "[image omitted]"})
+		default:
+			out = append(out, openai.ChatMessagePart{
+				Type:     openai.ChatMessagePartTypeImageURL,
+				ImageURL: &openai.ChatMessageImageURL{URL: part.Image.url()},
+			})
+		}
+	}
+	return out
+}
@@ -15,25 +15,86 @@ type Provider interface {
 
 // ChatRequest 是 LLM 对话请求
 type ChatRequest struct {
-	Messages    []Message // 对话历史
-	Model       string    // 模型名称
-	MaxTokens   int       // 最大输出 token 数
-	Temperature float64   // 温度
-	APIKey      string    // BYOK API Key
-	BaseURL     string    // 自定义端点 (可选)
+	Messages       []Message       // 对话历史
+	Model          string          // 模型名称
+	MaxTokens      int             // 最大输出 token 数
+	Temperature    float64         // 温度
+	APIKey         string          // BYOK API Key
+	BaseURL        string          // 自定义端点 (可选)
+	Reasoning      Reasoning       // 推理模型的思考设置 (可选)
+	Tools          []Tool          // 可供模型调用的工具 (可选)
+	ResponseFormat *ResponseFormat // 要求输出 JSON (可选)
 }
 
-// Message 是对话消息
+// Reasoning 是推理模型的思考设置，零值表示使用模型默认
+type Reasoning struct {
+	Effort       string // 思考强度: low, medium, high
+	BudgetTokens int    // 思考 token 预算
+}
+
+// reasoningBudget 各思考强度对应的 token 预算
+var reasoningBudget = map[string]int{"low": 1024, "medium": 4096, "high": 16384}
+
+// effort 返回思考强度，只设置了预算时按预算换算
+func (r Reasoning) effort() string {
+	switch {
+	case r.Effort != "":
+		return r.Effort
+	case r.BudgetTokens <= 0:
+		return ""
+	case r.BudgetTokens <= reasoningBudget["low"]:
+		return "low"
+	case r.BudgetTokens <= reasoningBudget["medium"]:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// budget 返回思考 token 预算，只设置了强度时按强度换算
+func (r Reasoning) budget() int {
+	if r.BudgetTokens > 0 {
+		return r.BudgetTokens
+	}
+	return reasoningBudget[r.Effort]
+}
+
+// Message 是对话消息。对话之后的 system 消息是本轮的额外要求，不属于可缓存的前缀
 type Message struct {
-	Role    string // system, user, assistant
-	Content string
+	Role       string // system, user, assistant, tool
+	Content    string
+	Parts      []ContentPart // 多模态内容 (文本与图片)，非空时代替 Content
+	ToolCalls  []ToolCall    // assistant 消息中模型发起的工具调用
+	ToolCallID string        // tool 消息对应的工具调用 ID，Content 为调用结果
 }
 
 // StreamEvent 是流式输出事件
 type StreamEvent struct {
-	Content string // 文本片段
-	Done    bool   // 是否结束
-	Err     error  // 错误信息
+	Content   string    // 回答文本片段
+	Reasoning string    // 思考过程片段 (推理模型)，不属于回答
+	ToolCall  *ToolCall // 工具调用增量
+	Usage     *Usage    // token 用量，随 Done 事件返回 (供应商未返回时为 nil)
+	Done      bool      // 是否结束
+	Err       error     // 错误信息
+}
+
+// Usage 是一次请求的 token 用量
+type Usage struct {
+	InputTokens      int // 输入 token 总数，含命中与写入缓存的部分
+	CachedTokens     int // 命中缓存的输入 token
+	CacheWriteTokens int // 写入缓存的输入 token (Anthropic 单独计费)
+	OutputTokens     int
+}
+
+// Add 累加 o 的用量，o 可以为 nil
+func (u *Usage) Add(o *Usage) {
+	if o == nil {
+		return
+	}
+	u.InputTokens += o.InputTokens
+	u.CachedTokens += o.CachedTokens
+	u.CacheWriteTokens += o.CacheWriteTokens
+	u.OutputTokens += o.OutputTokens
 }
 
 // Registry 管理所有注册的 LLM Provider
@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readReasoning 读完 stream，分别返回回答与思考过程
+func readReasoning(t *testing.T, stream <-chan StreamEvent) (content, reasoning string) {
+	t.Helper()
+	var answer, thought strings.Builder
+	for ev := range stream {
+		if ev.Err != nil {
+			t.Fatalf("stream error: %v", ev.Err)
+		}
+		answer.WriteString(ev.Content)
+		thought.WriteString(ev.Reasoning)
+	}
+	return answer.String(), thought.String()
+}
+
+// sseServer 以 SSE 依次输出 events，并记录请求体
+func sseServer(t *testing.T, body *map[string]any, events ...string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, ev := range events {
+			fmt.Fprintf(w, "data: %s\n\n", ev)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestThinkSplitter(t *testing.T) {
+	tests := []struct {
+		name            string
+		chunks          []string
+		answer, thought string
+	}{
+		{"plain", []string{"Hello", " world"}, "Hello world", ""},
+		{"tagged", []string{"<think>plan</think>Hi"}, "Hi", "plan"},
+		{"split tags", []string{"<th", "ink>pl", "an</thi", "nk>", "Hi"}, "Hi", "plan"},
+		{"unterminated", []string{"<think>plan", " more"}, "", "plan more"},
+		// 回答开始后的 <think> 是正文
+		{"tag in answer", []string{"Use <think>", " tags"}, "Use <think> tags", ""},
+		{"partial tag at end", []string{"a <"}, "a <", ""},
+	}
+	for _, tt := range tests {
+		var s thinkSplitter
+		var answer, thought string
+		for _, c := range tt.chunks {
+			a, r := s.split(c)
+			answer, thought = answer+a, thought+r
+		}
+		a, r := s.flush()
+		answer, thought = answer+a, thought+r
+		if answer != tt.answer || thought != tt.thought {
+			t.Errorf("%s: got (%q, %q), want (%q, %q)", tt.name, answer, thought, tt.answer, tt.thought)
+		}
+	}
+}
+
+func TestReasoning_EffortAndBudget(t *testing.T) {
+	if got := (Reasoning{BudgetTokens: 3000}).effort(); got != "medium" {
+		t.Errorf("effort from budget = %q, want medium", got)
+	}
+	if got := (Reasoning{Effort: "high"}).budget(); got != 16384 {
+		t.Errorf("budget from effort = %d, want 16384", got)
+	}
+	if (Reasoning{}).effort() != "" || (Reasoning{}).budget() != 0 {
+		t.Error("zero value should not enable reasoning")
+	}
+}
+
+func TestOpenAIProvider_ReasoningContent(t *testing.T) {
+	var body map[string]any
+	srv := sseServer(t, &body,
+		`{"choices":[{"index":0,"delta":{"reasoning_content":"Think first."}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"Question?"}}]}`,
+		`[DONE]`,
+	)
+
+	p := NewOpenAIProvider()
+	stream, err := p.ChatStream(context.Background(), &ChatRequest{
+		Messages:  []Message{{Role: "user", Content: "hi"}},
+		MaxTokens: 512,
+		APIKey:    "k",
+		BaseURL:   srv.URL,
+		Reasoning: Reasoning{Effort: "low"},
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	content, reasoning := readReasoning(t, stream)
+	if content != "Question?" || reasoning != "Think first." {
+		t.Errorf("got (%q, %q)", content, reasoning)
+	}
+	if body["reasoning_effort"] != "low" || body["max_completion_tokens"] != float64(512+1024) {
+		t.Errorf("unexpected request: %v", body)
+	}
+	if _, ok := body["max_tokens"]; ok {
+		t.Errorf("max_tokens should be replaced by max_completion_tokens: %v", body)
+	}
+	if _, ok := body["temperature"]; ok {
+		t.Errorf("temperature should be omitted for reasoning models: %v", body)
+	}
+}
+
+func TestAnthropicProvider_Thinking(t *testing.T) {
+	var body map[string]any
+	srv := sseServer(t, &body,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Think first."}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"abc"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Question?"}}`,
+		`{"type":"message_stop"}`,
+	)
+
+	p := NewAnthropicProvider()
+	stream, err := p.ChatStream(context.Background(), &ChatRequest{
+		Messages:  []Message{{Role: "user", Content: "hi"}},
+		MaxTokens: 512,
+		APIKey:    "k",
+		BaseURL:   srv.URL,
+		Reasoning: Reasoning{BudgetTokens: 2048},
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	content, reasoning := readReasoning(t, stream)
+	if content != "Question?" || reasoning != "Think first." {
+		t.Errorf("got (%q, %q)", content, reasoning)
+	}
+	thinking, _ := body["thinking"].(map[string]any)
+	if thinking["type"] != "enabled" || thinking["budget_tokens"] != float64(2048) || body["max_tokens"] != float64(512+2048) {
+		t.Errorf("unexpected request: %v", body)
+	}
+}
@@ -0,0 +1,270 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 结构化输出：要求模型只输出 JSON。OpenAI / Gemini 原生支持 JSON Schema，DeepSeek 只支持 JSON 对象，
+// Anthropic 通过强制调用工具 (有 Schema) 或预填 "{" 模拟。模型不一定遵守 Schema，结果统一用 ValidateJSON 校验。
+
+// 输出格式
+const (
+	FormatJSONObject = "json_object" // 任意 JSON 对象
+	FormatJSONSchema = "json_schema" // 符合 Schema 的 JSON 对象
+)
+
+// ResponseFormat 是结构化输出要求
+type ResponseFormat struct {
+	Type   string          // FormatJSONObject 或 FormatJSONSchema
+	Name   string          // Schema 名称，只能包含字母、数字、_ 与 -
+	Schema json.RawMessage // JSON Schema (type: object)
+}
+
+// JSONSchemaFormat 按 Go 类型生成 JSON Schema 输出要求
+func JSONSchemaFormat[T any]() *ResponseFormat {
+	var zero T
+	t := reflect.TypeOf(zero)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name := t.Name()
+	if name == "" {
+		name = "result"
+	}
+	return &ResponseFormat{Type: FormatJSONSchema, Name: name, Schema: SchemaOf[T]()}
+}
+
+// jsonSchema 是 JSON Schema 的子集，用于生成与校验
+type jsonSchema struct {
+	Type       string                 `json:"type,omitempty"`
+	Properties map[string]*jsonSchema `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+	Items      *jsonSchema            `json:"items,omitempty"`
+	Enum       []string               `json:"enum,omitempty"`
+	Minimum    *float64               `json:"minimum,omitempty"`
+	Maximum    *float64               `json:"maximum,omitempty"`
+}
+
+// SchemaOf 按 Go 类型生成 JSON Schema：字段名取 json tag，没有 omitempty 的字段为必填，
+// jsonschema tag 可以限定取值，如 `jsonschema:"minimum=0,maximum=100"`、`jsonschema:"enum=easy|hard"`
+func SchemaOf[T any]() json.RawMessage {
+	var zero T
+	data, err := json.Marshal(schemaOf(reflect.TypeOf(zero)))
+	if err != nil {
+		panic(fmt.Sprintf("llm: schema of %T: %v", zero, err))
+	}
+	return data
+}
+
+func schemaOf(t reflect.Type) *jsonSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object"}
+	case reflect.Struct:
+		s := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			prop := schemaOf(f.Type)
+			applySchemaTag(prop, f.Tag.Get("jsonschema"))
+			s.Properties[name] = prop
+			if !strings.Contains(opts, "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	}
+	return &jsonSchema{}
+}
+
+func applySchemaTag(s *jsonSchema, tag string) {
+	if tag == "" {
+		return
+	}
+	for _, kv := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(kv, "=")
+		switch key {
+		case "enum":
+			s.Enum = strings.Split(value, "|")
+		case "minimum", "maximum":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				panic(fmt.Sprintf("llm: invalid jsonschema tag %q", tag))
+			}
+			if key == "minimum" {
+				s.Minimum = &n
+			} else {
+				s.Maximum = &n
+			}
+		}
+	}
+}
+
+// ValidateJSON 按 JSON Schema 校验 data，支持 type、properties、required、items、enum、minimum 与 maximum
+func ValidateJSON(schema json.RawMessage, data []byte) error {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+	return s.validate("$", v)
+}
+
+func (s *jsonSchema) validate(path string, v any) error {
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object", path)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s.%s: required", path, name)
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+			if value, ok := obj[name]; ok {
+				if err := s.Properties[name].validate(path+"."+name, value); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array", path)
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string", path)
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+			return fmt.Errorf("%s: %q is not one of %v", path, str, s.Enum)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean", path)
+		}
+	case "integer", "number":
+		num, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: expected %s", path, s.Type)
+		}
+		f, err := num.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %s", path, num)
+		}
+		if s.Type == "integer" && f != float64(int64(f)) {
+			return fmt.Errorf("%s: expected integer, got %s", path, num)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return fmt.Errorf("%s: %s is less than %g", path, num, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fmt.Errorf("%s: %s is greater than %g", path, num, *s.Maximum)
+		}
+	}
+	return nil
+}
+
+// JSONError 表示模型输出不是符合要求的 JSON，Content 为原始输出
+type JSONError struct {
+	Content string
+	Err     error
+}
+
+func (e *JSONError) Error() string {
+	return fmt.Sprintf("llm: invalid json output: %v", e.Err)
+}
+
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+// ExtractJSON 取出输出中的 JSON 对象，去掉代码块标记与前后的说明文字
+func ExtractJSON(content string) string {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return content
+	}
+	return content[start : end+1]
+}
+
+// DecodeJSON 按 T 的 Schema 校验并解码模型输出，失败时返回 *JSONError
+func DecodeJSON[T any](content string) (T, error) {
+	var out T
+	data := []byte(ExtractJSON(content))
+	if err := ValidateJSON(SchemaOf[T](), data); err != nil {
+		return out, &JSONError{Content: content, Err: err}
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return out, &JSONError{Content: content, Err: err}
+	}
+	return out, nil
+}
+
+// ChatJSON 要求模型按 T 的 Schema 输出 JSON，并返回解码后的结果。req 未设置 ResponseFormat 时按 T 生成
+func ChatJSON[T any](ctx context.Context, p Provider, req *ChatRequest) (T, error) {
+	var zero T
+	r := *req
+	if r.ResponseFormat == nil {
+		r.ResponseFormat = JSONSchemaFormat[T]()
+	}
+	stream, err := p.ChatStream(ctx, &r)
+	if err != nil {
+		return zero, err
+	}
+	var sb strings.Builder
+	for event := range stream {
+		if event.Err != nil {
+			return zero, event.Err
+		}
+		if event.Done {
+			break
+		}
+		sb.WriteString(event.Content)
+	}
+	return DecodeJSON[T](sb.String())
+}
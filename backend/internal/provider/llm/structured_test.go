@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type verdict struct {
+	Score  int      `json:"score" jsonschema:"minimum=0,maximum=100"`
+	Level  string   `json:"level" jsonschema:"enum=junior|senior"`
+	Notes  []string `json:"notes,omitempty"`
+	Passed bool     `json:"passed"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	var got map[string]any
+	if err := json.Unmarshal(SchemaOf[verdict](), &got); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	props := got["properties"].(map[string]any)
+	score := props["score"].(map[string]any)
+	if got["type"] != "object" || score["type"] != "integer" || score["maximum"] != 100.0 {
+		t.Errorf("unexpected schema: %v", got)
+	}
+	if notes := props["notes"].(map[string]any); notes["type"] != "array" || notes["items"].(map[string]any)["type"] != "string" {
+		t.Errorf("unexpected notes schema: %v", notes)
+	}
+	if required := got["required"].([]any); len(required) != 3 {
+		t.Errorf("omitempty fields should be optional, required = %v", required)
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	schema := SchemaOf[verdict]()
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{`{"score":80,"level":"senior","passed":true,"notes":["ok"]}`, ""},
+		{`{"score":80.0,"level":"junior","passed":false}`, ""},
+		{`{"score":80,"level":"senior"}`, "$.passed: required"},
+		{`{"score":120,"level":"senior","passed":true}`, "$.score: 120 is greater than 100"},
+		{`{"score":8.5,"level":"senior","passed":true}`, "$.score: expected integer"},
+		{`{"score":80,"level":"lead","passed":true}`, `$.level: "lead" is not one of`},
+		{`{"score":80,"level":"senior","passed":true,"notes":[1]}`, "$.notes[0]: expected string"},
+		{`[1]`, "$: expected object"},
+		{`{"score":`, "invalid json"},
+	}
+	for _, tt := range tests {
+		err := ValidateJSON(schema, []byte(tt.data))
+		if tt.err == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.data, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: error = %v, want %q", tt.data, err, tt.err)
+		}
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	got, err := DecodeJSON[verdict]("Here you go:\n```json\n{\"score\":70,\"level\":\"junior\",\"passed\":true}\n```")
+	if err != nil || got.Score != 70 || got.Level != "junior" || !got.Passed {
+		t.Fatalf("DecodeJSON = %+v, %v", got, err)
+	}
+
+	_, err = DecodeJSON[verdict]("no json here")
+	var jsonErr *JSONError
+	if !errors.As(err, &jsonErr) || jsonErr.Content != "no json here" {
+		t.Errorf("expected JSONError with the raw output, got %v", err)
+	}
+}
+
+func TestOpenAIProvider_ResponseFormat(t *testing.T) {
+	tests := []struct {
+		provider *OpenAIProvider
+		typ      string
+	}{
+		{NewOpenAIProvider(), FormatJSONSchema},
+		{NewDeepSeekProvider(), FormatJSONObject},
+	}
+	for _, tt := range tests {
+		var body map[string]any
+		srv := sseServer(t, &body,
+			`{"choices":[{"index":0,"delta":{"content":"{\"score\":90,"}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":"\"level\":\"senior\",\"passed\":true}"}}]}`,
+			`[DONE]`,
+		)
+		got, err := ChatJSON[verdict](context.Background(), tt.provider, &ChatRequest{
+			Messages: []Message{{Role: "user", Content: "grade in json"}},
+			APIKey:   "k",
+			BaseURL:  srv.URL,
+		})
+		if err != nil || got.Score != 90 {
+			t.Fatalf("%s: ChatJSON = %+v, %v", tt.provider.Name(), got, err)
+		}
+		format, _ := body["response_format"].(map[string]any)
+		if format["type"] != tt.typ {
+			t.Errorf("%s: response_format = %v, want %s", tt.provider.Name(), format, tt.typ)
+		}
+		if tt.typ == FormatJSONSchema {
+			schema, _ := format["json_schema"].(map[string]any)
+			if schema["name"] != "verdict" || schema["schema"] == nil {
+				t.Errorf("unexpected json_schema: %v", format["json_schema"])
+			}
+		}
+	}
+}
+
+func TestAnthropicProvider_ResponseFormatTool(t *testing.T) {
+	var body map[string]any
+	srv := sseServer(t, &body,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"verdict","input":{}}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"score\":55,"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"level\":\"junior\",\"passed\":false}"}}`,
+		`{"type":"message_stop"}`,
+	)
+
+	got, err := ChatJSON[verdict](context.Background(), NewAnthropicProvider(), &ChatRequest{
+		Messages:  []Message{{Role: "user", Content: "grade"}},
+		Reasoning: Reasoning{Effort: "high"},
+		APIKey:    "k",
+		BaseURL:   srv.URL,
+	})
+	if err != nil || got.Score != 55 || got.Level != "junior" {
+		t.Fatalf("ChatJSON = %+v, %v", got, err)
+	}
+	choice, _ := body["tool_choice"].(map[string]any)
+	if choice["type"] != "tool" || choice["name"] != "verdict" {
+		t.Errorf("expected a forced tool call, got %v", body["tool_choice"])
+	}
+	if body["thinking"] != nil {
+		t.Errorf("thinking cannot be combined with a forced tool call")
+	}
+}
+
+func TestAnthropicProvider_ResponseFormatPrefill(t *testing.T) {
+	var body map[string]any
+	srv := sseServer(t, &body,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"\"ok\":true}"}}`,
+		`{"type":"message_stop"}`,
+	)
+
+	stream, err := NewAnthropicProvider().ChatStream(context.Background(), &ChatRequest{
+		Messages:       []Message{{Role: "user", Content: "json please"}},
+		ResponseFormat: &ResponseFormat{Type: FormatJSONObject},
+		APIKey:         "k",
+		BaseURL:        srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if content, _ := readToolCalls(t, stream); content != `{"ok":true}` {
+		t.Errorf("content = %q", content)
+	}
+	messages, _ := body["messages"].([]any)
+	if last := messages[len(messages)-1].(map[string]any); last["role"] != "assistant" || last["content"] != "{" {
+		t.Errorf("expected a prefilled assistant message, got %v", last)
+	}
+}
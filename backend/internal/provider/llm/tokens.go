@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// messageOverhead 每条消息在角色、分隔符等格式上的额外 token 开销
+const messageOverhead = 4
+
+// EstimateTokens 粗略估算文本的 token 数：中文等非 ASCII 字符按每字 1 token，ASCII 按每 4 字节 1 token。
+// 各家分词器不同，估算值偏保守，只用于上下文预算。
+func EstimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return other + (ascii+3)/4
+}
+
+// EstimateMessages 估算一组消息的 token 数，图片按固定开销计算
+func EstimateMessages(messages []Message) int {
+	n := 0
+	for _, m := range messages {
+		n += EstimateTokens(m.Text()) + m.images()*imageTokens + messageOverhead
+	}
+	return n
+}
+
+// DefaultContextWindow 未知模型的上下文窗口
+const DefaultContextWindow = 32768
+
+// contextWindows 常见模型的上下文窗口 (token)，按模型名前缀匹配，更具体的前缀在前
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"deepseek", 65536},
+	{"gemini-1.5-pro", 2097152},
+	{"gemini", 1048576},
+}
+
+// providerWindows 未指定模型时各 provider 默认模型的上下文窗口
+var providerWindows = map[string]int{
+	"openai":    128000,
+	"anthropic": 200000,
+	"deepseek":  65536,
+	"gemini":    1048576,
+}
+
+// ContextWindow 返回模型的上下文窗口：先按 overrides 中最长的匹配前缀，再查内置表，
+// 模型为空时使用 provider 默认模型的窗口。
+func ContextWindow(provider, model string, overrides map[string]int32) int {
+	best, found := "", false
+	for prefix, n := range overrides {
+		if n > 0 && strings.HasPrefix(model, prefix) && (!found || len(prefix) > len(best)) {
+			best, found = prefix, true
+		}
+	}
+	if found {
+		return int(overrides[best])
+	}
+	if model == "" {
+		if n, ok := providerWindows[provider]; ok {
+			return n
+		}
+		return DefaultContextWindow
+	}
+	for _, w := range contextWindows {
+		if strings.HasPrefix(model, w.prefix) {
+			return w.tokens
+		}
+	}
+	return DefaultContextWindow
+}
@@ -0,0 +1,47 @@
+package llm
+
+import "testing"
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abcd", 1},
+		{"hello world", 3},
+		{"你好世界", 4},
+		{"Go 语言", 3},
+	}
+	for _, tc := range tests {
+		if got := EstimateTokens(tc.text); got != tc.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tc.text, got, tc.want)
+		}
+	}
+	if got := EstimateMessages([]Message{{Content: "你好"}, {Content: "abcd"}}); got != 3+2*messageOverhead {
+		t.Errorf("EstimateMessages = %d", got)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	overrides := map[string]int32{"gpt-4o-mini": 64000, "local-": 8192}
+	tests := []struct {
+		provider, model string
+		want            int
+	}{
+		{"openai", "gpt-4o", 128000},
+		{"openai", "gpt-4o-mini", 64000},
+		{"openai", "gpt-4-0613", 8192},
+		{"anthropic", "claude-sonnet-4-20250514", 200000},
+		{"deepseek", "deepseek-chat", 65536},
+		{"gemini", "", 1048576},
+		{"openai", "local-qwen", 8192},
+		{"openai", "unknown-model", DefaultContextWindow},
+		{"mock", "", DefaultContextWindow},
+	}
+	for _, tc := range tests {
+		if got := ContextWindow(tc.provider, tc.model, overrides); got != tc.want {
+			t.Errorf("ContextWindow(%q, %q) = %d, want %d", tc.provider, tc.model, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Tool 是提供给模型调用的工具
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage // 参数的 JSON Schema (type: object)
+}
+
+// ToolCall 是模型发起的工具调用。流式输出时为增量：同一 Index 的首个增量带 ID 与 Name，
+// Arguments 分片输出，用 ToolCalls 合并
+type ToolCall struct {
+	Index     int
+	ID        string
+	Name      string
+	Arguments string // JSON 对象
+}
+
+// ToolCalls 按 Index 合并流式的工具调用增量
+type ToolCalls struct {
+	calls map[int]*ToolCall
+}
+
+// Add 合并一个增量
+func (c *ToolCalls) Add(delta *ToolCall) {
+	if c.calls == nil {
+		c.calls = map[int]*ToolCall{}
+	}
+	call, ok := c.calls[delta.Index]
+	if !ok {
+		call = &ToolCall{Index: delta.Index}
+		c.calls[delta.Index] = call
+	}
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Name != "" {
+		call.Name = delta.Name
+	}
+	call.Arguments += delta.Arguments
+}
+
+// List 按 Index 顺序返回完整的工具调用，没有参数的调用补为 {}
+func (c *ToolCalls) List() []ToolCall {
+	out := make([]ToolCall, 0, len(c.calls))
+	for _, call := range c.calls {
+		if call.Arguments == "" {
+			call.Arguments = "{}"
+		}
+		out = append(out, *call)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Index < out[j].Index })
+	return out
+}
@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+var weatherTool = Tool{
+	Name:        "get_weather",
+	Description: "Get the weather",
+	Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+}
+
+// readToolCalls 读完 stream，返回回答与合并后的工具调用
+func readToolCalls(t *testing.T, stream <-chan StreamEvent) (string, []ToolCall) {
+	t.Helper()
+	var content string
+	var calls ToolCalls
+	for ev := range stream {
+		if ev.Err != nil {
+			t.Fatalf("stream error: %v", ev.Err)
+		}
+		content += ev.Content
+		if ev.ToolCall != nil {
+			calls.Add(ev.ToolCall)
+		}
+	}
+	return content, calls.List()
+}
+
+func TestToolCalls_Merge(t *testing.T) {
+	var calls ToolCalls
+	for _, delta := range []*ToolCall{
+		{Index: 1, ID: "b", Name: "end"},
+		{Index: 0, ID: "a", Name: "score"},
+		{Index: 0, Arguments: `{"score":`},
+		{Index: 0, Arguments: `80}`},
+	} {
+		calls.Add(delta)
+	}
+	got := calls.List()
+	if len(got) != 2 || got[0].ID != "a" || got[0].Arguments != `{"score":80}` || got[1].Name != "end" || got[1].Arguments != "{}" {
+		t.Errorf("unexpected calls: %+v", got)
+	}
+}
+
+func TestOpenAIProvider_ToolCalls(t *testing.T) {
+	var body map[string]any
+	srv := sseServer(t, &body,
+		`{"choices":[{"index":0,"delta":{"content":"Let me check."}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+		`[DONE]`,
+	)
+
+	stream, err := NewOpenAIProvider().ChatStream(context.Background(), &ChatRequest{
+		Messages: []Message{
+			{Role: "user", Content: "weather?"},
+			{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_0", Name: "get_weather", Arguments: `{"city":"Rome"}`}}},
+			{Role: "tool", ToolCallID: "call_0", Content: "sunny"},
+		},
+		Tools:   []Tool{weatherTool},
+		APIKey:  "k",
+		BaseURL: srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	content, calls := readToolCalls(t, stream)
+	if content != "Let me check." || len(calls) != 1 || calls[0].ID != "call_1" || calls[0].Arguments != `{"city":"Paris"}` {
+		t.Errorf("got %q, %+v", content, calls)
+	}
+
+	tools, _ := body["tools"].([]any)
+	if len(tools) != 1 || tools[0].(map[string]any)["function"].(map[string]any)["name"] != "get_weather" {
+		t.Errorf("unexpected tools: %v", body["tools"])
+	}
+	messages, _ := body["messages"].([]any)
+	if len(messages) != 3 {
+		t.Fatalf("unexpected messages: %v", body["messages"])
+	}
+	if calls, _ := messages[1].(map[string]any)["tool_calls"].([]any); len(calls) != 1 {
+		t.Errorf("assistant tool calls not sent: %v", messages[1])
+	}
+	if messages[2].(map[string]any)["tool_call_id"] != "call_0" {
+		t.Errorf("tool result not sent: %v", messages[2])
+	}
+}
+
+func TestAnthropicProvider_ToolUse(t *testing.T) {
+	var body map[string]any
+	srv := sseServer(t, &body,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me check."}}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+		`{"type":"message_stop"}`,
+	)
+
+	stream, err := NewAnthropicProvider().ChatStream(context.Background(), &ChatRequest{
+		Messages: []Message{
+			{Role: "system", Content: "sys"},
+			{Role: "user", Content: "weather?"},
+			{Role: "assistant", Content: "Checking.", ToolCalls: []ToolCall{
+				{ID: "toolu_a", Name: "get_weather", Arguments: `{"city":"Rome"}`},
+				{ID: "toolu_b", Name: "get_weather", Arguments: `{"city":"Oslo"}`},
+			}},
+			{Role: "tool", ToolCallID: "toolu_a", Content: "sunny"},
+			{Role: "tool", ToolCallID: "toolu_b", Content: "snow"},
+		},
+		Tools:   []Tool{weatherTool},
+		APIKey:  "k",
+		BaseURL: srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	content, calls := readToolCalls(t, stream)
+	if content != "Let me check." || len(calls) != 1 || calls[0].ID != "toolu_1" || calls[0].Arguments != `{"city":"Paris"}` {
+		t.Errorf("got %q, %+v", content, calls)
+	}
+
+	tools, _ := body["tools"].([]any)
+	if len(tools) != 1 || tools[0].(map[string]any)["input_schema"] == nil {
+		t.Errorf("unexpected tools: %v", body["tools"])
+	}
+	messages, _ := body["messages"].([]any)
+	if len(messages) != 3 {
+		t.Fatalf("expected user, assistant and merged tool results, got %v", body["messages"])
+	}
+	assistant, _ := messages[1].(map[string]any)["content"].([]any)
+	if len(assistant) != 3 || assistant[1].(map[string]any)["type"] != "tool_use" {
+		t.Errorf("unexpected assistant blocks: %v", assistant)
+	}
+	results, _ := messages[2].(map[string]any)["content"].([]any)
+	if messages[2].(map[string]any)["role"] != "user" || len(results) != 2 || results[1].(map[string]any)["tool_use_id"] != "toolu_b" {
+		t.Errorf("unexpected tool results: %v", messages[2])
+	}
+}
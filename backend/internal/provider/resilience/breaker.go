@@ -0,0 +1,100 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerState 熔断器状态
+type breakerState int
+
+const (
+	stateClosed   breakerState = iota // 正常放行
+	stateOpen                         // 熔断中，拒绝请求直到冷却结束
+	stateHalfOpen                     // 冷却结束，放行一个探测请求
+)
+
+// breaker 单个供应商端点的熔断器：连续失败达到阈值后熔断，冷却后放行一个探测请求，成功则恢复
+type breaker struct {
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// Breakers 按 key (provider + base URL) 管理熔断器
+type Breakers struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewBreakers 创建熔断器集合；threshold 为触发熔断的连续失败次数
+func NewBreakers(threshold int, cooldown time.Duration) *Breakers {
+	return &Breakers{threshold: threshold, cooldown: cooldown, now: time.Now, breakers: map[string]*breaker{}}
+}
+
+// Allow 是否放行对 key 的请求；半开状态下同一时间只放行一个探测请求
+func (b *Breakers) Allow(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.get(key)
+	switch br.state {
+	case stateOpen:
+		if b.now().Sub(br.openedAt) < b.cooldown {
+			return false
+		}
+		br.state = stateHalfOpen
+		br.probing = true
+		return true
+	case stateHalfOpen:
+		if br.probing {
+			return false
+		}
+		br.probing = true
+		return true
+	}
+	return true
+}
+
+// Success 记录一次成功，关闭熔断器
+func (b *Breakers) Success(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.get(key)
+	br.state, br.failures, br.probing = stateClosed, 0, false
+}
+
+// Failure 记录一次暂时性失败；探测失败或连续失败达到阈值时熔断
+func (b *Breakers) Failure(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.get(key)
+	br.failures++
+	if br.state == stateHalfOpen || br.failures >= b.threshold {
+		br.state, br.openedAt, br.probing = stateOpen, b.now(), false
+	}
+}
+
+// Release 结束一次既未成功也未失败 (例如鉴权错误) 的请求，半开状态下允许下一个探测
+func (b *Breakers) Release(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.get(key).probing = false
+}
+
+func (b *Breakers) get(key string) *breaker {
+	br, ok := b.breakers[key]
+	if !ok {
+		br = &breaker{}
+		b.breakers[key] = br
+	}
+	return br
+}
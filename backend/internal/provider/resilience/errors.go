@@ -0,0 +1,60 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrCircuitOpen 供应商熔断中，暂不发起请求
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// StatusError 供应商返回的非 2xx HTTP 状态
+type StatusError struct {
+	Op   string // 例如 "anthropic llm"
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: status %d: %s", e.Op, e.Code, e.Body)
+}
+
+// Retryable 判断错误是否为暂时性错误 (限流 / 服务端错误 / 网络中断)，值得退避后重试。
+// 鉴权失败、参数错误等重试也不会成功的错误返回 false。
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if code, ok := statusCode(err); ok {
+		return code == 408 || code == 429 || code >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// statusCode 从各供应商的错误类型中取出 HTTP 状态码
+func statusCode(err error) (int, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code, true
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode != 0 {
+		return apiErr.HTTPStatusCode, true
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0 {
+		return reqErr.HTTPStatusCode, true
+	}
+	return 0, false
+}
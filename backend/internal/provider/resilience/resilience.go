@@ -0,0 +1,94 @@
+// Package resilience 为供应商调用提供重试、熔断与错误分类，供 LLM / TTS 的备用链使用。
+package resilience
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Config 重试与熔断配置，零值字段使用默认值
+type Config struct {
+	MaxAttempts      int           // 单个供应商的最大尝试次数 (含首次)，默认 3
+	InitialBackoff   time.Duration // 首次重试前的最大退避，默认 200ms
+	MaxBackoff       time.Duration // 退避上限，默认 2s
+	FailureThreshold int           // 连续失败多少次后熔断，默认 5
+	Cooldown         time.Duration // 熔断持续时间，默认 30s
+}
+
+func (c *Config) setDefaults() {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 3
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = 200 * time.Millisecond
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 2 * time.Second
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 5
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 30 * time.Second
+	}
+}
+
+// Guard 对同一类供应商调用统一施加重试与熔断
+type Guard struct {
+	cfg      Config
+	breakers *Breakers
+}
+
+// NewGuard 创建 Guard
+func NewGuard(cfg Config) *Guard {
+	cfg.setDefaults()
+	return &Guard{cfg: cfg, breakers: NewBreakers(cfg.FailureThreshold, cfg.Cooldown)}
+}
+
+// Do 调用 fn：暂时性错误按带抖动的指数退避重试，熔断中直接返回 ErrCircuitOpen。
+// key 标识供应商端点 (provider + base URL)，只有暂时性错误计入熔断。
+// fn 返回 permanent 为 true 时不再重试 (例如已经向调用方输出了部分结果)。
+func (g *Guard) Do(ctx context.Context, key string, fn func() (permanent bool, err error)) error {
+	var err error
+	for attempt := 0; attempt < g.cfg.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(g.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if !g.breakers.Allow(key) {
+			return ErrCircuitOpen
+		}
+
+		var permanent bool
+		permanent, err = fn()
+		switch {
+		case err == nil:
+			g.breakers.Success(key)
+			return nil
+		case Retryable(err) && ctx.Err() == nil:
+			g.breakers.Failure(key)
+		default:
+			g.breakers.Release(key)
+			return err
+		}
+		if permanent {
+			return err
+		}
+	}
+	return err
+}
+
+// backoff 第 attempt 次重试前的等待时间：在 [0, min(MaxBackoff, InitialBackoff*2^(attempt-1))] 内均匀随机 (full jitter)
+func (g *Guard) backoff(attempt int) time.Duration {
+	d := g.cfg.InitialBackoff << (attempt - 1)
+	if d <= 0 || d > g.cfg.MaxBackoff {
+		d = g.cfg.MaxBackoff
+	}
+	return rand.N(d + 1)
+}
@@ -0,0 +1,154 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{Op: "test", Code: 429}, true},
+		{&StatusError{Op: "test", Code: 503}, true},
+		{fmt.Errorf("wrapped: %w", &StatusError{Op: "test", Code: 529}), true},
+		{&StatusError{Op: "test", Code: 401}, false},
+		{&StatusError{Op: "test", Code: 400}, false},
+		{&openai.APIError{HTTPStatusCode: 500}, true},
+		{&openai.APIError{HTTPStatusCode: 403}, false},
+		{&openai.RequestError{HTTPStatusCode: 502}, true},
+		{io.ErrUnexpectedEOF, true},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{errors.New("api key is required"), false},
+		{nil, false},
+	}
+	for _, tc := range tests {
+		if got := Retryable(tc.err); got != tc.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestBreakers(t *testing.T) {
+	b := NewBreakers(2, time.Minute)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	b.Failure("a")
+	if !b.Allow("a") {
+		t.Fatal("one failure should not open the breaker")
+	}
+	b.Failure("a")
+	if b.Allow("a") {
+		t.Fatal("breaker should open after threshold")
+	}
+	if !b.Allow("b") {
+		t.Fatal("breakers should be independent per key")
+	}
+
+	// 冷却后只放行一个探测请求，探测失败重新熔断
+	now = now.Add(time.Minute)
+	if !b.Allow("a") || b.Allow("a") {
+		t.Fatal("half-open breaker should allow exactly one probe")
+	}
+	b.Failure("a")
+	if b.Allow("a") {
+		t.Fatal("failed probe should reopen the breaker")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.Allow("a") {
+		t.Fatal("expected probe after cooldown")
+	}
+	b.Success("a")
+	if !b.Allow("a") || !b.Allow("a") {
+		t.Fatal("successful probe should close the breaker")
+	}
+}
+
+func TestGuard_Do(t *testing.T) {
+	cfg := Config{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, FailureThreshold: 5}
+	transient := &StatusError{Op: "test", Code: 503}
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		g := NewGuard(cfg)
+		calls := 0
+		err := g.Do(context.Background(), "k", func() (bool, error) {
+			calls++
+			if calls < 3 {
+				return false, transient
+			}
+			return false, nil
+		})
+		if err != nil || calls != 3 {
+			t.Fatalf("err = %v, calls = %d", err, calls)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		g := NewGuard(cfg)
+		calls := 0
+		err := g.Do(context.Background(), "k", func() (bool, error) {
+			calls++
+			return false, transient
+		})
+		if !errors.Is(err, transient) || calls != 3 {
+			t.Fatalf("err = %v, calls = %d", err, calls)
+		}
+	})
+
+	t.Run("does not retry permanent errors", func(t *testing.T) {
+		g := NewGuard(cfg)
+		calls := 0
+		auth := &StatusError{Op: "test", Code: 401}
+		for i := 0; i < 10; i++ {
+			_ = g.Do(context.Background(), "k", func() (bool, error) {
+				calls++
+				return false, auth
+			})
+		}
+		if calls != 10 {
+			t.Fatalf("calls = %d, want 10 (no retries, breaker untouched)", calls)
+		}
+	})
+
+	t.Run("stops when output already started", func(t *testing.T) {
+		g := NewGuard(cfg)
+		calls := 0
+		err := g.Do(context.Background(), "k", func() (bool, error) {
+			calls++
+			return true, transient
+		})
+		if !errors.Is(err, transient) || calls != 1 {
+			t.Fatalf("err = %v, calls = %d", err, calls)
+		}
+	})
+
+	t.Run("opens circuit", func(t *testing.T) {
+		g := NewGuard(Config{MaxAttempts: 1, FailureThreshold: 2, Cooldown: time.Minute})
+		fail := func() (bool, error) { return false, transient }
+		_ = g.Do(context.Background(), "k", fail)
+		_ = g.Do(context.Background(), "k", fail)
+		if err := g.Do(context.Background(), "k", fail); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected ErrCircuitOpen, got %v", err)
+		}
+	})
+
+	t.Run("respects context during backoff", func(t *testing.T) {
+		g := NewGuard(Config{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := g.Do(ctx, "k", func() (bool, error) { return false, transient })
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded, got %v", err)
+		}
+	})
+}
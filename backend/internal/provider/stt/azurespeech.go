@@ -0,0 +1,175 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ai-interview/internal/provider/resilience"
+)
+
+// AzureSpeechProvider 实现 Microsoft Azure Speech 官方 STT REST API：
+// WAV / OGG 使用短音频识别接口 (最长 60 秒)，其他格式 (如浏览器录制的 WebM) 与长录音使用文件转写接口
+type AzureSpeechProvider struct {
+	region     string
+	httpClient *http.Client
+}
+
+// NewAzureSpeechProvider 创建 Azure Speech STT Provider，region 为空时需在请求中指定 BaseURL
+func NewAzureSpeechProvider(region string) *AzureSpeechProvider {
+	return &AzureSpeechProvider{region: region, httpClient: &http.Client{}}
+}
+
+func (p *AzureSpeechProvider) Name() string {
+	return "azurespeech"
+}
+
+// 短音频识别接口支持的格式
+var shortAudioTypes = map[string]string{
+	"wav": "audio/wav; codecs=audio/pcm; samplerate=16000",
+	"ogg": "audio/ogg; codecs=opus",
+}
+
+// 语言代码未带地区时使用的识别语言
+var defaultLocales = map[string]string{
+	"zh": "zh-CN",
+	"en": "en-US",
+	"ja": "ja-JP",
+}
+
+// locale 返回识别语言，如 zh-CN
+func locale(language string) string {
+	if language == "" {
+		return "zh-CN"
+	}
+	if strings.Contains(language, "-") {
+		return language
+	}
+	if l, ok := defaultLocales[language]; ok {
+		return l
+	}
+	return language
+}
+
+func (p *AzureSpeechProvider) Transcribe(ctx context.Context, req *Request) (*Result, error) {
+	if req.APIKey == "" {
+		return nil, fmt.Errorf("azurespeech stt: api key is required")
+	}
+	if p.region == "" && req.BaseURL == "" {
+		return nil, fmt.Errorf("azurespeech stt: region is required")
+	}
+	if contentType, ok := shortAudioTypes[req.Format]; ok {
+		return p.shortAudio(ctx, req, contentType)
+	}
+	return p.fileTranscription(ctx, req)
+}
+
+// endpoint 返回服务地址：请求指定的 BaseURL 优先，否则按区域与服务拼接
+func (p *AzureSpeechProvider) endpoint(baseURL, host string) string {
+	if baseURL != "" {
+		return strings.TrimRight(baseURL, "/")
+	}
+	return fmt.Sprintf("https://%s.%s", p.region, host)
+}
+
+// shortAudio 短音频识别，一次请求返回整段文本
+func (p *AzureSpeechProvider) shortAudio(ctx context.Context, req *Request, contentType string) (*Result, error) {
+	language := locale(req.Language)
+	u := p.endpoint(req.BaseURL, "stt.speech.microsoft.com") +
+		"/speech/recognition/conversation/cognitiveservices/v1?" +
+		url.Values{"language": {language}, "format": {"simple"}}.Encode()
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", u, req.Audio)
+	if err != nil {
+		return nil, fmt.Errorf("azurespeech stt: creating request: %w", err)
+	}
+	httpReq.Header.Set("Ocp-Apim-Subscription-Key", req.APIKey)
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.Header.Set("Accept", "application/json")
+
+	var body struct {
+		RecognitionStatus string
+		DisplayText       string
+		Duration          int64 // 100 纳秒
+	}
+	if err := p.do(httpReq, &body); err != nil {
+		return nil, err
+	}
+	switch body.RecognitionStatus {
+	case "Success":
+	case "NoMatch", "InitialSilenceTimeout", "BabbleTimeout":
+		// 没有识别到语音
+	default:
+		return nil, fmt.Errorf("azurespeech stt: recognition status %s", body.RecognitionStatus)
+	}
+	return &Result{Text: body.DisplayText, Language: language, Duration: float64(body.Duration) / 1e7}, nil
+}
+
+// fileTranscription 文件转写 (fast transcription)，同步返回整段录音的文本
+func (p *AzureSpeechProvider) fileTranscription(ctx context.Context, req *Request) (*Result, error) {
+	language := locale(req.Language)
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("audio", "audio."+req.Format)
+	if err != nil {
+		return nil, fmt.Errorf("azurespeech stt: creating form: %w", err)
+	}
+	if _, err := io.Copy(part, req.Audio); err != nil {
+		return nil, fmt.Errorf("azurespeech stt: reading audio: %w", err)
+	}
+	definition, _ := json.Marshal(map[string]any{"locales": []string{language}})
+	if err := mw.WriteField("definition", string(definition)); err != nil {
+		return nil, fmt.Errorf("azurespeech stt: creating form: %w", err)
+	}
+	mw.Close()
+
+	u := p.endpoint(req.BaseURL, "api.cognitive.microsoft.com") + "/speechtotext/transcriptions:transcribe?api-version=2024-11-15"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", u, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("azurespeech stt: creating request: %w", err)
+	}
+	httpReq.Header.Set("Ocp-Apim-Subscription-Key", req.APIKey)
+	httpReq.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var body struct {
+		DurationMilliseconds int64 `json:"durationMilliseconds"`
+		CombinedPhrases      []struct {
+			Text string `json:"text"`
+		} `json:"combinedPhrases"`
+	}
+	if err := p.do(httpReq, &body); err != nil {
+		return nil, err
+	}
+	texts := make([]string, 0, len(body.CombinedPhrases))
+	for _, phrase := range body.CombinedPhrases {
+		texts = append(texts, phrase.Text)
+	}
+	return &Result{
+		Text:     strings.Join(texts, " "),
+		Language: language,
+		Duration: float64(body.DurationMilliseconds) / 1000,
+	}, nil
+}
+
+// do 发送请求并解析 JSON 响应
+func (p *AzureSpeechProvider) do(httpReq *http.Request, out any) error {
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("azurespeech stt: request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &resilience.StatusError{Op: "azurespeech stt", Code: resp.StatusCode, Body: string(respBody)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("azurespeech stt: decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package stt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAzureSpeechProvider_Transcribe(t *testing.T) {
+	var path, language, contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Ocp-Apim-Subscription-Key") != "speech-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		path, language, contentType = r.URL.Path, r.URL.Query().Get("language"), r.Header.Get("Content-Type")
+		switch r.URL.Path {
+		case "/speech/recognition/conversation/cognitiveservices/v1":
+			fmt.Fprint(w, `{"RecognitionStatus":"Success","DisplayText":"你好。","Duration":15000000}`)
+		case "/speechtotext/transcriptions:transcribe":
+			if err := r.ParseMultipartForm(1 << 20); err != nil || !strings.Contains(r.FormValue("definition"), "en-US") {
+				http.Error(w, "bad form", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"durationMilliseconds":2500,"combinedPhrases":[{"text":"Hello there."}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewAzureSpeechProvider("")
+	res, err := p.Transcribe(context.Background(), &Request{
+		Audio: strings.NewReader("RIFF"), Format: "wav", Language: "zh", APIKey: "speech-key", BaseURL: srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("short audio: %v", err)
+	}
+	if language != "zh-CN" || !strings.HasPrefix(contentType, "audio/wav") || res.Text != "你好。" || res.Duration != 1.5 {
+		t.Errorf("unexpected short audio result: language=%q type=%q %+v", language, contentType, res)
+	}
+
+	res, err = p.Transcribe(context.Background(), &Request{
+		Audio: strings.NewReader("webm"), Format: "webm", Language: "en-US", APIKey: "speech-key", BaseURL: srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("file transcription: %v", err)
+	}
+	if path != "/speechtotext/transcriptions:transcribe" || res.Text != "Hello there." || res.Duration != 2.5 {
+		t.Errorf("webm should use file transcription: path=%q %+v", path, res)
+	}
+}
@@ -0,0 +1,66 @@
+package stt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var defaultMockTranscripts = []string{
+	"你好，我是一名后端工程师，有五年 Go 开发经验，最近在做一个高并发的订单系统。",
+	"最大的挑战是库存扣减的一致性，我们用 Redis 预扣加消息队列异步落库解决。",
+	"我会先做压测定位瓶颈，然后从缓存、分库分表和服务水平扩展几个方面优化。",
+}
+
+// MockProvider 按顺序循环返回预置的转写文本，用于演示模式和端到端测试
+type MockProvider struct {
+	mu          sync.Mutex
+	transcripts []string
+	next        int
+}
+
+// NewMockProvider 创建 mock STT Provider，transcripts 为空时使用内置默认值
+func NewMockProvider(transcripts []string) *MockProvider {
+	if len(transcripts) == 0 {
+		transcripts = defaultMockTranscripts
+	}
+	return &MockProvider{transcripts: transcripts}
+}
+
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+func (p *MockProvider) Transcribe(ctx context.Context, req *Request) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var size int64
+	if req.Audio != nil {
+		n, err := io.Copy(io.Discard, req.Audio)
+		if err != nil {
+			return nil, fmt.Errorf("mock stt: read audio: %w", err)
+		}
+		size = n
+	}
+
+	p.mu.Lock()
+	text := p.transcripts[p.next%len(p.transcripts)]
+	p.next++
+	p.mu.Unlock()
+
+	language := req.Language
+	if language == "" {
+		language = "zh"
+	}
+
+	// 仅 PCM (16-bit 单声道 24kHz) 可直接由字节数推算时长
+	var duration float64
+	if req.Format == "pcm" {
+		duration = float64(size) / (24000 * 2)
+	}
+
+	return &Result{Text: text, Language: language, Duration: duration}, nil
+}
@@ -18,7 +18,7 @@ type Provider interface {
 type Request struct {
 	Audio    io.Reader // 音频数据
 	Format   string    // 音频格式 (webm, wav, mp3)
-	Language string    // 语言代码 (zh, en)
+	Language string    // 语言代码 (zh-CN, en-US)
 	Model    string    // 模型名称 (whisper-1, gpt-4o-mini-transcribe)
 	APIKey   string    // BYOK API Key
 	BaseURL  string    // 自定义端点 (可选)
@@ -3,28 +3,53 @@ package stt
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	openai "github.com/sashabaranov/go-openai"
 )
 
 // WhisperProvider 实现 OpenAI Whisper STT
-type WhisperProvider struct{}
+type WhisperProvider struct {
+	name string
+	// azure 为 Azure OpenAI：BaseURL 为资源端点，请求发往 deployment
+	azure      bool
+	apiVersion string
+	deployment string
+}
 
 func NewWhisperProvider() *WhisperProvider {
-	return &WhisperProvider{}
+	return &WhisperProvider{name: "whisper"}
+}
+
+// AzureAPIVersion Azure OpenAI 的默认 API 版本
+const AzureAPIVersion = "2024-10-21"
+
+// NewAzureProvider 创建 Azure OpenAI STT Provider
+func NewAzureProvider(apiVersion, deployment string) *WhisperProvider {
+	if apiVersion == "" {
+		apiVersion = AzureAPIVersion
+	}
+	return &WhisperProvider{name: "azure", azure: true, apiVersion: apiVersion, deployment: deployment}
 }
 
 func (p *WhisperProvider) Name() string {
-	return "whisper"
+	return p.name
 }
 
 func (p *WhisperProvider) Transcribe(ctx context.Context, req *Request) (*Result, error) {
 	if req.APIKey == "" {
-		return nil, fmt.Errorf("whisper stt: api key is required")
+		return nil, fmt.Errorf("%s stt: api key is required", p.name)
 	}
 
 	config := openai.DefaultConfig(req.APIKey)
-	if req.BaseURL != "" {
+	if p.azure {
+		if req.BaseURL == "" {
+			return nil, fmt.Errorf("%s stt: endpoint is required", p.name)
+		}
+		config = openai.DefaultAzureConfig(req.APIKey, req.BaseURL)
+		config.APIVersion = p.apiVersion
+		config.AzureModelMapperFunc = p.deploymentFor
+	} else if req.BaseURL != "" {
 		config.BaseURL = req.BaseURL
 	}
 	client := openai.NewClientWithConfig(config)
@@ -34,7 +59,7 @@ func (p *WhisperProvider) Transcribe(ctx context.Context, req *Request) (*Result
 		model = "whisper-1"
 	}
 
-	language := req.Language
+	language, _, _ := strings.Cut(req.Language, "-") // Whisper 使用 ISO-639-1: zh-CN -> zh
 	if language == "" {
 		language = "zh"
 	}
@@ -48,7 +73,7 @@ func (p *WhisperProvider) Transcribe(ctx context.Context, req *Request) (*Result
 
 	resp, err := client.CreateTranscription(ctx, transcriptionReq)
 	if err != nil {
-		return nil, fmt.Errorf("whisper stt: %w", err)
+		return nil, fmt.Errorf("%s stt: %w", p.name, err)
 	}
 
 	return &Result{
@@ -57,3 +82,11 @@ func (p *WhisperProvider) Transcribe(ctx context.Context, req *Request) (*Result
 		Duration: float64(resp.Duration),
 	}, nil
 }
+
+// deploymentFor Azure 请求使用的部署名，未配置时与模型同名
+func (p *WhisperProvider) deploymentFor(model string) string {
+	if p.deployment != "" {
+		return p.deployment
+	}
+	return model
+}
@@ -0,0 +1,170 @@
+package tts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"ai-interview/internal/provider/resilience"
+)
+
+// AzureSpeechProvider 实现 Microsoft Azure Speech 官方 TTS REST API (SSML)
+type AzureSpeechProvider struct {
+	region     string
+	httpClient *http.Client
+}
+
+// NewAzureSpeechProvider 创建 Azure Speech TTS Provider，region 为空时需在请求中指定 BaseURL
+func NewAzureSpeechProvider(region string) *AzureSpeechProvider {
+	return &AzureSpeechProvider{region: region, httpClient: &http.Client{}}
+}
+
+func (p *AzureSpeechProvider) Name() string {
+	return "azurespeech"
+}
+
+// Voice 语音列表项
+type Voice struct {
+	Name        string // 合成时使用的音色名，如 zh-CN-XiaoxiaoNeural
+	DisplayName string
+	Locale      string
+	Gender      string
+	Styles      []string // 支持的说话风格
+	Roles       []string // 支持的角色扮演
+}
+
+// VoiceLister 可列出可用音色的 Provider
+type VoiceLister interface {
+	Voices(ctx context.Context, apiKey, baseURL string) ([]*Voice, error)
+}
+
+// endpoint 返回服务地址：请求指定的 BaseURL 优先，否则按区域拼接
+func (p *AzureSpeechProvider) endpoint(baseURL string) (string, error) {
+	if baseURL != "" {
+		return strings.TrimRight(baseURL, "/"), nil
+	}
+	if p.region == "" {
+		return "", fmt.Errorf("azurespeech tts: region is required")
+	}
+	return fmt.Sprintf("https://%s.tts.speech.microsoft.com", p.region), nil
+}
+
+func (p *AzureSpeechProvider) Synthesize(ctx context.Context, req *Request, w io.Writer) error {
+	if req.APIKey == "" {
+		return fmt.Errorf("azurespeech tts: api key is required")
+	}
+	endpoint, err := p.endpoint(req.BaseURL)
+	if err != nil {
+		return err
+	}
+
+	voice := req.Voice
+	if voice == "" {
+		voice = defaultVoice(req.Language)
+	}
+	language := req.Language
+	if language == "" {
+		// xml:lang 必填，按音色名前缀 (zh-CN-XiaoxiaoNeural) 推断
+		if parts := strings.SplitN(voice, "-", 3); len(parts) == 3 {
+			language = parts[0] + "-" + parts[1]
+		}
+	}
+	ssml := SSML{
+		Language: language,
+		Voice:    voice,
+		Style:    orDefault(req.Style, deliveryStyles, req.Delivery),
+		Role:     req.Role,
+		Rate:     req.Speed,
+		Pitch:    req.Pitch,
+		Text:     req.Text,
+	}.String()
+
+	outputFormat := "raw-24khz-16bit-mono-pcm"
+	switch req.Format {
+	case "mp3":
+		outputFormat = "audio-24khz-48kbitrate-mono-mp3"
+	case "opus":
+		outputFormat = "ogg-24khz-16bit-mono-opus"
+	case "wav":
+		outputFormat = "riff-24khz-16bit-mono-pcm"
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint+"/cognitiveservices/v1", strings.NewReader(ssml))
+	if err != nil {
+		return fmt.Errorf("azurespeech tts: creating request: %w", err)
+	}
+	httpReq.Header.Set("Ocp-Apim-Subscription-Key", req.APIKey)
+	httpReq.Header.Set("Content-Type", "application/ssml+xml")
+	httpReq.Header.Set("X-Microsoft-OutputFormat", outputFormat)
+	httpReq.Header.Set("User-Agent", "ai-interview")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("azurespeech tts: request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &resilience.StatusError{Op: "azurespeech tts", Code: resp.StatusCode, Body: string(respBody)}
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("azurespeech tts: streaming: %w", err)
+	}
+	return nil
+}
+
+// Voices 列出区域内可用的音色
+func (p *AzureSpeechProvider) Voices(ctx context.Context, apiKey, baseURL string) ([]*Voice, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("azurespeech tts: api key is required")
+	}
+	endpoint, err := p.endpoint(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint+"/cognitiveservices/voices/list", nil)
+	if err != nil {
+		return nil, fmt.Errorf("azurespeech tts: creating request: %w", err)
+	}
+	httpReq.Header.Set("Ocp-Apim-Subscription-Key", apiKey)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("azurespeech tts: request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, &resilience.StatusError{Op: "azurespeech tts", Code: resp.StatusCode, Body: string(respBody)}
+	}
+
+	var list []struct {
+		ShortName    string
+		DisplayName  string
+		Locale       string
+		Gender       string
+		StyleList    []string
+		RolePlayList []string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("azurespeech tts: decode voices: %w", err)
+	}
+	voices := make([]*Voice, 0, len(list))
+	for _, v := range list {
+		voices = append(voices, &Voice{
+			Name:        v.ShortName,
+			DisplayName: v.DisplayName,
+			Locale:      v.Locale,
+			Gender:      v.Gender,
+			Styles:      v.StyleList,
+			Roles:       v.RolePlayList,
+		})
+	}
+	return voices, nil
+}
@@ -0,0 +1,47 @@
+package tts
+
+// 语句的表达方式，由面试引擎按句推断，各 provider 映射为自身支持的参数
+const (
+	DeliveryNeutral     = "neutral"     // 中性提问
+	DeliveryEncouraging = "encouraging" // 鼓励性反馈
+	DeliveryProbing     = "probing"     // 追问
+)
+
+// deliveryInstructions gpt-4o-mini-tts 的语气指令
+var deliveryInstructions = map[string]string{
+	DeliveryNeutral:     "Speak as a calm, professional interviewer asking a clear question.",
+	DeliveryEncouraging: "Speak warmly and encouragingly, like an interviewer acknowledging a good answer.",
+	DeliveryProbing:     "Speak with focused curiosity, like an interviewer probing for more detail.",
+}
+
+// deliveryStyles Azure Speech 的说话风格，音色不支持时由服务端忽略
+var deliveryStyles = map[string]string{
+	DeliveryEncouraging: "friendly",
+	DeliveryProbing:     "serious",
+}
+
+// deliveryPitch Edge TTS 不支持说话风格，以音调近似
+var deliveryPitch = map[string]string{
+	DeliveryEncouraging: "+5%",
+	DeliveryProbing:     "-3%",
+}
+
+// voiceSettings ElevenLabs 的音色参数：稳定性越低越富表现力，style 放大说话人的风格
+type voiceSettings struct {
+	Stability float64
+	Style     float64
+}
+
+var deliveryVoiceSettings = map[string]voiceSettings{
+	DeliveryNeutral:     {Stability: 0.5, Style: 0},
+	DeliveryEncouraging: {Stability: 0.35, Style: 0.45},
+	DeliveryProbing:     {Stability: 0.6, Style: 0.2},
+}
+
+// orDefault 显式设置的值优先，否则取表达方式对应的值
+func orDefault(explicit string, table map[string]string, delivery string) string {
+	if explicit != "" {
+		return explicit
+	}
+	return table[delivery]
+}
@@ -0,0 +1,116 @@
+package tts
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// AudioDuration 从音频数据解析播放时长，支持 mp3 / wav / pcm (24kHz 16-bit 单声道)。
+// 无法解析时 ok 为 false，调用方可回退到 EstimateSpeechDuration。
+func AudioDuration(format string, data []byte) (d time.Duration, ok bool) {
+	switch format {
+	case "mp3":
+		return mp3Duration(data)
+	case "wav":
+		return wavDuration(data)
+	case "pcm":
+		return time.Duration(len(data)/2) * time.Second / 24000, len(data) > 0
+	}
+	return 0, false
+}
+
+// MPEG 音频帧头中的码率 (kbps) 与采样率表
+var (
+	mp3Bitrates = map[[2]int][16]int{
+		{1, 1}: {0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{1, 2}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{1, 3}: {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		{2, 1}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{2, 2}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{2, 3}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	}
+	mp3SampleRates = [3]int{44100, 48000, 32000}
+)
+
+// mp3Duration 跳过 ID3v2 标签后逐帧累加采样数 (兼容 VBR)
+func mp3Duration(data []byte) (time.Duration, bool) {
+	pos := 0
+	if len(data) >= 10 && string(data[:3]) == "ID3" {
+		size := int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f)
+		pos = 10 + size
+	}
+
+	var total time.Duration
+	frames := 0
+	for pos+4 <= len(data) {
+		h := binary.BigEndian.Uint32(data[pos:])
+		if h>>21 != 0x7ff {
+			pos++ // 非帧头，向后搜索同步字
+			continue
+		}
+		version := (h >> 19) & 3 // 0: MPEG2.5, 2: MPEG2, 3: MPEG1
+		layer := 4 - int((h>>17)&3)
+		brIndex := (h >> 12) & 0xf
+		srIndex := (h >> 10) & 3
+		if version == 1 || layer == 4 || brIndex == 0 || brIndex == 15 || srIndex == 3 {
+			pos++
+			continue
+		}
+
+		v := 1
+		sampleRate := mp3SampleRates[srIndex]
+		switch version {
+		case 2:
+			v, sampleRate = 2, sampleRate/2
+		case 0:
+			v, sampleRate = 2, sampleRate/4
+		}
+		bitrate := mp3Bitrates[[2]int{v, layer}][brIndex] * 1000
+		padding := int((h >> 9) & 1)
+
+		var size, samples int
+		switch {
+		case layer == 1:
+			size, samples = (12*bitrate/sampleRate+padding)*4, 384
+		case layer == 3 && v == 2:
+			size, samples = 72*bitrate/sampleRate+padding, 576
+		default:
+			size, samples = 144*bitrate/sampleRate+padding, 1152
+		}
+
+		total += time.Duration(samples) * time.Second / time.Duration(sampleRate)
+		frames++
+		pos += size
+	}
+	return total, frames > 0
+}
+
+// wavDuration 读取 fmt 块的字节率与 data 块长度
+func wavDuration(data []byte) (time.Duration, bool) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return 0, false
+	}
+	byteRate := 0
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		switch id {
+		case "fmt ":
+			if pos+20 > len(data) {
+				return 0, false
+			}
+			byteRate = int(binary.LittleEndian.Uint32(data[pos+16:]))
+		case "data":
+			if byteRate == 0 {
+				return 0, false
+			}
+			// 流式生成的 WAV 头中长度可能未知 (0 / 0xFFFFFFFF)，以实际数据为准
+			if avail := len(data) - pos - 8; size == 0 || size > avail {
+				size = avail
+			}
+			return time.Duration(size) * time.Second / time.Duration(byteRate), true
+		}
+		pos += 8 + size + size&1
+	}
+	return 0, false
+}
@@ -29,11 +29,7 @@ func (p *EdgeTTSProvider) Name() string {
 func (p *EdgeTTSProvider) Synthesize(ctx context.Context, req *Request, w io.Writer) error {
 	voice := req.Voice
 	if voice == "" {
-		if strings.HasPrefix(req.Language, "zh") {
-			voice = "zh-CN-XiaoxiaoNeural"
-		} else {
-			voice = "en-US-AriaNeural"
-		}
+		voice = defaultVoice(req.Language)
 	}
 
 	outputFormat := "audio-24khz-48kbitrate-mono-mp3"
@@ -70,12 +66,14 @@ func (p *EdgeTTSProvider) Synthesize(ctx context.Context, req *Request, w io.Wri
 		return fmt.Errorf("edgetts: send config: %w", err)
 	}
 
-	// 构建 SSML
-	ssml := fmt.Sprintf(
-		`<speak version='1.0' xmlns='http://www.w3.org/2001/10/synthesis' xml:lang='%s'>`+
-			`<voice name='%s'>%s</voice></speak>`,
-		req.Language, voice, escapeXML(req.Text),
-	)
+	// 构建 SSML (Edge 不支持 mstts:express-as，只设置语速与音调)
+	ssml := SSML{
+		Language: req.Language,
+		Voice:    voice,
+		Rate:     req.Speed,
+		Pitch:    orDefault(req.Pitch, deliveryPitch, req.Delivery),
+		Text:     req.Text,
+	}.String()
 
 	requestID := generateConnectID()
 	ssmlMsg := fmt.Sprintf(
@@ -129,12 +127,3 @@ func generateConnectID() string {
 	rand.Read(b)
 	return hex.EncodeToString(b)
 }
-
-func escapeXML(s string) string {
-	s = strings.ReplaceAll(s, "&", "&amp;")
-	s = strings.ReplaceAll(s, "<", "&lt;")
-	s = strings.ReplaceAll(s, ">", "&gt;")
-	s = strings.ReplaceAll(s, "'", "&apos;")
-	s = strings.ReplaceAll(s, "\"", "&quot;")
-	return s
-}
@@ -1,10 +1,14 @@
 package tts
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+
+	"ai-interview/internal/provider/resilience"
 )
 
 // ElevenLabsProvider 实现 ElevenLabs TTS API
@@ -50,20 +54,31 @@ func (p *ElevenLabsProvider) Synthesize(ctx context.Context, req *Request, w io.
 	}
 	url += "?output_format=" + outputFormat
 
-	body := fmt.Sprintf(`{
-		"text": %q,
+	settings, ok := deliveryVoiceSettings[req.Delivery]
+	if !ok {
+		settings = deliveryVoiceSettings[DeliveryNeutral]
+	}
+	// ElevenLabs 语速范围 0.7-1.2
+	speed := 1.0
+	if req.Speed > 0 {
+		speed = min(max(req.Speed, 0.7), 1.2)
+	}
+	body, err := json.Marshal(map[string]any{
+		"text":     req.Text,
 		"model_id": "eleven_flash_v2_5",
-		"voice_settings": {
-			"stability": 0.5,
-			"similarity_boost": 0.75,
-			"style": 0.0,
-			"use_speaker_boost": true
-		}
-	}`, req.Text)
-
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, io.NopCloser(
-		io.Reader(stringReader(body)),
-	))
+		"voice_settings": map[string]any{
+			"stability":         settings.Stability,
+			"similarity_boost":  0.75,
+			"style":             settings.Style,
+			"use_speaker_boost": true,
+			"speed":             speed,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("elevenlabs tts: encoding request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("elevenlabs tts: creating request: %w", err)
 	}
@@ -80,7 +95,7 @@ func (p *ElevenLabsProvider) Synthesize(ctx context.Context, req *Request, w io.
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("elevenlabs tts: status %d: %s", resp.StatusCode, string(respBody))
+		return &resilience.StatusError{Op: "elevenlabs tts", Code: resp.StatusCode, Body: string(respBody)}
 	}
 
 	if _, err := io.Copy(w, resp.Body); err != nil {
@@ -89,21 +104,3 @@ func (p *ElevenLabsProvider) Synthesize(ctx context.Context, req *Request, w io.
 
 	return nil
 }
-
-type stringReaderImpl struct {
-	s string
-	i int
-}
-
-func stringReader(s string) io.Reader {
-	return &stringReaderImpl{s: s}
-}
-
-func (r *stringReaderImpl) Read(p []byte) (n int, err error) {
-	if r.i >= len(r.s) {
-		return 0, io.EOF
-	}
-	n = copy(p, r.s[r.i:])
-	r.i += n
-	return n, nil
-}
@@ -0,0 +1,78 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"ai-interview/internal/provider/resilience"
+)
+
+// Target 备用链中的一个候选：provider 及调用它使用的音色与凭据
+type Target struct {
+	Provider Provider
+	Voice    string
+	APIKey   string
+	BaseURL  string
+}
+
+// key 熔断器 key：同一 provider 的不同端点分别熔断
+func (t Target) key() string {
+	return t.Provider.Name() + "|" + t.BaseURL
+}
+
+// Failover 按备用链依次调用多个 Provider，保证首选语音服务不可用时仍有声音。
+// 每个候选在写出首个字节前遇到暂时性错误时退避重试，熔断中的候选直接跳过；
+// 已写出部分音频后的错误原样返回。音色与凭据取自 Target，忽略请求中的 Voice / APIKey / BaseURL。
+type Failover struct {
+	guard      *resilience.Guard
+	targets    []Target
+	onFailover func(from Target, err error)
+}
+
+// NewFailover 创建备用链；onFailover 在放弃某个候选时调用 (可为 nil)
+func NewFailover(guard *resilience.Guard, targets []Target, onFailover func(from Target, err error)) *Failover {
+	return &Failover{guard: guard, targets: targets, onFailover: onFailover}
+}
+
+// Name 返回首选 provider 的名称
+func (f *Failover) Name() string {
+	return f.targets[0].Provider.Name()
+}
+
+func (f *Failover) Synthesize(ctx context.Context, req *Request, w io.Writer) error {
+	cw := &countingWriter{w: w}
+	var errs []error
+	for _, target := range f.targets {
+		r := *req
+		r.Voice, r.APIKey, r.BaseURL = target.Voice, target.APIKey, target.BaseURL
+		err := f.guard.Do(ctx, target.key(), func() (bool, error) {
+			err := target.Provider.Synthesize(ctx, &r, cw)
+			return cw.n > 0, err
+		})
+		if err == nil {
+			return nil
+		}
+		if cw.n > 0 || ctx.Err() != nil {
+			return err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", target.Provider.Name(), err))
+		if f.onFailover != nil {
+			f.onFailover(target, err)
+		}
+	}
+	return fmt.Errorf("all tts providers failed: %w", errors.Join(errs...))
+}
+
+// countingWriter 记录已写出的字节数，判断是否还能安全重试
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,81 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"ai-interview/internal/provider/resilience"
+)
+
+// scriptedProvider 写出 audio 后返回第 i 次调用对应的错误
+type scriptedProvider struct {
+	name  string
+	audio string
+	errs  []error
+	calls int
+	last  *Request
+}
+
+func (p *scriptedProvider) Name() string { return p.name }
+
+func (p *scriptedProvider) Synthesize(_ context.Context, req *Request, w io.Writer) error {
+	p.calls++
+	p.last = req
+	if p.audio != "" {
+		if _, err := io.WriteString(w, p.audio); err != nil {
+			return err
+		}
+	}
+	if p.calls <= len(p.errs) {
+		return p.errs[p.calls-1]
+	}
+	return nil
+}
+
+func testGuard() *resilience.Guard {
+	return resilience.NewGuard(resilience.Config{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+}
+
+func TestFailover_FallsBackToNextVoice(t *testing.T) {
+	limited := &resilience.StatusError{Op: "elevenlabs tts", Code: 429}
+	primary := &scriptedProvider{name: "elevenlabs", errs: []error{limited, limited}}
+	backup := &scriptedProvider{name: "edgetts", audio: "mp3"}
+	f := NewFailover(testGuard(), []Target{
+		{Provider: primary, Voice: "rachel", APIKey: "user-key"},
+		{Provider: backup, Voice: "zh-CN-XiaoxiaoNeural"},
+	}, nil)
+
+	var buf bytes.Buffer
+	if err := f.Synthesize(context.Background(), &Request{Text: "你好", Voice: "ignored"}, &buf); err != nil {
+		t.Fatalf("Synthesize error: %v", err)
+	}
+	if buf.String() != "mp3" {
+		t.Errorf("audio = %q", buf.String())
+	}
+	if primary.calls != 2 || primary.last.Voice != "rachel" || primary.last.APIKey != "user-key" {
+		t.Errorf("primary calls = %d, last request %+v", primary.calls, primary.last)
+	}
+	if backup.last.Voice != "zh-CN-XiaoxiaoNeural" || backup.last.APIKey != "" {
+		t.Errorf("fallback should use its own voice, got %+v", backup.last)
+	}
+}
+
+func TestFailover_NoRetryAfterPartialAudio(t *testing.T) {
+	unavailable := &resilience.StatusError{Op: "edgetts", Code: 503}
+	primary := &scriptedProvider{name: "edgetts", audio: "part", errs: []error{unavailable}}
+	backup := &scriptedProvider{name: "openai", audio: "full"}
+	f := NewFailover(testGuard(), []Target{{Provider: primary}, {Provider: backup}}, nil)
+
+	var buf bytes.Buffer
+	err := f.Synthesize(context.Background(), &Request{Text: "你好"}, &buf)
+	if !errors.Is(err, unavailable) {
+		t.Fatalf("expected primary error, got %v", err)
+	}
+	if primary.calls != 1 || backup.calls != 0 || buf.String() != "part" {
+		t.Errorf("primary calls %d, backup calls %d, audio %q", primary.calls, backup.calls, buf.String())
+	}
+}
@@ -0,0 +1,140 @@
+package tts
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MockProvider 生成与文本长度相称的静音 / 提示音音频，用于演示模式和端到端测试
+type MockProvider struct {
+	tone bool // true 输出 440Hz 提示音，false 输出静音 (仅 pcm / wav)
+}
+
+// NewMockProvider 创建 mock TTS Provider
+func NewMockProvider(tone bool) *MockProvider {
+	return &MockProvider{tone: tone}
+}
+
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+func (p *MockProvider) Synthesize(ctx context.Context, req *Request, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	duration := EstimateSpeechDuration(req.Text, req.Speed)
+	sampleRate := req.SampleRate
+	if sampleRate == 0 {
+		sampleRate = 24000
+	}
+
+	var data []byte
+	switch req.Format {
+	case "", "pcm":
+		data = p.pcm(duration, sampleRate)
+	case "wav":
+		pcm := p.pcm(duration, sampleRate)
+		data = append(wavHeader(len(pcm), sampleRate), pcm...)
+	case "mp3":
+		data = silentMP3(duration)
+	default:
+		return fmt.Errorf("mock tts: unsupported format %q", req.Format)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("mock tts: write audio: %w", err)
+	}
+	return nil
+}
+
+// EstimateSpeechDuration 按正常语速估算朗读时长：中文约 4 字/秒，英文约 2.5 词/秒
+func EstimateSpeechDuration(text string, speed float64) time.Duration {
+	cjk, words := 0, 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+
+	d := time.Duration(cjk)*250*time.Millisecond + time.Duration(words)*400*time.Millisecond
+	if strings.TrimSpace(text) != "" && d < 500*time.Millisecond {
+		d = 500 * time.Millisecond
+	}
+	if speed > 0 {
+		d = time.Duration(float64(d) / speed)
+	}
+	return d
+}
+
+// pcm 生成 16-bit little-endian 单声道 PCM
+func (p *MockProvider) pcm(duration time.Duration, sampleRate int) []byte {
+	samples := int(duration.Seconds() * float64(sampleRate))
+	buf := make([]byte, samples*2)
+	if !p.tone {
+		return buf
+	}
+	for i := 0; i < samples; i++ {
+		v := int16(0.2 * math.MaxInt16 * math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate)))
+		binary.LittleEndian.PutUint16(buf[i*2:], uint16(v))
+	}
+	return buf
+}
+
+// wavHeader 生成 44 字节的 PCM WAV 头 (16-bit 单声道)
+func wavHeader(dataLen, sampleRate int) []byte {
+	h := make([]byte, 44)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], uint32(36+dataLen))
+	copy(h[8:], "WAVE")
+	copy(h[12:], "fmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)                   // fmt chunk size
+	binary.LittleEndian.PutUint16(h[20:], 1)                    // PCM
+	binary.LittleEndian.PutUint16(h[22:], 1)                    // 单声道
+	binary.LittleEndian.PutUint32(h[24:], uint32(sampleRate))   // 采样率
+	binary.LittleEndian.PutUint32(h[28:], uint32(sampleRate*2)) // 字节率
+	binary.LittleEndian.PutUint16(h[32:], 2)                    // block align
+	binary.LittleEndian.PutUint16(h[34:], 16)                   // bits per sample
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], uint32(dataLen))
+	return h
+}
+
+const (
+	// MPEG-1 Layer III, 128kbps, 44.1kHz, 单声道, 无 CRC
+	mp3FrameHeader = 0xFFFB90C0
+	// 每帧字节数 = 144 * bitrate / sampleRate = 144 * 128000 / 44100
+	mp3FrameSize = 417
+	// 每帧 1152 个采样
+	mp3FrameDuration = time.Second * 1152 / 44100
+)
+
+// silentMP3 生成合法的静音 MP3 帧序列：side info 与主数据全零即解码为静音
+func silentMP3(duration time.Duration) []byte {
+	frames := int((duration + mp3FrameDuration - 1) / mp3FrameDuration)
+	if frames == 0 {
+		frames = 1
+	}
+	buf := make([]byte, frames*mp3FrameSize)
+	for i := 0; i < frames; i++ {
+		binary.BigEndian.PutUint32(buf[i*mp3FrameSize:], mp3FrameHeader)
+	}
+	return buf
+}
@@ -9,23 +9,47 @@ import (
 )
 
 // OpenAIProvider 实现 OpenAI TTS API
-type OpenAIProvider struct{}
+type OpenAIProvider struct {
+	name string
+	// azure 为 Azure OpenAI：BaseURL 为资源端点，请求发往 deployment
+	azure      bool
+	apiVersion string
+	deployment string
+}
 
 func NewOpenAIProvider() *OpenAIProvider {
-	return &OpenAIProvider{}
+	return &OpenAIProvider{name: "openai"}
 }
 
+// NewAzureProvider 创建 Azure OpenAI TTS Provider
+func NewAzureProvider(apiVersion, deployment string) *OpenAIProvider {
+	if apiVersion == "" {
+		apiVersion = AzureAPIVersion
+	}
+	return &OpenAIProvider{name: "azure", azure: true, apiVersion: apiVersion, deployment: deployment}
+}
+
+// AzureAPIVersion Azure OpenAI 的默认 API 版本
+const AzureAPIVersion = "2025-03-01-preview"
+
 func (p *OpenAIProvider) Name() string {
-	return "openai"
+	return p.name
 }
 
 func (p *OpenAIProvider) Synthesize(ctx context.Context, req *Request, w io.Writer) error {
 	if req.APIKey == "" {
-		return fmt.Errorf("openai tts: api key is required")
+		return fmt.Errorf("%s tts: api key is required", p.name)
 	}
 
 	config := openai.DefaultConfig(req.APIKey)
-	if req.BaseURL != "" {
+	if p.azure {
+		if req.BaseURL == "" {
+			return fmt.Errorf("%s tts: endpoint is required", p.name)
+		}
+		config = openai.DefaultAzureConfig(req.APIKey, req.BaseURL)
+		config.APIVersion = p.apiVersion
+		config.AzureModelMapperFunc = p.deploymentFor
+	} else if req.BaseURL != "" {
 		config.BaseURL = req.BaseURL
 	}
 	client := openai.NewClientWithConfig(config)
@@ -35,8 +59,13 @@ func (p *OpenAIProvider) Synthesize(ctx context.Context, req *Request, w io.Writ
 		voice = openai.SpeechVoice(req.Voice)
 	}
 
+	instructions := req.Instructions
+	if !p.azure {
+		// Azure 的模型由部署决定，不一定支持 instructions，只发送显式设置的指令
+		instructions = orDefault(instructions, deliveryInstructions, req.Delivery)
+	}
 	model := openai.TTSModel1
-	if req.Instructions != "" {
+	if instructions != "" {
 		// gpt-4o-mini-tts 支持 instructions 参数
 		model = openai.TTSModelGPT4oMini
 	}
@@ -62,20 +91,29 @@ func (p *OpenAIProvider) Synthesize(ctx context.Context, req *Request, w io.Writ
 		Model:          model,
 		Input:          req.Text,
 		Voice:          voice,
+		Instructions:   instructions,
 		ResponseFormat: format,
 		Speed:          speed,
 	}
 
 	resp, err := client.CreateSpeech(ctx, speechReq)
 	if err != nil {
-		return fmt.Errorf("openai tts: %w", err)
+		return fmt.Errorf("%s tts: %w", p.name, err)
 	}
 	defer resp.Close()
 
 	// 流式写入音频数据
 	if _, err := io.Copy(w, resp); err != nil {
-		return fmt.Errorf("openai tts: streaming: %w", err)
+		return fmt.Errorf("%s tts: streaming: %w", p.name, err)
 	}
 
 	return nil
 }
+
+// deploymentFor Azure 请求使用的部署名，未配置时与模型同名
+func (p *OpenAIProvider) deploymentFor(model string) string {
+	if p.deployment != "" {
+		return p.deployment
+	}
+	return model
+}
@@ -22,7 +22,11 @@ type Request struct {
 	Format       string  // 音频格式 (pcm, mp3, opus)
 	SampleRate   int     // 采样率 (24000)
 	Speed        float64 // 语速 (0.5-2.0)
+	Delivery     string  // 表达方式 (neutral, encouraging, probing)，未显式设置语气参数时由 provider 映射
 	Instructions string  // 语气/情感指令 (仅 gpt-4o-mini-tts 支持)
+	Style        string  // SSML 说话风格 (仅 Azure Speech 支持)
+	Role         string  // SSML 角色扮演 (仅 Azure Speech 支持)
+	Pitch        string  // SSML 音调，如 +5%、low (Edge / Azure Speech)
 	APIKey       string  // BYOK API Key
 	BaseURL      string  // 自定义端点 (可选)
 }
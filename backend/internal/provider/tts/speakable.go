@@ -0,0 +1,384 @@
+package tts
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 朗读文本规范化：LLM 回复中常有 Markdown、代码与符号，直接朗读会念出星号、反引号和链接。
+// Normalizer 去除格式标记并跳过代码块，Verbalize 按语言展开数字、单位与技术缩写。
+
+// Normalizer 按到达顺序处理一条回复切分出的片段，记录是否处于代码块中；零值可用
+type Normalizer struct {
+	inCode bool
+}
+
+// codeNotice 代码块开始时朗读的提示，代码内容只在文本通道展示
+var codeNotice = map[bool]string{
+	true:  "这里有一段代码，请看屏幕上的文字。",
+	false: "Here is a code snippet, please see the text on screen.",
+}
+
+// Speakable 返回片段中适合朗读的文本：去除 Markdown 标记、链接地址与 HTML 标签，
+// 代码块替换为一句提示；片段只有格式或代码时返回空串。language 决定代码提示与换行停顿的语言
+func (n *Normalizer) Speakable(text, language string) string {
+	chinese := strings.HasPrefix(language, "zh")
+	lines := strings.Split(text, "\n")
+	var out []string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			n.inCode = !n.inCode
+			if n.inCode {
+				out = append(out, codeNotice[chinese])
+			}
+			continue
+		}
+		if n.inCode {
+			continue
+		}
+		s := stripMarkdown(line)
+		if s == "" {
+			continue
+		}
+		// 列表项、标题等整行没有句末标点，补一个停顿
+		if i < len(lines)-1 && !endsWithPunct(s) {
+			if chinese {
+				s += "，"
+			} else {
+				s += ","
+			}
+		}
+		out = append(out, s)
+	}
+	return strings.Join(out, " ")
+}
+
+var (
+	mdRule       = regexp.MustCompile(`^\s*(?:[-*_]\s*){3,}$|^\s*\|?\s*:?-{3,}`)
+	mdLinePrefix = regexp.MustCompile(`^\s*(?:#{1,6}\s+|>\s*|[-*+]\s+|\d+[.)]\s+)+`)
+	mdImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdURL        = regexp.MustCompile(`(?:https?://|www\.)\S+`)
+	mdInlineCode = regexp.MustCompile("`([^`]*)`")
+	mdHTMLTag    = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	mdEmphasis   = strings.NewReplacer("**", "", "__", "", "~~", "", "*", "", "`", "", "_", " ", "|", " ")
+	spaces       = regexp.MustCompile(`\s+`)
+	cjkPunct     = regexp.MustCompile(`\s+([，。！？；：、）」』》])`)
+)
+
+// stripMarkdown 去除一行中的 Markdown 标记，保留链接文字与行内代码内容
+func stripMarkdown(line string) string {
+	if mdRule.MatchString(line) {
+		return ""
+	}
+	line = mdLinePrefix.ReplaceAllString(line, "")
+	line = mdImage.ReplaceAllString(line, "$1")
+	line = mdLink.ReplaceAllString(line, "$1")
+	line = mdURL.ReplaceAllString(line, "")
+	line = mdInlineCode.ReplaceAllString(line, "$1")
+	line = mdHTMLTag.ReplaceAllString(line, "")
+	line = mdEmphasis.Replace(line)
+	line = cjkPunct.ReplaceAllString(spaces.ReplaceAllString(line, " "), "$1") // 去掉链接后中文标点前残留的空格
+	return strings.TrimSpace(line)
+}
+
+func endsWithPunct(s string) bool {
+	r := []rune(s)
+	return unicode.IsPunct(r[len(r)-1])
+}
+
+// Verbalize 按语言展开技术缩写、符号、数字与单位，只处理中文与英文，其他语言原样返回
+func Verbalize(text, language string) string {
+	var v *verbalizer
+	switch {
+	case strings.HasPrefix(language, "zh"):
+		v = zhVerbalizer
+	case strings.HasPrefix(language, "en"):
+		v = enVerbalizer
+	default:
+		return text
+	}
+	text = v.terms.ReplaceAllStringFunc(text, func(m string) string { return v.termReadings[m] })
+	text = bigO.ReplaceAllString(text, v.bigO)
+	text = power.ReplaceAllStringFunc(text, func(m string) string {
+		sub := power.FindStringSubmatch(m)
+		if sub[2] == "2" {
+			return sub[1] + v.squared
+		}
+		return sub[1] + strings.ReplaceAll(v.power, "%s", sub[2])
+	})
+	text = number.ReplaceAllStringFunc(text, func(m string) string {
+		return v.number(number.FindStringSubmatch(m))
+	})
+	return strings.TrimSpace(spaces.ReplaceAllString(text, " "))
+}
+
+// verbalizer 一种语言的朗读规则
+type verbalizer struct {
+	termReadings map[string]string
+	terms        *regexp.Regexp
+	bigO         string // O(n) 的读法，$1 为括号内的内容
+	squared      string
+	power        string // %s 为指数
+	integer      func(n int64) string
+	digits       []string
+	digitSep     string // 逐位朗读时数字之间的分隔
+	point        string
+	percent      func(num string) string
+	units        map[string]func(num string, one bool) string
+}
+
+var (
+	bigO  = regexp.MustCompile(`\bO\(([^()]+)\)`)
+	power = regexp.MustCompile(`(\w)\^(\d+)`)
+	// 版本号与 IP 地址 (多个小数点) 原样保留；数字与字母相连 (utf8、x86、64bit) 时不展开
+	number = regexp.MustCompile(`\b\d+(?:\.\d+){2,}\b|\b(\d{1,3}(?:,\d{3})+|\d+)(?:\.(\d+))?(?:(%)|\s?(ms|μs|ns|min|KB|MB|GB|TB)\b|(s|h|k|K|x)\b|\b)`)
+)
+
+// maxSpokenInteger 按数值朗读的整数上限 (一万亿)，更大的数逐位朗读
+const maxSpokenInteger = 1e12
+
+// number 展开 number 正则的一处匹配，超出范围的整数与以 0 开头的数字逐位朗读
+func (v *verbalizer) number(sub []string) string {
+	if sub[1] == "" {
+		return sub[0]
+	}
+	intPart, frac, percent, unit := strings.ReplaceAll(sub[1], ",", ""), sub[2], sub[3], sub[4]+sub[5]
+	if unit == "k" || unit == "K" {
+		// 乘以一千后仍在整数朗读范围内才展开，否则保留单位、逐位朗读
+		unit = "k"
+		f, _ := strconv.ParseFloat(intPart+"."+frac+"0", 64)
+		if f *= 1000; f < maxSpokenInteger && f == math.Trunc(f) {
+			intPart, frac, unit = strconv.FormatInt(int64(f), 10), "", ""
+		}
+	}
+	n, err := strconv.ParseInt(intPart, 10, 64)
+	var s string
+	if err != nil || n >= maxSpokenInteger || (len(intPart) > 1 && intPart[0] == '0') {
+		s = v.spell(intPart)
+	} else {
+		s = v.integer(n)
+	}
+	if frac != "" {
+		s += v.point + v.spell(frac)
+	}
+	switch {
+	case percent != "":
+		return v.percent(s)
+	case unit != "":
+		return v.units[unit](s, intPart == "1" && frac == "")
+	}
+	return s
+}
+
+// spell 逐位朗读数字
+func (v *verbalizer) spell(digits string) string {
+	words := make([]string, 0, len(digits))
+	for _, d := range digits {
+		words = append(words, v.digits[d-'0'])
+	}
+	return strings.Join(words, v.digitSep)
+}
+
+// compileTerms 把术语表编译为一个正则，长的术语优先；以字母数字开头或结尾的术语要求词边界
+func compileTerms(readings map[string]string) *regexp.Regexp {
+	terms := make([]string, 0, len(readings))
+	for t := range readings {
+		terms = append(terms, t)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if len(terms[i]) != len(terms[j]) {
+			return len(terms[i]) > len(terms[j])
+		}
+		return terms[i] < terms[j]
+	})
+	isWord := func(b byte) bool {
+		return b == '_' || (b < unicode.MaxASCII && unicode.IsLetter(rune(b))) || unicode.IsDigit(rune(b))
+	}
+	alts := make([]string, len(terms))
+	for i, t := range terms {
+		p := regexp.QuoteMeta(t)
+		if isWord(t[0]) {
+			p = `\b` + p
+		}
+		if isWord(t[len(t)-1]) {
+			p += `\b`
+		}
+		alts[i] = p
+	}
+	return regexp.MustCompile(strings.Join(alts, "|"))
+}
+
+var enVerbalizer, zhVerbalizer *verbalizer
+
+func init() {
+	en := map[string]string{
+		"C++": "C plus plus", "C#": "C sharp", "F#": "F sharp", ".NET": " dot net",
+		"Node.js": "Node JS", "Vue.js": "Vue JS", "Next.js": "Next JS",
+		"k8s": "Kubernetes", "K8s": "Kubernetes", "i18n": "internationalization",
+		"JSON": "Jason", "YAML": "yamel", "MySQL": "My S Q L", "PostgreSQL": "Postgres Q L", "nginx": "engine X", "Nginx": "engine X",
+		"CI/CD": "C I C D", "I/O": "I O", "TCP/IP": "TCP IP",
+		"e.g.": "for example", "i.e.": "that is", "etc.": "et cetera", "vs.": "versus", "vs": "versus",
+		"->": " to ", "=>": " to ", "&&": " and ", "&": " and ",
+		"==": " equals ", "!=": " not equal to ", "<=": " less than or equal to ", ">=": " greater than or equal to ",
+	}
+	zh := map[string]string{
+		"C++": "C加加", "C#": "C sharp", "F#": "F sharp", ".NET": " dot net",
+		"Node.js": "Node JS", "Vue.js": "Vue JS", "Next.js": "Next JS",
+		"k8s": "Kubernetes", "K8s": "Kubernetes", "i18n": "国际化",
+		"JSON": "Jason", "YAML": "yamel", "MySQL": "My S Q L", "PostgreSQL": "Postgres Q L", "nginx": "engine X", "Nginx": "engine X",
+		"CI/CD": "C I C D", "I/O": "I O", "TCP/IP": "TCP IP",
+		"e.g.": "例如", "i.e.": "也就是", "etc.": "等等", "vs.": "对比", "vs": "对比",
+		"->": "到", "=>": "到", "&&": "并且", "&": "和",
+		"==": "等于", "!=": "不等于", "<=": "小于等于", ">=": "大于等于",
+	}
+	enVerbalizer = &verbalizer{
+		termReadings: en,
+		terms:        compileTerms(en),
+		bigO:         "O of $1",
+		squared:      " squared",
+		power:        " to the power of %s",
+		integer:      enInteger,
+		digits:       []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine"},
+		digitSep:     " ",
+		point:        " point ",
+		percent:      func(num string) string { return num + " percent" },
+		units: map[string]func(string, bool) string{
+			"ms": enUnit("millisecond"), "μs": enUnit("microsecond"), "ns": enUnit("nanosecond"),
+			"s": enUnit("second"), "min": enUnit("minute"), "h": enUnit("hour"),
+			"KB": enUnit("kilobyte"), "MB": enUnit("megabyte"), "GB": enUnit("gigabyte"), "TB": enUnit("terabyte"),
+			"k": func(num string, _ bool) string { return num + " thousand" },
+			"x": func(num string, _ bool) string { return num + " times" },
+		},
+	}
+	zhVerbalizer = &verbalizer{
+		termReadings: zh,
+		terms:        compileTerms(zh),
+		bigO:         "O $1",
+		squared:      "的平方",
+		power:        "的%s次方",
+		integer:      zhInteger,
+		digits:       zhDigits,
+		point:        "点",
+		percent:      func(num string) string { return "百分之" + num },
+		units: map[string]func(string, bool) string{
+			"ms": zhUnit("毫秒"), "μs": zhUnit("微秒"), "ns": zhUnit("纳秒"),
+			"s": zhUnit("秒"), "min": zhUnit("分钟"), "h": zhUnit("小时"),
+			"KB": zhUnit("千字节"), "MB": zhUnit("兆字节"), "GB": zhUnit("吉字节"), "TB": zhUnit("太字节"),
+			"k": zhUnit("千"),
+			"x": zhUnit("倍"),
+		},
+	}
+}
+
+func enUnit(name string) func(string, bool) string {
+	return func(num string, one bool) string {
+		if one {
+			return num + " " + name
+		}
+		return num + " " + name + "s"
+	}
+}
+
+func zhUnit(name string) func(string, bool) string {
+	return func(num string, _ bool) string { return num + name }
+}
+
+var (
+	enOnes = []string{
+		"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+	}
+	enTens = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+)
+
+// enInteger 英文读出整数 (小于一万亿)
+func enInteger(n int64) string {
+	switch {
+	case n < 20:
+		return enOnes[n]
+	case n < 100:
+		if n%10 == 0 {
+			return enTens[n/10]
+		}
+		return enTens[n/10] + "-" + enOnes[n%10]
+	case n < 1000:
+		if n%100 == 0 {
+			return enOnes[n/100] + " hundred"
+		}
+		return enOnes[n/100] + " hundred " + enInteger(n%100)
+	}
+	for _, scale := range []struct {
+		value int64
+		name  string
+	}{{1e9, "billion"}, {1e6, "million"}, {1e3, "thousand"}} {
+		if n >= scale.value {
+			s := enInteger(n/scale.value) + " " + scale.name
+			if n%scale.value != 0 {
+				s += " " + enInteger(n%scale.value)
+			}
+			return s
+		}
+	}
+	return ""
+}
+
+var (
+	zhDigits     = []string{"零", "一", "二", "三", "四", "五", "六", "七", "八", "九"}
+	zhGroupUnits = []string{"", "万", "亿"}
+)
+
+// zhInteger 中文读出整数 (小于一万亿)：按万分组，组间与组内的空位读一个「零」，10-19 读作「十几」
+func zhInteger(n int64) string {
+	if n == 0 {
+		return zhDigits[0]
+	}
+	var groups []int64
+	for ; n > 0; n /= 10000 {
+		groups = append(groups, n%10000)
+	}
+	var b strings.Builder
+	gap := false
+	for i := len(groups) - 1; i >= 0; i-- {
+		g := groups[i]
+		if g == 0 {
+			gap = true
+			continue
+		}
+		if b.Len() > 0 && (gap || g < 1000) {
+			b.WriteString(zhDigits[0])
+		}
+		b.WriteString(zhGroup(g))
+		b.WriteString(zhGroupUnits[i])
+		gap = false
+	}
+	if s := b.String(); strings.HasPrefix(s, "一十") {
+		return strings.TrimPrefix(s, "一")
+	}
+	return b.String()
+}
+
+// zhGroup 读出 1-9999
+func zhGroup(g int64) string {
+	var b strings.Builder
+	gap := false
+	for i, place := range []string{"千", "百", "十", ""} {
+		d := g / int64(math.Pow10(3-i)) % 10
+		if d == 0 {
+			gap = b.Len() > 0
+			continue
+		}
+		if gap {
+			b.WriteString(zhDigits[0])
+			gap = false
+		}
+		b.WriteString(zhDigits[d])
+		b.WriteString(place)
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package tts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SSML 微软语音 (Edge / Azure Speech) 的合成标记。Style / Role 通过 mstts:express-as 设置，仅 Azure 神经语音支持
+type SSML struct {
+	Language    string
+	Voice       string
+	Style       string  // 说话风格，如 cheerful、empathetic
+	StyleDegree float64 // 风格强度 (0.01-2)，0 表示默认
+	Role        string  // 角色扮演，如 YoungAdultFemale
+	Rate        float64 // 语速倍数，0 或 1 表示默认
+	Pitch       string  // 音调，如 +5%、low
+	Text        string
+}
+
+// String 生成 SSML 文档，文本与属性均已转义
+func (s SSML) String() string {
+	var b strings.Builder
+	b.WriteString(`<speak version='1.0' xmlns='http://www.w3.org/2001/10/synthesis'`)
+	if s.Style != "" || s.Role != "" {
+		b.WriteString(` xmlns:mstts='https://www.w3.org/2001/mstts'`)
+	}
+	fmt.Fprintf(&b, ` xml:lang='%s'><voice name='%s'>`, escapeXML(s.Language), escapeXML(s.Voice))
+
+	content := escapeXML(s.Text)
+	if prosody := s.prosody(); prosody != "" {
+		content = "<prosody" + prosody + ">" + content + "</prosody>"
+	}
+	if s.Style != "" || s.Role != "" {
+		var attrs strings.Builder
+		if s.Style != "" {
+			fmt.Fprintf(&attrs, ` style='%s'`, escapeXML(s.Style))
+			if s.StyleDegree > 0 {
+				fmt.Fprintf(&attrs, ` styledegree='%.2f'`, s.StyleDegree)
+			}
+		}
+		if s.Role != "" {
+			fmt.Fprintf(&attrs, ` role='%s'`, escapeXML(s.Role))
+		}
+		content = "<mstts:express-as" + attrs.String() + ">" + content + "</mstts:express-as>"
+	}
+	b.WriteString(content)
+	b.WriteString(`</voice></speak>`)
+	return b.String()
+}
+
+// prosody 返回 prosody 元素的属性，语速与音调均为默认时为空
+func (s SSML) prosody() string {
+	var attrs strings.Builder
+	if s.Rate > 0 && s.Rate != 1 {
+		fmt.Fprintf(&attrs, ` rate='%+.0f%%'`, (s.Rate-1)*100)
+	}
+	if s.Pitch != "" {
+		fmt.Fprintf(&attrs, ` pitch='%s'`, escapeXML(s.Pitch))
+	}
+	return attrs.String()
+}
+
+func escapeXML(s string) string {
+	s = strings.ReplaceAll(s, "&", "&amp;")
+	s = strings.ReplaceAll(s, "<", "&lt;")
+	s = strings.ReplaceAll(s, ">", "&gt;")
+	s = strings.ReplaceAll(s, "'", "&apos;")
+	s = strings.ReplaceAll(s, "\"", "&quot;")
+	return s
+}
+
+// defaultVoice 微软语音在未指定音色时按语言选择的默认音色
+func defaultVoice(language string) string {
+	if strings.HasPrefix(language, "zh") {
+		return "zh-CN-XiaoxiaoNeural"
+	}
+	return "en-US-AriaNeural"
+}
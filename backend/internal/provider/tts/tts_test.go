@@ -1,7 +1,15 @@
 package tts
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestRegistry_RegisterAndGet(t *testing.T) {
@@ -47,6 +55,7 @@ func TestProviderNames(t *testing.T) {
 		{NewFishAudioProvider(), "fishaudio"},
 		{NewElevenLabsProvider(), "elevenlabs"},
 		{NewEdgeTTSProvider(), "edgetts"},
+		{NewMockProvider(false), "mock"},
 	}
 
 	for _, tc := range tests {
@@ -55,3 +64,243 @@ func TestProviderNames(t *testing.T) {
 		}
 	}
 }
+
+func TestMockProvider_Formats(t *testing.T) {
+	p := NewMockProvider(true)
+	text := "你好，欢迎参加面试。" // 9 个汉字 ≈ 2.25s
+
+	var pcm bytes.Buffer
+	if err := p.Synthesize(context.Background(), &Request{Text: text, Format: "pcm", SampleRate: 16000}, &pcm); err != nil {
+		t.Fatalf("pcm error: %v", err)
+	}
+	if want := int(EstimateSpeechDuration(text, 0).Seconds()*16000) * 2; pcm.Len() != want {
+		t.Errorf("pcm length = %d, want %d", pcm.Len(), want)
+	}
+
+	var wav bytes.Buffer
+	if err := p.Synthesize(context.Background(), &Request{Text: text, Format: "wav"}, &wav); err != nil {
+		t.Fatalf("wav error: %v", err)
+	}
+	if !bytes.HasPrefix(wav.Bytes(), []byte("RIFF")) || string(wav.Bytes()[8:12]) != "WAVE" {
+		t.Error("wav output should start with RIFF/WAVE header")
+	}
+
+	var mp3 bytes.Buffer
+	if err := p.Synthesize(context.Background(), &Request{Text: text, Format: "mp3"}, &mp3); err != nil {
+		t.Fatalf("mp3 error: %v", err)
+	}
+	if mp3.Len() == 0 || mp3.Len()%mp3FrameSize != 0 {
+		t.Fatalf("mp3 length %d should be a multiple of frame size %d", mp3.Len(), mp3FrameSize)
+	}
+	if b := mp3.Bytes(); b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		t.Error("mp3 output should start with a frame sync word")
+	}
+
+	if err := p.Synthesize(context.Background(), &Request{Text: text, Format: "opus"}, &bytes.Buffer{}); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestEstimateSpeechDuration_Speed(t *testing.T) {
+	normal := EstimateSpeechDuration("This is a short sentence.", 1.0)
+	fast := EstimateSpeechDuration("This is a short sentence.", 2.0)
+	if fast*2 != normal {
+		t.Errorf("speed 2.0 should halve duration: normal=%v fast=%v", normal, fast)
+	}
+}
+
+func TestAudioDuration(t *testing.T) {
+	p := NewMockProvider(false)
+	text := "Tell me about a system you designed." // 7 词 ≈ 2.8s
+	want := EstimateSpeechDuration(text, 0)
+
+	for _, format := range []string{"mp3", "wav", "pcm"} {
+		var buf bytes.Buffer
+		if err := p.Synthesize(context.Background(), &Request{Text: text, Format: format, SampleRate: 24000}, &buf); err != nil {
+			t.Fatalf("%s error: %v", format, err)
+		}
+		got, ok := AudioDuration(format, buf.Bytes())
+		if !ok {
+			t.Fatalf("%s: duration not detected", format)
+		}
+		// mp3 按整帧对齐，允许一帧误差
+		if diff := got - want; diff < -mp3FrameDuration || diff > mp3FrameDuration {
+			t.Errorf("%s duration = %v, want ≈ %v", format, got, want)
+		}
+	}
+
+	// ID3v2 标签应被跳过
+	tagged := append([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 4, 0xFF, 0xFB, 0, 0}, silentMP3(time.Second)...)
+	if got, ok := AudioDuration("mp3", tagged); !ok || got < time.Second || got > time.Second+mp3FrameDuration {
+		t.Errorf("mp3 with ID3 tag: duration = %v, ok = %v", got, ok)
+	}
+
+	if _, ok := AudioDuration("opus", []byte{1, 2, 3}); ok {
+		t.Error("opus duration should not be detected")
+	}
+	if _, ok := AudioDuration("wav", []byte("not a wav file")); ok {
+		t.Error("invalid wav should not be detected")
+	}
+}
+
+func TestAzureProvider_Synthesize(t *testing.T) {
+	var path, version, key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, version, key = r.URL.Path, r.URL.Query().Get("api-version"), r.Header.Get("api-key")
+		w.Write([]byte("pcm"))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	err := NewAzureProvider("2025-03-01-preview", "tts-prod").Synthesize(context.Background(), &Request{
+		Text: "hi", APIKey: "azure-key", BaseURL: srv.URL,
+	}, &buf)
+	if err != nil {
+		t.Fatalf("Synthesize error: %v", err)
+	}
+	if path != "/openai/deployments/tts-prod/audio/speech" || version != "2025-03-01-preview" || key != "azure-key" {
+		t.Errorf("unexpected request: path=%q api-version=%q api-key=%q", path, version, key)
+	}
+	if buf.String() != "pcm" {
+		t.Errorf("unexpected audio %q", buf.String())
+	}
+}
+
+func TestSSML(t *testing.T) {
+	got := SSML{Language: "zh-CN", Voice: "zh-CN-XiaoxiaoNeural", Text: "a < b & 'c'"}.String()
+	want := `<speak version='1.0' xmlns='http://www.w3.org/2001/10/synthesis' xml:lang='zh-CN'>` +
+		`<voice name='zh-CN-XiaoxiaoNeural'>a &lt; b &amp; &apos;c&apos;</voice></speak>`
+	if got != want {
+		t.Errorf("plain ssml:\n got %s\nwant %s", got, want)
+	}
+
+	got = SSML{Language: "en-US", Voice: "en-US-AriaNeural", Style: "cheerful", StyleDegree: 1.5, Role: "YoungAdultFemale", Rate: 1.2, Pitch: "-5%", Text: "Hi"}.String()
+	for _, part := range []string{
+		`xmlns:mstts='https://www.w3.org/2001/mstts'`,
+		`<mstts:express-as style='cheerful' styledegree='1.50' role='YoungAdultFemale'>`,
+		`<prosody rate='+20%' pitch='-5%'>Hi</prosody>`,
+	} {
+		if !strings.Contains(got, part) {
+			t.Errorf("ssml missing %q:\n%s", part, got)
+		}
+	}
+}
+
+func TestAzureSpeechProvider(t *testing.T) {
+	var ssml, format, key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("Ocp-Apim-Subscription-Key")
+		switch r.URL.Path {
+		case "/cognitiveservices/v1":
+			body, _ := io.ReadAll(r.Body)
+			ssml, format = string(body), r.Header.Get("X-Microsoft-OutputFormat")
+			w.Write([]byte("mp3"))
+		case "/cognitiveservices/voices/list":
+			w.Write([]byte(`[{"ShortName":"zh-CN-XiaoxiaoNeural","DisplayName":"Xiaoxiao","Locale":"zh-CN","Gender":"Female","StyleList":["cheerful"]}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewAzureSpeechProvider("")
+	var buf bytes.Buffer
+	err := p.Synthesize(context.Background(), &Request{
+		Text: "你好", Voice: "zh-CN-XiaoxiaoNeural", Style: "cheerful", Format: "mp3", APIKey: "speech-key", BaseURL: srv.URL,
+	}, &buf)
+	if err != nil {
+		t.Fatalf("Synthesize error: %v", err)
+	}
+	if key != "speech-key" || format != "audio-24khz-48kbitrate-mono-mp3" || buf.String() != "mp3" {
+		t.Errorf("unexpected request: key=%q format=%q audio=%q", key, format, buf.String())
+	}
+	if !strings.Contains(ssml, "xml:lang='zh-CN'") || !strings.Contains(ssml, "style='cheerful'") {
+		t.Errorf("language should be derived from the voice and style applied: %s", ssml)
+	}
+
+	voices, err := p.Voices(context.Background(), "speech-key", srv.URL)
+	if err != nil {
+		t.Fatalf("Voices error: %v", err)
+	}
+	if len(voices) != 1 || voices[0].Name != "zh-CN-XiaoxiaoNeural" || len(voices[0].Styles) != 1 {
+		t.Errorf("unexpected voices: %+v", voices)
+	}
+
+	if err := p.Synthesize(context.Background(), &Request{Text: "hi", APIKey: "k"}, &buf); err == nil {
+		t.Error("synthesize without region or endpoint should fail")
+	}
+}
+
+func TestDelivery(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte("audio"))
+	}))
+	defer srv.Close()
+	req := &Request{Text: "Why?", Delivery: DeliveryProbing, Speed: 1.5, APIKey: "k", BaseURL: srv.URL}
+
+	if err := NewOpenAIProvider().Synthesize(context.Background(), req, io.Discard); err != nil {
+		t.Fatalf("openai: %v", err)
+	}
+	if body["instructions"] != deliveryInstructions[DeliveryProbing] || body["model"] != "gpt-4o-mini-tts" {
+		t.Errorf("openai should map delivery to instructions: %v", body)
+	}
+
+	if err := NewElevenLabsProvider().Synthesize(context.Background(), req, io.Discard); err != nil {
+		t.Fatalf("elevenlabs: %v", err)
+	}
+	settings, _ := body["voice_settings"].(map[string]any)
+	if settings["stability"] != 0.6 || settings["speed"] != 1.2 {
+		t.Errorf("elevenlabs should map delivery to voice settings and clamp speed: %v", settings)
+	}
+}
+
+func TestNormalizer_Speakable(t *testing.T) {
+	var n Normalizer
+	chunks := []struct{ in, want string }{
+		{"## 思路\n- 用 **哈希表** 记录 `seen`，", "思路， 用 哈希表 记录 seen，"},
+		{"参考 [文档](https://go.dev/doc) 或 https://example.com 。", "参考 文档 或。"},
+		{"代码如下：\n```go\nfunc main() {", "代码如下： 这里有一段代码，请看屏幕上的文字。"},
+		{"\tfmt.", ""},
+		{"Println(1)\n}\n```\n时间复杂度是多少？", "时间复杂度是多少？"},
+	}
+	for _, c := range chunks {
+		if got := n.Speakable(c.in, "zh-CN"); got != c.want {
+			t.Errorf("Speakable(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+
+	if got := new(Normalizer).Speakable("> Use *snake_case* names\n---\n1. First", "en-US"); got != "Use snake case names, First" {
+		t.Errorf("english markdown: %q", got)
+	}
+}
+
+func TestVerbalize(t *testing.T) {
+	tests := []struct{ text, language, want string }{
+		{"延迟 100ms，命中率 99.5%", "zh-CN", "延迟 一百毫秒，命中率 百分之九十九点五"},
+		{"10k QPS，1024 个连接，编号 007", "zh-CN", "一万 QPS，一千零二十四 个连接，编号 零零七"},
+		{"排序是 O(n^2)，用 C++ 或 k8s", "zh-CN", "排序是 O n的平方，用 C加加 或 Kubernetes"},
+		{"It took 1 min or 2s, about 1,500 MB.", "en-US", "It took one minute or two seconds, about one thousand five hundred megabytes."},
+		{"Go 1.21.3 on x86 with utf8, e.g. 3x faster", "en-US", "Go 1.21.3 on x86 with utf8, for example three times faster"},
+		{"JSON vs YAML: a == b", "en-US", "Jason versus yamel: a equals b"},
+		{"100 ms", "ja-JP", "100 ms"},
+		{"99999999999999999999k users", "en-US", strings.Repeat("nine ", 20) + "thousand users"},
+		{"99999999999999999999k 用户", "zh-CN", strings.Repeat("九", 20) + "千 用户"},
+	}
+	for _, tt := range tests {
+		if got := Verbalize(tt.text, tt.language); got != tt.want {
+			t.Errorf("Verbalize(%q, %q) = %q, want %q", tt.text, tt.language, got, tt.want)
+		}
+	}
+
+	for n, want := range map[int64]string{10: "十", 15: "十五", 110: "一百一十", 1001: "一千零一", 10010: "一万零一十", 100000: "十万", 100000001: "一亿零一"} {
+		if got := zhInteger(n); got != want {
+			t.Errorf("zhInteger(%d) = %q, want %q", n, got, want)
+		}
+	}
+	if got := enInteger(2_000_042); got != "two million forty-two" {
+		t.Errorf("enInteger = %q", got)
+	}
+}
@@ -0,0 +1,202 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// 沙箱辅助进程：服务端以 initArg 重新执行自身，辅助进程在新的命名空间中搭建只读根文件系统、
+// 切换到沙箱用户并设置 rlimit，然后 exec 目标程序。包的 init 检查 argv[0]，因此导入本包的
+// 任何二进制 (服务端与测试) 都能充当辅助进程。
+
+const (
+	initArg        = "ai-interview-sandbox-init"
+	initFailedCode = 125 // 辅助进程自身失败的退出码
+	initErrPrefix  = "sandbox init: "
+)
+
+// initSpec 传给辅助进程的运行参数 (argv[1]，JSON)
+type initSpec struct {
+	Root   string      `json:"root,omitempty"` // 新根文件系统的挂载点，为空时不隔离文件系统
+	Mounts []bindMount `json:"mounts,omitempty"`
+	UID    int         `json:"uid,omitempty"` // 命名空间内切换到的用户
+	GID    int         `json:"gid,omitempty"`
+	Limits []resLimit  `json:"limits,omitempty"`
+	Dir    string      `json:"dir"`
+	Argv   []string    `json:"argv,omitempty"` // 为空时只搭建环境后退出 (探测隔离是否可用)
+}
+
+// bindMount 从宿主机挂载到新根文件系统相同路径的文件或目录，默认只读
+type bindMount struct {
+	Source   string `json:"source"`
+	Writable bool   `json:"writable,omitempty"`
+	Device   bool   `json:"device,omitempty"` // 设备文件，不加 nodev
+}
+
+type resLimit struct {
+	Resource int    `json:"resource"`
+	Value    uint64 `json:"value"`
+}
+
+func init() {
+	if len(os.Args) == 2 && os.Args[0] == initArg {
+		runInit(os.Args[1])
+	}
+}
+
+// runInit 辅助进程入口，成功时 exec 目标程序，不会返回
+func runInit(arg string) {
+	// no_new_privs 与父进程退出信号是线程属性，必须在 exec 的线程上设置
+	runtime.LockOSThread()
+	var spec initSpec
+	err := json.Unmarshal([]byte(arg), &spec)
+	if err == nil {
+		err = spec.apply()
+	}
+	if err == nil && len(spec.Argv) > 0 {
+		err = syscall.Exec(spec.Argv[0], spec.Argv, os.Environ())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, initErrPrefix+err.Error())
+		os.Exit(initFailedCode)
+	}
+	os.Exit(0)
+}
+
+func (spec *initSpec) apply() error {
+	if spec.Root != "" {
+		if err := spec.pivotRoot(); err != nil {
+			return err
+		}
+		if err := dropPrivileges(spec.UID, spec.GID); err != nil {
+			return err
+		}
+	}
+	for _, l := range spec.Limits {
+		if err := unix.Setrlimit(l.Resource, &unix.Rlimit{Cur: l.Value, Max: l.Value}); err != nil {
+			return fmt.Errorf("setrlimit %d: %w", l.Resource, err)
+		}
+	}
+	if err := os.Chdir(spec.Dir); err != nil {
+		return fmt.Errorf("chdir: %w", err)
+	}
+	return nil
+}
+
+// pivotRoot 在 Root 上挂载 tmpfs，绑定挂载 Mounts 与新的 /proc，切换为根目录后只读重挂
+func (spec *initSpec) pivotRoot() error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	root := spec.Root
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755,size=1m"); err != nil {
+		return fmt.Errorf("mount rootfs: %w", err)
+	}
+	for _, m := range spec.Mounts {
+		if err := bind(root, m); err != nil {
+			return fmt.Errorf("bind %s: %w", m.Source, err)
+		}
+	}
+	// 新的 PID 命名空间中只能看到沙箱内的进程；内核拒绝时 (如 /proc 被部分遮挡的容器) 不提供 /proc
+	if err := os.Mkdir(filepath.Join(root, "proc"), 0o555); err != nil {
+		return fmt.Errorf("create /proc: %w", err)
+	}
+	_ = unix.Mount("proc", filepath.Join(root, "proc"), "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+
+	oldRoot := filepath.Join(root, ".old")
+	if err := os.Mkdir(oldRoot, 0o700); err != nil {
+		return fmt.Errorf("create old root: %w", err)
+	}
+	if err := unix.PivotRoot(root, oldRoot); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		return fmt.Errorf("chdir new root: %w", err)
+	}
+	if err := unix.Unmount("/.old", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("detach old root: %w", err)
+	}
+	if err := os.Remove("/.old"); err != nil {
+		return fmt.Errorf("remove old root: %w", err)
+	}
+	if err := unix.Mount("", "/", "", unix.MS_REMOUNT|unix.MS_BIND|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("remount rootfs read-only: %w", err)
+	}
+	return nil
+}
+
+// bind 把 m.Source 挂载到 root 下的相同路径；宿主机上的符号链接 (如 merged /usr 的 /bin) 原样复制
+func bind(root string, m bindMount) error {
+	target := filepath.Join(root, m.Source)
+	fi, err := os.Lstat(m.Source)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(m.Source)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		return os.Symlink(link, target)
+	}
+	if fi.IsDir() {
+		err = os.MkdirAll(target, 0o755)
+	} else if err = os.MkdirAll(filepath.Dir(target), 0o755); err == nil {
+		var f *os.File
+		if f, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0o644); err == nil {
+			err = f.Close()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := unix.Mount(m.Source, target, "", unix.MS_BIND, ""); err != nil {
+		return err
+	}
+
+	flags := uintptr(unix.MS_REMOUNT | unix.MS_BIND | unix.MS_NOSUID)
+	if !m.Writable {
+		flags |= unix.MS_RDONLY
+	}
+	if !m.Device {
+		flags |= unix.MS_NODEV
+	}
+	// 用户命名空间中重挂载必须保留源挂载点上被锁定的标志
+	var st unix.Statfs_t
+	if err := unix.Statfs(target, &st); err != nil {
+		return err
+	}
+	flags |= uintptr(st.Flags) & (unix.MS_RDONLY | unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC |
+		unix.MS_NOATIME | unix.MS_NODIRATIME | unix.MS_RELATIME)
+	return unix.Mount("", target, "", flags, "")
+}
+
+// dropPrivileges 切换到沙箱用户，并禁止通过 setuid 程序重新获得权限
+func dropPrivileges(uid, gid int) error {
+	if err := syscall.Setgroups(nil); err != nil {
+		return fmt.Errorf("setgroups: %w", err)
+	}
+	if err := syscall.Setresgid(gid, gid, gid); err != nil {
+		return fmt.Errorf("setgid: %w", err)
+	}
+	if err := syscall.Setresuid(uid, uid, uid); err != nil {
+		return fmt.Errorf("setuid: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	// 切换用户会清除父进程退出信号，重新设置
+	if err := unix.Prctl(unix.PR_SET_PDEATHSIG, uintptr(unix.SIGKILL), 0, 0, 0); err != nil {
+		return fmt.Errorf("set pdeathsig: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,428 @@
+// Package sandbox 在本地受限环境中编译、运行候选人提交的代码。
+//
+// 隔离手段：独立的 user / mount / PID / network / IPC / UTS 命名空间，程序以专用的非特权用户运行在
+// 只读的最小根文件系统中 (只有系统库、语言工具链与本次提交的工作目录)，看不到服务端的配置、数据库与进程；
+// rlimit 限制 CPU 时间、进程数、地址空间、文件大小与文件描述符；墙钟超时杀死整个进程组，输出截断。
+// 命名空间不可用时默认拒绝启动。
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// Language 支持的编程语言
+type Language string
+
+const (
+	Go         Language = "go"
+	Python     Language = "python"
+	JavaScript Language = "javascript"
+)
+
+// Languages 返回支持的语言列表
+func Languages() []Language {
+	return []Language{Go, Python, JavaScript}
+}
+
+// ParseLanguage 校验语言名称
+func ParseLanguage(s string) (Language, error) {
+	for _, l := range Languages() {
+		if string(l) == s {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported language %q", s)
+}
+
+// Config 沙箱配置
+type Config struct {
+	WorkDir         string        // 临时目录根路径，默认 os.TempDir()/ai-interview-sandbox
+	Timeout         time.Duration // 单次运行墙钟超时
+	CompileTimeout  time.Duration // 编译超时 (Go)
+	MemoryMB        int           // 内存上限
+	MaxOutputBytes  int           // stdout / stderr 各自保留的最大字节数
+	MaxConcurrent   int           // 同时运行的程序数
+	MaxProcesses    int           // 单次运行的进程 / 线程数上限
+	UID             int           // 运行程序的专用用户，默认 65534 (nobody)
+	GID             int           // 运行程序的专用用户组，默认 65534 (nogroup)
+	AllowUnisolated bool          // 命名空间不可用时仍以服务端用户运行 (仅限本地开发)
+}
+
+func (c *Config) setDefaults() {
+	if c.WorkDir == "" {
+		c.WorkDir = filepath.Join(os.TempDir(), "ai-interview-sandbox")
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.CompileTimeout <= 0 {
+		c.CompileTimeout = 30 * time.Second
+	}
+	if c.MemoryMB <= 0 {
+		c.MemoryMB = 256
+	}
+	if c.MaxOutputBytes <= 0 {
+		c.MaxOutputBytes = 64 << 10
+	}
+	if c.MaxConcurrent <= 0 {
+		c.MaxConcurrent = 4
+	}
+	if c.MaxProcesses <= 0 {
+		c.MaxProcesses = 64
+	}
+	if c.UID <= 0 {
+		c.UID = 65534
+	}
+	if c.GID <= 0 {
+		c.GID = 65534
+	}
+}
+
+// runtimeReserveMB Go 运行时与 V8 启动即保留大量虚拟地址空间，地址空间上限在内存上限之外为其预留
+var runtimeReserveMB = map[Language]int{Go: 1024, JavaScript: 1024}
+
+// Go 编译的资源上限：go build 会并行启动多个编译进程
+const (
+	compileMemoryMB = 4096
+	compileProcs    = 512
+)
+
+// toolchain 一种语言在宿主机上的解释器 / 编译器
+type toolchain struct {
+	exe    string   // 可执行文件的绝对路径
+	mounts []string // 沙箱中需要只读挂载的安装目录
+}
+
+// resolveTools 定位各语言的工具链，未安装的语言不可用。pyenv 等 shim 不是真正的解释器，
+// 因此向解释器本身查询路径
+func resolveTools() map[Language]*toolchain {
+	tools := map[Language]*toolchain{}
+	query := func(name string, args ...string) []string {
+		out, err := exec.Command(name, args...).Output()
+		if err != nil {
+			return nil
+		}
+		return strings.Fields(string(out))
+	}
+	add := func(lang Language, exe string, mounts ...string) {
+		if real, err := filepath.EvalSymlinks(exe); err == nil {
+			mounts = append(mounts, filepath.Dir(filepath.Dir(real)))
+		}
+		tools[lang] = &toolchain{exe: exe, mounts: append(mounts, filepath.Dir(filepath.Dir(exe)))}
+	}
+	if out := query("go", "env", "GOROOT"); len(out) == 1 {
+		add(Go, filepath.Join(out[0], "bin", "go"), out[0])
+	}
+	if out := query("python3", "-I", "-S", "-c", "import sys; print(sys.executable, sys.base_prefix)"); len(out) == 2 {
+		add(Python, out[0], out[1])
+	}
+	if out := query("node", "-p", "process.execPath"); len(out) == 1 {
+		add(JavaScript, out[0])
+	}
+	return tools
+}
+
+// Sandbox 代码执行沙箱
+type Sandbox struct {
+	cfg      Config
+	isolated bool
+	tools    map[Language]*toolchain
+	slots    chan struct{}
+}
+
+// New 创建沙箱并探测命名空间隔离是否可用；不可用时返回错误，除非开启 AllowUnisolated
+func New(cfg Config, logger log.Logger) (*Sandbox, error) {
+	cfg.setDefaults()
+	if err := os.MkdirAll(cfg.WorkDir, 0o700); err != nil {
+		return nil, fmt.Errorf("create sandbox workdir: %w", err)
+	}
+
+	s := &Sandbox{cfg: cfg, tools: resolveTools(), slots: make(chan struct{}, cfg.MaxConcurrent)}
+	if err := s.probeIsolation(); err != nil {
+		if !cfg.AllowUnisolated {
+			return nil, fmt.Errorf("sandbox isolation unavailable (set allow_unisolated only for local development): %w", err)
+		}
+		log.NewHelper(logger).Errorf("SANDBOX ISOLATION DISABLED: namespaces unavailable (%v); submitted code runs as the server user "+
+			"with access to its files, processes and network. Never expose this instance to untrusted candidates.", err)
+		return s, nil
+	}
+	s.isolated = true
+	if err := s.prepareCache(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// prepareCache 创建跨提交复用的 Go 编译缓存，交给沙箱用户
+func (s *Sandbox) prepareCache() error {
+	dir := s.goCache()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create go cache: %w", err)
+	}
+	return filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return s.chown(path)
+	})
+}
+
+func (s *Sandbox) goCache() string {
+	return filepath.Join(s.cfg.WorkDir, "gocache")
+}
+
+// Isolated 是否运行在独立的命名空间与只读根文件系统中
+func (s *Sandbox) Isolated() bool {
+	return s.isolated
+}
+
+// CompileError 编译失败
+type CompileError struct {
+	Output string
+}
+
+func (e *CompileError) Error() string {
+	return "compile error: " + e.Output
+}
+
+// Result 一次运行的结果
+type Result struct {
+	Stdout    string
+	Stderr    string
+	ExitCode  int
+	TimedOut  bool
+	Truncated bool // 输出超过 MaxOutputBytes 被截断
+	Duration  time.Duration
+}
+
+// Program 已准备好 (写入源码并完成编译) 的程序，可多次运行
+type Program struct {
+	s        *Sandbox
+	dir      string
+	argv     []string
+	env      []string // 运行时追加在 baseEnv 之后的环境变量
+	memoryMB int      // 地址空间上限
+}
+
+// Prepare 写入源码，需要时编译；编译失败返回 *CompileError
+func (s *Sandbox) Prepare(ctx context.Context, lang Language, code string) (*Program, error) {
+	tool := s.tools[lang]
+	if tool == nil {
+		return nil, fmt.Errorf("%s is not installed on this server", lang)
+	}
+	dir, err := os.MkdirTemp(s.cfg.WorkDir, "run-")
+	if err != nil {
+		return nil, fmt.Errorf("create run dir: %w", err)
+	}
+	p := &Program{s: s, dir: dir, memoryMB: s.cfg.MemoryMB + runtimeReserveMB[lang]}
+
+	var file string
+	switch lang {
+	case Go:
+		file = "main.go"
+	case Python:
+		file = "main.py"
+	case JavaScript:
+		file = "main.js"
+	default:
+		_ = p.Close()
+		return nil, fmt.Errorf("unsupported language %q", lang)
+	}
+	source := filepath.Join(dir, file)
+	if err := os.WriteFile(source, []byte(code), 0o600); err != nil {
+		_ = p.Close()
+		return nil, fmt.Errorf("write source: %w", err)
+	}
+	if err := s.chown(dir, source); err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+
+	// 地址空间是硬上限；GOMEMLIMIT 与 V8 堆上限让运行时在触顶前先回收内存
+	switch lang {
+	case Go:
+		if err := s.compileGo(ctx, p, tool); err != nil {
+			_ = p.Close()
+			return nil, err
+		}
+		p.argv = []string{filepath.Join(dir, "main")}
+		p.env = append(p.env, "GOMEMLIMIT="+strconv.Itoa(s.cfg.MemoryMB)+"MiB", "GOMAXPROCS=1")
+	case Python:
+		p.argv = []string{tool.exe, "-I", "-S", source}
+	case JavaScript:
+		p.argv = []string{tool.exe, "--max-old-space-size=" + strconv.Itoa(s.cfg.MemoryMB), source}
+	}
+	return p, nil
+}
+
+func (s *Sandbox) compileGo(ctx context.Context, p *Program, tool *toolchain) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.CompileTimeout)
+	defer cancel()
+
+	env := append(s.baseEnv(p.dir),
+		"GOROOT="+filepath.Dir(filepath.Dir(tool.exe)),
+		"GOCACHE="+s.goCache(), // 跨提交复用编译缓存
+		"GOPATH="+filepath.Join(p.dir, "gopath"),
+		"GOPROXY=off", "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local", "CGO_ENABLED=0",
+	)
+	res, err := s.exec(ctx, execSpec{
+		argv:     []string{tool.exe, "build", "-o", "main", "main.go"},
+		env:      env,
+		dir:      p.dir,
+		memoryMB: compileMemoryMB,
+		procs:    compileProcs,
+		writable: []string{s.goCache()},
+	})
+	if err != nil {
+		return err
+	}
+	if res.TimedOut {
+		return &CompileError{Output: "compilation timed out"}
+	}
+	if res.ExitCode != 0 {
+		return &CompileError{Output: strings.ReplaceAll(res.Stderr, p.dir+string(filepath.Separator), "")}
+	}
+	return nil
+}
+
+// Run 以 stdin 作为标准输入运行程序。每次运行使用新的可写工作目录，程序目录只读，
+// 同一提交的各个测试用例之间不能通过文件传递状态
+func (p *Program) Run(ctx context.Context, stdin string) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.s.cfg.Timeout)
+	defer cancel()
+
+	dir, err := os.MkdirTemp(p.s.cfg.WorkDir, "exec-")
+	if err != nil {
+		return nil, fmt.Errorf("create exec dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := p.s.chown(dir); err != nil {
+		return nil, err
+	}
+	return p.s.exec(ctx, execSpec{
+		argv:      p.argv,
+		env:       append(p.s.baseEnv(dir), p.env...),
+		dir:       dir,
+		stdin:     stdin,
+		memoryMB:  p.memoryMB,
+		procs:     p.s.cfg.MaxProcesses,
+		limitFile: true,
+		readonly:  []string{p.dir},
+	})
+}
+
+// Close 删除程序目录
+func (p *Program) Close() error {
+	return os.RemoveAll(p.dir)
+}
+
+// baseEnv 最小化环境变量，不继承服务端的密钥等配置
+func (s *Sandbox) baseEnv(dir string) []string {
+	return []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"LANG=C.UTF-8",
+	}
+}
+
+type execSpec struct {
+	argv       []string
+	env        []string
+	dir        string
+	stdin      string
+	cpuSeconds int
+	memoryMB   int      // 地址空间上限
+	procs      int      // 进程 / 线程数上限
+	limitFile  bool     // 限制写入文件大小与文件描述符 (编译不受限)
+	writable   []string // 工作目录之外需要可写的目录
+	readonly   []string // 工作目录之外只读挂载的目录 (程序目录)
+}
+
+// exec 由辅助进程设置隔离与 rlimit 后 exec 目标程序
+func (s *Sandbox) exec(ctx context.Context, spec execSpec) (*Result, error) {
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		return &Result{TimedOut: true}, nil
+	}
+
+	spec.cpuSeconds = int(s.timeoutFor(ctx).Seconds()) + 1
+	cmd, err := s.command(ctx, spec, s.isolated)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdin = strings.NewReader(spec.stdin)
+	stdout := &limitedBuffer{max: s.cfg.MaxOutputBytes}
+	stderr := &limitedBuffer{max: s.cfg.MaxOutputBytes}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	cmd.Cancel = func() error { return killGroup(cmd) }
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	res := &Result{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Duration:  time.Since(start),
+		TimedOut:  errors.Is(ctx.Err(), context.DeadlineExceeded),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	case res.TimedOut:
+		res.ExitCode = -1
+	default:
+		return nil, fmt.Errorf("run %s: %w", spec.argv[0], err)
+	}
+	if err := initError(res); err != nil {
+		return nil, fmt.Errorf("run %s: %w", spec.argv[0], err)
+	}
+	return res, nil
+}
+
+func (s *Sandbox) timeoutFor(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return s.cfg.Timeout
+}
+
+// limitedBuffer 只保留前 max 字节，其余丢弃
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
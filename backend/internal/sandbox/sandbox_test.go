@@ -0,0 +1,239 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+func newTestSandbox(t *testing.T, timeout time.Duration) *Sandbox {
+	t.Helper()
+	s, err := New(Config{WorkDir: t.TempDir(), Timeout: timeout, MaxOutputBytes: 1024, AllowUnisolated: true}, log.NewStdLogger(io.Discard))
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	return s
+}
+
+func requireTool(t *testing.T, name string) {
+	t.Helper()
+	// 首次编译需构建标准库缓存，耗时十几秒
+	if name == "go" && testing.Short() {
+		t.Skip("skipping go compilation in short mode")
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s not installed", name)
+	}
+}
+
+func runOnce(t *testing.T, s *Sandbox, lang Language, code, stdin string) *Result {
+	t.Helper()
+	p, err := s.Prepare(context.Background(), lang, code)
+	if err != nil {
+		t.Fatalf("Prepare error: %v", err)
+	}
+	defer p.Close()
+	res, err := p.Run(context.Background(), stdin)
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	return res
+}
+
+func TestSandbox_Languages(t *testing.T) {
+	s := newTestSandbox(t, 10*time.Second)
+	tests := []struct {
+		tool string
+		lang Language
+		code string
+	}{
+		{"python3", Python, "import sys\nprint(sum(int(x) for x in sys.stdin.read().split()))\n"},
+		{"node", JavaScript, "const s = require('fs').readFileSync(0, 'utf8');\nconsole.log(s.trim().split(/\\s+/).map(Number).reduce((a, b) => a + b, 0));\n"},
+		{"go", Go, "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tvar a, b int\n\tfmt.Scan(&a, &b)\n\tfmt.Println(a + b)\n}\n"},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.lang), func(t *testing.T) {
+			requireTool(t, tc.tool)
+			res := runOnce(t, s, tc.lang, tc.code, "2 40\n")
+			if res.ExitCode != 0 || strings.TrimSpace(res.Stdout) != "42" {
+				t.Errorf("unexpected result: exit=%d stdout=%q stderr=%q", res.ExitCode, res.Stdout, res.Stderr)
+			}
+		})
+	}
+}
+
+func TestSandbox_Timeout(t *testing.T) {
+	requireTool(t, "python3")
+	s := newTestSandbox(t, 500*time.Millisecond)
+
+	start := time.Now()
+	res := runOnce(t, s, Python, "while True:\n    pass\n", "")
+	if !res.TimedOut {
+		t.Errorf("expected timeout, got %+v", res)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("timeout took too long: %v", elapsed)
+	}
+}
+
+func TestSandbox_OutputTruncated(t *testing.T) {
+	requireTool(t, "python3")
+	s := newTestSandbox(t, 5*time.Second)
+
+	res := runOnce(t, s, Python, "print('x' * 100000)\n", "")
+	if !res.Truncated || len(res.Stdout) > 1024 {
+		t.Errorf("expected truncated output, got %d bytes truncated=%v", len(res.Stdout), res.Truncated)
+	}
+}
+
+func TestSandbox_NoNetwork(t *testing.T) {
+	requireTool(t, "python3")
+	s := newTestSandbox(t, 5*time.Second)
+	if !s.Isolated() {
+		t.Skip("namespace isolation unavailable")
+	}
+
+	code := "import socket\ntry:\n    socket.create_connection(('1.1.1.1', 53), timeout=2)\n    print('connected')\nexcept OSError:\n    print('blocked')\n"
+	if res := runOnce(t, s, Python, code, ""); strings.TrimSpace(res.Stdout) != "blocked" {
+		t.Errorf("network should be unreachable, got %q", res.Stdout)
+	}
+}
+
+func TestSandbox_EnvNotInherited(t *testing.T) {
+	requireTool(t, "python3")
+	t.Setenv("ENCRYPTION_KEY", "super-secret")
+	s := newTestSandbox(t, 5*time.Second)
+
+	res := runOnce(t, s, Python, "import os\nprint(os.environ.get('ENCRYPTION_KEY', 'unset'))\n", "")
+	if strings.TrimSpace(res.Stdout) != "unset" {
+		t.Errorf("server environment leaked into sandbox: %q", res.Stdout)
+	}
+}
+
+func TestSandbox_ServerFilesHidden(t *testing.T) {
+	requireTool(t, "python3")
+	s := newTestSandbox(t, 5*time.Second)
+	if !s.Isolated() {
+		t.Skip("namespace isolation unavailable")
+	}
+
+	secret := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(secret, []byte("jwt_secret: s3cret\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := filepath.Abs("../../configs/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{secret, config, fmt.Sprintf("/proc/%d/environ", os.Getpid()), fmt.Sprintf("/proc/%d/cmdline", os.Getppid())}
+
+	code := "import sys\nfor path in sys.stdin.read().split():\n    try:\n        open(path, 'rb').read(1)\n        print('readable', path)\n    except OSError:\n        print('denied')\n"
+	res := runOnce(t, s, Python, code, strings.Join(paths, "\n"))
+	if strings.Contains(res.Stdout, "readable") || strings.Count(res.Stdout, "denied") != len(paths) {
+		t.Errorf("server files should not be readable from the sandbox: stdout=%q stderr=%q", res.Stdout, res.Stderr)
+	}
+}
+
+func TestSandbox_FreshDirPerRun(t *testing.T) {
+	requireTool(t, "python3")
+	s := newTestSandbox(t, 5*time.Second)
+
+	// 每次运行检查并写入工作目录与程序目录中的文件，第二次运行不能看到第一次写入的文件
+	code := "import os\nhome = os.path.dirname(os.path.abspath(__file__))\n" +
+		"for path in ('state', os.path.join(home, 'state')):\n" +
+		"    print('seen' if os.path.exists(path) else 'fresh')\n" +
+		"    try:\n        open(path, 'w').write('1')\n    except OSError:\n        pass\n"
+	p, err := s.Prepare(context.Background(), Python, code)
+	if err != nil {
+		t.Fatalf("Prepare error: %v", err)
+	}
+	defer p.Close()
+
+	for i := 1; i <= 2; i++ {
+		res, err := p.Run(context.Background(), "")
+		if err != nil {
+			t.Fatalf("run %d error: %v", i, err)
+		}
+		lines := strings.Fields(res.Stdout)
+		if len(lines) != 2 {
+			t.Fatalf("run %d: unexpected output: stdout=%q stderr=%q", i, res.Stdout, res.Stderr)
+		}
+		if lines[0] != "fresh" {
+			t.Errorf("run %d: working dir state leaked between runs", i)
+		}
+		// 未隔离时程序目录不是只读挂载
+		if s.Isolated() && lines[1] != "fresh" {
+			t.Errorf("run %d: program dir should be read-only", i)
+		}
+	}
+}
+
+func TestSandbox_ProcessLimit(t *testing.T) {
+	requireTool(t, "python3")
+	s := newTestSandbox(t, 5*time.Second)
+	if !s.Isolated() {
+		t.Skip("namespace isolation unavailable")
+	}
+
+	code := "import os, time\nn = 0\ntry:\n    while n < 1000:\n        if os.fork() == 0:\n            time.sleep(2)\n            os._exit(0)\n        n += 1\n    print('unlimited')\nexcept OSError:\n    print('limited')\n"
+	if res := runOnce(t, s, Python, code, ""); strings.TrimSpace(res.Stdout) != "limited" {
+		t.Errorf("fork bomb should hit RLIMIT_NPROC, got stdout=%q stderr=%q", res.Stdout, res.Stderr)
+	}
+}
+
+func TestSandbox_MemoryLimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("rlimits are only applied on linux")
+	}
+	s := newTestSandbox(t, 10*time.Second)
+	tests := []struct {
+		tool string
+		lang Language
+		code string
+	}{
+		{"python3", Python, "b = bytearray(2 << 30)\nprint('allocated')\n"},
+		{"node", JavaScript, "const b = Buffer.alloc(2 * 1024 ** 3);\nconsole.log('allocated');\n"},
+		{"go", Go, "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tb := make([]byte, 2<<30)\n\tb[len(b)-1] = 1\n\tfmt.Println(\"allocated\")\n}\n"},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.lang), func(t *testing.T) {
+			requireTool(t, tc.tool)
+			if res := runOnce(t, s, tc.lang, tc.code, ""); res.ExitCode == 0 || strings.Contains(res.Stdout, "allocated") {
+				t.Errorf("2 GiB allocation should exceed the memory cap: exit=%d stdout=%q", res.ExitCode, res.Stdout)
+			}
+		})
+	}
+}
+
+func TestSandbox_CompileError(t *testing.T) {
+	requireTool(t, "go")
+	s := newTestSandbox(t, 5*time.Second)
+
+	_, err := s.Prepare(context.Background(), Go, "package main\n\nfunc main() { undefined() }\n")
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) {
+		t.Fatalf("expected CompileError, got %v", err)
+	}
+	if !strings.Contains(compileErr.Output, "undefined") {
+		t.Errorf("compile output should mention the error, got %q", compileErr.Output)
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	if l, err := ParseLanguage("python"); err != nil || l != Python {
+		t.Errorf("ParseLanguage(python) = %q, %v", l, err)
+	}
+	if _, err := ParseLanguage("rust"); err == nil {
+		t.Error("expected error for unsupported language")
+	}
+}
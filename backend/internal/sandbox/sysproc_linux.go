@@ -0,0 +1,184 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// systemMounts 只读挂载进沙箱根文件系统的系统目录与文件，不存在的跳过
+var systemMounts = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32",
+	"/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d", "/etc/alternatives", "/etc/localtime",
+}
+
+var deviceMounts = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// command 通过辅助进程运行 spec：isolated 时进入新的 user / mount / PID / network / IPC / UTS 命名空间，
+// 以沙箱用户运行在只读根文件系统中；否则只设置 rlimit
+func (s *Sandbox) command(ctx context.Context, spec execSpec, isolated bool) (*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locate executable: %w", err)
+	}
+	helper := initSpec{Limits: s.rlimits(spec), Dir: spec.dir, Argv: spec.argv}
+	if isolated {
+		helper.Root = s.rootDir()
+		helper.UID, helper.GID = s.cfg.UID, s.cfg.GID
+		helper.Mounts = s.mounts(spec)
+	}
+	arg, err := json.Marshal(helper)
+	if err != nil {
+		return nil, fmt.Errorf("encode sandbox spec: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, self)
+	cmd.Args = []string{initArg, string(arg)}
+	cmd.Dir = spec.dir
+	cmd.Env = spec.env
+	cmd.SysProcAttr = s.sysProcAttr(isolated)
+	return cmd, nil
+}
+
+// sysProcAttr 新建进程组便于超时时整体杀死；isolated 时命名空间内的 0 映射为服务端用户 (辅助进程搭建环境用)，
+// 沙箱用户映射为宿主机上相同的 uid / gid
+func (s *Sandbox) sysProcAttr(isolated bool) *syscall.SysProcAttr {
+	attr := &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+	if isolated {
+		attr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWNET | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+		attr.UidMappings = []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getuid(), Size: 1},
+			{ContainerID: s.cfg.UID, HostID: s.cfg.UID, Size: 1},
+		}
+		attr.GidMappings = []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getgid(), Size: 1},
+			{ContainerID: s.cfg.GID, HostID: s.cfg.GID, Size: 1},
+		}
+		attr.GidMappingsEnableSetgroups = true
+	}
+	return attr
+}
+
+// rlimits 按 spec 计算辅助进程 exec 前设置的资源上限
+func (s *Sandbox) rlimits(spec execSpec) []resLimit {
+	limits := []resLimit{
+		{unix.RLIMIT_CPU, uint64(spec.cpuSeconds)},
+		{unix.RLIMIT_CORE, 0},
+		{unix.RLIMIT_NPROC, uint64(spec.procs)},
+		{unix.RLIMIT_AS, uint64(spec.memoryMB) << 20},
+	}
+	if spec.limitFile {
+		limits = append(limits,
+			resLimit{unix.RLIMIT_FSIZE, uint64(s.cfg.MaxOutputBytes)},
+			resLimit{unix.RLIMIT_NOFILE, 64},
+		)
+	}
+	return limits
+}
+
+// mounts 沙箱根文件系统中的挂载：系统目录、工具链、设备文件与 spec.readonly 只读，工作目录与 spec.writable 可写
+func (s *Sandbox) mounts(spec execSpec) []bindMount {
+	var mounts []bindMount
+	seen := map[string]bool{}
+	add := func(m bindMount) {
+		if seen[m.Source] {
+			return
+		}
+		if _, err := os.Lstat(m.Source); err != nil {
+			return
+		}
+		seen[m.Source] = true
+		mounts = append(mounts, m)
+	}
+	for _, p := range systemMounts {
+		add(bindMount{Source: p})
+	}
+	for _, t := range s.tools {
+		for _, p := range t.mounts {
+			add(bindMount{Source: p})
+		}
+	}
+	for _, p := range deviceMounts {
+		add(bindMount{Source: p, Device: true})
+	}
+	for _, p := range spec.readonly {
+		add(bindMount{Source: p})
+	}
+	add(bindMount{Source: spec.dir, Writable: true})
+	for _, p := range spec.writable {
+		add(bindMount{Source: p, Writable: true})
+	}
+	return mounts
+}
+
+// rootDir 沙箱根文件系统的挂载点，每次运行在自己的 mount 命名空间中挂载 tmpfs
+func (s *Sandbox) rootDir() string {
+	return filepath.Join(s.cfg.WorkDir, "rootfs")
+}
+
+// chown 把运行目录交给沙箱用户，未隔离时程序以服务端用户运行，无需修改
+func (s *Sandbox) chown(paths ...string) error {
+	if !s.isolated {
+		return nil
+	}
+	for _, p := range paths {
+		if err := os.Chown(p, s.cfg.UID, s.cfg.GID); err != nil {
+			return fmt.Errorf("chown %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// probeIsolation 以完整的隔离参数启动一次辅助进程 (不运行程序)，检查内核与权限是否支持
+func (s *Sandbox) probeIsolation() error {
+	if s.cfg.UID == os.Getuid() || s.cfg.GID == os.Getgid() {
+		return fmt.Errorf("sandbox uid/gid %d/%d must differ from the server's", s.cfg.UID, s.cfg.GID)
+	}
+	if err := os.MkdirAll(s.rootDir(), 0o755); err != nil {
+		return fmt.Errorf("create rootfs mount point: %w", err)
+	}
+	dir, err := os.MkdirTemp(s.cfg.WorkDir, "probe-")
+	if err != nil {
+		return fmt.Errorf("create probe dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chown(dir, s.cfg.UID, s.cfg.GID); err != nil {
+		return fmt.Errorf("chown probe dir: %w", err)
+	}
+
+	cmd, err := s.command(context.Background(), execSpec{dir: dir, cpuSeconds: 1, memoryMB: 64, procs: 1}, true)
+	if err != nil {
+		return err
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, strings.TrimPrefix(msg, initErrPrefix))
+		}
+		return err
+	}
+	return nil
+}
+
+// initError 辅助进程自身失败时返回其错误信息
+func initError(res *Result) error {
+	if res.ExitCode == initFailedCode && strings.HasPrefix(res.Stderr, initErrPrefix) {
+		return errors.New(strings.TrimSpace(res.Stderr))
+	}
+	return nil
+}
+
+func killGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
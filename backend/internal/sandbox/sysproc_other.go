@@ -0,0 +1,36 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+// command 非 Linux 系统没有命名空间与 rlimit，直接运行程序 (仅用于本地开发，需开启 AllowUnisolated)
+func (s *Sandbox) command(ctx context.Context, spec execSpec, _ bool) (*exec.Cmd, error) {
+	cmd := exec.CommandContext(ctx, spec.argv[0], spec.argv[1:]...)
+	cmd.Dir = spec.dir
+	cmd.Env = spec.env
+	return cmd, nil
+}
+
+func (s *Sandbox) probeIsolation() error {
+	return errors.New("namespaces are only supported on linux")
+}
+
+func (s *Sandbox) chown(...string) error {
+	return nil
+}
+
+func initError(*Result) error {
+	return nil
+}
+
+func killGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
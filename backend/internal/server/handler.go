@@ -2,9 +2,14 @@ package server
 
 import (
 	"ai-interview/internal/biz"
+	"ai-interview/internal/export"
 	"ai-interview/internal/middleware"
 	"ai-interview/internal/service"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/go-kratos/kratos/v2/transport/http"
 )
@@ -91,24 +96,38 @@ func (h *authHandlerImpl) GetSettings(ctx http.Context) error {
 		return ctx.JSON(200, map[string]any{
 			"llm_provider":    "",
 			"llm_api_key_set": false,
+			"llm_fallbacks":   []string{},
 			"tts_provider":    "",
 			"tts_api_key_set": false,
+			"tts_speed":       1.0,
 			"tts_enabled":     true,
+			"tts_fallbacks":   []string{},
 			"stt_provider":    "browser",
 		})
 	}
 
 	return ctx.JSON(200, map[string]any{
-		"llm_provider":    settings.LLMProvider,
-		"llm_api_key_set": settings.LLMAPIKey != "",
-		"llm_base_url":    settings.LLMBaseURL,
-		"llm_model":       settings.LLMModel,
-		"tts_provider":    settings.TTSProvider,
-		"tts_api_key_set": settings.TTSAPIKey != "",
-		"tts_voice":       settings.TTSVoice,
-		"tts_enabled":     settings.TTSEnabled,
-		"stt_provider":    settings.STTProvider,
-		"stt_api_key_set": settings.STTAPIKey != "",
+		"llm_provider":          settings.LLMProvider,
+		"llm_api_key_set":       settings.LLMAPIKey != "",
+		"llm_base_url":          settings.LLMBaseURL,
+		"llm_model":             settings.LLMModel,
+		"llm_fallbacks":         nonNil(settings.LLMFallbacks),
+		"tts_provider":          settings.TTSProvider,
+		"tts_api_key_set":       settings.TTSAPIKey != "",
+		"tts_voice":             settings.TTSVoice,
+		"tts_speed":             settings.TTSSpeed,
+		"tts_enabled":           settings.TTSEnabled,
+		"tts_fallbacks":         nonNil(settings.TTSFallbacks),
+		"stt_provider":          settings.STTProvider,
+		"stt_api_key_set":       settings.STTAPIKey != "",
+		"azure_endpoint":        settings.AzureEndpoint,
+		"azure_api_key_set":     settings.AzureAPIKey != "",
+		"azure_api_version":     settings.AzureAPIVersion,
+		"azure_chat_deployment": settings.AzureChatDeployment,
+		"azure_tts_deployment":  settings.AzureTTSDeployment,
+		"azure_stt_deployment":  settings.AzureSTTDeployment,
+		"speech_region":         settings.SpeechRegion,
+		"speech_api_key_set":    settings.SpeechAPIKey != "",
 	})
 }
 
@@ -119,16 +138,27 @@ func (h *authHandlerImpl) UpdateSettings(ctx http.Context) error {
 	}
 
 	var req struct {
-		LLMProvider string `json:"llm_provider"`
-		LLMAPIKey   string `json:"llm_api_key"`
-		LLMBaseURL  string `json:"llm_base_url"`
-		LLMModel    string `json:"llm_model"`
-		TTSProvider string `json:"tts_provider"`
-		TTSAPIKey   string `json:"tts_api_key"`
-		TTSVoice    string `json:"tts_voice"`
-		TTSEnabled  bool   `json:"tts_enabled"`
-		STTProvider string `json:"stt_provider"`
-		STTAPIKey   string `json:"stt_api_key"`
+		LLMProvider         string   `json:"llm_provider"`
+		LLMAPIKey           string   `json:"llm_api_key"`
+		LLMBaseURL          string   `json:"llm_base_url"`
+		LLMModel            string   `json:"llm_model"`
+		LLMFallbacks        []string `json:"llm_fallbacks"`
+		TTSProvider         string   `json:"tts_provider"`
+		TTSAPIKey           string   `json:"tts_api_key"`
+		TTSVoice            string   `json:"tts_voice"`
+		TTSSpeed            float64  `json:"tts_speed"`
+		TTSEnabled          bool     `json:"tts_enabled"`
+		TTSFallbacks        []string `json:"tts_fallbacks"`
+		STTProvider         string   `json:"stt_provider"`
+		STTAPIKey           string   `json:"stt_api_key"`
+		AzureEndpoint       string   `json:"azure_endpoint"`
+		AzureAPIKey         string   `json:"azure_api_key"`
+		AzureAPIVersion     string   `json:"azure_api_version"`
+		AzureChatDeployment string   `json:"azure_chat_deployment"`
+		AzureTTSDeployment  string   `json:"azure_tts_deployment"`
+		AzureSTTDeployment  string   `json:"azure_stt_deployment"`
+		SpeechRegion        string   `json:"speech_region"`
+		SpeechAPIKey        string   `json:"speech_api_key"`
 	}
 	if err := ctx.Bind(&req); err != nil {
 		return ctx.JSON(400, map[string]string{"error": "invalid request"})
@@ -136,26 +166,136 @@ func (h *authHandlerImpl) UpdateSettings(ctx http.Context) error {
 
 	// API Keys 加密由 Service 层处理 (AuthService.UpdateSettings)
 	settings := &biz.UserSettings{
-		UserID:      userID,
-		LLMProvider: req.LLMProvider,
-		LLMAPIKey:   req.LLMAPIKey,
-		LLMBaseURL:  req.LLMBaseURL,
-		LLMModel:    req.LLMModel,
-		TTSProvider: req.TTSProvider,
-		TTSAPIKey:   req.TTSAPIKey,
-		TTSVoice:    req.TTSVoice,
-		TTSEnabled:  req.TTSEnabled,
-		STTProvider: req.STTProvider,
-		STTAPIKey:   req.STTAPIKey,
+		UserID:              userID,
+		LLMProvider:         req.LLMProvider,
+		LLMAPIKey:           req.LLMAPIKey,
+		LLMBaseURL:          req.LLMBaseURL,
+		LLMModel:            req.LLMModel,
+		LLMFallbacks:        req.LLMFallbacks,
+		TTSProvider:         req.TTSProvider,
+		TTSAPIKey:           req.TTSAPIKey,
+		TTSVoice:            req.TTSVoice,
+		TTSSpeed:            req.TTSSpeed,
+		TTSEnabled:          req.TTSEnabled,
+		TTSFallbacks:        req.TTSFallbacks,
+		STTProvider:         req.STTProvider,
+		STTAPIKey:           req.STTAPIKey,
+		AzureEndpoint:       req.AzureEndpoint,
+		AzureAPIKey:         req.AzureAPIKey,
+		AzureAPIVersion:     req.AzureAPIVersion,
+		AzureChatDeployment: req.AzureChatDeployment,
+		AzureTTSDeployment:  req.AzureTTSDeployment,
+		AzureSTTDeployment:  req.AzureSTTDeployment,
+		SpeechRegion:        req.SpeechRegion,
+		SpeechAPIKey:        req.SpeechAPIKey,
 	}
 
 	if err := h.svc.UpdateSettings(ctx, settings); err != nil {
+		if errors.Is(err, biz.ErrInvalidFallbacks) || errors.Is(err, biz.ErrInvalidTTSSpeed) {
+			return ctx.JSON(400, map[string]string{"error": err.Error()})
+		}
 		return ctx.JSON(500, map[string]string{"error": err.Error()})
 	}
 
 	return ctx.JSON(200, map[string]any{"success": true})
 }
 
+// ListLLMProviders 列出可用的 LLM provider：内置、系统级与用户注册的
+func (h *authHandlerImpl) ListLLMProviders(ctx http.Context) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return ctx.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	list, err := h.svc.ListLLMProviders(ctx, userID)
+	if err != nil {
+		return ctx.JSON(500, map[string]string{"error": err.Error()})
+	}
+	items := make([]map[string]any, 0, len(list))
+	for _, p := range list {
+		item := map[string]any{
+			"name":          p.Name,
+			"scope":         p.Scope,
+			"default_model": p.DefaultModel,
+		}
+		if cp := p.Custom; cp != nil {
+			headers := cp.Headers
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			item["base_url"] = cp.BaseURL
+			item["auth"] = cp.Auth
+			item["auth_header"] = cp.AuthHeader
+			item["api_key_set"] = cp.APIKey != ""
+			item["headers"] = headers
+		}
+		items = append(items, item)
+	}
+	return ctx.JSON(200, map[string]any{"providers": items})
+}
+
+// SaveLLMProvider 注册或更新用户的 OpenAI 兼容 provider，api_key 为空时保留已保存的 Key
+func (h *authHandlerImpl) SaveLLMProvider(ctx http.Context) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return ctx.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req struct {
+		BaseURL      string            `json:"base_url"`
+		Auth         string            `json:"auth"`
+		AuthHeader   string            `json:"auth_header"`
+		APIKey       string            `json:"api_key"`
+		DefaultModel string            `json:"default_model"`
+		Headers      map[string]string `json:"headers"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	cp := &biz.CustomProvider{
+		UserID:       userID,
+		Name:         ctx.Vars().Get("name"),
+		BaseURL:      strings.TrimSpace(req.BaseURL),
+		Auth:         req.Auth,
+		AuthHeader:   strings.TrimSpace(req.AuthHeader),
+		APIKey:       req.APIKey,
+		DefaultModel: strings.TrimSpace(req.DefaultModel),
+		Headers:      req.Headers,
+	}
+	if err := h.svc.SaveCustomProvider(ctx, cp); err != nil {
+		if errors.Is(err, biz.ErrInvalidCustomProvider) {
+			return ctx.JSON(400, map[string]string{"error": err.Error()})
+		}
+		return ctx.JSON(500, map[string]string{"error": err.Error()})
+	}
+	return ctx.JSON(200, map[string]any{"success": true})
+}
+
+// DeleteLLMProvider 删除用户注册的 provider
+func (h *authHandlerImpl) DeleteLLMProvider(ctx http.Context) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return ctx.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	if err := h.svc.DeleteCustomProvider(ctx, userID, ctx.Vars().Get("name")); err != nil {
+		if errors.Is(err, biz.ErrCustomProviderNotFound) {
+			return ctx.JSON(404, map[string]string{"error": err.Error()})
+		}
+		return ctx.JSON(500, map[string]string{"error": err.Error()})
+	}
+	return ctx.JSON(200, map[string]any{"success": true})
+}
+
+// nonNil 保证列表序列化为 [] 而不是 null
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
 // interviewHandlerImpl Interview HTTP handler
 type interviewHandlerImpl struct {
 	svc *service.InterviewService
@@ -180,12 +320,27 @@ func (h *interviewHandlerImpl) Create(ctx http.Context) error {
 		TTSProvider string `json:"tts_provider"`
 		TTSVoice    string `json:"tts_voice"`
 		Resume      string `json:"resume"`
+		Mode        string `json:"mode"`
+		ProblemID   string `json:"problem_id"`
+		Persona     string `json:"persona"`
+		Panel       []struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			Role        string `json:"role"`
+			Focus       string `json:"focus"`
+			Persona     string `json:"persona"`
+			LLMProvider string `json:"llm_provider"`
+			LLMModel    string `json:"llm_model"`
+			TTSVoice    string `json:"tts_voice"`
+		} `json:"panel"`
 	}
 	if err := ctx.Bind(&req); err != nil {
 		return ctx.JSON(400, map[string]string{"error": "invalid request"})
 	}
 
 	interview := &biz.Interview{
+		Mode:        req.Mode,
+		ProblemID:   req.ProblemID,
 		Title:       req.Title,
 		Position:    req.Position,
 		Language:    req.Language,
@@ -194,9 +349,25 @@ func (h *interviewHandlerImpl) Create(ctx http.Context) error {
 		TTSProvider: req.TTSProvider,
 		TTSVoice:    req.TTSVoice,
 		Resume:      req.Resume,
+		Persona:     req.Persona,
+	}
+	for _, p := range req.Panel {
+		interview.Panel = append(interview.Panel, &biz.Panelist{
+			ID:          p.ID,
+			Name:        p.Name,
+			Role:        p.Role,
+			Focus:       p.Focus,
+			Persona:     p.Persona,
+			LLMProvider: p.LLMProvider,
+			LLMModel:    p.LLMModel,
+			TTSVoice:    p.TTSVoice,
+		})
 	}
 
 	created, err := h.svc.CreateInterview(ctx, userID, interview)
+	if errors.Is(err, biz.ErrPersonaNotFound) || errors.Is(err, biz.ErrInvalidPanel) {
+		return ctx.JSON(400, map[string]string{"error": err.Error()})
+	}
 	if err != nil {
 		return ctx.JSON(500, map[string]string{"error": err.Error()})
 	}
@@ -205,6 +376,10 @@ func (h *interviewHandlerImpl) Create(ctx http.Context) error {
 		"id":            created.ID,
 		"title":         created.Title,
 		"status":        created.Status,
+		"mode":          created.Mode,
+		"problem_id":    created.ProblemID,
+		"persona":       created.Persona,
+		"panel":         panelView(created.Panel),
 		"websocket_url": "/api/v1/ws/interview/" + strconv.FormatInt(created.ID, 10),
 		"created_at":    created.CreatedAt,
 	})
@@ -231,6 +406,7 @@ func (h *interviewHandlerImpl) List(ctx http.Context) error {
 			"title":      i.Title,
 			"position":   i.Position,
 			"status":     i.Status,
+			"mode":       i.Mode,
 			"created_at": i.CreatedAt,
 		})
 	}
@@ -254,20 +430,340 @@ func (h *interviewHandlerImpl) Get(ctx http.Context) error {
 		msgItems = append(msgItems, map[string]any{
 			"id":         m.ID,
 			"role":       m.Role,
+			"speaker":    m.Speaker,
 			"content":    m.Content,
+			"difficulty": m.Difficulty,
 			"created_at": m.CreatedAt,
 		})
 	}
 
-	return ctx.JSON(200, map[string]any{
+	resp := map[string]any{
 		"id":         interview.ID,
 		"title":      interview.Title,
 		"position":   interview.Position,
 		"status":     interview.Status,
 		"language":   interview.Language,
+		"mode":       interview.Mode,
+		"persona":    interview.Persona,
+		"panel":      panelView(interview.Panel),
 		"messages":   msgItems,
 		"created_at": interview.CreatedAt,
-	})
+	}
+	if interview.Mode == biz.InterviewModeCoding {
+		if problem, err := h.svc.GetProblem(interview.ProblemID); err == nil {
+			resp["problem"] = problemView(problem)
+		}
+	}
+	return ctx.JSON(200, resp)
+}
+
+// Export 导出面试文字稿 / 报告：format=markdown|json|html|pdf
+func (h *interviewHandlerImpl) Export(ctx http.Context) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return ctx.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	id, _ := strconv.ParseInt(ctx.Vars().Get("id"), 10, 64)
+	format, err := export.ParseFormat(ctx.Query().Get("format"))
+	if err != nil {
+		return ctx.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	file, err := h.svc.ExportInterview(ctx, userID, id, format)
+	if errors.Is(err, biz.ErrInterviewNotFound) {
+		return ctx.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return ctx.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	ctx.Response().Header().Set("Content-Disposition", `attachment; filename="`+file.Name+`"`)
+	return ctx.Blob(200, file.ContentType, file.Data)
+}
+
+// ListRecordings 列出面试录音 (双方每段语音)，url 可直接用于播放
+func (h *interviewHandlerImpl) ListRecordings(ctx http.Context) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return ctx.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	id, _ := strconv.ParseInt(ctx.Vars().Get("id"), 10, 64)
+
+	recs, err := h.svc.ListRecordings(ctx, userID, id)
+	if errors.Is(err, biz.ErrInterviewNotFound) {
+		return ctx.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return ctx.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	items := make([]map[string]any, 0, len(recs))
+	for _, r := range recs {
+		items = append(items, map[string]any{
+			"id":          r.ID,
+			"message_id":  r.MessageID,
+			"role":        r.Role,
+			"seq":         r.Seq,
+			"format":      r.Format,
+			"duration_ms": r.DurationMs,
+			"size_bytes":  r.SizeBytes,
+			"url":         fmt.Sprintf("/api/v1/recordings/%d", r.ID),
+			"created_at":  r.CreatedAt,
+		})
+	}
+	return ctx.JSON(200, map[string]any{"recordings": items})
+}
+
+// ListAttachments 列出候选人附带的图片，url 可直接用于显示
+func (h *interviewHandlerImpl) ListAttachments(ctx http.Context) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return ctx.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	id, _ := strconv.ParseInt(ctx.Vars().Get("id"), 10, 64)
+
+	list, err := h.svc.ListAttachments(ctx, userID, id)
+	if errors.Is(err, biz.ErrInterviewNotFound) {
+		return ctx.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return ctx.JSON(500, map[string]string{"error": err.Error()})
+	}
+	return ctx.JSON(200, map[string]any{"attachments": attachmentsView(list)})
+}
+
+// GetAttachment 返回图片内容
+func (h *interviewHandlerImpl) GetAttachment(ctx http.Context) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return ctx.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	id, _ := strconv.ParseInt(ctx.Vars().Get("id"), 10, 64)
+
+	a, err := h.svc.GetAttachment(ctx, userID, id)
+	if errors.Is(err, biz.ErrAttachmentNotFound) {
+		return ctx.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return ctx.JSON(500, map[string]string{"error": err.Error()})
+	}
+	ctx.Response().Header().Set("Cache-Control", "private, max-age=86400")
+	return ctx.Blob(200, a.ContentType, a.Data)
+}
+
+// imageUpload 消息中附带的图片，data 为 base64 或 data URL
+type imageUpload struct {
+	Data string `json:"data"`
+}
+
+// decodeImages 解码上传的图片，类型与大小由 biz 校验
+func decodeImages(in []imageUpload) ([]*biz.Attachment, error) {
+	images := make([]*biz.Attachment, 0, len(in))
+	for _, img := range in {
+		data := img.Data
+		if strings.HasPrefix(data, "data:") {
+			_, data, _ = strings.Cut(data, ",")
+		}
+		raw, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid base64 data", biz.ErrInvalidAttachment)
+		}
+		images = append(images, &biz.Attachment{Data: raw})
+	}
+	return images, nil
+}
+
+func attachmentsView(list []*biz.Attachment) []map[string]any {
+	items := make([]map[string]any, 0, len(list))
+	for _, a := range list {
+		items = append(items, map[string]any{
+			"id":           a.ID,
+			"message_id":   a.MessageID,
+			"content_type": a.ContentType,
+			"size_bytes":   a.SizeBytes,
+			"url":          fmt.Sprintf("/api/v1/attachments/%d", a.ID),
+			"created_at":   a.CreatedAt,
+		})
+	}
+	return items
+}
+
+// GetRecording 播放录音，download=1 时作为附件下载
+func (h *interviewHandlerImpl) GetRecording(ctx http.Context) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return ctx.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	id, _ := strconv.ParseInt(ctx.Vars().Get("id"), 10, 64)
+
+	rec, body, size, err := h.svc.OpenRecording(ctx, userID, id)
+	if errors.Is(err, biz.ErrRecordingNotFound) || errors.Is(err, biz.ErrAudioNotFound) {
+		return ctx.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if errors.Is(err, biz.ErrAudioDisabled) {
+		return ctx.JSON(503, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return ctx.JSON(500, map[string]string{"error": err.Error()})
+	}
+	defer body.Close()
+
+	header := ctx.Response().Header()
+	if size > 0 {
+		header.Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	header.Set("Cache-Control", "private, max-age=86400")
+	if ctx.Query().Get("download") == "1" {
+		name := fmt.Sprintf("interview-%d-%d-%s-%d.%s", rec.InterviewID, rec.MessageID, rec.Role, rec.Seq, rec.Format)
+		header.Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	}
+	return ctx.Stream(200, biz.AudioContentType(rec.Format), body)
+}
+
+// ListProblems 列出编程题库，编程模式未启用时返回空列表
+func (h *interviewHandlerImpl) ListProblems(ctx http.Context) error {
+	problems := h.svc.ListProblems()
+	items := make([]map[string]any, 0, len(problems))
+	for _, p := range problems {
+		items = append(items, map[string]any{
+			"id":         p.ID,
+			"title":      p.Title,
+			"difficulty": p.Difficulty,
+		})
+	}
+	return ctx.JSON(200, map[string]any{"problems": items})
+}
+
+// ListPersonas 列出面试官人设
+func (h *interviewHandlerImpl) ListPersonas(ctx http.Context) error {
+	personas, defaultID := h.svc.ListPersonas()
+	items := make([]map[string]any, 0, len(personas))
+	for _, p := range personas {
+		items = append(items, map[string]any{
+			"id":          p.ID,
+			"name":        p.Name,
+			"description": p.Description,
+			"pacing":      p.Pacing,
+			"follow_up":   p.FollowUp,
+		})
+	}
+	return ctx.JSON(200, map[string]any{"personas": items, "default": defaultID})
+}
+
+// ListTTSVoices 列出 TTS provider 的可用音色，使用用户设置中的资源与 Key
+func (h *interviewHandlerImpl) ListTTSVoices(ctx http.Context) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return ctx.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	voices, err := h.svc.ListTTSVoices(ctx, userID, ctx.Query().Get("provider"))
+	if errors.Is(err, biz.ErrVoiceListUnsupported) {
+		return ctx.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return ctx.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	items := make([]map[string]any, 0, len(voices))
+	for _, v := range voices {
+		items = append(items, map[string]any{
+			"name":         v.Name,
+			"display_name": v.DisplayName,
+			"locale":       v.Locale,
+			"gender":       v.Gender,
+			"styles":       nonNil(v.Styles),
+			"roles":        nonNil(v.Roles),
+		})
+	}
+	return ctx.JSON(200, map[string]any{"voices": items})
+}
+
+// ListSubmissions 列出编程面试的代码提交记录
+func (h *interviewHandlerImpl) ListSubmissions(ctx http.Context) error {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return ctx.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	interviewID, _ := strconv.ParseInt(ctx.Vars().Get("id"), 10, 64)
+
+	subs, err := h.svc.ListSubmissions(ctx, userID, interviewID)
+	if errors.Is(err, biz.ErrInterviewNotFound) {
+		return ctx.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return ctx.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	items := make([]map[string]any, 0, len(subs))
+	for _, sub := range subs {
+		items = append(items, submissionView(sub))
+	}
+	return ctx.JSON(200, map[string]any{"submissions": items})
+}
+
+// panelView 小组面试的面试官 (不含 LLM 设置)，单面试官时为空数组
+func panelView(panel []*biz.Panelist) []map[string]any {
+	items := make([]map[string]any, 0, len(panel))
+	for _, p := range panel {
+		items = append(items, map[string]any{
+			"id":      p.ID,
+			"name":    p.Name,
+			"role":    p.Role,
+			"focus":   p.Focus,
+			"persona": p.Persona,
+		})
+	}
+	return items
+}
+
+// problemView 题目的对外视图，只包含样例用例
+func problemView(p *biz.Problem) map[string]any {
+	samples := make([]map[string]string, 0)
+	for _, tc := range p.SampleTests() {
+		samples = append(samples, map[string]string{"input": tc.Input, "output": tc.Output})
+	}
+	return map[string]any{
+		"id":          p.ID,
+		"title":       p.Title,
+		"difficulty":  p.Difficulty,
+		"description": p.Description,
+		"templates":   p.Templates,
+		"samples":     samples,
+	}
+}
+
+// submissionView 提交记录的对外视图，隐藏用例只给出状态
+func submissionView(sub *biz.CodeSubmission) map[string]any {
+	results := make([]map[string]any, 0, len(sub.Results))
+	for _, r := range sub.Results {
+		item := map[string]any{
+			"index":       r.Index,
+			"hidden":      r.Hidden,
+			"status":      r.Status,
+			"duration_ms": r.DurationMs,
+		}
+		if !r.Hidden {
+			item["input"] = r.Input
+			item["expected"] = r.Expected
+			item["actual"] = r.Actual
+			item["stderr"] = r.Stderr
+		}
+		results = append(results, item)
+	}
+	return map[string]any{
+		"id":             sub.ID,
+		"problem_id":     sub.ProblemID,
+		"language":       sub.Language,
+		"code":           sub.Code,
+		"status":         sub.Status,
+		"passed":         sub.Passed,
+		"total":          sub.Total,
+		"compile_output": sub.CompileOutput,
+		"results":        results,
+		"created_at":     sub.CreatedAt,
+	}
 }
 
 func (h *interviewHandlerImpl) SendMessage(ctx http.Context) error {
@@ -279,28 +775,45 @@ func (h *interviewHandlerImpl) SendMessage(ctx http.Context) error {
 	interviewID, _ := strconv.ParseInt(ctx.Vars().Get("id"), 10, 64)
 
 	var req struct {
-		Content string `json:"content"`
+		Content string        `json:"content"`
+		Images  []imageUpload `json:"images"`
 	}
 	if err := ctx.Bind(&req); err != nil {
 		return ctx.JSON(400, map[string]string{"error": "invalid request"})
 	}
+	images, err := decodeImages(req.Images)
+	if err != nil {
+		return ctx.JSON(400, map[string]string{"error": err.Error()})
+	}
 
-	userMsg, assistantContent, err := h.svc.SendMessage(ctx, interviewID, req.Content, userID)
+	userMsg, assistantMsg, eval, err := h.svc.SendMessage(ctx, interviewID, req.Content, images, userID)
+	if errors.Is(err, biz.ErrAttachmentDisabled) || errors.Is(err, biz.ErrInvalidAttachment) {
+		return ctx.JSON(400, map[string]string{"error": err.Error()})
+	}
 	if err != nil {
 		return ctx.JSON(500, map[string]string{"error": err.Error()})
 	}
 
-	return ctx.JSON(200, map[string]any{
+	resp := map[string]any{
 		"user_message": map[string]any{
-			"id":      userMsg.ID,
-			"role":    userMsg.Role,
-			"content": userMsg.Content,
+			"id":          userMsg.ID,
+			"role":        userMsg.Role,
+			"content":     userMsg.Content,
+			"attachments": attachmentsView(userMsg.Attachments),
 		},
 		"assistant_message": map[string]any{
-			"role":    "assistant",
-			"content": assistantContent,
+			"id":         assistantMsg.ID,
+			"role":       assistantMsg.Role,
+			"speaker":    assistantMsg.Speaker,
+			"difficulty": assistantMsg.Difficulty,
+			"content":    assistantMsg.Content,
 		},
-	})
+	}
+	// 面试官结束了面试
+	if eval != nil {
+		resp["evaluation"] = map[string]any{"overall_score": eval.OverallScore, "summary": eval.Summary}
+	}
+	return ctx.JSON(200, resp)
 }
 
 func (h *interviewHandlerImpl) End(ctx http.Context) error {
@@ -348,6 +861,7 @@ func (h *interviewHandlerImpl) GetEvaluation(ctx http.Context) error {
 		"strengths":     eval.Strengths,
 		"weaknesses":    eval.Weaknesses,
 		"suggestions":   eval.Suggestions,
+		"level":         eval.Level,
 		"created_at":    eval.CreatedAt,
 	})
 }
@@ -68,6 +68,9 @@ func registerRoutes(
 	router.GET("/api/v1/auth/profile", withAuth(jwtHelper, authHandler(authSvc).GetProfile))
 	router.GET("/api/v1/auth/settings", withAuth(jwtHelper, authHandler(authSvc).GetSettings))
 	router.PUT("/api/v1/auth/settings", withAuth(jwtHelper, authHandler(authSvc).UpdateSettings))
+	router.GET("/api/v1/llm-providers", withAuth(jwtHelper, authHandler(authSvc).ListLLMProviders))
+	router.PUT("/api/v1/llm-providers/{name}", withAuth(jwtHelper, authHandler(authSvc).SaveLLMProvider))
+	router.DELETE("/api/v1/llm-providers/{name}", withAuth(jwtHelper, authHandler(authSvc).DeleteLLMProvider))
 
 	// Interview 路由
 	router.POST("/api/v1/interviews", withAuth(jwtHelper, interviewHandler(interviewSvc).Create))
@@ -76,6 +79,15 @@ func registerRoutes(
 	router.POST("/api/v1/interviews/{id}/messages", withAuth(jwtHelper, interviewHandler(interviewSvc).SendMessage))
 	router.POST("/api/v1/interviews/{id}/end", withAuth(jwtHelper, interviewHandler(interviewSvc).End))
 	router.GET("/api/v1/interviews/{id}/evaluation", withAuth(jwtHelper, interviewHandler(interviewSvc).GetEvaluation))
+	router.GET("/api/v1/interviews/{id}/export", withAuth(jwtHelper, interviewHandler(interviewSvc).Export))
+	router.GET("/api/v1/interviews/{id}/submissions", withAuth(jwtHelper, interviewHandler(interviewSvc).ListSubmissions))
+	router.GET("/api/v1/interviews/{id}/recordings", withAuth(jwtHelper, interviewHandler(interviewSvc).ListRecordings))
+	router.GET("/api/v1/recordings/{id}", withAuth(jwtHelper, interviewHandler(interviewSvc).GetRecording))
+	router.GET("/api/v1/interviews/{id}/attachments", withAuth(jwtHelper, interviewHandler(interviewSvc).ListAttachments))
+	router.GET("/api/v1/attachments/{id}", withAuth(jwtHelper, interviewHandler(interviewSvc).GetAttachment))
+	router.GET("/api/v1/problems", withAuth(jwtHelper, interviewHandler(interviewSvc).ListProblems))
+	router.GET("/api/v1/personas", withAuth(jwtHelper, interviewHandler(interviewSvc).ListPersonas))
+	router.GET("/api/v1/tts/voices", withAuth(jwtHelper, interviewHandler(interviewSvc).ListTTSVoices))
 
 	// WebSocket 路由 (面试实时交互)
 	router.GET("/api/v1/ws/interview/{id}", withAuth(jwtHelper, wsHandler.Handle))
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"ai-interview/internal/biz"
+	"ai-interview/internal/conf"
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// AudioJanitor 按 data.audio.retention 定期清理过期录音，实现 kratos transport.Server
+type AudioJanitor struct {
+	audioUC  *biz.AudioUsecase
+	interval time.Duration
+	stop     chan struct{}
+	log      *log.Helper
+}
+
+// NewAudioJanitor 创建录音清理任务，默认每小时执行一次
+func NewAudioJanitor(audioUC *biz.AudioUsecase, c *conf.Data, logger log.Logger) *AudioJanitor {
+	interval := time.Hour
+	if c != nil && c.Audio != nil && c.Audio.CleanupInterval.AsDuration() > 0 {
+		interval = c.Audio.CleanupInterval.AsDuration()
+	}
+	return &AudioJanitor{audioUC: audioUC, interval: interval, stop: make(chan struct{}), log: log.NewHelper(logger)}
+}
+
+// Start 阻塞运行直到 Stop；未启用录音或永久保留时直接等待退出
+func (j *AudioJanitor) Start(ctx context.Context) error {
+	if !j.audioUC.Enabled() || j.audioUC.Retention() <= 0 {
+		<-j.stop
+		return nil
+	}
+	j.log.Infof("audio janitor started: retention=%v interval=%v", j.audioUC.Retention(), j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		j.cleanup(ctx)
+		select {
+		case <-ticker.C:
+		case <-j.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Stop 停止清理任务
+func (j *AudioJanitor) Stop(context.Context) error {
+	close(j.stop)
+	return nil
+}
+
+func (j *AudioJanitor) cleanup(ctx context.Context) {
+	n, err := j.audioUC.CleanupExpired(ctx)
+	if err != nil {
+		j.log.Errorf("cleanup expired recordings: %v", err)
+	}
+	if n > 0 {
+		j.log.Infof("deleted %d expired recording(s)", n)
+	}
+}
@@ -3,4 +3,4 @@ package server
 import "github.com/google/wire"
 
 // ProviderSet is server providers.
-var ProviderSet = wire.NewSet(NewHTTPServer, NewWebSocketHandler)
+var ProviderSet = wire.NewSet(NewHTTPServer, NewWebSocketHandler, NewSessionHub, NewAudioJanitor)
@@ -0,0 +1,340 @@
+package server
+
+import (
+	"ai-interview/internal/biz"
+	"ai-interview/internal/conf"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"nhooyr.io/websocket"
+)
+
+const (
+	defaultTurnTimeout = 5 * time.Minute
+	defaultOwnerTTL    = 30 * time.Second
+	wsWriteTimeout     = 10 * time.Second
+)
+
+// ErrTurnInProgress 上一轮回复尚未结束时又收到了新的回答
+var ErrTurnInProgress = errors.New("previous response is still in progress")
+
+// SessionHub 协调面试会话。会话独立于 WebSocket 连接和实例：回复在后台轮次中生成，
+// 事件分配 seq 写入缓存后经 SessionRegistry 广播，由持有候选人连接的实例 (不一定是生成回复的实例) 推送。
+// 同一场面试在整个集群内只有一个候选人连接，新连接接管旧连接。
+type SessionHub struct {
+	store       biz.SessionEventStore
+	registry    biz.SessionRegistry
+	nodeID      string
+	turnTimeout time.Duration
+	ownerTTL    time.Duration
+	logger      *log.Helper
+
+	nextID atomic.Int64
+}
+
+// NewSessionHub 创建会话管理器
+func NewSessionHub(store biz.SessionEventStore, registry biz.SessionRegistry, c *conf.Server, logger log.Logger) *SessionHub {
+	hub := &SessionHub{
+		store:       store,
+		registry:    registry,
+		turnTimeout: defaultTurnTimeout,
+		ownerTTL:    defaultOwnerTTL,
+		logger:      log.NewHelper(logger),
+	}
+	if c != nil && c.Websocket != nil {
+		if c.Websocket.TurnTimeout.AsDuration() > 0 {
+			hub.turnTimeout = c.Websocket.TurnTimeout.AsDuration()
+		}
+		if c.Websocket.OwnerTtl.AsDuration() > 0 {
+			hub.ownerTTL = c.Websocket.OwnerTtl.AsDuration()
+		}
+		hub.nodeID = c.Websocket.NodeId
+	}
+	if hub.nodeID == "" {
+		host, _ := os.Hostname()
+		hub.nodeID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return hub
+}
+
+// newOwner 生成集群内唯一的连接 / 轮次标识
+func (hub *SessionHub) newOwner() string {
+	return fmt.Sprintf("%s/%d", hub.nodeID, hub.nextID.Add(1))
+}
+
+// connection 本实例持有的候选人连接
+type connection struct {
+	hub         *SessionHub
+	interviewID int64
+	owner       string
+	conn        *websocket.Conn
+	cancel      context.CancelFunc
+
+	mu        sync.Mutex
+	delivered int64         // 最近推送的 seq
+	notify    chan struct{} // 每推送一个事件关闭并替换
+}
+
+// attach 接入候选人连接：声明归属并通知旧连接 (可能在其他实例上) 关闭，订阅会话事件；
+// resume 时补发 lastSeq 之后的缓存事件。先订阅再读缓存，按 seq 去重，补发与实时推送之间不会重复或遗漏。
+func (hub *SessionHub) attach(ctx context.Context, conn *websocket.Conn, interviewID int64, resume bool, lastSeq int64) (*connection, error) {
+	c := &connection{hub: hub, interviewID: interviewID, owner: hub.newOwner(), conn: conn, notify: make(chan struct{})}
+	if err := hub.registry.Claim(ctx, interviewID, c.owner, hub.ownerTTL); err != nil {
+		return nil, err
+	}
+
+	subCtx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	events, err := hub.registry.Subscribe(subCtx, interviewID)
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+	takeover, _ := json.Marshal(map[string]string{"owner": c.owner})
+	if err := hub.registry.Publish(ctx, interviewID, &biz.SessionEvent{Type: biz.SessionEventTakeover, Data: takeover}); err != nil {
+		hub.logger.Errorf("announce takeover of interview %d: %v", interviewID, err)
+	}
+
+	var replayedUpTo int64
+	if resume {
+		if replayedUpTo, err = c.replay(ctx, lastSeq); err != nil {
+			c.close()
+			return nil, err
+		}
+	}
+
+	go c.forward(subCtx, events, replayedUpTo)
+	go c.heartbeat(subCtx)
+	return c, nil
+}
+
+// replay 推送 resumed 及缓存中 lastSeq 之后的事件，返回已补发到的 seq
+func (c *connection) replay(ctx context.Context, lastSeq int64) (int64, error) {
+	events, latest, err := c.hub.store.Since(ctx, c.interviewID, lastSeq)
+	if err != nil {
+		return 0, err
+	}
+	inProgress, err := c.hub.registry.TurnLocked(ctx, c.interviewID)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeJSON(ctx, c.conn, wsResponse{Type: "resumed", Data: map[string]any{
+		"last_seq":         latest,
+		"replayed":         len(events),
+		"gap":              biz.ReplayGap(lastSeq, latest, events),
+		"turn_in_progress": inProgress,
+	}}); err != nil {
+		return 0, err
+	}
+
+	sent := lastSeq
+	for _, ev := range events {
+		if err := writeEvent(ctx, c.conn, ev); err != nil {
+			return 0, err
+		}
+		sent = ev.Seq
+	}
+	return sent, nil
+}
+
+// forward 把订阅到的事件推送给连接；被接管、写失败或订阅中断 (消费过慢) 时关闭连接，由客户端重连补发
+func (c *connection) forward(ctx context.Context, events <-chan *biz.SessionEvent, skipUpTo int64) {
+	for ev := range events {
+		if ev.Type == biz.SessionEventTakeover {
+			var takeover struct {
+				Owner string `json:"owner"`
+			}
+			if err := json.Unmarshal(ev.Data, &takeover); err == nil && takeover.Owner != c.owner {
+				_ = c.conn.Close(websocket.StatusPolicyViolation, "superseded by a newer connection")
+				return
+			}
+			continue
+		}
+		// 跳过补发时已推送的事件；之后的序号可能因缓存过期重新计数，不再去重
+		if ev.Seq != 0 && ev.Seq <= skipUpTo {
+			continue
+		}
+		skipUpTo = 0
+
+		writeCtx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+		err := writeEvent(writeCtx, c.conn, ev)
+		cancel()
+		if err != nil {
+			c.hub.logger.Infof("WebSocket write failed for interview %d: %v", c.interviewID, err)
+			_ = c.conn.Close(websocket.StatusGoingAway, "write failed")
+			return
+		}
+		c.markDelivered(ev.Seq)
+	}
+	if ctx.Err() == nil {
+		_ = c.conn.Close(websocket.StatusTryAgainLater, "session events lagging, reconnect to resume")
+	}
+}
+
+func (c *connection) markDelivered(seq int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.delivered = seq
+	close(c.notify)
+	c.notify = make(chan struct{})
+}
+
+// waitDelivered 等待 seq 及之前的事件推送给连接，超时或连接已关闭时放弃
+func (c *connection) waitDelivered(ctx context.Context, seq int64) {
+	ctx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+	defer cancel()
+
+	for {
+		c.mu.Lock()
+		delivered, notify := c.delivered, c.notify
+		c.mu.Unlock()
+		if delivered >= seq {
+			return
+		}
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// heartbeat 定期续约归属，租约已被接管时关闭连接
+func (c *connection) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(c.hub.ownerTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := c.hub.registry.Renew(ctx, c.interviewID, c.owner, c.hub.ownerTTL)
+		if errors.Is(err, biz.ErrSessionTakenOver) {
+			_ = c.conn.Close(websocket.StatusPolicyViolation, "superseded by a newer connection")
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			c.hub.logger.Errorf("renew session of interview %d: %v", c.interviewID, err)
+		}
+	}
+}
+
+// close 取消订阅并释放归属 (已被接管时不影响新连接)
+func (c *connection) close() {
+	c.cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+	defer cancel()
+	if err := c.hub.registry.Release(ctx, c.interviewID, c.owner); err != nil {
+		c.hub.logger.Errorf("release session of interview %d: %v", c.interviewID, err)
+	}
+}
+
+// startTurn 在后台执行一轮回复，不随连接断开而取消；整个集群内同一场面试同一时间只允许一个轮次
+func (hub *SessionHub) startTurn(ctx context.Context, interviewID int64, fn func(ctx context.Context, t *turn)) (<-chan struct{}, error) {
+	owner := hub.newOwner()
+	ok, err := hub.registry.LockTurn(ctx, interviewID, owner, hub.turnTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTurnInProgress
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+			defer cancel()
+			if err := hub.registry.UnlockTurn(ctx, interviewID, owner); err != nil {
+				hub.logger.Errorf("unlock turn of interview %d: %v", interviewID, err)
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), hub.turnTimeout)
+		defer cancel()
+		fn(ctx, &turn{hub: hub, interviewID: interviewID})
+	}()
+	return done, nil
+}
+
+// turn 一轮回复产生的事件：按产生顺序分配 seq、写入缓存并广播
+type turn struct {
+	hub         *SessionHub
+	interviewID int64
+
+	// mu 文本与 TTS 音频在不同 goroutine 产生，保证分配 seq 与广播的顺序一致
+	mu      sync.Mutex
+	lastSeq int64
+}
+
+// emit 推送 JSON 事件
+func (t *turn) emit(typ string, data any) {
+	ev := &biz.SessionEvent{Type: typ}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			t.hub.logger.Errorf("marshal %s event: %v", typ, err)
+			return
+		}
+		ev.Data = raw
+	}
+	t.publish(ev)
+}
+
+// emitAudio 推送一段 TTS 音频
+func (t *turn) emitAudio(audio []byte) {
+	t.publish(&biz.SessionEvent{Type: biz.SessionEventAudio, Audio: audio})
+}
+
+func (t *turn) publish(ev *biz.SessionEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+	defer cancel()
+
+	// 缓存失败时仍实时推送 (seq 为 0)，只是断线后无法补发
+	if _, err := t.hub.store.Append(ctx, t.interviewID, ev); err != nil {
+		t.hub.logger.Errorf("buffer session event for interview %d: %v", t.interviewID, err)
+	}
+	t.lastSeq = ev.Seq
+	if err := t.hub.registry.Publish(ctx, t.interviewID, ev); err != nil {
+		t.hub.logger.Errorf("publish session event for interview %d: %v", t.interviewID, err)
+	}
+}
+
+// writeEvent 写入一个带序号的事件：JSON 事件带 seq 字段，
+// 音频为二进制帧，前 8 字节是大端序的 seq，之后是 mp3 数据
+func writeEvent(ctx context.Context, conn *websocket.Conn, ev *biz.SessionEvent) error {
+	if ev.Type == biz.SessionEventAudio {
+		frame := make([]byte, 8+len(ev.Audio))
+		binary.BigEndian.PutUint64(frame, uint64(ev.Seq))
+		copy(frame[8:], ev.Audio)
+		return conn.Write(ctx, websocket.MessageBinary, frame)
+	}
+	msg := wsResponse{Type: ev.Type, Seq: ev.Seq}
+	if len(ev.Data) > 0 {
+		msg.Data = ev.Data
+	}
+	return writeJSON(ctx, conn, msg)
+}
+
+func writeJSON(ctx context.Context, conn *websocket.Conn, msg wsResponse) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return conn.Write(ctx, websocket.MessageText, data)
+}
@@ -3,13 +3,18 @@ package server
 import (
 	"ai-interview/internal/biz"
 	"ai-interview/internal/middleware"
+	"ai-interview/internal/provider/stt"
 	"ai-interview/internal/provider/tts"
 	"ai-interview/internal/service"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/transport/http"
@@ -18,13 +23,18 @@ import (
 
 // wsMessage WebSocket 消息格式
 type wsMessage struct {
-	Type string `json:"type"` // "text", "audio", "end", "ping"
-	Data string `json:"data,omitempty"`
+	Type     string        `json:"type"` // "text", "audio", "code_submit", "end", "ping"
+	Data     string        `json:"data,omitempty"`
+	Language string        `json:"language,omitempty"` // code_submit: go, python, javascript
+	Format   string        `json:"format,omitempty"`   // audio: webm, wav, mp3, ogg
+	Text     string        `json:"text,omitempty"`     // audio: 浏览器端已识别的文本，为空时由服务端 STT 转写
+	Images   []imageUpload `json:"images,omitempty"`   // text: 附带的白板、截图
 }
 
 // wsResponse WebSocket 响应
 type wsResponse struct {
-	Type string `json:"type"` // "text_start", "text_delta", "text_end", "audio", "status", "error", "evaluation"
+	Type string `json:"type"`          // "text_start", "text_delta", "text_end", "transcript", "status", "error", "evaluation", "problem", "code_result", "resumed"
+	Seq  int64  `json:"seq,omitempty"` // 会话事件序号，连接级消息 (connected / pong / 请求错误) 不带
 	Data any    `json:"data,omitempty"`
 }
 
@@ -32,6 +42,8 @@ type wsResponse struct {
 type WebSocketHandler struct {
 	interviewSvc *service.InterviewService
 	interviewUC  *biz.InterviewUsecase
+	audioUC      *biz.AudioUsecase
+	sessions     *SessionHub
 	logger       *log.Helper
 }
 
@@ -39,16 +51,20 @@ type WebSocketHandler struct {
 func NewWebSocketHandler(
 	interviewSvc *service.InterviewService,
 	interviewUC *biz.InterviewUsecase,
+	audioUC *biz.AudioUsecase,
+	sessions *SessionHub,
 	logger log.Logger,
 ) *WebSocketHandler {
 	return &WebSocketHandler{
 		interviewSvc: interviewSvc,
 		interviewUC:  interviewUC,
+		audioUC:      audioUC,
+		sessions:     sessions,
 		logger:       log.NewHelper(logger),
 	}
 }
 
-// Handle 处理 WebSocket 连接
+// Handle 处理 WebSocket 连接；带 last_seq 参数时为断线重连，补发该序号之后的事件
 func (h *WebSocketHandler) Handle(httpCtx http.Context) error {
 	userID, ok := middleware.UserIDFromContext(httpCtx)
 	if !ok {
@@ -57,6 +73,27 @@ func (h *WebSocketHandler) Handle(httpCtx http.Context) error {
 
 	interviewID, _ := strconv.ParseInt(httpCtx.Vars().Get("id"), 10, 64)
 
+	var lastSeq int64
+	resume := httpCtx.Query().Has("last_seq")
+	if resume {
+		var err error
+		if lastSeq, err = strconv.ParseInt(httpCtx.Query().Get("last_seq"), 10, 64); err != nil || lastSeq < 0 {
+			return httpCtx.JSON(400, map[string]string{"error": "invalid last_seq"})
+		}
+	}
+
+	// 升级与挂载会话前确认面试属于当前用户，不存在与无权访问一样返回 404
+	interview, _, err := h.interviewSvc.GetInterview(httpCtx, interviewID)
+	if err == nil && interview.UserID != userID {
+		err = biz.ErrInterviewNotFound
+	}
+	if errors.Is(err, biz.ErrInterviewNotFound) {
+		return httpCtx.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return httpCtx.JSON(500, map[string]string{"error": err.Error()})
+	}
+
 	// 升级 HTTP 连接为 WebSocket
 	conn, err := websocket.Accept(httpCtx.Response(), httpCtx.Request(), &websocket.AcceptOptions{
 		InsecureSkipVerify: true, // 开发阶段允许跨域
@@ -66,13 +103,35 @@ func (h *WebSocketHandler) Handle(httpCtx http.Context) error {
 		return err
 	}
 	defer conn.Close(websocket.StatusNormalClosure, "done")
+	// 候选人录音以 base64 放在 JSON 消息中，读取上限按录音大小放宽
+	conn.SetReadLimit(h.audioUC.MaxUploadBytes()*4/3 + 4096)
+
+	// WebSocket 会话不受 HTTP server 请求超时限制，生命周期随连接结束
+	ctx, cancel := context.WithCancel(context.WithoutCancel(httpCtx.Request().Context()))
+	defer cancel()
 
-	ctx := httpCtx.Request().Context()
+	h.logger.Infof("WebSocket connected: user=%d interview=%d resume=%t last_seq=%d", userID, interviewID, resume, lastSeq)
 
-	h.logger.Infof("WebSocket connected: user=%d interview=%d", userID, interviewID)
+	// 新连接发送连接成功状态；重连由 attach 发送 resumed 并补发错过的事件
+	if !resume {
+		h.sendJSON(ctx, conn, wsResponse{Type: "status", Data: "connected"})
+	}
+	session, err := h.sessions.attach(ctx, conn, interviewID, resume, lastSeq)
+	if err != nil {
+		h.logger.Errorf("attach session for interview %d: %v", interviewID, err)
+		h.sendJSON(ctx, conn, wsResponse{Type: "error", Data: "session unavailable"})
+		return nil
+	}
+	defer session.close()
 
-	// 发送连接成功状态
-	h.sendJSON(ctx, conn, wsResponse{Type: "status", Data: "connected"})
+	// 编程面试：下发题目 (不含隐藏用例)，重连时客户端已有题目
+	if !resume {
+		if interview.Mode == biz.InterviewModeCoding {
+			if problem, err := h.interviewSvc.GetProblem(interview.ProblemID); err == nil {
+				h.sendJSON(ctx, conn, wsResponse{Type: "problem", Data: problemView(problem)})
+			}
+		}
+	}
 
 	// 消息循环
 	for {
@@ -88,11 +147,27 @@ func (h *WebSocketHandler) Handle(httpCtx http.Context) error {
 			continue
 		}
 
+		// 回答在后台轮次中处理，连接断开不会中断正在生成的回复
 		switch msg.Type {
 		case "text":
-			h.handleTextMessage(ctx, conn, interviewID, userID, msg.Data)
+			images, err := decodeImages(msg.Images)
+			if err != nil {
+				h.sendJSON(ctx, conn, wsResponse{Type: "error", Data: err.Error()})
+				continue
+			}
+			h.startTurn(ctx, conn, interviewID, func(ctx context.Context, t *turn) {
+				h.handleTextMessage(ctx, t, interviewID, userID, msg.Data, images, nil)
+			})
+		case "audio":
+			h.startTurn(ctx, conn, interviewID, func(ctx context.Context, t *turn) {
+				h.handleAudioMessage(ctx, t, interviewID, userID, msg)
+			})
+		case "code_submit":
+			h.startTurn(ctx, conn, interviewID, func(ctx context.Context, t *turn) {
+				h.handleCodeSubmit(ctx, t, interview, userID, msg.Language, msg.Data)
+			})
 		case "end":
-			h.handleEndInterview(ctx, conn, interviewID, userID)
+			h.endInterview(ctx, session, interviewID, userID)
 			return nil
 		case "ping":
 			h.sendJSON(ctx, conn, wsResponse{Type: "status", Data: "pong"})
@@ -102,45 +177,175 @@ func (h *WebSocketHandler) Handle(httpCtx http.Context) error {
 	}
 }
 
-// handleTextMessage 处理文本消息 - LLM 流式 + TTS
+// startTurn 开始一轮处理，上一轮尚未结束时拒绝
+func (h *WebSocketHandler) startTurn(ctx context.Context, conn *websocket.Conn, interviewID int64, fn func(ctx context.Context, t *turn)) {
+	if _, err := h.sessions.startTurn(ctx, interviewID, fn); err != nil {
+		h.sendJSON(ctx, conn, wsResponse{Type: "error", Data: err.Error()})
+	}
+}
+
+// endInterview 等待进行中的回复 (可能在其他实例上) 结束后生成评估，评估推送给连接后才关闭连接
+func (h *WebSocketHandler) endInterview(ctx context.Context, session *connection, interviewID, userID int64) {
+	for {
+		var end *turn
+		done, err := h.sessions.startTurn(ctx, interviewID, func(ctx context.Context, t *turn) {
+			end = t
+			h.handleEndInterview(ctx, t, interviewID, userID)
+		})
+		if errors.Is(err, ErrTurnInProgress) {
+			select {
+			case <-time.After(200 * time.Millisecond):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err != nil {
+			h.sendJSON(ctx, session.conn, wsResponse{Type: "error", Data: err.Error()})
+			return
+		}
+		<-done
+		session.waitDelivered(ctx, end.lastSeq)
+		return
+	}
+}
+
+// handleAudioMessage 处理候选人语音：base64 解码，必要时由服务端 STT 转写，
+// 之后与文本消息流程一致，并把原始录音关联到该条用户消息
+func (h *WebSocketHandler) handleAudioMessage(
+	ctx context.Context,
+	t *turn,
+	interviewID int64,
+	userID int64,
+	msg wsMessage,
+) {
+	audio, err := base64.StdEncoding.DecodeString(msg.Data)
+	if err != nil || len(audio) == 0 {
+		t.emit("error", "invalid audio data")
+		return
+	}
+	if int64(len(audio)) > h.audioUC.MaxUploadBytes() {
+		t.emit("error", fmt.Sprintf("audio exceeds %d bytes", h.audioUC.MaxUploadBytes()))
+		return
+	}
+	clip := &biz.AudioClip{Format: msg.Format, Data: audio}
+	if clip.Format == "" {
+		clip.Format = "webm"
+	}
+
+	text := strings.TrimSpace(msg.Text)
+	if text == "" {
+		text, clip.Duration, err = h.transcribe(ctx, interviewID, userID, clip)
+		if err != nil {
+			t.emit("error", err.Error())
+			return
+		}
+		t.emit("transcript", text)
+	}
+	if text == "" {
+		t.emit("error", "no speech recognized")
+		return
+	}
+
+	h.handleTextMessage(ctx, t, interviewID, userID, text, nil, clip)
+}
+
+// transcribe 使用用户设置的服务端 STT 转写录音
+func (h *WebSocketHandler) transcribe(ctx context.Context, interviewID, userID int64, clip *biz.AudioClip) (string, time.Duration, error) {
+	settings, err := h.interviewSvc.GetUserSettings(ctx, userID)
+	if errors.Is(err, service.ErrDecryptAPIKey) {
+		return "", 0, err
+	}
+	provider, apiKey, baseURL := h.interviewUC.ResolveSTTProvider(settings)
+	if provider == nil {
+		return "", 0, errors.New("server-side speech recognition is not configured")
+	}
+
+	req := &stt.Request{Audio: bytes.NewReader(clip.Data), Format: clip.Format, APIKey: apiKey, BaseURL: baseURL}
+	if interview, _, err := h.interviewSvc.GetInterview(ctx, interviewID); err == nil {
+		req.Language = interview.Language
+	}
+
+	res, err := provider.Transcribe(ctx, req)
+	if err != nil {
+		return "", 0, fmt.Errorf("transcribe: %w", err)
+	}
+	return strings.TrimSpace(res.Text), time.Duration(res.Duration * float64(time.Second)), nil
+}
+
+// handleTextMessage 处理文本消息 - LLM 流式 + TTS；images 为候选人附带的图片，
+// userAudio 为候选人的原始录音 (文本输入时为 nil)
 func (h *WebSocketHandler) handleTextMessage(
 	ctx context.Context,
-	conn *websocket.Conn,
+	t *turn,
 	interviewID int64,
 	userID int64,
 	content string,
+	images []*biz.Attachment,
+	userAudio *biz.AudioClip,
 ) {
-	// 1. 获取用户设置
-	settings, _ := h.interviewSvc.GetUserSettings(ctx, userID)
-
-	// 2. 通知开始回复
-	h.sendJSON(ctx, conn, wsResponse{Type: "text_start"})
+	// 1. 获取用户设置 (未保存过设置时为 nil，使用默认值)
+	settings, err := h.interviewSvc.GetUserSettings(ctx, userID)
+	if errors.Is(err, service.ErrDecryptAPIKey) {
+		t.emit("error", err.Error())
+		return
+	}
 
-	// 3. 调用 LLM 流式 API
-	userMsg, streamCh, err := h.interviewUC.StreamMessage(ctx, interviewID, content, settings)
+	// 2. 调用 LLM 流式 API (小组面试先选出本轮发言的面试官)
+	userMsg, reply, err := h.interviewUC.StreamMessage(ctx, interviewID, content, images, settings)
 	if err != nil {
-		h.sendJSON(ctx, conn, wsResponse{Type: "error", Data: err.Error()})
+		t.emit("error", err.Error())
 		return
 	}
 
-	// 发送用户消息确认
-	h.sendJSON(ctx, conn, wsResponse{
-		Type: "status",
-		Data: map[string]any{"user_message_id": userMsg.ID},
-	})
+	// 3. 通知开始回复，小组面试带上发言人，自适应难度带上问题难度
+	start := map[string]any{}
+	if reply.Speaker != nil {
+		start["speaker"], start["name"], start["role"] = reply.Speaker.ID, reply.Speaker.Name, reply.Speaker.Role
+	}
+	if reply.Difficulty > 0 {
+		start["difficulty"] = reply.Difficulty
+	}
+	var data any
+	if len(start) > 0 {
+		data = start
+	}
+	t.emit("text_start", data)
+
+	// 发送用户消息确认，附带图片时带上图片地址
+	ack := map[string]any{"user_message_id": userMsg.ID}
+	if len(userMsg.Attachments) > 0 {
+		ack["attachments"] = attachmentsView(userMsg.Attachments)
+	}
+	t.emit("status", ack)
 
 	// 4. 流式读取 LLM 回复，同时做句子切分 + TTS
 	var fullContent strings.Builder
 	var sentenceBuffer strings.Builder
-	ttsEnabled := settings != nil && settings.TTSEnabled
-	var ttsProvider tts.Provider
-	if ttsEnabled && settings.TTSProvider != "" {
-		ttsProvider, _ = h.interviewUC.GetTTSProvider(settings.TTSProvider)
+	speech := h.interviewUC.ResolveSpeech(ctx, interviewID, reply.Speaker, settings)
+
+	// 句子按顺序交给单个 goroutine 合成，保证音频帧顺序与文本一致；
+	// 合成的音频按句保存，ttsDone 之后才读取
+	var sentences chan string
+	var assistantAudio []*biz.AudioClip
+	ttsDone := make(chan struct{})
+	if speech != nil {
+		sentences = make(chan string, 16)
+		go func() {
+			defer close(ttsDone)
+			for sentence := range sentences {
+				if clip := h.synthesizeAndSend(ctx, t, speech, sentence); clip != nil {
+					assistantAudio = append(assistantAudio, clip)
+				}
+			}
+		}()
+	} else {
+		close(ttsDone)
 	}
 
-	for event := range streamCh {
+	for event := range reply.Stream {
 		if event.Err != nil {
-			h.sendJSON(ctx, conn, wsResponse{Type: "error", Data: event.Err.Error()})
+			t.emit("error", event.Err.Error())
 			break
 		}
 		if event.Done {
@@ -148,91 +353,135 @@ func (h *WebSocketHandler) handleTextMessage(
 		}
 
 		// 发送文本 delta
-		h.sendJSON(ctx, conn, wsResponse{Type: "text_delta", Data: event.Content})
+		t.emit("text_delta", event.Content)
 		fullContent.WriteString(event.Content)
 		sentenceBuffer.WriteString(event.Content)
 
 		// 检测句子边界，触发 TTS
-		if ttsProvider != nil && isSentenceEnd(sentenceBuffer.String()) {
+		if speech != nil && isSentenceEnd(sentenceBuffer.String()) {
 			sentence := strings.TrimSpace(sentenceBuffer.String())
 			sentenceBuffer.Reset()
 			if sentence != "" {
-				go h.synthesizeAndSend(ctx, conn, ttsProvider, sentence, settings)
+				sentences <- sentence
 			}
 		}
 	}
 
 	// 处理剩余的文本
-	if ttsProvider != nil && sentenceBuffer.Len() > 0 {
-		sentence := strings.TrimSpace(sentenceBuffer.String())
-		if sentence != "" {
-			h.synthesizeAndSend(ctx, conn, ttsProvider, sentence, settings)
+	if speech != nil {
+		if sentence := strings.TrimSpace(sentenceBuffer.String()); sentence != "" {
+			sentences <- sentence
+		}
+		close(sentences)
+	}
+	<-ttsDone
+
+	if userAudio != nil {
+		h.saveRecordings(ctx, interviewID, userMsg.ID, "user", []*biz.AudioClip{userAudio})
+	}
+	if fullContent.Len() > 0 {
+		assistantMsg, err := h.interviewUC.SaveAssistantMessage(ctx, interviewID, reply, fullContent.String())
+		if err != nil {
+			h.logger.Errorf("save assistant message: %v", err)
+		} else if len(assistantAudio) > 0 {
+			h.saveRecordings(ctx, interviewID, assistantMsg.ID, "assistant", assistantAudio)
 		}
 	}
 
 	// 发送文本结束
-	h.sendJSON(ctx, conn, wsResponse{Type: "text_end", Data: fullContent.String()})
+	t.emit("text_end", fullContent.String())
+
+	// 面试官通过 end_interview 结束面试
+	if reply.EndRequested() {
+		h.handleEndInterview(ctx, t, interviewID, userID)
+	}
+}
+
+// handleCodeSubmit 运行提交的代码，返回测试结果，并把结果交给面试官 (LLM) 继续追问
+func (h *WebSocketHandler) handleCodeSubmit(
+	ctx context.Context,
+	t *turn,
+	interview *biz.Interview,
+	userID int64,
+	language string,
+	code string,
+) {
+	t.emit("status", "running")
+
+	sub, err := h.interviewSvc.SubmitCode(ctx, userID, interview.ID, language, code)
+	if err != nil {
+		t.emit("error", err.Error())
+		return
+	}
+	t.emit("code_result", submissionView(sub))
+
+	content, err := h.interviewUC.FormatSubmission(interview, sub)
+	if err != nil {
+		t.emit("error", err.Error())
+		return
+	}
+	h.handleTextMessage(ctx, t, interview.ID, userID, content, nil, nil)
 }
 
 // handleEndInterview 处理结束面试
 func (h *WebSocketHandler) handleEndInterview(
 	ctx context.Context,
-	conn *websocket.Conn,
+	t *turn,
 	interviewID int64,
 	userID int64,
 ) {
-	h.sendJSON(ctx, conn, wsResponse{Type: "status", Data: "evaluating"})
+	t.emit("status", "evaluating")
 
 	eval, err := h.interviewSvc.EndInterview(ctx, interviewID, userID)
 	if err != nil {
-		h.sendJSON(ctx, conn, wsResponse{Type: "error", Data: err.Error()})
+		t.emit("error", err.Error())
 		return
 	}
 
-	h.sendJSON(ctx, conn, wsResponse{
-		Type: "evaluation",
-		Data: map[string]any{
-			"overall_score": eval.OverallScore,
-			"summary":       eval.Summary,
-		},
+	t.emit("evaluation", map[string]any{
+		"overall_score": eval.OverallScore,
+		"summary":       eval.Summary,
 	})
 }
 
-// synthesizeAndSend TTS 合成并发送音频
+// saveRecordings 保存录音，未启用录音时跳过；失败只记录日志，不影响面试进行
+func (h *WebSocketHandler) saveRecordings(ctx context.Context, interviewID, messageID int64, role string, clips []*biz.AudioClip) {
+	if !h.audioUC.Enabled() {
+		return
+	}
+	if _, err := h.audioUC.SaveRecordings(ctx, interviewID, messageID, role, clips); err != nil {
+		h.logger.Errorf("save %s recordings for message %d: %v", role, messageID, err)
+	}
+}
+
+// synthesizeAndSend TTS 合成并发送音频，返回合成的音频片段 (失败时为 nil)；
+// speech 为 ResolveSpeech 返回的备用链与语速，语言与语气按句推断，音色与凭据由链中的候选提供；
+// 只合成可朗读的文本，text_delta 仍发送原文
 func (h *WebSocketHandler) synthesizeAndSend(
 	ctx context.Context,
-	conn *websocket.Conn,
-	provider tts.Provider,
+	t *turn,
+	speech *biz.Speech,
 	text string,
-	settings *biz.UserSettings,
-) {
-	var buf bytes.Buffer
-	req := &tts.Request{
-		Text:   text,
-		Voice:  settings.TTSVoice,
-		APIKey: settings.TTSAPIKey,
-		Speed:  1.0,
-		Format: "mp3",
+) *biz.AudioClip {
+	req := speech.Request(text)
+	if req == nil {
+		return nil
 	}
-
-	if err := provider.Synthesize(ctx, req, &buf); err != nil {
+	req.Format = "mp3"
+	var buf bytes.Buffer
+	if err := speech.Provider.Synthesize(ctx, req, &buf); err != nil {
 		h.logger.Errorf("TTS synthesize error: %v", err)
-		return
+		return nil
 	}
 
 	// 发送音频二进制数据
-	if err := conn.Write(ctx, websocket.MessageBinary, buf.Bytes()); err != nil {
-		h.logger.Errorf("WebSocket write audio error: %v", err)
-	}
+	t.emitAudio(buf.Bytes())
+	return &biz.AudioClip{Format: req.Format, Data: buf.Bytes(), Duration: tts.EstimateSpeechDuration(req.Text, req.Speed)}
 }
 
 // sendJSON 发送 JSON 消息
 func (h *WebSocketHandler) sendJSON(ctx context.Context, conn *websocket.Conn, msg wsResponse) {
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return
-	}
-	_ = conn.Write(ctx, websocket.MessageText, data)
+	_ = writeJSON(ctx, conn, msg)
 }
 
 // isSentenceEnd 检测句子边界
@@ -0,0 +1,799 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-interview/internal/biz"
+	"ai-interview/internal/conf"
+	"ai-interview/internal/data"
+	"ai-interview/internal/middleware"
+	"ai-interview/internal/provider/llm"
+	"ai-interview/internal/provider/stt"
+	"ai-interview/internal/provider/tts"
+	"ai-interview/internal/sandbox"
+	"ai-interview/internal/service"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"nhooyr.io/websocket"
+)
+
+var e2eQuestions = []string{
+	"你好，请先做个自我介绍。",
+	"说说你做过最有挑战的项目。",
+}
+
+const e2eEvaluation = `{"overall_score":88,"summary":"表现良好","categories":[],` +
+	`"strengths":"表达清晰","weaknesses":"无","suggestions":"保持"}`
+
+// newE2EServer 组装完整的 HTTP 服务：SQLite + mock providers (演示模式)，coding 非 nil 时启用编程模式；
+// tokenDelay 为 mock LLM 相邻 token 的间隔
+func newE2EServer(t *testing.T, coding *conf.Coding, tokenDelay time.Duration) *httptest.Server {
+	return newE2ECluster(t, coding, tokenDelay, 1)[0]
+}
+
+// newE2ECluster 组装多个实例，共享数据库、会话事件缓存和会话协调器 (相当于部署中共享的 MySQL + Redis)
+func newE2ECluster(t *testing.T, coding *conf.Coding, tokenDelay time.Duration, replicas int) []*httptest.Server {
+	t.Helper()
+	logger := log.NewStdLogger(io.Discard)
+
+	d, cleanup, err := data.NewData(&conf.Data{
+		Database: &conf.Data_Database{
+			Driver:      "sqlite",
+			Source:      filepath.Join(t.TempDir(), "e2e.db"),
+			AutoMigrate: true,
+		},
+	}, logger)
+	if err != nil {
+		t.Fatalf("NewData error: %v", err)
+	}
+	t.Cleanup(cleanup)
+
+	llmRegistry := llm.NewRegistry()
+	llmRegistry.Register(llm.NewMockProvider(llm.MockScript{Questions: e2eQuestions, Evaluation: e2eEvaluation, TokenDelay: tokenDelay}))
+	ttsRegistry := tts.NewRegistry()
+	ttsRegistry.Register(tts.NewMockProvider(false))
+	sttRegistry := stt.NewRegistry()
+	sttRegistry.Register(stt.NewMockProvider(nil))
+
+	jwtHelper := middleware.NewJWTHelper("e2e-secret", time.Hour)
+	keys, err := middleware.NewEnvKeySource("e2e:" + strings.Repeat("ab", 32)).Load(context.Background())
+	if err != nil {
+		t.Fatalf("load keys: %v", err)
+	}
+	encryptor, err := middleware.NewEncryptor(keys)
+	if err != nil {
+		t.Fatalf("NewEncryptor error: %v", err)
+	}
+
+	var sb *sandbox.Sandbox
+	if coding != nil {
+		if sb, err = sandbox.New(sandbox.Config{WorkDir: t.TempDir(), AllowUnisolated: true}, logger); err != nil {
+			t.Fatalf("sandbox.New error: %v", err)
+		}
+	}
+
+	audioStore, err := data.NewLocalAudioStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalAudioStore error: %v", err)
+	}
+
+	userRepo := data.NewUserRepo(d, logger)
+	interviewRepo := data.NewInterviewRepo(d, logger)
+	userUC := biz.NewUserUsecase(userRepo, nil, nil, logger)
+	codingUC := biz.NewCodingUsecase(data.NewCodingRepo(d, logger), interviewRepo, sb, coding, logger)
+	prompts, err := biz.NewPrompts(nil)
+	if err != nil {
+		t.Fatalf("NewPrompts error: %v", err)
+	}
+	personas, err := biz.NewPersonas(nil)
+	if err != nil {
+		t.Fatalf("NewPersonas error: %v", err)
+	}
+	interviewUC := biz.NewInterviewUsecase(interviewRepo, userRepo,
+		llmRegistry, ttsRegistry, sttRegistry, codingUC, prompts, personas, nil, nil, nil, &conf.Demo{Enabled: true}, logger)
+	authSvc := service.NewAuthService(userUC, jwtHelper, encryptor)
+	audioUC := biz.NewAudioUsecase(data.NewAudioRepo(d, logger), audioStore, nil, logger)
+	interviewSvc := service.NewInterviewService(interviewUC, userUC, codingUC, audioUC, encryptor)
+
+	serverConf := &conf.Server{Http: &conf.Server_HTTP{Timeout: conf.NewDuration(time.Second)}}
+	events := data.NewSessionEventStore(d, serverConf, logger)
+	registry := data.NewSessionRegistry(d, logger)
+
+	var servers []*httptest.Server
+	for i := range replicas {
+		nodeConf := &conf.Server{
+			Http:      serverConf.Http,
+			Websocket: &conf.Server_WebSocket{NodeId: fmt.Sprintf("node-%d", i+1)},
+		}
+		hub := NewSessionHub(events, registry, nodeConf, logger)
+		srv := NewHTTPServer(nodeConf,
+			authSvc, interviewSvc, NewWebSocketHandler(interviewSvc, interviewUC, audioUC, hub, logger), jwtHelper, logger)
+		ts := httptest.NewServer(srv)
+		t.Cleanup(ts.Close)
+		servers = append(servers, ts)
+	}
+	return servers
+}
+
+func postJSON(t *testing.T, method, url, token string, body, out any) {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	req, _ := nethttp.NewRequest(method, url, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := nethttp.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		raw, _ := io.ReadAll(resp.Body)
+		t.Fatalf("%s %s: status %d: %s", method, url, resp.StatusCode, raw)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s response: %v", url, err)
+		}
+	}
+}
+
+// readUntil 读取 WebSocket 消息直到出现指定类型，返回该消息及期间收到的音频帧数
+func readUntil(ctx context.Context, t *testing.T, conn *websocket.Conn, want string) (map[string]any, int) {
+	t.Helper()
+	audioFrames := 0
+	for {
+		typ, raw, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatalf("read while waiting for %q: %v", want, err)
+		}
+		if typ == websocket.MessageBinary {
+			audioFrames++
+			continue
+		}
+		var msg map[string]any
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			t.Fatalf("invalid json message: %s", raw)
+		}
+		if msg["type"] == "error" {
+			t.Fatalf("server error: %v", msg["data"])
+		}
+		if msg["type"] == want {
+			return msg, audioFrames
+		}
+	}
+}
+
+func TestWebSocketE2E_DemoInterview(t *testing.T) {
+	ts := newE2EServer(t, nil, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var auth struct {
+		Token string `json:"token"`
+	}
+	postJSON(t, "POST", ts.URL+"/api/v1/auth/register", "",
+		map[string]string{"email": "e2e@example.com", "password": "password123", "nickname": "e2e"}, &auth)
+	postJSON(t, "PUT", ts.URL+"/api/v1/auth/settings", auth.Token,
+		map[string]any{"tts_provider": "mock", "tts_enabled": true}, nil)
+
+	var created struct {
+		ID           int64  `json:"id"`
+		WebsocketURL string `json:"websocket_url"`
+	}
+	postJSON(t, "POST", ts.URL+"/api/v1/interviews", auth.Token,
+		map[string]string{"title": "E2E", "position": "后端工程师", "language": "zh"}, &created)
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + created.WebsocketURL + "?token=" + auth.Token
+	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	conn.SetReadLimit(1 << 20) // 音频帧超过默认 32KB 限制
+
+	if msg, _ := readUntil(ctx, t, conn, "status"); msg["data"] != "connected" {
+		t.Fatalf("expected connected status, got %v", msg)
+	}
+
+	// 超过 HTTP 请求超时 (1s) 后会话仍然可用
+	time.Sleep(1200 * time.Millisecond)
+
+	for i, question := range e2eQuestions {
+		send := fmt.Sprintf(`{"type":"text","data":"第 %d 轮回答"}`, i+1)
+		if i == 0 {
+			// 第一轮使用语音回答，由服务端 (mock) STT 转写
+			send = `{"type":"audio","format":"webm","data":"` + base64.StdEncoding.EncodeToString([]byte("candidate webm audio")) + `"}`
+		}
+		if err := conn.Write(ctx, websocket.MessageText, []byte(send)); err != nil {
+			t.Fatalf("write text: %v", err)
+		}
+		if i == 0 {
+			if msg, _ := readUntil(ctx, t, conn, "transcript"); !strings.Contains(msg["data"].(string), "后端工程师") {
+				t.Errorf("unexpected transcript: %v", msg["data"])
+			}
+		}
+		msg, audioFrames := readUntil(ctx, t, conn, "text_end")
+		if msg["data"] != question {
+			t.Errorf("turn %d: got %q, want %q", i+1, msg["data"], question)
+		}
+		if audioFrames == 0 {
+			t.Errorf("turn %d: expected TTS audio frames", i+1)
+		}
+	}
+
+	if err := conn.Write(ctx, websocket.MessageText, []byte(`{"type":"end"}`)); err != nil {
+		t.Fatalf("write end: %v", err)
+	}
+	msg, _ := readUntil(ctx, t, conn, "evaluation")
+	evalData, _ := msg["data"].(map[string]any)
+	if evalData["overall_score"] != float64(88) || evalData["summary"] != "表现良好" {
+		t.Errorf("unexpected evaluation: %v", evalData)
+	}
+
+	// 助手消息应已落库
+	var detail struct {
+		Status   string `json:"status"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	req, _ := nethttp.NewRequest("GET", fmt.Sprintf("%s/api/v1/interviews/%d", ts.URL, created.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+auth.Token)
+	resp, err := nethttp.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get interview: %v", err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&detail); err != nil {
+		t.Fatalf("decode interview: %v", err)
+	}
+	if detail.Status != "completed" {
+		t.Errorf("status = %q, want completed", detail.Status)
+	}
+	var assistant []string
+	for _, m := range detail.Messages {
+		if m.Role == "assistant" {
+			assistant = append(assistant, m.Content)
+		}
+	}
+	if strings.Join(assistant, "|") != strings.Join(e2eQuestions, "|") {
+		t.Errorf("assistant messages = %q, want %q", assistant, e2eQuestions)
+	}
+
+	// 导出报告
+	exportURL := fmt.Sprintf("%s/api/v1/interviews/%d/export", ts.URL, created.ID)
+	for format, want := range map[string]string{"markdown": "综合得分: 88 / 100", "pdf": "%PDF-1.4", "json": `"overall_score": 88`} {
+		status, header, body := httpGet(t, exportURL+"?format="+format, auth.Token)
+		if status != 200 || !strings.Contains(body, want) {
+			t.Errorf("export %s: status %d, body missing %q", format, status, want)
+		}
+		if !strings.HasPrefix(header.Get("Content-Disposition"), "attachment;") {
+			t.Errorf("export %s: missing attachment header", format)
+		}
+	}
+	if status, _, _ := httpGet(t, exportURL+"?format=docx", auth.Token); status != 400 {
+		t.Errorf("unsupported format: status %d, want 400", status)
+	}
+
+	var other struct {
+		Token string `json:"token"`
+	}
+	postJSON(t, "POST", ts.URL+"/api/v1/auth/register", "",
+		map[string]string{"email": "other@example.com", "password": "password123", "nickname": "other"}, &other)
+	if status, _, _ := httpGet(t, exportURL, other.Token); status != 404 {
+		t.Errorf("export by another user: status %d, want 404", status)
+	}
+
+	// 双方录音按消息保存，可播放 / 下载
+	var recordings struct {
+		Recordings []struct {
+			ID         int64  `json:"id"`
+			Role       string `json:"role"`
+			Format     string `json:"format"`
+			DurationMs int64  `json:"duration_ms"`
+			URL        string `json:"url"`
+		} `json:"recordings"`
+	}
+	recordingsURL := fmt.Sprintf("%s/api/v1/interviews/%d/recordings", ts.URL, created.ID)
+	status, _, body := httpGet(t, recordingsURL, auth.Token)
+	if status != 200 {
+		t.Fatalf("list recordings: status %d: %s", status, body)
+	}
+	_ = json.Unmarshal([]byte(body), &recordings)
+	roles := map[string]int{}
+	for _, r := range recordings.Recordings {
+		roles[r.Role]++
+	}
+	if roles["user"] != 1 || roles["assistant"] < len(e2eQuestions) {
+		t.Fatalf("unexpected recordings: %+v", recordings.Recordings)
+	}
+	for _, r := range recordings.Recordings {
+		if r.Role == "assistant" && (r.Format != "mp3" || r.DurationMs == 0) {
+			t.Errorf("assistant recording missing format/duration: %+v", r)
+		}
+	}
+
+	last := recordings.Recordings[len(recordings.Recordings)-1]
+	status, header, body := httpGet(t, ts.URL+last.URL+"?download=1", auth.Token)
+	if status != 200 || header.Get("Content-Type") != "audio/mpeg" || body[0] != 0xFF {
+		t.Errorf("play recording: status %d, content-type %q", status, header.Get("Content-Type"))
+	}
+	if !strings.HasPrefix(header.Get("Content-Disposition"), "attachment;") {
+		t.Error("download=1 should return an attachment")
+	}
+	if status, _, body := httpGet(t, ts.URL+recordings.Recordings[0].URL+"?token="+auth.Token, ""); status != 200 || body != "candidate webm audio" {
+		t.Errorf("play candidate recording via token query: status %d, body %q", status, body)
+	}
+	if status, _, _ := httpGet(t, ts.URL+last.URL, other.Token); status != 404 {
+		t.Errorf("recording by another user: status %d, want 404", status)
+	}
+	if status, _, _ := httpGet(t, recordingsURL, other.Token); status != 404 {
+		t.Errorf("list recordings by another user: status %d, want 404", status)
+	}
+}
+
+func httpGet(t *testing.T, url, token string) (int, nethttp.Header, string) {
+	t.Helper()
+	req, _ := nethttp.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := nethttp.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header, string(body)
+}
+
+func TestWebSocketE2E_PanelInterview(t *testing.T) {
+	ts := newE2EServer(t, nil, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var auth struct {
+		Token string `json:"token"`
+	}
+	postJSON(t, "POST", ts.URL+"/api/v1/auth/register", "",
+		map[string]string{"email": "panel@example.com", "password": "password123", "nickname": "panel"}, &auth)
+
+	var created struct {
+		ID           int64            `json:"id"`
+		WebsocketURL string           `json:"websocket_url"`
+		Panel        []map[string]any `json:"panel"`
+	}
+	postJSON(t, "POST", ts.URL+"/api/v1/interviews", auth.Token, map[string]any{
+		"title": "Panel", "position": "后端工程师",
+		"panel": []map[string]string{
+			{"name": "张工", "role": "技术面试官", "focus": "Go 并发"},
+			{"id": "hm", "name": "李经理", "role": "招聘经理"},
+		},
+	}, &created)
+	if len(created.Panel) != 2 || created.Panel[0]["id"] != "p1" || created.Panel[1]["id"] != "hm" {
+		t.Fatalf("unexpected panel: %v", created.Panel)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + created.WebsocketURL + "?token=" + auth.Token
+	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	conn.SetReadLimit(1 << 20)
+
+	// 默认 rotation：每位面试官连续发言 2 轮；点名时交给被点名的面试官
+	answers := []string{"你好", "第 2 轮回答", "第 3 轮回答", "张工，我想补充一下"}
+	want := []string{"p1", "p1", "hm", "p1"}
+	for i, answer := range answers {
+		send, _ := json.Marshal(map[string]string{"type": "text", "data": answer})
+		if err := conn.Write(ctx, websocket.MessageText, send); err != nil {
+			t.Fatalf("write text: %v", err)
+		}
+		msg, _ := readUntil(ctx, t, conn, "text_start")
+		speaker, _ := msg["data"].(map[string]any)
+		if speaker["speaker"] != want[i] {
+			t.Errorf("turn %d: speaker %v, want %s", i+1, speaker, want[i])
+		}
+		readUntil(ctx, t, conn, "text_end")
+	}
+
+	var detail struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Speaker string `json:"speaker"`
+		} `json:"messages"`
+	}
+	status, _, body := httpGet(t, fmt.Sprintf("%s/api/v1/interviews/%d", ts.URL, created.ID), auth.Token)
+	if status != 200 {
+		t.Fatalf("get interview: status %d", status)
+	}
+	if err := json.Unmarshal([]byte(body), &detail); err != nil {
+		t.Fatalf("decode interview: %v", err)
+	}
+	var speakers []string
+	for _, m := range detail.Messages {
+		if m.Role == "assistant" {
+			speakers = append(speakers, m.Speaker)
+		}
+	}
+	if strings.Join(speakers, ",") != strings.Join(want, ",") {
+		t.Errorf("persisted speakers = %v, want %v", speakers, want)
+	}
+
+	// 面试官人数不足时拒绝创建
+	b, _ := json.Marshal(map[string]any{"title": "x", "panel": []map[string]string{{"name": "solo"}}})
+	req, _ := nethttp.NewRequest("POST", ts.URL+"/api/v1/interviews", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+auth.Token)
+	resp, err := nethttp.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("create interview: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 400 {
+		t.Errorf("single panelist: status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestWebSocketE2E_CodingInterview(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	ts := newE2EServer(t, &conf.Coding{
+		Enabled: true,
+		Problems: []*conf.Coding_Problem{{
+			Id:    "a-plus-b",
+			Title: "A + B",
+			Tests: []*conf.Coding_TestCase{
+				{Input: "1 2\n", Output: "3\n"},
+				{Input: "-5 5\n", Output: "0\n", Hidden: true},
+			},
+		}},
+	}, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var auth struct {
+		Token string `json:"token"`
+	}
+	postJSON(t, "POST", ts.URL+"/api/v1/auth/register", "",
+		map[string]string{"email": "coding@example.com", "password": "password123", "nickname": "coding"}, &auth)
+
+	var created struct {
+		ID           int64  `json:"id"`
+		ProblemID    string `json:"problem_id"`
+		WebsocketURL string `json:"websocket_url"`
+	}
+	postJSON(t, "POST", ts.URL+"/api/v1/interviews", auth.Token,
+		map[string]string{"title": "Coding", "position": "后端工程师", "mode": "coding"}, &created)
+	if created.ProblemID != "a-plus-b" {
+		t.Fatalf("problem_id = %q, want a-plus-b", created.ProblemID)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + created.WebsocketURL + "?token=" + auth.Token
+	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	conn.SetReadLimit(1 << 20)
+
+	msg, _ := readUntil(ctx, t, conn, "problem")
+	problem, _ := msg["data"].(map[string]any)
+	if samples, _ := problem["samples"].([]any); len(samples) != 1 {
+		t.Fatalf("problem should expose only the sample test, got %v", problem["samples"])
+	}
+
+	submit, _ := json.Marshal(map[string]string{
+		"type":     "code_submit",
+		"language": "python",
+		"data":     "a, b = map(int, input().split())\nprint(a + b)\n",
+	})
+	if err := conn.Write(ctx, websocket.MessageText, submit); err != nil {
+		t.Fatalf("write code_submit: %v", err)
+	}
+	msg, _ = readUntil(ctx, t, conn, "code_result")
+	result, _ := msg["data"].(map[string]any)
+	if result["status"] != biz.SubmissionAccepted || result["passed"] != float64(2) {
+		t.Fatalf("unexpected code_result: %v", result)
+	}
+	results, _ := result["results"].([]any)
+	if hidden, _ := results[1].(map[string]any); hidden["input"] != nil || hidden["actual"] != nil {
+		t.Errorf("hidden test leaked input/output: %v", hidden)
+	}
+
+	// 测试结果交给面试官继续追问
+	if msg, _ := readUntil(ctx, t, conn, "text_end"); msg["data"] != e2eQuestions[0] {
+		t.Errorf("follow-up = %q, want %q", msg["data"], e2eQuestions[0])
+	}
+
+	var subs struct {
+		Submissions []map[string]any `json:"submissions"`
+	}
+	req, _ := nethttp.NewRequest("GET", fmt.Sprintf("%s/api/v1/interviews/%d/submissions", ts.URL, created.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+auth.Token)
+	resp, err := nethttp.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("list submissions: %v", err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&subs); err != nil {
+		t.Fatalf("decode submissions: %v", err)
+	}
+	if len(subs.Submissions) != 1 || subs.Submissions[0]["status"] != biz.SubmissionAccepted {
+		t.Errorf("unexpected submissions: %v", subs.Submissions)
+	}
+
+	// 其他用户不能查看这场面试的提交
+	var other struct {
+		Token string `json:"token"`
+	}
+	postJSON(t, "POST", ts.URL+"/api/v1/auth/register", "",
+		map[string]string{"email": "other@example.com", "password": "password123", "nickname": "other"}, &other)
+	if status, _, _ := httpGet(t, fmt.Sprintf("%s/api/v1/interviews/%d/submissions", ts.URL, created.ID), other.Token); status != 404 {
+		t.Errorf("other user's submissions: status %d, want 404", status)
+	}
+}
+
+// seqReader 读取带序号的会话事件，校验序号连续 (不重复、不遗漏)
+type seqReader struct {
+	t       *testing.T
+	conn    *websocket.Conn
+	lastSeq int64
+}
+
+// next 返回下一个会话事件的类型和 JSON 消息 (音频帧为 nil)，连接级消息 (无 seq) 原样返回
+func (r *seqReader) next(ctx context.Context) (string, map[string]any) {
+	r.t.Helper()
+	typ, raw, err := r.conn.Read(ctx)
+	if err != nil {
+		r.t.Fatalf("read after seq %d: %v", r.lastSeq, err)
+	}
+	if typ == websocket.MessageBinary {
+		if len(raw) <= 8 {
+			r.t.Fatalf("audio frame too short: %d bytes", len(raw))
+		}
+		r.check(int64(binary.BigEndian.Uint64(raw)))
+		return "audio", nil
+	}
+	var msg map[string]any
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		r.t.Fatalf("invalid json message: %s", raw)
+	}
+	if seq, ok := msg["seq"].(float64); ok {
+		r.check(int64(seq))
+	}
+	return msg["type"].(string), msg
+}
+
+func (r *seqReader) check(seq int64) {
+	r.t.Helper()
+	if seq != r.lastSeq+1 {
+		r.t.Fatalf("seq %d after %d: events duplicated or lost", seq, r.lastSeq)
+	}
+	r.lastSeq = seq
+}
+
+func (r *seqReader) until(ctx context.Context, want string) map[string]any {
+	r.t.Helper()
+	for {
+		typ, msg := r.next(ctx)
+		if typ == "error" {
+			r.t.Fatalf("server error: %v", msg["data"])
+		}
+		if typ == want {
+			return msg
+		}
+	}
+}
+
+func TestWebSocketE2E_Resume(t *testing.T) {
+	// 放慢 mock LLM，保证断线时回复仍在生成
+	ts := newE2EServer(t, nil, 30*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var auth struct {
+		Token string `json:"token"`
+	}
+	postJSON(t, "POST", ts.URL+"/api/v1/auth/register", "",
+		map[string]string{"email": "resume@example.com", "password": "password123", "nickname": "resume"}, &auth)
+	postJSON(t, "PUT", ts.URL+"/api/v1/auth/settings", auth.Token,
+		map[string]any{"tts_provider": "mock", "tts_enabled": true}, nil)
+
+	var created struct {
+		ID           int64  `json:"id"`
+		WebsocketURL string `json:"websocket_url"`
+	}
+	postJSON(t, "POST", ts.URL+"/api/v1/interviews", auth.Token,
+		map[string]string{"title": "Resume", "position": "后端工程师", "language": "zh"}, &created)
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + created.WebsocketURL + "?token=" + auth.Token
+	dial := func(query string) *seqReader {
+		conn, _, err := websocket.Dial(ctx, wsURL+query, nil)
+		if err != nil {
+			t.Fatalf("dial websocket: %v", err)
+		}
+		conn.SetReadLimit(1 << 20)
+		return &seqReader{t: t, conn: conn}
+	}
+
+	first := dial("")
+	if typ, msg := first.next(ctx); typ != "status" || msg["data"] != "connected" {
+		t.Fatalf("expected connected status, got %v", msg)
+	}
+	if err := first.conn.Write(ctx, websocket.MessageText, []byte(`{"type":"text","data":"第 1 轮回答"}`)); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	first.until(ctx, "text_end")
+
+	// 第二轮回复进行中时断线
+	if err := first.conn.Write(ctx, websocket.MessageText, []byte(`{"type":"text","data":"第 2 轮回答"}`)); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	first.until(ctx, "text_delta")
+	if err := first.conn.Write(ctx, websocket.MessageText, []byte(`{"type":"text","data":"重复提交"}`)); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	for {
+		if typ, msg := first.next(ctx); typ == "error" {
+			if msg["data"] != ErrTurnInProgress.Error() {
+				t.Fatalf("unexpected error: %v", msg["data"])
+			}
+			break
+		}
+	}
+	first.conn.CloseNow()
+
+	second := dial(fmt.Sprintf("&last_seq=%d", first.lastSeq))
+	defer second.conn.Close(websocket.StatusNormalClosure, "")
+	second.lastSeq = first.lastSeq
+	typ, msg := second.next(ctx)
+	resumed, _ := msg["data"].(map[string]any)
+	if typ != "resumed" || resumed["gap"] != false || resumed["turn_in_progress"] != true {
+		t.Fatalf("unexpected resume handshake: %v", msg)
+	}
+	if msg := second.until(ctx, "text_end"); msg["data"] != e2eQuestions[1] {
+		t.Errorf("resumed turn: got %q, want %q", msg["data"], e2eQuestions[1])
+	}
+
+	// 轮次没有因断线重跑：每轮只保存一条候选人消息和一条助手消息
+	var detail struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	_, _, body := httpGet(t, fmt.Sprintf("%s/api/v1/interviews/%d", ts.URL, created.ID), auth.Token)
+	if err := json.Unmarshal([]byte(body), &detail); err != nil {
+		t.Fatalf("decode interview: %v", err)
+	}
+	var roles []string
+	for _, m := range detail.Messages {
+		roles = append(roles, m.Role)
+	}
+	if strings.Join(roles, ",") != "user,assistant,user,assistant" {
+		t.Errorf("messages = %v, want two turns", roles)
+	}
+
+	// 序号超出缓存范围 (例如服务端缓存已过期) 时告知客户端存在缺口
+	stale := dial("&last_seq=100000")
+	defer stale.conn.Close(websocket.StatusNormalClosure, "")
+	if _, msg := stale.next(ctx); msg["type"] != "resumed" || msg["data"].(map[string]any)["gap"] != true {
+		t.Errorf("expected gap for unknown seq, got %v", msg)
+	}
+	// 其他用户不能连接或接管该面试的会话
+	var other struct {
+		Token string `json:"token"`
+	}
+	postJSON(t, "POST", ts.URL+"/api/v1/auth/register", "",
+		map[string]string{"email": "intruder@example.com", "password": "password123", "nickname": "intruder"}, &other)
+	otherURL := "ws" + strings.TrimPrefix(ts.URL, "http") + created.WebsocketURL + "?token=" + other.Token
+	for _, query := range []string{"", "&last_seq=0"} {
+		conn, resp, err := websocket.Dial(ctx, otherURL+query, nil)
+		if err == nil {
+			conn.CloseNow()
+			t.Fatalf("other user dial %q: expected rejection", query)
+		}
+		if resp == nil || resp.StatusCode != 404 {
+			t.Errorf("other user dial %q: got %v, want 404", query, resp)
+		}
+	}
+}
+
+func TestWebSocketE2E_CrossReplica(t *testing.T) {
+	// 两个实例共享会话协调器；回复在实例 1 生成期间候选人改连实例 2
+	cluster := newE2ECluster(t, nil, 30*time.Millisecond, 2)
+	nodeA, nodeB := cluster[0], cluster[1]
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var auth struct {
+		Token string `json:"token"`
+	}
+	postJSON(t, "POST", nodeA.URL+"/api/v1/auth/register", "",
+		map[string]string{"email": "cluster@example.com", "password": "password123", "nickname": "cluster"}, &auth)
+
+	var created struct {
+		ID           int64  `json:"id"`
+		WebsocketURL string `json:"websocket_url"`
+	}
+	postJSON(t, "POST", nodeA.URL+"/api/v1/interviews", auth.Token,
+		map[string]string{"title": "Cluster", "position": "后端工程师", "language": "zh"}, &created)
+
+	dial := func(ts *httptest.Server, query string) *seqReader {
+		url := "ws" + strings.TrimPrefix(ts.URL, "http") + created.WebsocketURL + "?token=" + auth.Token + query
+		conn, _, err := websocket.Dial(ctx, url, nil)
+		if err != nil {
+			t.Fatalf("dial websocket: %v", err)
+		}
+		conn.SetReadLimit(1 << 20)
+		return &seqReader{t: t, conn: conn}
+	}
+
+	a := dial(nodeA, "")
+	defer a.conn.CloseNow()
+	a.until(ctx, "status")
+	if err := a.conn.Write(ctx, websocket.MessageText, []byte(`{"type":"text","data":"第 1 轮回答"}`)); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	a.until(ctx, "text_delta")
+
+	// 同一场面试在集群内只保留一个候选人连接：实例 2 上的新连接接管，实例 1 上的旧连接被关闭；
+	// 实例 1 上仍在生成的回复经广播推送到实例 2
+	b := dial(nodeB, fmt.Sprintf("&last_seq=%d", a.lastSeq))
+	defer b.conn.Close(websocket.StatusNormalClosure, "")
+	b.lastSeq = a.lastSeq
+	typ, msg := b.next(ctx)
+	resumed, _ := msg["data"].(map[string]any)
+	if typ != "resumed" || resumed["gap"] != false || resumed["turn_in_progress"] != true {
+		t.Fatalf("unexpected resume handshake: %v", msg)
+	}
+	if msg := b.until(ctx, "text_end"); msg["data"] != e2eQuestions[0] {
+		t.Errorf("turn 1: got %q, want %q", msg["data"], e2eQuestions[0])
+	}
+	for {
+		_, _, err := a.conn.Read(ctx)
+		if err != nil {
+			if websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+				t.Fatalf("old connection closed with %v, want policy violation", err)
+			}
+			break
+		}
+	}
+
+	// 实例 2 上的回答生成的事件推送到实例 2 的连接
+	if err := b.conn.Write(ctx, websocket.MessageText, []byte(`{"type":"text","data":"第 2 轮回答"}`)); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	if msg := b.until(ctx, "text_end"); msg["data"] != e2eQuestions[1] {
+		t.Errorf("turn 2: got %q, want %q", msg["data"], e2eQuestions[1])
+	}
+
+	// 在实例 1 上重连并结束面试：评估在实例 1 生成
+	c := dial(nodeA, fmt.Sprintf("&last_seq=%d", b.lastSeq))
+	defer c.conn.CloseNow()
+	c.lastSeq = b.lastSeq
+	c.until(ctx, "resumed")
+	if err := c.conn.Write(ctx, websocket.MessageText, []byte(`{"type":"end"}`)); err != nil {
+		t.Fatalf("write end: %v", err)
+	}
+	if msg := c.until(ctx, "evaluation"); msg["data"].(map[string]any)["overall_score"] != float64(88) {
+		t.Errorf("unexpected evaluation: %v", msg["data"])
+	}
+}
@@ -79,6 +79,20 @@ func (s *AuthService) UpdateSettings(ctx context.Context, settings *biz.UserSett
 			}
 			settings.STTAPIKey = encrypted
 		}
+		if settings.AzureAPIKey != "" {
+			encrypted, err := s.encryptor.Encrypt(settings.AzureAPIKey)
+			if err != nil {
+				return fmt.Errorf("encrypt azure api key: %w", err)
+			}
+			settings.AzureAPIKey = encrypted
+		}
+		if settings.SpeechAPIKey != "" {
+			encrypted, err := s.encryptor.Encrypt(settings.SpeechAPIKey)
+			if err != nil {
+				return fmt.Errorf("encrypt speech api key: %w", err)
+			}
+			settings.SpeechAPIKey = encrypted
+		}
 	}
 	return s.uc.UpdateSettings(ctx, settings)
 }
@@ -87,3 +101,25 @@ func (s *AuthService) UpdateSettings(ctx context.Context, settings *biz.UserSett
 func (s *AuthService) GetSettings(ctx context.Context, userID int64) (*biz.UserSettings, error) {
 	return s.uc.GetSettings(ctx, userID)
 }
+
+// ListLLMProviders 列出用户可用的 LLM provider
+func (s *AuthService) ListLLMProviders(ctx context.Context, userID int64) ([]*biz.ProviderInfo, error) {
+	return s.uc.ListLLMProviders(ctx, userID)
+}
+
+// SaveCustomProvider 注册或更新用户的 LLM provider，API Key 加密后保存
+func (s *AuthService) SaveCustomProvider(ctx context.Context, cp *biz.CustomProvider) error {
+	if s.encryptor != nil && cp.APIKey != "" {
+		encrypted, err := s.encryptor.Encrypt(cp.APIKey)
+		if err != nil {
+			return fmt.Errorf("encrypt llm provider api key: %w", err)
+		}
+		cp.APIKey = encrypted
+	}
+	return s.uc.SaveCustomProvider(ctx, cp)
+}
+
+// DeleteCustomProvider 删除用户注册的 LLM provider
+func (s *AuthService) DeleteCustomProvider(ctx context.Context, userID int64, name string) error {
+	return s.uc.DeleteCustomProvider(ctx, userID, name)
+}
@@ -2,20 +2,30 @@ package service
 
 import (
 	"ai-interview/internal/biz"
+	"ai-interview/internal/export"
 	"ai-interview/internal/middleware"
+	"ai-interview/internal/provider/tts"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 )
 
+// ErrDecryptAPIKey 已保存的 API Key 无法解密 (密钥已轮换移除或密文损坏)，需用户重新填写
+var ErrDecryptAPIKey = errors.New("stored api key cannot be decrypted, please re-enter it in settings")
+
 // InterviewService 面试服务
 type InterviewService struct {
 	interviewUC *biz.InterviewUsecase
 	userUC      *biz.UserUsecase
+	codingUC    *biz.CodingUsecase
+	audioUC     *biz.AudioUsecase
 	encryptor   *middleware.Encryptor
 }
 
 // NewInterviewService 创建面试服务
-func NewInterviewService(interviewUC *biz.InterviewUsecase, userUC *biz.UserUsecase, encryptor *middleware.Encryptor) *InterviewService {
-	return &InterviewService{interviewUC: interviewUC, userUC: userUC, encryptor: encryptor}
+func NewInterviewService(interviewUC *biz.InterviewUsecase, userUC *biz.UserUsecase, codingUC *biz.CodingUsecase, audioUC *biz.AudioUsecase, encryptor *middleware.Encryptor) *InterviewService {
+	return &InterviewService{interviewUC: interviewUC, userUC: userUC, codingUC: codingUC, audioUC: audioUC, encryptor: encryptor}
 }
 
 // CreateInterview 创建面试会话
@@ -33,17 +43,21 @@ func (s *InterviewService) ListInterviews(ctx context.Context, userID int64, pag
 	return s.interviewUC.ListInterviews(ctx, userID, page, pageSize)
 }
 
-// SendMessage 发送消息
-func (s *InterviewService) SendMessage(ctx context.Context, interviewID int64, content string, userID int64) (*biz.InterviewMessage, string, error) {
-	settings, _ := s.userUC.GetSettings(ctx, userID)
-	s.decryptSettings(settings)
-	return s.interviewUC.SendMessage(ctx, interviewID, content, settings)
+// SendMessage 发送消息 (可附带图片)，返回用户消息与面试官的回复；面试官结束面试时另返回评估
+func (s *InterviewService) SendMessage(ctx context.Context, interviewID int64, content string, images []*biz.Attachment, userID int64) (*biz.InterviewMessage, *biz.InterviewMessage, *biz.Evaluation, error) {
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return s.interviewUC.SendMessage(ctx, interviewID, content, images, settings)
 }
 
 // EndInterview 结束面试
 func (s *InterviewService) EndInterview(ctx context.Context, id int64, userID int64) (*biz.Evaluation, error) {
-	settings, _ := s.userUC.GetSettings(ctx, userID)
-	s.decryptSettings(settings)
+	settings, err := s.GetUserSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
 	return s.interviewUC.EndInterview(ctx, id, settings)
 }
 
@@ -52,34 +66,191 @@ func (s *InterviewService) GetEvaluation(ctx context.Context, interviewID int64)
 	return s.interviewUC.GetEvaluation(ctx, interviewID)
 }
 
-// GetUserSettings 获取用户设置 (供 WebSocket handler 使用)
-func (s *InterviewService) GetUserSettings(ctx context.Context, userID int64) (*biz.UserSettings, error) {
-	settings, err := s.userUC.GetSettings(ctx, userID)
+// ListProblems 列出编程题库，编程模式未启用时为空
+func (s *InterviewService) ListProblems() []*biz.Problem {
+	if !s.codingUC.Enabled() {
+		return nil
+	}
+	return s.codingUC.ListProblems()
+}
+
+// ListPersonas 列出面试官人设及默认人设 ID
+func (s *InterviewService) ListPersonas() ([]*biz.Persona, string) {
+	return s.interviewUC.ListPersonas(), s.interviewUC.DefaultPersona()
+}
+
+// ListTTSVoices 列出 TTS provider 的可用音色
+func (s *InterviewService) ListTTSVoices(ctx context.Context, userID int64, provider string) ([]*tts.Voice, error) {
+	settings, err := s.GetUserSettings(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	s.decryptSettings(settings)
-	return settings, nil
+	return s.interviewUC.ListTTSVoices(ctx, provider, settings)
 }
 
-// decryptSettings 解密 settings 中的 API Keys
-func (s *InterviewService) decryptSettings(settings *biz.UserSettings) {
-	if s.encryptor == nil || settings == nil {
-		return
+// GetProblem 获取编程题
+func (s *InterviewService) GetProblem(id string) (*biz.Problem, error) {
+	return s.codingUC.GetProblem(id)
+}
+
+// SubmitCode 运行候选人提交的代码，只能提交到自己的面试
+func (s *InterviewService) SubmitCode(ctx context.Context, userID, interviewID int64, language, code string) (*biz.CodeSubmission, error) {
+	if err := s.checkOwner(ctx, userID, interviewID); err != nil {
+		return nil, err
 	}
-	if settings.LLMAPIKey != "" {
-		if decrypted, err := s.encryptor.Decrypt(settings.LLMAPIKey); err == nil {
-			settings.LLMAPIKey = decrypted
+	return s.codingUC.Submit(ctx, interviewID, language, code)
+}
+
+// ListSubmissions 列出面试的代码提交，只能查看自己面试的提交
+func (s *InterviewService) ListSubmissions(ctx context.Context, userID, interviewID int64) ([]*biz.CodeSubmission, error) {
+	if err := s.checkOwner(ctx, userID, interviewID); err != nil {
+		return nil, err
+	}
+	return s.codingUC.ListSubmissions(ctx, interviewID)
+}
+
+// ExportInterview 导出面试记录与评估报告，只能导出自己的面试
+func (s *InterviewService) ExportInterview(ctx context.Context, userID, interviewID int64, format export.Format) (*export.File, error) {
+	interview, messages, err := s.interviewUC.GetInterview(ctx, interviewID)
+	if err != nil {
+		return nil, err
+	}
+	if interview.UserID != userID {
+		return nil, biz.ErrInterviewNotFound
+	}
+
+	report := &export.Report{Interview: interview, Messages: messages}
+	report.Evaluation, err = s.interviewUC.GetEvaluation(ctx, interviewID)
+	if err != nil && !errors.Is(err, biz.ErrEvaluationNotFound) {
+		return nil, fmt.Errorf("get evaluation: %w", err)
+	}
+	if interview.Mode == biz.InterviewModeCoding {
+		if report.Submissions, err = s.codingUC.ListSubmissions(ctx, interviewID); err != nil {
+			return nil, fmt.Errorf("list submissions: %w", err)
 		}
 	}
-	if settings.TTSAPIKey != "" {
-		if decrypted, err := s.encryptor.Decrypt(settings.TTSAPIKey); err == nil {
-			settings.TTSAPIKey = decrypted
+
+	return export.Render(format, report)
+}
+
+// ListRecordings 列出面试录音，只能查看自己的面试
+func (s *InterviewService) ListRecordings(ctx context.Context, userID, interviewID int64) ([]*biz.AudioRecording, error) {
+	if err := s.checkOwner(ctx, userID, interviewID); err != nil {
+		return nil, err
+	}
+	return s.audioUC.ListRecordings(ctx, interviewID)
+}
+
+// OpenRecording 打开录音内容用于播放 / 下载，只能访问自己面试的录音
+func (s *InterviewService) OpenRecording(ctx context.Context, userID, recordingID int64) (*biz.AudioRecording, io.ReadCloser, int64, error) {
+	rec, err := s.audioUC.GetRecording(ctx, recordingID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if err := s.checkOwner(ctx, userID, rec.InterviewID); err != nil {
+		if errors.Is(err, biz.ErrInterviewNotFound) {
+			return nil, nil, 0, biz.ErrRecordingNotFound
 		}
+		return nil, nil, 0, err
 	}
-	if settings.STTAPIKey != "" {
-		if decrypted, err := s.encryptor.Decrypt(settings.STTAPIKey); err == nil {
-			settings.STTAPIKey = decrypted
+	rc, size, err := s.audioUC.OpenRecording(ctx, rec)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return rec, rc, size, nil
+}
+
+// ListAttachments 列出面试中候选人附带的图片 (不含内容)，只能查看自己的面试
+func (s *InterviewService) ListAttachments(ctx context.Context, userID, interviewID int64) ([]*biz.Attachment, error) {
+	if err := s.checkOwner(ctx, userID, interviewID); err != nil {
+		return nil, err
+	}
+	return s.interviewUC.ListAttachments(ctx, interviewID)
+}
+
+// GetAttachment 获取图片内容，只能访问自己面试的图片
+func (s *InterviewService) GetAttachment(ctx context.Context, userID, id int64) (*biz.Attachment, error) {
+	a, err := s.interviewUC.GetAttachment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkOwner(ctx, userID, a.InterviewID); err != nil {
+		if errors.Is(err, biz.ErrInterviewNotFound) {
+			return nil, biz.ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+// checkOwner 面试不存在或不属于该用户时返回 ErrInterviewNotFound
+func (s *InterviewService) checkOwner(ctx context.Context, userID, interviewID int64) error {
+	interview, _, err := s.interviewUC.GetInterview(ctx, interviewID)
+	if err != nil {
+		return err
+	}
+	if interview.UserID != userID {
+		return biz.ErrInterviewNotFound
+	}
+	return nil
+}
+
+// GetUserSettings 获取解密后的用户设置与用户注册的 LLM provider (也供 WebSocket handler 使用)；
+// 未保存过设置且没有注册 provider 时返回 nil，使用默认值
+func (s *InterviewService) GetUserSettings(ctx context.Context, userID int64) (*biz.UserSettings, error) {
+	settings, _ := s.userUC.GetSettings(ctx, userID)
+	custom, err := s.userUC.ListCustomProviders(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list custom providers: %w", err)
+	}
+	if len(custom) > 0 {
+		if settings == nil {
+			settings = &biz.UserSettings{UserID: userID}
+		}
+		settings.CustomProviders = custom
+	}
+	if err := s.decryptSettings(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// decryptSettings 解密 settings 中的 API Keys，任一失败即返回 ErrDecryptAPIKey，
+// 不会把密文当作 API Key 传给 provider
+func (s *InterviewService) decryptSettings(settings *biz.UserSettings) error {
+	if settings == nil {
+		return nil
+	}
+	type field struct {
+		name  string
+		value *string
+	}
+	fields := []field{
+		{"llm_api_key", &settings.LLMAPIKey},
+		{"tts_api_key", &settings.TTSAPIKey},
+		{"stt_api_key", &settings.STTAPIKey},
+		{"azure_api_key", &settings.AzureAPIKey},
+		{"speech_api_key", &settings.SpeechAPIKey},
+	}
+	for _, cp := range settings.CustomProviders {
+		fields = append(fields, field{"llm provider " + cp.Name, &cp.APIKey})
+	}
+	for _, f := range fields {
+		if *f.value == "" {
+			continue
+		}
+		if s.encryptor == nil {
+			// 未配置加密时按明文存储；若已是密文说明密钥配置丢失
+			if _, err := middleware.KeyID(*f.value); err == nil {
+				return fmt.Errorf("%w (%s): encryption key not configured", ErrDecryptAPIKey, f.name)
+			}
+			continue
+		}
+		decrypted, err := s.encryptor.Decrypt(*f.value)
+		if err != nil {
+			return fmt.Errorf("%w (%s): %w", ErrDecryptAPIKey, f.name, err)
 		}
+		*f.value = decrypted
 	}
+	return nil
 }
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ai-interview/internal/middleware"
+)
+
+// ReencryptOptions keys reencrypt 选项
+type ReencryptOptions struct {
+	LegacyKey        string // 旧版无 keyID 密文使用的 hex 密钥
+	EncryptPlaintext bool   // 把非密文的值视为明文加密 (ENCRYPTION_KEY 未配置期间保存的数据)
+	DryRun           bool   // 只统计不写入
+}
+
+// ReencryptFailure 单个字段的重加密失败
+type ReencryptFailure struct {
+	UserID int64
+	Field  string
+	Err    error
+}
+
+// ReencryptResult keys reencrypt 统计结果
+type ReencryptResult struct {
+	Scanned   int // 扫描的行数 (用户设置与用户注册的 provider)
+	Rewritten int // 已用 primary 密钥重写的行数
+	Conflicts int // 重写期间被用户修改、已跳过的行数
+	Failures  []ReencryptFailure
+}
+
+const reencryptBatchSize = 100
+
+// ReencryptAPIKeys 把所有已保存的 API Key (用户设置与用户注册的 LLM provider) 重新加密到当前 primary 密钥，用于密钥轮换。
+// 已使用 primary 密钥的值保持不变；无法解密的值记录在 Failures 中，不会被修改。
+func (s *AuthService) ReencryptAPIKeys(ctx context.Context, opts ReencryptOptions) (*ReencryptResult, error) {
+	if s.encryptor == nil {
+		return nil, errors.New("encryption key not configured")
+	}
+
+	result := &ReencryptResult{}
+	if err := s.reencryptSettings(ctx, opts, result); err != nil {
+		return result, err
+	}
+	if err := s.reencryptCustomProviders(ctx, opts, result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// reencryptSettings 重新加密用户设置中的 API Key
+func (s *AuthService) reencryptSettings(ctx context.Context, opts ReencryptOptions, result *ReencryptResult) error {
+	var after int64
+	for {
+		batch, err := s.uc.ListSettings(ctx, after, reencryptBatchSize)
+		if err != nil {
+			return fmt.Errorf("list settings: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		for _, old := range batch {
+			after = old.UserID
+			result.Scanned++
+
+			updated := *old
+			changed := false
+			for _, f := range []struct {
+				name  string
+				value *string
+			}{
+				{"llm_api_key", &updated.LLMAPIKey},
+				{"tts_api_key", &updated.TTSAPIKey},
+				{"stt_api_key", &updated.STTAPIKey},
+				{"azure_api_key", &updated.AzureAPIKey},
+				{"speech_api_key", &updated.SpeechAPIKey},
+			} {
+				rewritten, err := s.reencryptValue(*f.value, opts)
+				if err != nil {
+					result.Failures = append(result.Failures, ReencryptFailure{UserID: old.UserID, Field: f.name, Err: err})
+					continue
+				}
+				if rewritten != *f.value {
+					*f.value = rewritten
+					changed = true
+				}
+			}
+			if !changed || opts.DryRun {
+				if changed {
+					result.Rewritten++
+				}
+				continue
+			}
+
+			ok, err := s.uc.ReplaceAPIKeys(ctx, old, &updated)
+			if err != nil {
+				return fmt.Errorf("update settings of user %d: %w", old.UserID, err)
+			}
+			if ok {
+				result.Rewritten++
+			} else {
+				result.Conflicts++
+			}
+		}
+	}
+}
+
+// reencryptCustomProviders 重新加密用户注册的 LLM provider 的 API Key
+func (s *AuthService) reencryptCustomProviders(ctx context.Context, opts ReencryptOptions, result *ReencryptResult) error {
+	var after int64
+	for {
+		batch, err := s.uc.ListAllCustomProviders(ctx, after, reencryptBatchSize)
+		if err != nil {
+			return fmt.Errorf("list custom providers: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		for _, cp := range batch {
+			after = cp.ID
+			result.Scanned++
+
+			rewritten, err := s.reencryptValue(cp.APIKey, opts)
+			if err != nil {
+				result.Failures = append(result.Failures, ReencryptFailure{UserID: cp.UserID, Field: "llm provider " + cp.Name, Err: err})
+				continue
+			}
+			if rewritten == cp.APIKey {
+				continue
+			}
+			if opts.DryRun {
+				result.Rewritten++
+				continue
+			}
+
+			ok, err := s.uc.ReplaceCustomProviderKey(ctx, cp.ID, cp.APIKey, rewritten)
+			if err != nil {
+				return fmt.Errorf("update llm provider %s of user %d: %w", cp.Name, cp.UserID, err)
+			}
+			if ok {
+				result.Rewritten++
+			} else {
+				result.Conflicts++
+			}
+		}
+	}
+}
+
+// reencryptValue 返回使用 primary 密钥加密的值，无需重写时原样返回
+func (s *AuthService) reencryptValue(value string, opts ReencryptOptions) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+
+	var plaintext string
+	keyID, err := middleware.KeyID(value)
+	switch {
+	case err == nil:
+		if keyID == s.encryptor.PrimaryKeyID() {
+			return value, nil
+		}
+		if plaintext, err = s.encryptor.Decrypt(value); err != nil {
+			return "", err
+		}
+	case opts.LegacyKey != "":
+		plaintext, err = middleware.DecryptLegacy(opts.LegacyKey, value)
+		// 不是 hex 密文的值才按明文处理，避免把用错密钥的旧密文当作明文再加密
+		if errors.Is(err, middleware.ErrMalformedCiphertext) && opts.EncryptPlaintext {
+			plaintext, err = value, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("decrypt legacy ciphertext: %w", err)
+		}
+	case opts.EncryptPlaintext:
+		plaintext = value
+	default:
+		return "", err
+	}
+
+	return s.encryptor.Encrypt(plaintext)
+}
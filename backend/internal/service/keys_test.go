@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-interview/internal/biz"
+	"ai-interview/internal/conf"
+	"ai-interview/internal/data"
+	"ai-interview/internal/middleware"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	oldKey = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	newKey = "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+)
+
+func newEncryptor(t *testing.T, spec string) *middleware.Encryptor {
+	t.Helper()
+	set, err := middleware.NewEnvKeySource(spec).Load(context.Background())
+	if err != nil {
+		t.Fatalf("load keys: %v", err)
+	}
+	enc, err := middleware.NewEncryptor(set)
+	if err != nil {
+		t.Fatalf("NewEncryptor error: %v", err)
+	}
+	return enc
+}
+
+// legacyEncrypt 生成引入 keyID 之前的 hex 密文
+func legacyEncrypt(t *testing.T, hexKey, plaintext string) string {
+	t.Helper()
+	key, _ := hex.DecodeString(hexKey)
+	block, _ := aes.NewCipher(key)
+	aesGCM, _ := cipher.NewGCM(block)
+	nonce := make([]byte, aesGCM.NonceSize())
+	return hex.EncodeToString(aesGCM.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestSQLiteIntegration_ReencryptAPIKeys(t *testing.T) {
+	logger := log.NewStdLogger(io.Discard)
+	d, cleanup, err := data.NewData(&conf.Data{Database: &conf.Data_Database{
+		Driver:      "sqlite",
+		Source:      filepath.Join(t.TempDir(), "keys.db"),
+		AutoMigrate: true,
+	}}, logger)
+	if err != nil {
+		t.Fatalf("NewData error: %v", err)
+	}
+	t.Cleanup(cleanup)
+
+	ctx := context.Background()
+	repo := data.NewUserRepo(d, logger)
+	uc := biz.NewUserUsecase(repo, nil, nil, logger)
+
+	oldEnc := newEncryptor(t, "k1:"+oldKey)
+	v1Cipher, _ := oldEnc.Encrypt("sk-v1")
+	seed := []*biz.UserSettings{
+		{LLMAPIKey: v1Cipher},                                // 旧 keyID 密文
+		{LLMAPIKey: legacyEncrypt(t, oldKey, "sk-legacy")},   // 无 keyID 旧格式
+		{TTSAPIKey: "sk-plain"},                              // 未配置加密时的明文
+		{LLMAPIKey: "v1:gone:00:00", STTAPIKey: "sk-plain2"}, // 未知 keyID 的字段保持不变
+	}
+	for i, s := range seed {
+		u, err := repo.Create(ctx, &biz.User{Email: strings.Repeat("u", i+1) + "@example.com", PasswordHash: "x"})
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		s.UserID = u.ID
+		if err := repo.UpdateSettings(ctx, s); err != nil {
+			t.Fatalf("UpdateSettings error: %v", err)
+		}
+	}
+
+	vllmCipher, _ := oldEnc.Encrypt("sk-vllm")
+	if err := repo.SaveCustomProvider(ctx, &biz.CustomProvider{UserID: seed[0].UserID, Name: "my-vllm", BaseURL: "http://localhost:8000/v1", APIKey: vllmCipher}); err != nil {
+		t.Fatalf("SaveCustomProvider error: %v", err)
+	}
+
+	rotated := newEncryptor(t, "k2:"+newKey+",k1:"+oldKey)
+	svc := NewAuthService(uc, nil, rotated)
+	opts := ReencryptOptions{LegacyKey: oldKey, EncryptPlaintext: true}
+
+	dry, err := svc.ReencryptAPIKeys(ctx, ReencryptOptions{LegacyKey: oldKey, EncryptPlaintext: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run error: %v", err)
+	}
+	if dry.Scanned != 5 || dry.Rewritten != 5 || len(dry.Failures) != 1 {
+		t.Errorf("unexpected dry run result: %+v", dry)
+	}
+	if s, _ := repo.GetSettings(ctx, seed[0].UserID); s.LLMAPIKey != v1Cipher {
+		t.Error("dry run should not modify settings")
+	}
+
+	result, err := svc.ReencryptAPIKeys(ctx, opts)
+	if err != nil {
+		t.Fatalf("ReencryptAPIKeys error: %v", err)
+	}
+	if result.Rewritten != 5 || len(result.Failures) != 1 || result.Failures[0].Field != "llm_api_key" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// 只保留新密钥也能解密全部重写后的值
+	interviewSvc := NewInterviewService(nil, uc, nil, nil, newEncryptor(t, "k2:"+newKey))
+	for i, want := range []string{"sk-v1", "sk-legacy", "sk-plain"} {
+		s, err := interviewSvc.GetUserSettings(ctx, seed[i].UserID)
+		if err != nil {
+			t.Fatalf("user %d: GetUserSettings error: %v", seed[i].UserID, err)
+		}
+		if got := s.LLMAPIKey + s.TTSAPIKey; got != want {
+			t.Errorf("user %d: api key = %q, want %q", seed[i].UserID, got, want)
+		}
+	}
+
+	if s, _ := interviewSvc.GetUserSettings(ctx, seed[0].UserID); len(s.CustomProviders) != 1 || s.CustomProviders[0].APIKey != "sk-vllm" {
+		t.Errorf("custom provider key should be re-encrypted and decrypted: %+v", s.CustomProviders)
+	}
+
+	// 无法解密的值返回明确错误，而不是把密文当作 API Key
+	if _, err := interviewSvc.GetUserSettings(ctx, seed[3].UserID); err == nil {
+		t.Error("expected ErrDecryptAPIKey for unknown key id")
+	}
+
+	// 再次执行为幂等操作
+	again, err := svc.ReencryptAPIKeys(ctx, opts)
+	if err != nil {
+		t.Fatalf("second run error: %v", err)
+	}
+	if again.Rewritten != 0 || len(again.Failures) != 1 {
+		t.Errorf("second run should rewrite nothing, got %+v", again)
+	}
+}
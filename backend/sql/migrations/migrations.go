@@ -0,0 +1,10 @@
+// Package migrations 内嵌数据库迁移文件，供 data.Migrator 使用。
+// 每种数据库方言一个子目录 (mysql/, sqlite/)，版本号保持一致。
+package migrations
+
+import "embed"
+
+// FS 包含全部方言的 NNNNNN_name.up.sql / NNNNNN_name.down.sql 迁移文件
+//
+//go:embed mysql/*.sql sqlite/*.sql
+var FS embed.FS
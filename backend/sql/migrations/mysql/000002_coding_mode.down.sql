@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS code_submissions;
+
+ALTER TABLE interviews
+    DROP COLUMN problem_id,
+    DROP COLUMN mode;
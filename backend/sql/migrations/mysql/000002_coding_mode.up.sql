@@ -0,0 +1,19 @@
+ALTER TABLE interviews
+    ADD COLUMN mode VARCHAR(16) NOT NULL DEFAULT 'chat' AFTER resume,
+    ADD COLUMN problem_id VARCHAR(64) NOT NULL DEFAULT '' AFTER mode;
+
+CREATE TABLE IF NOT EXISTS code_submissions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    interview_id BIGINT NOT NULL,
+    problem_id VARCHAR(64) NOT NULL,
+    language VARCHAR(16) NOT NULL,
+    code MEDIUMTEXT NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    passed INT NOT NULL DEFAULT 0,
+    total INT NOT NULL DEFAULT 0,
+    compile_output TEXT NOT NULL,
+    results JSON,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_interview_id (interview_id),
+    CONSTRAINT fk_code_submissions_interview FOREIGN KEY (interview_id) REFERENCES interviews(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
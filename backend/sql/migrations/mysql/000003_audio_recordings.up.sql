@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS audio_recordings (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    interview_id BIGINT NOT NULL,
+    message_id BIGINT NOT NULL,
+    role ENUM('user', 'assistant') NOT NULL,
+    seq INT NOT NULL DEFAULT 0,
+    storage_key VARCHAR(512) NOT NULL,
+    format VARCHAR(16) NOT NULL,
+    duration_ms INT NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_interview_id (interview_id),
+    INDEX idx_created_at (created_at),
+    CONSTRAINT fk_audio_recordings_interview FOREIGN KEY (interview_id) REFERENCES interviews(id) ON DELETE CASCADE,
+    CONSTRAINT fk_audio_recordings_message FOREIGN KEY (message_id) REFERENCES interview_messages(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,3 @@
+ALTER TABLE user_settings
+    DROP COLUMN tts_fallbacks,
+    DROP COLUMN llm_fallbacks;
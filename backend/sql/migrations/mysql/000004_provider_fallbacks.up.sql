@@ -0,0 +1,3 @@
+ALTER TABLE user_settings
+    ADD COLUMN llm_fallbacks VARCHAR(255) NOT NULL DEFAULT '' AFTER llm_model,
+    ADD COLUMN tts_fallbacks VARCHAR(255) NOT NULL DEFAULT '' AFTER tts_enabled;
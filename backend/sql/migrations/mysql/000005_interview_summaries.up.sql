@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS interview_summaries (
+    interview_id BIGINT PRIMARY KEY,
+    content TEXT NOT NULL,
+    questions JSON,
+    through_message_id BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_interview_summaries_interview FOREIGN KEY (interview_id) REFERENCES interviews(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
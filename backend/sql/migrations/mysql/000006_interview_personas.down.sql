@@ -0,0 +1 @@
+ALTER TABLE interviews DROP COLUMN persona;
@@ -0,0 +1 @@
+ALTER TABLE interviews ADD COLUMN persona VARCHAR(64) NOT NULL DEFAULT '' AFTER problem_id;
@@ -0,0 +1,2 @@
+ALTER TABLE interviews ADD COLUMN panel JSON AFTER persona;
+ALTER TABLE interview_messages ADD COLUMN speaker VARCHAR(64) NOT NULL DEFAULT '' AFTER role;
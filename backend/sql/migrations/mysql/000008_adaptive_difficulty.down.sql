@@ -0,0 +1,3 @@
+ALTER TABLE evaluations DROP COLUMN level;
+ALTER TABLE interview_messages DROP COLUMN score;
+ALTER TABLE interview_messages DROP COLUMN difficulty;
@@ -0,0 +1,3 @@
+ALTER TABLE interview_messages ADD COLUMN difficulty TINYINT NOT NULL DEFAULT 0 AFTER content;
+ALTER TABLE interview_messages ADD COLUMN score TINYINT NULL AFTER difficulty;
+ALTER TABLE evaluations ADD COLUMN level DOUBLE NOT NULL DEFAULT 0 AFTER suggestions;
@@ -0,0 +1,2 @@
+ALTER TABLE interview_messages ADD COLUMN reasoning MEDIUMTEXT NULL AFTER score;
+ALTER TABLE evaluations ADD COLUMN reasoning MEDIUMTEXT NULL AFTER level;
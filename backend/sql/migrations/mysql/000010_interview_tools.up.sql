@@ -0,0 +1 @@
+ALTER TABLE interview_messages ADD COLUMN question TEXT NULL AFTER reasoning;
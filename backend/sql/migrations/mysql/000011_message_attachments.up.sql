@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS message_attachments (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    interview_id BIGINT NOT NULL,
+    message_id BIGINT NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    data MEDIUMBLOB NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_interview_id (interview_id),
+    CONSTRAINT fk_message_attachments_interview FOREIGN KEY (interview_id) REFERENCES interviews(id) ON DELETE CASCADE,
+    CONSTRAINT fk_message_attachments_message FOREIGN KEY (message_id) REFERENCES interview_messages(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
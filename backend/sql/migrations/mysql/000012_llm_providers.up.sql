@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS llm_providers (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    base_url VARCHAR(500) NOT NULL,
+    auth VARCHAR(20) NOT NULL DEFAULT 'bearer',
+    auth_header VARCHAR(100) NOT NULL DEFAULT '',
+    api_key TEXT NOT NULL,
+    default_model VARCHAR(100) NOT NULL DEFAULT '',
+    headers TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_llm_providers_user_name (user_id, name),
+    CONSTRAINT fk_llm_providers_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
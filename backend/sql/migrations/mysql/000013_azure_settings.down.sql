@@ -0,0 +1,7 @@
+ALTER TABLE user_settings
+    DROP COLUMN azure_stt_deployment,
+    DROP COLUMN azure_tts_deployment,
+    DROP COLUMN azure_chat_deployment,
+    DROP COLUMN azure_api_version,
+    DROP COLUMN azure_api_key,
+    DROP COLUMN azure_endpoint;
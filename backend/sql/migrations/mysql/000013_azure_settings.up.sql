@@ -0,0 +1,7 @@
+ALTER TABLE user_settings
+    ADD COLUMN azure_endpoint VARCHAR(500) NOT NULL DEFAULT '' AFTER stt_api_key,
+    ADD COLUMN azure_api_key TEXT NOT NULL AFTER azure_endpoint,
+    ADD COLUMN azure_api_version VARCHAR(50) NOT NULL DEFAULT '' AFTER azure_api_key,
+    ADD COLUMN azure_chat_deployment VARCHAR(100) NOT NULL DEFAULT '' AFTER azure_api_version,
+    ADD COLUMN azure_tts_deployment VARCHAR(100) NOT NULL DEFAULT '' AFTER azure_chat_deployment,
+    ADD COLUMN azure_stt_deployment VARCHAR(100) NOT NULL DEFAULT '' AFTER azure_tts_deployment;
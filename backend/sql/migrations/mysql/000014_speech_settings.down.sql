@@ -0,0 +1,3 @@
+ALTER TABLE user_settings
+    DROP COLUMN speech_api_key,
+    DROP COLUMN speech_region;
@@ -0,0 +1,3 @@
+ALTER TABLE user_settings
+    ADD COLUMN speech_region VARCHAR(50) NOT NULL DEFAULT '' AFTER azure_stt_deployment,
+    ADD COLUMN speech_api_key TEXT NOT NULL AFTER speech_region;
@@ -0,0 +1 @@
+ALTER TABLE user_settings ADD COLUMN tts_speed DOUBLE NOT NULL DEFAULT 1 AFTER tts_voice;
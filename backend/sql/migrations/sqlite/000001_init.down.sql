@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS evaluations;
+DROP TABLE IF EXISTS interview_messages;
+DROP TABLE IF EXISTS interviews;
+DROP TABLE IF EXISTS user_settings;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,89 @@
+-- SQLite 版本: ENUM 改为 TEXT + CHECK，JSON 改为 TEXT，
+-- ON UPDATE CURRENT_TIMESTAMP 改为 AFTER UPDATE 触发器。
+
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL UNIQUE,
+    nickname TEXT NOT NULL DEFAULT '',
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_users_updated_at
+AFTER UPDATE ON users FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE users SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.id;
+END;
+
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id INTEGER PRIMARY KEY,
+    llm_provider TEXT NOT NULL DEFAULT '',
+    llm_api_key TEXT NOT NULL,
+    llm_base_url TEXT NOT NULL DEFAULT '',
+    llm_model TEXT NOT NULL DEFAULT '',
+    tts_provider TEXT NOT NULL DEFAULT '',
+    tts_api_key TEXT NOT NULL,
+    tts_voice TEXT NOT NULL DEFAULT '',
+    tts_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    stt_provider TEXT NOT NULL DEFAULT 'browser',
+    stt_api_key TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_settings_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_user_settings_updated_at
+AFTER UPDATE ON user_settings FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE user_settings SET updated_at = CURRENT_TIMESTAMP WHERE user_id = OLD.user_id;
+END;
+
+CREATE TABLE IF NOT EXISTS interviews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    position TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'in_progress', 'completed')),
+    language TEXT NOT NULL DEFAULT 'zh-CN',
+    llm_provider TEXT NOT NULL DEFAULT '',
+    llm_model TEXT NOT NULL DEFAULT '',
+    tts_provider TEXT NOT NULL DEFAULT '',
+    tts_voice TEXT NOT NULL DEFAULT '',
+    resume TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_interviews_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_interviews_user_id ON interviews (user_id);
+CREATE INDEX IF NOT EXISTS idx_interviews_status ON interviews (status);
+
+CREATE TRIGGER IF NOT EXISTS trg_interviews_updated_at
+AFTER UPDATE ON interviews FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE interviews SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.id;
+END;
+
+CREATE TABLE IF NOT EXISTS interview_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    interview_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('system', 'user', 'assistant')),
+    content TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_messages_interview FOREIGN KEY (interview_id) REFERENCES interviews(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_interview_messages_interview_id ON interview_messages (interview_id);
+
+CREATE TABLE IF NOT EXISTS evaluations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    interview_id INTEGER NOT NULL UNIQUE,
+    overall_score INTEGER NOT NULL DEFAULT 0,
+    summary TEXT NOT NULL,
+    categories TEXT,
+    strengths TEXT NOT NULL,
+    weaknesses TEXT NOT NULL,
+    suggestions TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_evaluations_interview FOREIGN KEY (interview_id) REFERENCES interviews(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS code_submissions;
+
+ALTER TABLE interviews DROP COLUMN problem_id;
+ALTER TABLE interviews DROP COLUMN mode;
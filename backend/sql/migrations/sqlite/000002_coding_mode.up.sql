@@ -0,0 +1,19 @@
+ALTER TABLE interviews ADD COLUMN mode TEXT NOT NULL DEFAULT 'chat';
+ALTER TABLE interviews ADD COLUMN problem_id TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS code_submissions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    interview_id INTEGER NOT NULL,
+    problem_id TEXT NOT NULL,
+    language TEXT NOT NULL,
+    code TEXT NOT NULL,
+    status TEXT NOT NULL,
+    passed INTEGER NOT NULL DEFAULT 0,
+    total INTEGER NOT NULL DEFAULT 0,
+    compile_output TEXT NOT NULL,
+    results TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_code_submissions_interview FOREIGN KEY (interview_id) REFERENCES interviews(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_code_submissions_interview_id ON code_submissions (interview_id);
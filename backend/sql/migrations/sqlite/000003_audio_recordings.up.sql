@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS audio_recordings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    interview_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('user', 'assistant')),
+    seq INTEGER NOT NULL DEFAULT 0,
+    storage_key TEXT NOT NULL,
+    format TEXT NOT NULL,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_audio_recordings_interview FOREIGN KEY (interview_id) REFERENCES interviews(id) ON DELETE CASCADE,
+    CONSTRAINT fk_audio_recordings_message FOREIGN KEY (message_id) REFERENCES interview_messages(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_audio_recordings_interview_id ON audio_recordings (interview_id);
+CREATE INDEX IF NOT EXISTS idx_audio_recordings_created_at ON audio_recordings (created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE user_settings DROP COLUMN tts_fallbacks;
+ALTER TABLE user_settings DROP COLUMN llm_fallbacks;
@@ -0,0 +1,2 @@
+ALTER TABLE user_settings ADD COLUMN llm_fallbacks TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_settings ADD COLUMN tts_fallbacks TEXT NOT NULL DEFAULT '';
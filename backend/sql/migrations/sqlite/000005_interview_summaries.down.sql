@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS trg_interview_summaries_updated_at;
+DROP TABLE IF EXISTS interview_summaries;
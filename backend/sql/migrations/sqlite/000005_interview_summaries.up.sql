@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS interview_summaries (
+    interview_id INTEGER PRIMARY KEY,
+    content TEXT NOT NULL,
+    questions TEXT,
+    through_message_id INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_interview_summaries_interview FOREIGN KEY (interview_id) REFERENCES interviews(id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_interview_summaries_updated_at
+AFTER UPDATE ON interview_summaries FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE interview_summaries SET updated_at = CURRENT_TIMESTAMP WHERE interview_id = OLD.interview_id;
+END;
@@ -0,0 +1 @@
+ALTER TABLE interviews ADD COLUMN persona TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE interview_messages DROP COLUMN speaker;
+ALTER TABLE interviews DROP COLUMN panel;
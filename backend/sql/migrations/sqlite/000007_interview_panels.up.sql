@@ -0,0 +1,2 @@
+ALTER TABLE interviews ADD COLUMN panel TEXT;
+ALTER TABLE interview_messages ADD COLUMN speaker TEXT NOT NULL DEFAULT '';
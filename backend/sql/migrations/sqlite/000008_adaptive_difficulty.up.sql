@@ -0,0 +1,3 @@
+ALTER TABLE interview_messages ADD COLUMN difficulty INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE interview_messages ADD COLUMN score INTEGER;
+ALTER TABLE evaluations ADD COLUMN level REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE evaluations DROP COLUMN reasoning;
+ALTER TABLE interview_messages DROP COLUMN reasoning;
@@ -0,0 +1,2 @@
+ALTER TABLE interview_messages ADD COLUMN reasoning TEXT;
+ALTER TABLE evaluations ADD COLUMN reasoning TEXT;
@@ -0,0 +1 @@
+ALTER TABLE interview_messages DROP COLUMN question;
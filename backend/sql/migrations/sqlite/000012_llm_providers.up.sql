@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS llm_providers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    base_url TEXT NOT NULL,
+    auth TEXT NOT NULL DEFAULT 'bearer',
+    auth_header TEXT NOT NULL DEFAULT '',
+    api_key TEXT NOT NULL,
+    default_model TEXT NOT NULL DEFAULT '',
+    headers TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_llm_providers_user_name UNIQUE (user_id, name),
+    CONSTRAINT fk_llm_providers_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_llm_providers_updated_at
+AFTER UPDATE ON llm_providers FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE llm_providers SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.id;
+END;
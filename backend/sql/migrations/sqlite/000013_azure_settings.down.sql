@@ -0,0 +1,6 @@
+ALTER TABLE user_settings DROP COLUMN azure_stt_deployment;
+ALTER TABLE user_settings DROP COLUMN azure_tts_deployment;
+ALTER TABLE user_settings DROP COLUMN azure_chat_deployment;
+ALTER TABLE user_settings DROP COLUMN azure_api_version;
+ALTER TABLE user_settings DROP COLUMN azure_api_key;
+ALTER TABLE user_settings DROP COLUMN azure_endpoint;
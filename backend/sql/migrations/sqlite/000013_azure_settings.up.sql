@@ -0,0 +1,6 @@
+ALTER TABLE user_settings ADD COLUMN azure_endpoint TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_settings ADD COLUMN azure_api_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_settings ADD COLUMN azure_api_version TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_settings ADD COLUMN azure_chat_deployment TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_settings ADD COLUMN azure_tts_deployment TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_settings ADD COLUMN azure_stt_deployment TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE user_settings DROP COLUMN speech_api_key;
+ALTER TABLE user_settings DROP COLUMN speech_region;
@@ -0,0 +1,2 @@
+ALTER TABLE user_settings ADD COLUMN speech_region TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_settings ADD COLUMN speech_api_key TEXT NOT NULL DEFAULT '';
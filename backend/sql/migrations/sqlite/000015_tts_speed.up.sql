@@ -0,0 +1 @@
+ALTER TABLE user_settings ADD COLUMN tts_speed REAL NOT NULL DEFAULT 1;
@@ -0,0 +1,22 @@
+-- name: CreateAudioRecording :execlastid
+INSERT INTO audio_recordings (interview_id, message_id, role, seq, storage_key,
+    format, duration_ms, size_bytes)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetAudioRecordingByID :one
+SELECT id, interview_id, message_id, role, seq, storage_key,
+    format, duration_ms, size_bytes, created_at
+FROM audio_recordings WHERE id = ?;
+
+-- name: ListAudioRecordingsByInterviewID :many
+SELECT id, interview_id, message_id, role, seq, storage_key,
+    format, duration_ms, size_bytes, created_at
+FROM audio_recordings WHERE interview_id = ? ORDER BY message_id ASC, seq ASC;
+
+-- name: ListAudioRecordingsCreatedBefore :many
+SELECT id, interview_id, message_id, role, seq, storage_key,
+    format, duration_ms, size_bytes, created_at
+FROM audio_recordings WHERE created_at < ? ORDER BY id ASC LIMIT ?;
+
+-- name: DeleteAudioRecording :exec
+DELETE FROM audio_recordings WHERE id = ?;
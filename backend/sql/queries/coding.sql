@@ -0,0 +1,9 @@
+-- name: CreateCodeSubmission :execlastid
+INSERT INTO code_submissions (interview_id, problem_id, language, code, status,
+    passed, total, compile_output, results)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListCodeSubmissionsByInterviewID :many
+SELECT id, interview_id, problem_id, language, code, status,
+    passed, total, compile_output, results, created_at
+FROM code_submissions WHERE interview_id = ? ORDER BY id ASC;
@@ -1,15 +1,15 @@
 -- name: CreateInterview :execlastid
 INSERT INTO interviews (user_id, title, position, status, language,
-    llm_provider, llm_model, tts_provider, tts_voice, resume)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+    llm_provider, llm_model, tts_provider, tts_voice, resume, mode, problem_id, persona, panel)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: GetInterviewByID :one
 SELECT id, user_id, title, position, status, language,
-    llm_provider, llm_model, tts_provider, tts_voice, resume, created_at, updated_at
+    llm_provider, llm_model, tts_provider, tts_voice, resume, mode, problem_id, persona, panel, created_at, updated_at
 FROM interviews WHERE id = ?;
 
 -- name: ListInterviewsByUserID :many
-SELECT id, user_id, title, position, status, language, created_at, updated_at
+SELECT id, user_id, title, position, status, language, mode, created_at, updated_at
 FROM interviews WHERE user_id = ? ORDER BY created_at DESC LIMIT ? OFFSET ?;
 
 -- name: CountInterviewsByUserID :one
@@ -19,16 +19,43 @@ SELECT COUNT(*) FROM interviews WHERE user_id = ?;
 UPDATE interviews SET status = ? WHERE id = ?;
 
 -- name: CreateMessage :execlastid
-INSERT INTO interview_messages (interview_id, role, content) VALUES (?, ?, ?);
+INSERT INTO interview_messages (interview_id, role, speaker, content, difficulty, score, reasoning, question) VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: UpdateMessageScore :exec
+UPDATE interview_messages SET score = ? WHERE id = ?;
 
 -- name: ListMessagesByInterviewID :many
-SELECT id, interview_id, role, content, created_at
+SELECT id, interview_id, role, speaker, content, difficulty, score, reasoning, question, created_at
 FROM interview_messages WHERE interview_id = ? ORDER BY id ASC;
 
 -- name: CreateEvaluation :execlastid
-INSERT INTO evaluations (interview_id, overall_score, summary, categories, strengths, weaknesses, suggestions)
-VALUES (?, ?, ?, ?, ?, ?, ?);
+INSERT INTO evaluations (interview_id, overall_score, summary, categories, strengths, weaknesses, suggestions, level, reasoning)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: GetEvaluationByInterviewID :one
-SELECT id, interview_id, overall_score, summary, categories, strengths, weaknesses, suggestions, created_at
+SELECT id, interview_id, overall_score, summary, categories, strengths, weaknesses, suggestions, level, reasoning, created_at
 FROM evaluations WHERE interview_id = ?;
+
+-- name: UpsertInterviewSummary :exec
+INSERT INTO interview_summaries (interview_id, content, questions, through_message_id)
+VALUES (?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    content = VALUES(content),
+    questions = VALUES(questions),
+    through_message_id = VALUES(through_message_id);
+
+-- name: GetInterviewSummary :one
+SELECT interview_id, content, questions, through_message_id, updated_at
+FROM interview_summaries WHERE interview_id = ?;
+
+-- name: CreateAttachment :execlastid
+INSERT INTO message_attachments (interview_id, message_id, content_type, data, size_bytes)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetAttachmentByID :one
+SELECT id, interview_id, message_id, content_type, data, size_bytes, created_at
+FROM message_attachments WHERE id = ?;
+
+-- name: ListAttachmentsByInterviewID :many
+SELECT id, interview_id, message_id, content_type, size_bytes, created_at
+FROM message_attachments WHERE interview_id = ? ORDER BY id ASC;
@@ -10,22 +10,73 @@ SELECT id, email, nickname, password_hash, created_at, updated_at
 FROM users WHERE email = ?;
 
 -- name: UpsertUserSettings :exec
-INSERT INTO user_settings (user_id, llm_provider, llm_api_key, llm_base_url, llm_model,
-    tts_provider, tts_api_key, tts_voice, tts_enabled, stt_provider, stt_api_key)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+INSERT INTO user_settings (user_id, llm_provider, llm_api_key, llm_base_url, llm_model, llm_fallbacks,
+    tts_provider, tts_api_key, tts_voice, tts_speed, tts_enabled, tts_fallbacks, stt_provider, stt_api_key,
+    azure_endpoint, azure_api_key, azure_api_version, azure_chat_deployment, azure_tts_deployment, azure_stt_deployment,
+    speech_region, speech_api_key)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON DUPLICATE KEY UPDATE
     llm_provider = VALUES(llm_provider),
     llm_api_key = VALUES(llm_api_key),
     llm_base_url = VALUES(llm_base_url),
     llm_model = VALUES(llm_model),
+    llm_fallbacks = VALUES(llm_fallbacks),
     tts_provider = VALUES(tts_provider),
     tts_api_key = VALUES(tts_api_key),
     tts_voice = VALUES(tts_voice),
+    tts_speed = VALUES(tts_speed),
     tts_enabled = VALUES(tts_enabled),
+    tts_fallbacks = VALUES(tts_fallbacks),
     stt_provider = VALUES(stt_provider),
-    stt_api_key = VALUES(stt_api_key);
+    stt_api_key = VALUES(stt_api_key),
+    azure_endpoint = VALUES(azure_endpoint),
+    azure_api_key = VALUES(azure_api_key),
+    azure_api_version = VALUES(azure_api_version),
+    azure_chat_deployment = VALUES(azure_chat_deployment),
+    azure_tts_deployment = VALUES(azure_tts_deployment),
+    azure_stt_deployment = VALUES(azure_stt_deployment),
+    speech_region = VALUES(speech_region),
+    speech_api_key = VALUES(speech_api_key);
 
 -- name: GetUserSettings :one
-SELECT user_id, llm_provider, llm_api_key, llm_base_url, llm_model,
-    tts_provider, tts_api_key, tts_voice, tts_enabled, stt_provider, stt_api_key
+SELECT user_id, llm_provider, llm_api_key, llm_base_url, llm_model, llm_fallbacks,
+    tts_provider, tts_api_key, tts_voice, tts_speed, tts_enabled, tts_fallbacks, stt_provider, stt_api_key,
+    azure_endpoint, azure_api_key, azure_api_version, azure_chat_deployment, azure_tts_deployment, azure_stt_deployment,
+    speech_region, speech_api_key
 FROM user_settings WHERE user_id = ?;
+
+-- name: ListUserSettings :many
+SELECT user_id, llm_provider, llm_api_key, llm_base_url, llm_model, llm_fallbacks,
+    tts_provider, tts_api_key, tts_voice, tts_speed, tts_enabled, tts_fallbacks, stt_provider, stt_api_key,
+    azure_endpoint, azure_api_key, azure_api_version, azure_chat_deployment, azure_tts_deployment, azure_stt_deployment,
+    speech_region, speech_api_key
+FROM user_settings WHERE user_id > ? ORDER BY user_id LIMIT ?;
+
+-- name: ReplaceUserAPIKeys :execrows
+UPDATE user_settings SET llm_api_key = ?, tts_api_key = ?, stt_api_key = ?, azure_api_key = ?, speech_api_key = ?
+WHERE user_id = ? AND llm_api_key = ? AND tts_api_key = ? AND stt_api_key = ? AND azure_api_key = ? AND speech_api_key = ?;
+
+-- name: ListCustomProviders :many
+SELECT id, user_id, name, base_url, auth, auth_header, api_key, default_model, headers, created_at, updated_at
+FROM llm_providers WHERE user_id = ? ORDER BY name;
+
+-- name: UpsertCustomProvider :exec
+INSERT INTO llm_providers (user_id, name, base_url, auth, auth_header, api_key, default_model, headers)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    base_url = VALUES(base_url),
+    auth = VALUES(auth),
+    auth_header = VALUES(auth_header),
+    api_key = VALUES(api_key),
+    default_model = VALUES(default_model),
+    headers = VALUES(headers);
+
+-- name: DeleteCustomProvider :execrows
+DELETE FROM llm_providers WHERE user_id = ? AND name = ?;
+
+-- name: ListAllCustomProviders :many
+SELECT id, user_id, name, base_url, auth, auth_header, api_key, default_model, headers, created_at, updated_at
+FROM llm_providers WHERE id > ? ORDER BY id LIMIT ?;
+
+-- name: ReplaceCustomProviderKey :execrows
+UPDATE llm_providers SET api_key = ? WHERE id = ? AND api_key = ?;
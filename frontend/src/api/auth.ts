@@ -18,12 +18,23 @@ export interface UserSettings {
   llm_api_key_set: boolean
   llm_base_url: string
   llm_model: string
+  llm_fallbacks: string[]
   tts_provider: string
   tts_api_key_set: boolean
   tts_voice: string
+  tts_speed: number
   tts_enabled: boolean
+  tts_fallbacks: string[]
   stt_provider: string
   stt_api_key_set: boolean
+  azure_endpoint: string
+  azure_api_key_set: boolean
+  azure_api_version: string
+  azure_chat_deployment: string
+  azure_tts_deployment: string
+  azure_stt_deployment: string
+  speech_region: string
+  speech_api_key_set: boolean
 }
 
 export interface UpdateSettingsPayload {
@@ -31,12 +42,57 @@ export interface UpdateSettingsPayload {
   llm_api_key?: string
   llm_base_url?: string
   llm_model?: string
+  llm_fallbacks?: string[]
   tts_provider?: string
   tts_api_key?: string
   tts_voice?: string
+  tts_speed?: number
   tts_enabled?: boolean
+  tts_fallbacks?: string[]
   stt_provider?: string
   stt_api_key?: string
+  azure_endpoint?: string
+  azure_api_key?: string
+  azure_api_version?: string
+  azure_chat_deployment?: string
+  azure_tts_deployment?: string
+  azure_stt_deployment?: string
+  speech_region?: string
+  speech_api_key?: string
+}
+
+// TTS 音色，styles / roles 为 SSML 支持的说话风格与角色扮演
+export interface TTSVoice {
+  name: string
+  display_name: string
+  locale: string
+  gender: string
+  styles: string[]
+  roles: string[]
+}
+
+export type LLMAuth = 'bearer' | 'header' | 'none'
+
+// 可用的 LLM provider：内置、系统级 (服务端配置) 与用户注册的 OpenAI 兼容服务
+export interface LLMProvider {
+  name: string
+  scope: 'builtin' | 'system' | 'user'
+  default_model: string
+  // 以下仅用户注册的 provider 返回
+  base_url?: string
+  auth?: LLMAuth
+  auth_header?: string
+  api_key_set?: boolean
+  headers?: Record<string, string>
+}
+
+export interface SaveLLMProviderPayload {
+  base_url: string
+  auth: LLMAuth
+  auth_header?: string
+  api_key?: string // 留空保留已保存的 Key
+  default_model?: string
+  headers?: Record<string, string>
 }
 
 export const authApi = {
@@ -55,4 +111,16 @@ export const authApi = {
   updateSettings(payload: UpdateSettingsPayload) {
     return client.put<{ success: boolean }>('/auth/settings', payload)
   },
+  llmProviders() {
+    return client.get<{ providers: LLMProvider[] }>('/llm-providers')
+  },
+  saveLLMProvider(name: string, payload: SaveLLMProviderPayload) {
+    return client.put<{ success: boolean }>(`/llm-providers/${encodeURIComponent(name)}`, payload)
+  },
+  deleteLLMProvider(name: string) {
+    return client.delete<{ success: boolean }>(`/llm-providers/${encodeURIComponent(name)}`)
+  },
+  ttsVoices(provider: string) {
+    return client.get<{ voices: TTSVoice[] }>('/tts/voices', { params: { provider } })
+  },
 }
@@ -1,9 +1,22 @@
 export { authApi } from './auth'
 export { interviewApi } from './interview'
-export type { LoginResponse, UserProfile, UserSettings, UpdateSettingsPayload } from './auth'
+export type {
+  LoginResponse,
+  UserProfile,
+  UserSettings,
+  UpdateSettingsPayload,
+  LLMAuth,
+  LLMProvider,
+  SaveLLMProviderPayload,
+  TTSVoice,
+} from './auth'
 export type {
   Interview,
   InterviewMessage,
   Evaluation,
   CreateInterviewPayload,
+  Persona,
+  Panelist,
+  PanelistPayload,
+  Attachment,
 } from './interview'
@@ -6,14 +6,51 @@ export interface Interview {
   position: string
   status: string
   language: string
+  persona?: string
+  panel?: Panelist[]
   created_at: string
   websocket_url?: string
 }
 
+export interface Panelist {
+  id: string
+  name: string
+  role?: string
+  focus?: string
+  persona?: string
+}
+
+// 创建小组面试时声明的面试官，id 为空时由服务端生成
+export interface PanelistPayload extends Partial<Panelist> {
+  llm_provider?: string
+  llm_model?: string
+  tts_voice?: string
+}
+
+export interface Persona {
+  id: string
+  name: string
+  description: string
+  pacing: 'relaxed' | 'normal' | 'brisk'
+  follow_up: 'gentle' | 'normal' | 'aggressive'
+}
+
 export interface InterviewMessage {
   id: number
   role: 'user' | 'assistant' | 'system'
+  speaker?: string
   content: string
+  difficulty?: number // 自适应难度下面试官问题的难度 (1-5)，0 表示未分级
+  attachments?: Attachment[]
+  created_at: string
+}
+
+export interface Attachment {
+  id: number
+  message_id: number
+  content_type: string
+  size_bytes: number
+  url: string
   created_at: string
 }
 
@@ -26,6 +63,21 @@ export interface Evaluation {
   strengths: string
   weaknesses: string
   suggestions: string
+  level: number // 自适应难度估计的能力等级 (1-5)，0 表示未启用
+  created_at: string
+}
+
+export type ExportFormat = 'markdown' | 'json' | 'html' | 'pdf'
+
+export interface Recording {
+  id: number
+  message_id: number
+  role: 'user' | 'assistant'
+  seq: number
+  format: string
+  duration_ms: number
+  size_bytes: number
+  url: string
   created_at: string
 }
 
@@ -38,12 +90,17 @@ export interface CreateInterviewPayload {
   tts_provider?: string
   tts_voice?: string
   resume?: string
+  persona?: string
+  panel?: PanelistPayload[]
 }
 
 export const interviewApi = {
   create(payload: CreateInterviewPayload) {
     return client.post<Interview & { websocket_url: string }>('/interviews', payload)
   },
+  personas() {
+    return client.get<{ personas: Persona[]; default: string }>('/personas')
+  },
   list(page = 1, pageSize = 20) {
     return client.get<{ interviews: Interview[]; total: number }>('/interviews', {
       params: { page, page_size: pageSize },
@@ -52,11 +109,13 @@ export const interviewApi = {
   get(id: number) {
     return client.get<Interview & { messages: InterviewMessage[] }>(`/interviews/${id}`)
   },
-  sendMessage(id: number, content: string) {
+  // images 为 data URL，需在配置中开启图片附件
+  sendMessage(id: number, content: string, images: string[] = []) {
     return client.post<{
       user_message: InterviewMessage
-      assistant_message: { role: string; content: string }
-    }>(`/interviews/${id}/messages`, { content })
+      assistant_message: Omit<InterviewMessage, 'created_at'>
+      evaluation?: Pick<Evaluation, 'overall_score' | 'summary'> // 面试官结束了面试
+    }>(`/interviews/${id}/messages`, { content, images: images.map((data) => ({ data })) })
   },
   end(id: number) {
     return client.post<{ status: string; evaluation_summary: string }>(
@@ -66,4 +125,26 @@ export const interviewApi = {
   getEvaluation(id: number) {
     return client.get<Evaluation>(`/interviews/${id}/evaluation`)
   },
+  export(id: number, format: ExportFormat) {
+    return client.get<Blob>(`/interviews/${id}/export`, {
+      params: { format },
+      responseType: 'blob',
+    })
+  },
+  recordings(id: number) {
+    return client.get<{ recordings: Recording[] }>(`/interviews/${id}/recordings`)
+  },
+  // <audio> 无法携带 Authorization 头，播放地址通过 ?token= 认证
+  recordingSrc(rec: Recording, download = false) {
+    const params = new URLSearchParams({ token: localStorage.getItem('token') ?? '' })
+    if (download) params.set('download', '1')
+    return `${rec.url}?${params}`
+  },
+  attachments(id: number) {
+    return client.get<{ attachments: Attachment[] }>(`/interviews/${id}/attachments`)
+  },
+  // <img> 同样通过 ?token= 认证
+  attachmentSrc(a: Attachment) {
+    return `${a.url}?${new URLSearchParams({ token: localStorage.getItem('token') ?? '' })}`
+  },
 }
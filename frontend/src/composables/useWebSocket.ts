@@ -1,39 +1,64 @@
 import { ref, onUnmounted } from 'vue'
 
 export interface WSMessage {
-  type: 'text_start' | 'text_delta' | 'text_end' | 'audio' | 'status' | 'error' | 'evaluation'
+  type: 'text_start' | 'text_delta' | 'text_end' | 'audio' | 'transcript' | 'status' | 'error' | 'evaluation' | 'resumed'
+  seq?: number
   data: any
 }
 
+// text_start 的 data：小组面试中本轮发言的面试官与自适应难度下的问题难度，都没有时为空
+export interface SpeakerInfo {
+  speaker?: string
+  name?: string
+  role?: string
+  difficulty?: number
+}
+
+const RECONNECT_DELAYS = [500, 1000, 2000, 5000]
+
 /**
  * WebSocket composable - 面试实时交互
+ *
+ * 服务端事件带递增的 seq，断线后自动以 last_seq 重连，服务端补发错过的事件。
  */
 export function useWebSocket() {
   const connected = ref(false)
   const error = ref<string | null>(null)
   let ws: WebSocket | null = null
+  let url = ''
+  let lastSeq = 0
+  let attempts = 0
+  let closedByUser = false
+  let reconnectTimer: ReturnType<typeof setTimeout> | undefined
   const handlers = new Map<string, ((data: any) => void)[]>()
 
-  function connect(url: string) {
+  function open(resume: boolean) {
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
-    const wsUrl = `${protocol}//${window.location.host}${url}`
     const token = localStorage.getItem('token')
+    let wsUrl = `${protocol}//${window.location.host}${url}?token=${token}`
+    if (resume) wsUrl += `&last_seq=${lastSeq}`
 
-    ws = new WebSocket(`${wsUrl}?token=${token}`)
+    ws = new WebSocket(wsUrl)
     ws.binaryType = 'arraybuffer'
 
     ws.onopen = () => {
       connected.value = true
       error.value = null
+      attempts = 0
     }
 
     ws.onmessage = (event) => {
       if (event.data instanceof ArrayBuffer) {
-        emit('audio', event.data)
+        // 音频帧：前 8 字节为大端序 seq
+        const view = new DataView(event.data)
+        track(Number(view.getBigUint64(0)))
+        emit('audio', event.data.slice(8))
         return
       }
       try {
         const msg: WSMessage = JSON.parse(event.data)
+        if (msg.seq) track(msg.seq)
+        if (msg.type === 'resumed') lastSeq = msg.data.last_seq
         emit(msg.type, msg.data)
       } catch {
         emit('text', event.data)
@@ -44,11 +69,35 @@ export function useWebSocket() {
       error.value = 'WebSocket error'
     }
 
-    ws.onclose = () => {
+    ws.onclose = (event) => {
       connected.value = false
+      ws = null
+      if (closedByUser) return
+      // 1008: 同一场面试在别处 (其他标签页 / 设备) 建立了新连接，不再自动重连
+      if (event.code === 1008) {
+        error.value = '面试已在其他页面打开'
+        emit('superseded', event.reason)
+        return
+      }
+      const delay = RECONNECT_DELAYS[Math.min(attempts, RECONNECT_DELAYS.length - 1)]
+      attempts++
+      reconnectTimer = setTimeout(() => open(true), delay)
     }
   }
 
+  // 事件按序到达，记录最近一个 seq (服务端缓存过期后序号会重新计数)
+  function track(seq: number) {
+    lastSeq = seq
+  }
+
+  function connect(path: string) {
+    url = path
+    lastSeq = 0
+    attempts = 0
+    closedByUser = false
+    open(false)
+  }
+
   function send(data: string | object) {
     if (!ws || ws.readyState !== WebSocket.OPEN) return
     ws.send(typeof data === 'string' ? data : JSON.stringify(data))
@@ -64,6 +113,8 @@ export function useWebSocket() {
   }
 
   function disconnect() {
+    closedByUser = true
+    clearTimeout(reconnectTimer)
     ws?.close()
     ws = null
     connected.value = false
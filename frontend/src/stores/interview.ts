@@ -30,9 +30,15 @@ export const useInterviewStore = defineStore('interview', () => {
   async function fetchInterview(id: number) {
     loading.value = true
     try {
-      const { data } = await interviewApi.get(id)
+      const [{ data }, { data: files }] = await Promise.all([
+        interviewApi.get(id),
+        interviewApi.attachments(id),
+      ])
       current.value = data
-      messages.value = data.messages ?? []
+      messages.value = (data.messages ?? []).map((m) => ({
+        ...m,
+        attachments: files.attachments.filter((a) => a.message_id === m.id),
+      }))
     } finally {
       loading.value = false
     }
@@ -43,20 +49,26 @@ export const useInterviewStore = defineStore('interview', () => {
     return data
   }
 
-  async function sendMessage(id: number, content: string) {
-    const { data } = await interviewApi.sendMessage(id, content)
+  async function sendMessage(id: number, content: string, images: string[] = []) {
+    const { data } = await interviewApi.sendMessage(id, content, images)
     messages.value.push({
       id: data.user_message.id,
       role: 'user',
       content: data.user_message.content,
+      attachments: data.user_message.attachments,
       created_at: new Date().toISOString(),
     })
     messages.value.push({
-      id: 0,
+      id: data.assistant_message.id,
       role: 'assistant',
+      speaker: data.assistant_message.speaker,
+      difficulty: data.assistant_message.difficulty,
       content: data.assistant_message.content,
       created_at: new Date().toISOString(),
     })
+    if (data.evaluation && current.value) {
+      current.value.status = 'completed'
+    }
     return data
   }
 